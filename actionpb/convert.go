@@ -0,0 +1,285 @@
+// Package actionpb contains protobuf definitions of the core types of the action package, along with converters
+// to and from the existing structs. This allows devices, states and intents to be sent over gRPC, or stored in a
+// strongly typed form which can be read from other languages.
+//
+// The messages are named after, and their fields match the JSON of, the corresponding action types. Most conversions
+// therefore go through the JSON form, so the result is the same as if the JSON had been exchanged with Google.
+package actionpb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+)
+
+// toProto converts the value to the message with the same JSON form.
+func toProto(v interface{}, msg proto.Message) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(data, msg)
+}
+
+// fromProto converts the message to the value with the same JSON form.
+func fromProto(msg proto.Message, v interface{}) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// toStruct converts the map to a Struct, returning nil if the map is empty.
+func toStruct(m map[string]interface{}) (*structpb.Struct, error) {
+	if len(m) < 1 {
+		return nil, nil
+	}
+	s := &structpb.Struct{}
+	if err := toProto(m, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fromStruct converts the Struct to a map, returning an empty map if it is nil.
+func fromStruct(s *structpb.Struct) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if s == nil {
+		return m, nil
+	}
+	if err := fromProto(s, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FromDevice converts the device to its protobuf form, including its challenges.
+func FromDevice(d *action.Device) (*Device, error) {
+	pb := &Device{}
+	if err := toProto(d, pb); err != nil {
+		return nil, fmt.Errorf("device %s: %w", d.ID, err)
+	}
+	if len(d.Challenges) > 0 {
+		pb.Challenges = map[string]string{}
+		for command, challenge := range d.Challenges {
+			pb.Challenges[command] = challenge
+		}
+	}
+	return pb, nil
+}
+
+// ToDevice converts the protobuf device to an action.Device, including its challenges.
+func ToDevice(pb *Device) (*action.Device, error) {
+	d := &action.Device{}
+	if err := fromProto(pb, d); err != nil {
+		return nil, fmt.Errorf("device %s: %w", pb.GetId(), err)
+	}
+	// Match the devices created by the constructors, so traits can be added to the converted device.
+	if d.Attributes == nil {
+		d.Attributes = map[string]interface{}{}
+	}
+	if len(pb.Challenges) > 0 {
+		d.Challenges = map[string]string{}
+		for command, challenge := range pb.Challenges {
+			d.Challenges[command] = challenge
+		}
+	}
+	return d, nil
+}
+
+// FromDeviceState converts the state to its protobuf form.
+func FromDeviceState(ds action.DeviceState) (*DeviceState, error) {
+	state, err := toStruct(ds.State)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceState{
+		Online:    ds.Online,
+		Status:    ds.Status,
+		ErrorCode: ds.ErrorCode,
+		State:     state,
+	}, nil
+}
+
+// ToDeviceState converts the protobuf state to an action.DeviceState.
+func ToDeviceState(pb *DeviceState) (action.DeviceState, error) {
+	ds := action.NewDeviceState(pb.GetOnline())
+	ds.Status = pb.GetStatus()
+	ds.ErrorCode = pb.GetErrorCode()
+
+	state, err := fromStruct(pb.GetState())
+	if err != nil {
+		return action.DeviceState{}, err
+	}
+	ds.State = state
+	return ds, nil
+}
+
+// FromCommand converts the command to its protobuf form. Any follow-up token is included in the params.
+func FromCommand(c action.Command) (*Command, error) {
+	pb := &Command{}
+	if err := toProto(c, pb); err != nil {
+		return nil, fmt.Errorf("command %s: %w", c.Name, err)
+	}
+	return pb, nil
+}
+
+// ToCommand converts the protobuf command to an action.Command, parsing the params of the commands the action
+// package supports in the same way as when they are received from Google.
+func ToCommand(pb *Command) (action.Command, error) {
+	c := action.Command{}
+	if err := fromProto(pb, &c); err != nil {
+		return action.Command{}, fmt.Errorf("command %s: %w", pb.GetCommand(), err)
+	}
+	return c, nil
+}
+
+// FromFulfillmentRequest converts the request to its protobuf form.
+// The payloads of intents other than QUERY and EXECUTE are not included.
+func FromFulfillmentRequest(req *action.FulfillmentRequest) (*FulfillmentRequest, error) {
+	pb := &FulfillmentRequest{
+		RequestId: req.RequestID,
+	}
+	for _, input := range req.Inputs {
+		pbInput := &FulfillmentInput{
+			Intent: input.Intent,
+		}
+		if input.Query != nil {
+			query := &QueryPayload{}
+			if err := toProto(input.Query, query); err != nil {
+				return nil, err
+			}
+			pbInput.Payload = &FulfillmentInput_Query{Query: query}
+		} else if input.Execute != nil {
+			execute := &ExecutePayload{}
+			if err := toProto(input.Execute, execute); err != nil {
+				return nil, err
+			}
+			pbInput.Payload = &FulfillmentInput_Execute{Execute: execute}
+		}
+		pb.Inputs = append(pb.Inputs, pbInput)
+	}
+	return pb, nil
+}
+
+// ToFulfillmentRequest converts the protobuf request to an action.FulfillmentRequest.
+func ToFulfillmentRequest(pb *FulfillmentRequest) (*action.FulfillmentRequest, error) {
+	req := &action.FulfillmentRequest{
+		RequestID: pb.GetRequestId(),
+	}
+	for _, pbInput := range pb.GetInputs() {
+		input := action.FulfillmentInput{
+			Intent: pbInput.GetIntent(),
+		}
+		if query := pbInput.GetQuery(); query != nil {
+			input.Query = &action.QueryPayload{}
+			if err := fromProto(query, input.Query); err != nil {
+				return nil, err
+			}
+		} else if execute := pbInput.GetExecute(); execute != nil {
+			input.Execute = &action.ExecutePayload{}
+			if err := fromProto(execute, input.Execute); err != nil {
+				return nil, err
+			}
+		}
+		req.Inputs = append(req.Inputs, input)
+	}
+	return req, nil
+}
+
+// FromSyncFulfillmentResponse converts the SYNC response to its protobuf form.
+func FromSyncFulfillmentResponse(resp *action.SyncFulfillmentResponse) (*SyncFulfillmentResponse, error) {
+	pb := &SyncFulfillmentResponse{}
+	if err := toProto(resp, pb); err != nil {
+		return nil, err
+	}
+	// The challenges aren't part of the JSON form, so are added separately.
+	for idx, device := range resp.Payload.Devices {
+		pbDevice, err := FromDevice(device)
+		if err != nil {
+			return nil, err
+		}
+		pb.Payload.Devices[idx].Challenges = pbDevice.Challenges
+	}
+	return pb, nil
+}
+
+// ToSyncFulfillmentResponse converts the protobuf SYNC response to an action.SyncFulfillmentResponse.
+func ToSyncFulfillmentResponse(pb *SyncFulfillmentResponse) (*action.SyncFulfillmentResponse, error) {
+	resp := &action.SyncFulfillmentResponse{
+		RequestID: pb.GetRequestId(),
+	}
+	resp.Payload.UserID = pb.GetPayload().GetAgentUserId()
+	resp.Payload.ErrorCode = pb.GetPayload().GetErrorCode()
+	resp.Payload.DebugString = pb.GetPayload().GetDebugString()
+	for _, pbDevice := range pb.GetPayload().GetDevices() {
+		device, err := ToDevice(pbDevice)
+		if err != nil {
+			return nil, err
+		}
+		resp.Payload.Devices = append(resp.Payload.Devices, device)
+	}
+	return resp, nil
+}
+
+// FromQueryFulfillmentResponse converts the QUERY response to its protobuf form.
+func FromQueryFulfillmentResponse(resp *action.QueryFulfillmentResponse) (*QueryFulfillmentResponse, error) {
+	pb := &QueryFulfillmentResponse{
+		RequestId: resp.RequestID,
+		Payload: &QueryFulfillmentPayload{
+			ErrorCode:   resp.Payload.ErrorCode,
+			DebugString: resp.Payload.DebugString,
+			Devices:     map[string]*DeviceState{},
+		},
+	}
+	for deviceID, state := range resp.Payload.Devices {
+		pbState, err := FromDeviceState(state)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", deviceID, err)
+		}
+		pb.Payload.Devices[deviceID] = pbState
+	}
+	return pb, nil
+}
+
+// ToQueryFulfillmentResponse converts the protobuf QUERY response to an action.QueryFulfillmentResponse.
+func ToQueryFulfillmentResponse(pb *QueryFulfillmentResponse) (*action.QueryFulfillmentResponse, error) {
+	resp := &action.QueryFulfillmentResponse{
+		RequestID: pb.GetRequestId(),
+	}
+	resp.Payload.ErrorCode = pb.GetPayload().GetErrorCode()
+	resp.Payload.DebugString = pb.GetPayload().GetDebugString()
+	resp.Payload.Devices = map[string]action.DeviceState{}
+	for deviceID, pbState := range pb.GetPayload().GetDevices() {
+		state, err := ToDeviceState(pbState)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", deviceID, err)
+		}
+		resp.Payload.Devices[deviceID] = state
+	}
+	return resp, nil
+}
+
+// FromExecuteFulfillmentResponse converts the EXECUTE response to its protobuf form.
+func FromExecuteFulfillmentResponse(resp *action.ExecuteFulfillmentResponse) (*ExecuteFulfillmentResponse, error) {
+	pb := &ExecuteFulfillmentResponse{}
+	if err := toProto(resp, pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+// ToExecuteFulfillmentResponse converts the protobuf EXECUTE response to an action.ExecuteFulfillmentResponse.
+func ToExecuteFulfillmentResponse(pb *ExecuteFulfillmentResponse) (*action.ExecuteFulfillmentResponse, error) {
+	resp := &action.ExecuteFulfillmentResponse{}
+	if err := fromProto(pb, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,153 @@
+package actionpb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+)
+
+func readGolden(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("..", "testdata", "golden", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// assertSameJSON checks both values serialize to the same JSON.
+func assertSameJSON(t *testing.T, expected interface{}, actual interface{}) {
+	expectedData, err := json.Marshal(expected)
+	assert.Nil(t, err)
+	actualData, err := json.Marshal(actual)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expectedData), string(actualData))
+}
+
+func TestDeviceRoundtrip(t *testing.T) {
+	device := action.NewLight("light-1").AddBrightnessTrait(false).RequireChallenge("action.devices.commands.OnOff", action.ChallengeAckNeeded)
+	device.Name.Name = "Lamp"
+	device.RoomHint = "Office"
+	device.CustomData["zone"] = 3
+
+	pb, err := FromDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, "light-1", pb.Id)
+	assert.Equal(t, "Lamp", pb.Name.Name)
+	assert.Equal(t, map[string]string{"action.devices.commands.OnOff": action.ChallengeAckNeeded}, pb.Challenges)
+
+	// The message survives being serialized, i.e. for storage.
+	data, err := proto.Marshal(pb)
+	assert.Nil(t, err)
+	decoded := &Device{}
+	assert.Nil(t, proto.Unmarshal(data, decoded))
+
+	converted, err := ToDevice(decoded)
+	assert.Nil(t, err)
+	assertSameJSON(t, device, converted)
+	assert.Equal(t, device.Challenges, converted.Challenges)
+	assert.True(t, converted.Traits["action.devices.traits.Brightness"])
+}
+
+func TestDeviceStateRoundtrip(t *testing.T) {
+	state := action.NewDeviceState(true).RecordOnOff(true).RecordBrightness(42)
+	state.Status = "SUCCESS"
+
+	pb, err := FromDeviceState(state)
+	assert.Nil(t, err)
+	assert.True(t, pb.Online)
+	assert.Equal(t, "SUCCESS", pb.Status)
+
+	converted, err := ToDeviceState(pb)
+	assert.Nil(t, err)
+	assertSameJSON(t, state, converted)
+
+	offline, err := ToDeviceState(&DeviceState{})
+	assert.Nil(t, err)
+	assert.False(t, offline.Online)
+	assert.NotNil(t, offline.State)
+}
+
+func TestCommandRoundtrip(t *testing.T) {
+	command := action.Command{
+		Name:          "action.devices.commands.OnOff",
+		OnOff:         &action.CommandOnOff{On: true},
+		FollowUpToken: "token",
+		Challenge:     &action.CommandChallenge{Ack: true},
+	}
+
+	pb, err := FromCommand(command)
+	assert.Nil(t, err)
+	assert.Equal(t, "action.devices.commands.OnOff", pb.Command)
+	assert.True(t, pb.Challenge.Ack)
+	assert.Equal(t, "token", pb.Params.Fields["followUpToken"].GetStringValue())
+
+	converted, err := ToCommand(pb)
+	assert.Nil(t, err)
+	assert.Equal(t, command, converted)
+}
+
+func TestFulfillmentRequestRoundtrip(t *testing.T) {
+	for _, file := range []string{"sync_request.json", "query_request.json", "execute_request.json", "disconnect_request.json"} {
+		t.Run(file, func(t *testing.T) {
+			req := &action.FulfillmentRequest{}
+			assert.Nil(t, json.Unmarshal(readGolden(t, file), req))
+
+			pb, err := FromFulfillmentRequest(req)
+			assert.Nil(t, err)
+			assert.Equal(t, req.RequestID, pb.RequestId)
+			assert.Equal(t, req.Inputs[0].Intent, pb.Inputs[0].Intent)
+
+			converted, err := ToFulfillmentRequest(pb)
+			assert.Nil(t, err)
+			assertSameJSON(t, req, converted)
+		})
+	}
+}
+
+func TestSyncFulfillmentResponseRoundtrip(t *testing.T) {
+	resp := &action.SyncFulfillmentResponse{}
+	assert.Nil(t, json.Unmarshal(readGolden(t, "sync_response.json"), resp))
+	resp.Payload.Devices[0].RequireChallenge("action.devices.commands.OnOff", action.ChallengePinNeeded)
+
+	pb, err := FromSyncFulfillmentResponse(resp)
+	assert.Nil(t, err)
+	assert.Len(t, pb.Payload.Devices, len(resp.Payload.Devices))
+	assert.Equal(t, action.ChallengePinNeeded, pb.Payload.Devices[0].Challenges["action.devices.commands.OnOff"])
+
+	converted, err := ToSyncFulfillmentResponse(pb)
+	assert.Nil(t, err)
+	assertSameJSON(t, resp, converted)
+	assert.Equal(t, resp.Payload.Devices[0].Challenges, converted.Payload.Devices[0].Challenges)
+}
+
+func TestQueryFulfillmentResponseRoundtrip(t *testing.T) {
+	resp := &action.QueryFulfillmentResponse{}
+	assert.Nil(t, json.Unmarshal(readGolden(t, "query_response.json"), resp))
+
+	pb, err := FromQueryFulfillmentResponse(resp)
+	assert.Nil(t, err)
+	assert.Len(t, pb.Payload.Devices, len(resp.Payload.Devices))
+
+	converted, err := ToQueryFulfillmentResponse(pb)
+	assert.Nil(t, err)
+	assertSameJSON(t, resp, converted)
+}
+
+func TestExecuteFulfillmentResponseRoundtrip(t *testing.T) {
+	resp := &action.ExecuteFulfillmentResponse{}
+	assert.Nil(t, json.Unmarshal(readGolden(t, "execute_response.json"), resp))
+
+	pb, err := FromExecuteFulfillmentResponse(resp)
+	assert.Nil(t, err)
+	assert.Len(t, pb.Payload.Commands, len(resp.Payload.Commands))
+
+	converted, err := ToExecuteFulfillmentResponse(pb)
+	assert.Nil(t, err)
+	assertSameJSON(t, resp, converted)
+}
@@ -0,0 +1,84 @@
+package action
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// TraitAccess describes whether a trait of a device has been marked as command only or query only,
+// and what that implies for how QUERY and EXECUTE are handled for the trait.
+type TraitAccess struct {
+	Trait       string
+	CommandOnly bool
+	QueryOnly   bool
+	// Implications contains a human-readable explanation of each consequence of the flags.
+	Implications []string
+}
+
+// TraitAccessReport lists the command only (i.e. commandOnlyOnOff) and query only (i.e. queryOnlyOnOff) settings
+// of each trait of the device, sorted by trait name. Traits with neither setting are included for completeness.
+func (d *Device) TraitAccessReport() []TraitAccess {
+	var report []TraitAccess
+	for trait := range d.Traits {
+		access := TraitAccess{
+			Trait: trait,
+		}
+		access.CommandOnly, _ = d.Attributes["commandOnly"+traitShortName(trait)].(bool)
+		access.QueryOnly, _ = d.Attributes["queryOnly"+traitShortName(trait)].(bool)
+
+		capability := traitCapabilities[trait]
+		if access.CommandOnly && len(capability.States) > 0 {
+			access.Implications = append(access.Implications, "state is not queried; omit its values from QUERY responses and ReportState")
+		}
+		if access.QueryOnly && len(capability.Commands) > 0 {
+			access.Implications = append(access.Implications, "commands are not sent in EXECUTE; the state can only be reported")
+		}
+		if access.CommandOnly && access.QueryOnly {
+			access.Implications = append(access.Implications, "the trait can neither be queried nor commanded")
+		}
+
+		report = append(report, access)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Trait < report[j].Trait
+	})
+	return report
+}
+
+// CommandOnlyStates returns the sorted state values in ds which belong to a trait the device has marked as command only.
+// Google doesn't expect these values to be reported, so their presence usually indicates a misconfigured device.
+func (ds DeviceState) CommandOnlyStates(d *Device) []string {
+	var states []string
+	for k := range ds.State {
+		trait, known := stateTraits[k]
+		if !known || !d.Traits[trait] {
+			continue
+		}
+		if commandOnly, _ := d.Attributes["commandOnly"+traitShortName(trait)].(bool); commandOnly {
+			states = append(states, k)
+		}
+	}
+	sort.Strings(states)
+	return states
+}
+
+// warnCommandOnlyStates logs a warning for each of the states which contain values for command only traits.
+// This is only done when the devices of the user are known (see WithExecuteValidation).
+func (s *Service) warnCommandOnlyStates(ctx context.Context, agentUserID string, devices map[string]*Device, states map[string]DeviceState) {
+	for deviceID, state := range states {
+		device, found := devices[deviceID]
+		if !found {
+			continue
+		}
+		if commandOnlyStates := state.CommandOnlyStates(device); len(commandOnlyStates) > 0 {
+			s.logger.Warn("state reported for command only trait",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceID),
+				zap.Strings("states", commandOnlyStates),
+			)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDeviceTraitAccessReport(t *testing.T) {
+	d := NewLight("light-1").AddBrightnessTrait(true)
+	d.Attributes["queryOnlyOnOff"] = true
+
+	assert.Equal(t, []TraitAccess{
+		{
+			Trait:        TraitBrightness,
+			CommandOnly:  true,
+			Implications: []string{"state is not queried; omit its values from QUERY responses and ReportState"},
+		},
+		{
+			Trait:        TraitOnOff,
+			QueryOnly:    true,
+			Implications: []string{"commands are not sent in EXECUTE; the state can only be reported"},
+		},
+	}, d.TraitAccessReport())
+}
+
+func TestDeviceStateCommandOnlyStates(t *testing.T) {
+	d := NewLight("light-1").AddBrightnessTrait(true)
+
+	assert.Empty(t, NewDeviceState(true).RecordOnOff(true).CommandOnlyStates(d))
+	assert.Equal(t, []string{"brightness"}, NewDeviceState(true).RecordOnOff(true).RecordBrightness(10).CommandOnlyStates(d))
+}
+
+func TestServiceWarnCommandOnlyStates(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	svc := NewService(zap.New(core), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithExecuteValidation(false),
+	)
+	svc.registry.record("agent-id", []*Device{NewLight("light-1").AddBrightnessTrait(true)})
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true).RecordBrightness(10),
+	})
+	assert.Nil(t, err)
+
+	entries := logs.FilterMessage("state reported for command only trait").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "light-1", entries[0].ContextMap()["device_id"])
+}
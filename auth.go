@@ -0,0 +1,94 @@
+package action
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrAccessTokenMissing is returned by a TokenExtractor if the request doesn't contain an access token.
+	ErrAccessTokenMissing = errors.New("access token missing")
+	// ErrAccessTokenNotBearer is returned by the bearer TokenExtractor if the Authorization header uses a different scheme.
+	ErrAccessTokenNotBearer = errors.New("access token must be bearer")
+)
+
+// TokenExtractor retrieves the access token to validate from a fulfillment request.
+// By default the token is expected in the Authorization header using the Bearer scheme, as Google sends it.
+// Returning ErrAccessTokenMissing, or any other error, causes the request to be rejected with a 401.
+type TokenExtractor interface {
+	ExtractToken(r *http.Request) (string, error)
+}
+
+// TokenExtractorFunc allows a function to be used as a TokenExtractor.
+type TokenExtractorFunc func(r *http.Request) (string, error)
+
+// ExtractToken calls the underlying function.
+func (f TokenExtractorFunc) ExtractToken(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// WithTokenExtractor replaces the default bearer token extraction.
+// This is intended for development setups or gateways which supply the token in a non-standard way.
+func WithTokenExtractor(extractor TokenExtractor) ServiceOption {
+	return func(s *Service) {
+		s.tokenExtractor = extractor
+	}
+}
+
+// BearerTokenExtractor retrieves the token from the Authorization header using the Bearer scheme.
+// This is the default TokenExtractor.
+func BearerTokenExtractor() TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) (string, error) {
+		authHeader := r.Header.Get("Authorization")
+		if len(authHeader) < 1 {
+			return "", ErrAccessTokenMissing
+		}
+
+		authTokenParts := strings.Split(authHeader, " ")
+		if len(authTokenParts) != 2 || strings.ToLower(authTokenParts[0]) != "bearer" {
+			return "", ErrAccessTokenNotBearer
+		}
+		return authTokenParts[1], nil
+	})
+}
+
+// BasicAuthTokenExtractor retrieves the token from the password of the Authorization header using the Basic scheme.
+// If no password is supplied the username is used as the token instead.
+func BasicAuthTokenExtractor() TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) (string, error) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return "", ErrAccessTokenMissing
+		}
+		if len(password) > 0 {
+			return password, nil
+		} else if len(username) > 0 {
+			return username, nil
+		}
+		return "", ErrAccessTokenMissing
+	})
+}
+
+// HeaderTokenExtractor retrieves the token from the full value of the named header.
+func HeaderTokenExtractor(header string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) (string, error) {
+		token := r.Header.Get(header)
+		if len(token) < 1 {
+			return "", ErrAccessTokenMissing
+		}
+		return token, nil
+	})
+}
+
+// QueryParamTokenExtractor retrieves the token from the named URL query parameter.
+// Tokens in URLs are likely to end up in logs so this should only be used during development.
+func QueryParamTokenExtractor(param string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) (string, error) {
+		token := r.URL.Query().Get(param)
+		if len(token) < 1 {
+			return "", ErrAccessTokenMissing
+		}
+		return token, nil
+	})
+}
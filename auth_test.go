@@ -0,0 +1,128 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTokenExtractors(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		extractor TokenExtractor
+		setup     func(r *http.Request)
+		wantToken string
+		wantErr   error
+	}{
+		{
+			name:      "bearer",
+			extractor: BearerTokenExtractor(),
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer asdf")
+			},
+			wantToken: "asdf",
+		},
+		{
+			name:      "bearer missing",
+			extractor: BearerTokenExtractor(),
+			setup:     func(r *http.Request) {},
+			wantErr:   ErrAccessTokenMissing,
+		},
+		{
+			name:      "bearer wrong scheme",
+			extractor: BearerTokenExtractor(),
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Token asdf")
+			},
+			wantErr: ErrAccessTokenNotBearer,
+		},
+		{
+			name:      "basic password",
+			extractor: BasicAuthTokenExtractor(),
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("user", "asdf")
+			},
+			wantToken: "asdf",
+		},
+		{
+			name:      "basic username",
+			extractor: BasicAuthTokenExtractor(),
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("asdf", "")
+			},
+			wantToken: "asdf",
+		},
+		{
+			name:      "basic missing",
+			extractor: BasicAuthTokenExtractor(),
+			setup:     func(r *http.Request) {},
+			wantErr:   ErrAccessTokenMissing,
+		},
+		{
+			name:      "header",
+			extractor: HeaderTokenExtractor("X-Access-Token"),
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Access-Token", "asdf")
+			},
+			wantToken: "asdf",
+		},
+		{
+			name:      "query param",
+			extractor: QueryParamTokenExtractor("access_token"),
+			setup: func(r *http.Request) {
+				r.URL.RawQuery = "access_token=asdf"
+			},
+			wantToken: "asdf",
+		},
+		{
+			name:      "query param missing",
+			extractor: QueryParamTokenExtractor("access_token"),
+			setup:     func(r *http.Request) {},
+			wantErr:   ErrAccessTokenMissing,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, nil)
+			tt.setup(req)
+
+			token, err := tt.extractor.ExtractToken(req)
+			assert.Equal(t, tt.wantToken, token)
+			assert.True(t, errors.Is(err, tt.wantErr))
+		})
+	}
+}
+
+func TestGoogleFulfillmentHandlerTokenExtractor(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	svc := NewService(logger, authenticator, &testProvider{}, nil, WithTokenExtractor(QueryParamTokenExtractor("access_token")))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	body := []byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath+"?access_token=asdf", bytes.NewBuffer(body))
+	req.Header.Set("content-type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer(body))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "Access Token Required", rr.Body.String())
+}
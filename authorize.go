@@ -0,0 +1,101 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotAuthorized may be returned by an Authorizer to deny a command; the device is reported as failing with authFailure.
+	ErrNotAuthorized = errors.New("not authorized")
+)
+
+// ChallengeRequiredError may be returned by an Authorizer to require the user complete a secondary verification
+// (i.e. ChallengePinNeeded) before the command is executed; the device is reported as needing the challenge.
+type ChallengeRequiredError struct {
+	Type string
+}
+
+// Error returns the required challenge type.
+func (e *ChallengeRequiredError) Error() string {
+	return fmt.Sprintf("challenge required: %s", e.Type)
+}
+
+// Authorizer decides whether the user may execute a command against a device.
+// Returning nil allows the command. Returning a ChallengeRequiredError causes the user to be challenged;
+// any other error (typically ErrNotAuthorized) denies the command with authFailure.
+type Authorizer interface {
+	Authorize(ctx context.Context, agentUserID string, device DeviceArg, command Command) error
+}
+
+// AuthorizerFunc allows a function to be used as an Authorizer.
+type AuthorizerFunc func(ctx context.Context, agentUserID string, device DeviceArg, command Command) error
+
+// Authorize calls the underlying function.
+func (f AuthorizerFunc) Authorize(ctx context.Context, agentUserID string, device DeviceArg, command Command) error {
+	return f(ctx, agentUserID, device, command)
+}
+
+// WithAuthorizer causes each command in EXECUTE to be checked by the authorizer before it is passed to the provider.
+// Devices with a command which is denied are removed from the request and reported as failing,
+// so authorization checks don't need to be repeated in every Execute implementation.
+// The principal of the access token is available from the context; see PrincipalFromContext.
+func WithAuthorizer(authorizer Authorizer) ServiceOption {
+	return func(s *Service) {
+		s.authorizer = authorizer
+	}
+}
+
+// authorizeExecute checks each command against the authorizer.
+// It returns the commands which are allowed, along with the IDs of the devices which were denied indexed by
+// error code or challenge type.
+func (s *Service) authorizeExecute(ctx context.Context, agentUserID string, commandArgs []CommandArg) ([]CommandArg, map[string][]string) {
+	failures := map[string][]string{}
+	var authorized []CommandArg
+	for _, commandArg := range commandArgs {
+		allowed := CommandArg{
+			Commands:      commandArg.Commands,
+			FollowUpToken: commandArg.FollowUpToken,
+		}
+
+		for _, deviceArg := range commandArg.TargetDevices {
+			var err error
+			for _, command := range commandArg.Commands {
+				if err = s.authorizer.Authorize(ctx, agentUserID, deviceArg, command); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				allowed.TargetDevices = append(allowed.TargetDevices, deviceArg)
+				continue
+			}
+
+			var challengeErr *ChallengeRequiredError
+			if errors.As(err, &challengeErr) {
+				failures[challengeErr.Type] = append(failures[challengeErr.Type], deviceArg.ID)
+				continue
+			}
+
+			s.logger.Info("command not authorized",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceArg.ID),
+				zap.Error(err),
+			)
+			failures[ErrorCodeAuthFailure] = append(failures[ErrorCodeAuthFailure], deviceArg.ID)
+		}
+
+		if len(allowed.TargetDevices) > 0 {
+			authorized = append(authorized, allowed)
+		}
+	}
+	return authorized, failures
+}
+
+// isChallengeType returns whether the value is one of the challenge types rather than an error code.
+func isChallengeType(value string) bool {
+	return value == ChallengeAckNeeded || value == ChallengePinNeeded || value == ChallengeFailedPinNeeded
+}
@@ -0,0 +1,59 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGoogleFulfillmentHandlerExecuteAuthorizer(t *testing.T) {
+	provider := &testProvider{
+		executeRespDeviceState: NewDeviceState(true).RecordOnOff(true),
+		executeRespUpdated:     []string{"light-1"},
+	}
+	authorizer := AuthorizerFunc(func(ctx context.Context, agentUserID string, device DeviceArg, command Command) error {
+		assert.Equal(t, "agent-id", agentUserID)
+		switch device.ID {
+		case "lock-1":
+			return ErrNotAuthorized
+		case "lock-2":
+			return &ChallengeRequiredError{Type: ChallengePinNeeded}
+		}
+		return nil
+	})
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil,
+		WithAuthorizer(authorizer),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+				"commands": [{
+					"devices": [{"id": "light-1"}, {"id": "lock-1"}, {"id": "lock-2"}],
+					"execution": [{
+						"command": "action.devices.commands.OnOff",
+						"params": {"on": true}
+					}]
+				}]
+			}
+		}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, provider.executeReq.Commands, 1)
+	assert.Equal(t, []DeviceArg{{ID: "light-1"}}, provider.executeReq.Commands[0].TargetDevices)
+	assert.Contains(t, rr.Body.String(), `{"ids":["light-1"],"status":"SUCCESS","states":{"on":true,"online":true}}`)
+	assert.Contains(t, rr.Body.String(), `{"ids":["lock-2"],"status":"ERROR","errorCode":"challengeNeeded","challengeNeeded":{"type":"pinNeeded"}}`)
+	assert.Contains(t, rr.Body.String(), `{"ids":["lock-1"],"status":"ERROR","errorCode":"authFailure"}`)
+}
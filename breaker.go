@@ -0,0 +1,220 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is returned instead of making a call while the circuit breaker guarding it is open.
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// The states a CircuitBreaker can be in.
+const (
+	// CircuitClosed allows every call through.
+	CircuitClosed = "closed"
+	// CircuitOpen fails every call immediately, until the open duration has passed.
+	CircuitOpen = "open"
+	// CircuitHalfOpen allows a single probe call through; its result decides whether the circuit closes or opens again.
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops calls to a failing dependency for a period once a number of consecutive calls have failed,
+// so requests fail quickly rather than piling up waiting on timeouts. Once the open duration has passed a single
+// probe call is allowed through; the circuit closes if it succeeds and opens again if it fails.
+// A CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+	clock        Clock
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker which opens after failureThreshold consecutive failures,
+// remaining open for openDuration before probing.
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold:    failureThreshold,
+		openDuration: openDuration,
+		clock:        systemClock(),
+		state:        CircuitClosed,
+	}
+}
+
+// State returns the current state of the circuit (CircuitClosed, CircuitOpen or CircuitHalfOpen).
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// allow returns ErrCircuitOpen if the call should not be made. If nil is returned the result of the call must be
+// supplied to record.
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if cb.clock.Now().Sub(cb.openedAt) < cb.openDuration {
+			return ErrCircuitOpen
+		}
+		// This call is the probe; any others are rejected until it completes.
+		cb.state = CircuitHalfOpen
+		return nil
+	case CircuitHalfOpen:
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// abandon is used in place of record if the call was abandoned by the caller, so its result isn't known.
+// If the call was the probe the circuit is reopened without resetting the open duration, so the next call probes instead.
+func (cb *CircuitBreaker) abandon() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitHalfOpen {
+		cb.state = CircuitOpen
+	}
+}
+
+// record updates the state of the circuit with the result of a call.
+func (cb *CircuitBreaker) record(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !failed {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.clock.Now()
+	}
+}
+
+// WithHomeGraphCircuitBreaker guards the HomeGraph calls made by the Service with the circuit breaker.
+// Calls which fail to reach Google, or which Google answers with a server error or by throttling, count as failures;
+// calls abandoned because their context was cancelled or timed out do not.
+// While the circuit is open the calls fail immediately with a HomeGraphError wrapping ErrCircuitOpen.
+func WithHomeGraphCircuitBreaker(breaker *CircuitBreaker) ServiceOption {
+	return func(s *Service) {
+		s.homeGraphBreaker = breaker
+	}
+}
+
+// checkHomeGraphCircuit returns an error if the HomeGraph circuit breaker is preventing calls.
+func (s *Service) checkHomeGraphCircuit(method string, requestID string) error {
+	if s.homeGraphBreaker == nil {
+		return nil
+	}
+	if err := s.homeGraphBreaker.allow(); err != nil {
+		return &HomeGraphError{
+			Method:    method,
+			RequestID: requestID,
+			Err:       err,
+		}
+	}
+	return nil
+}
+
+// recordHomeGraphCall records the result of a HomeGraph call in the debug recorder and circuit breaker.
+// Calls which failed because the caller's context was cancelled or timed out say nothing about the health of the
+// HomeGraph, so aren't recorded as failures by the circuit breaker.
+func (s *Service) recordHomeGraphCall(ctx context.Context, method string, agentUserID string, statusCode int, err error) {
+	s.debug.recordHomeGraphCall(method, agentUserID, statusCode, err)
+	if s.homeGraphBreaker == nil {
+		return
+	}
+
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		s.homeGraphBreaker.abandon()
+		return
+	} else if rejected := classifyHomeGraphError(err); rejected != err {
+		statusCode = rejected.(*HomeGraphRejectedError).Status
+	} else if err != nil {
+		s.homeGraphBreaker.record(true)
+		return
+	}
+	s.homeGraphBreaker.record(statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests)
+}
+
+// CircuitBreakerProvider guards a Provider with a circuit breaker. While the circuit is open requests fail immediately
+// with transientError. Errors returned by the provider count as failures, except for IntentErrors reporting something
+// other than transientError or timeout, as those are deliberate responses rather than a sign of an unhealthy provider.
+type CircuitBreakerProvider struct {
+	provider Provider
+	breaker  *CircuitBreaker
+}
+
+// NewCircuitBreakerProvider creates a provider which guards calls to provider using breaker.
+func NewCircuitBreakerProvider(provider Provider, breaker *CircuitBreaker) *CircuitBreakerProvider {
+	return &CircuitBreakerProvider{
+		provider: provider,
+		breaker:  breaker,
+	}
+}
+
+// call makes the call if the circuit allows it, recording the result.
+func (cbp *CircuitBreakerProvider) call(fn func() error) error {
+	if err := cbp.breaker.allow(); err != nil {
+		return NewTransientError(err)
+	}
+
+	err := fn()
+	failed := err != nil
+	if intentErr, ok := asIntentError(err); ok {
+		failed = intentErr.ErrorCode == ErrorCodeTransientError || intentErr.ErrorCode == ErrorCodeTimeout
+	}
+	cbp.breaker.record(failed)
+	return err
+}
+
+// Sync calls the guarded provider.
+func (cbp *CircuitBreakerProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	var resp *SyncResponse
+	err := cbp.call(func() (err error) {
+		resp, err = cbp.provider.Sync(ctx, agentUserID)
+		return err
+	})
+	return resp, err
+}
+
+// Disconnect calls the guarded provider.
+func (cbp *CircuitBreakerProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	return cbp.call(func() error {
+		return cbp.provider.Disconnect(ctx, agentUserID)
+	})
+}
+
+// Query calls the guarded provider.
+func (cbp *CircuitBreakerProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	var resp *QueryResponse
+	err := cbp.call(func() (err error) {
+		resp, err = cbp.provider.Query(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Execute calls the guarded provider.
+func (cbp *CircuitBreakerProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	var resp *ExecuteResponse
+	err := cbp.call(func() (err error) {
+		resp, err = cbp.provider.Execute(ctx, req)
+		return err
+	})
+	return resp, err
+}
@@ -0,0 +1,118 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(2, time.Minute)
+	cb.clock = ClockFunc(func() time.Time {
+		return now
+	})
+
+	assert.Nil(t, cb.allow())
+	cb.record(true)
+	assert.Nil(t, cb.allow())
+	cb.record(false)
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	// Two consecutive failures open the circuit.
+	cb.record(true)
+	cb.record(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.Equal(t, ErrCircuitOpen, cb.allow())
+
+	// Once the open duration passes a single probe is allowed through.
+	now = now.Add(time.Minute)
+	assert.Nil(t, cb.allow())
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.Equal(t, ErrCircuitOpen, cb.allow())
+
+	// A failed probe opens the circuit again.
+	cb.record(true)
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.Equal(t, ErrCircuitOpen, cb.allow())
+
+	// An abandoned probe lets the next call probe instead.
+	now = now.Add(time.Minute)
+	assert.Nil(t, cb.allow())
+	cb.abandon()
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	assert.Nil(t, cb.allow())
+	cb.record(false)
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.Nil(t, cb.allow())
+}
+
+func TestCircuitBreakerProvider(t *testing.T) {
+	provider := &testProvider{
+		syncErr:  errors.New("backend down"),
+		queryErr: NewIntentError(ErrorCodeDeviceNotFound, nil),
+	}
+	cbp := NewCircuitBreakerProvider(provider, NewCircuitBreaker(2, time.Minute))
+
+	// Deliberate error codes don't count as failures.
+	for i := 0; i < 3; i++ {
+		_, err := cbp.Query(context.Background(), &QueryRequest{})
+		intentErr, _ := asIntentError(err)
+		assert.Equal(t, ErrorCodeDeviceNotFound, intentErr.ErrorCode)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := cbp.Sync(context.Background(), "agent-id")
+		assert.Equal(t, provider.syncErr, err)
+	}
+
+	_, err := cbp.Query(context.Background(), &QueryRequest{})
+	intentErr, ok := asIntentError(err)
+	assert.True(t, ok)
+	assert.Equal(t, ErrorCodeTransientError, intentErr.ErrorCode)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+}
+
+func TestServiceHomeGraphCircuitBreaker(t *testing.T) {
+	thg := &testHomeGraph{failOn: "agent-id"}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithHomeGraphCircuitBreaker(NewCircuitBreaker(2, time.Minute)),
+	)
+
+	for i := 0; i < 2; i++ {
+		err := svc.RequestSync(context.Background(), "agent-id")
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+
+	err := svc.RequestSync(context.Background(), "agent-id")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	var hgErr *HomeGraphError
+	assert.True(t, errors.As(err, &hgErr))
+	assert.Equal(t, "requestSync", hgErr.Method)
+	assert.Len(t, thg.paths, 2)
+}
+
+func TestServiceHomeGraphCircuitBreakerCancelled(t *testing.T) {
+	thg := &testHomeGraph{}
+	breaker := NewCircuitBreaker(2, time.Minute)
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithHomeGraphCircuitBreaker(breaker),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		err := svc.RequestSync(ctx, "agent-id")
+		assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+	}
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	assert.Nil(t, svc.RequestSync(context.Background(), "agent-id"))
+	assert.Equal(t, []string{"/v1/devices:requestSync"}, thg.paths)
+}
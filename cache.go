@@ -0,0 +1,125 @@
+package action
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachedState is a device state along with when it was recorded.
+type cachedState struct {
+	state    DeviceState
+	recorded time.Time
+}
+
+// CachingProvider serves QUERY from the most recently known state of each device, only passing the request to the
+// wrapped Provider for devices whose state is older than the TTL. States are learned from the responses to QUERY
+// and from Update, which should be called whenever the state of a device is reported (i.e. alongside ReportState).
+// The cached states of the devices targeted by EXECUTE are discarded, as the command is likely to change them.
+type CachingProvider struct {
+	provider Provider
+	ttl      time.Duration
+	clock    Clock
+
+	mu     sync.Mutex
+	states map[string]cachedState
+}
+
+// NewCachingProvider creates a provider which caches the states returned by provider for the ttl.
+func NewCachingProvider(provider Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		provider: provider,
+		ttl:      ttl,
+		clock:    systemClock(),
+		states:   map[string]cachedState{},
+	}
+}
+
+// Update records the current state of the device of the user.
+func (cp *CachingProvider) Update(agentUserID string, deviceID string, state DeviceState) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.states[agentUserID+"/"+deviceID] = cachedState{
+		state:    state,
+		recorded: cp.clock.Now(),
+	}
+}
+
+// Invalidate discards the cached state of the device of the user, so the next QUERY for it reaches the provider.
+func (cp *CachingProvider) Invalidate(agentUserID string, deviceID string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	delete(cp.states, agentUserID+"/"+deviceID)
+}
+
+// lookup returns the cached state of the device, if it is fresh.
+func (cp *CachingProvider) lookup(agentUserID string, deviceID string) (DeviceState, bool) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cached, found := cp.states[agentUserID+"/"+deviceID]
+	if !found || cp.clock.Now().Sub(cached.recorded) > cp.ttl {
+		return DeviceState{}, false
+	}
+	return cached.state, true
+}
+
+// Sync calls the wrapped provider.
+func (cp *CachingProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	return cp.provider.Sync(ctx, agentUserID)
+}
+
+// Disconnect calls the wrapped provider.
+func (cp *CachingProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	return cp.provider.Disconnect(ctx, agentUserID)
+}
+
+// Query returns the cached state of each requested device, asking the wrapped provider for any which are missing or stale.
+func (cp *CachingProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp := &QueryResponse{
+		States: map[string]DeviceState{},
+	}
+	missReq := &QueryRequest{
+		AgentID: req.AgentID,
+	}
+	for _, device := range req.Devices {
+		if state, found := cp.lookup(req.AgentID, device.ID); found {
+			resp.States[device.ID] = state
+			continue
+		}
+		missReq.Devices = append(missReq.Devices, device)
+	}
+	if len(missReq.Devices) < 1 {
+		return resp, nil
+	}
+
+	missResp, err := cp.provider.Query(ctx, missReq)
+	if err != nil {
+		return nil, err
+	} else if len(missResp.ErrorCode) > 0 {
+		return missResp, nil
+	}
+
+	for _, device := range missReq.Devices {
+		state, found := missResp.States[device.ID]
+		if !found {
+			continue
+		}
+		resp.States[device.ID] = state
+		if len(state.ErrorCode) < 1 && state.Status != "ERROR" {
+			cp.Update(req.AgentID, device.ID, state)
+		}
+	}
+	return resp, nil
+}
+
+// Execute discards the cached state of each targeted device, then calls the wrapped provider.
+func (cp *CachingProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	for _, commandArg := range req.Commands {
+		for _, device := range commandArg.TargetDevices {
+			cp.Invalidate(req.AgentID, device.ID)
+		}
+	}
+	return cp.provider.Execute(ctx, req)
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingProviderQuery(t *testing.T) {
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+			"light-2": {Status: "ERROR", ErrorCode: ErrorCodeDeviceOffline},
+		},
+	}
+	cp := NewCachingProvider(provider, time.Minute)
+	cp.clock = ClockFunc(func() time.Time {
+		return now
+	})
+
+	query := func() *QueryResponse {
+		provider.queryReq = nil
+		resp, err := cp.Query(context.Background(), &QueryRequest{
+			AgentID: "agent-id",
+			Devices: []DeviceArg{{ID: "light-1"}, {ID: "light-2"}, {ID: "light-3"}},
+		})
+		assert.Nil(t, err)
+		return resp
+	}
+
+	cp.Update("agent-id", "light-3", NewDeviceState(true).RecordBrightness(10))
+
+	resp := query()
+	assert.Equal(t, []DeviceArg{{ID: "light-1"}, {ID: "light-2"}}, provider.queryReq.Devices)
+	assert.Len(t, resp.States, 3)
+	assert.Equal(t, 10, resp.States["light-3"].State["brightness"])
+
+	// Error states aren't cached.
+	now = now.Add(30 * time.Second)
+	resp = query()
+	assert.Equal(t, []DeviceArg{{ID: "light-2"}}, provider.queryReq.Devices)
+	assert.Equal(t, true, resp.States["light-1"].State["on"])
+
+	// Stale states are requested again.
+	now = now.Add(45 * time.Second)
+	query()
+	assert.Equal(t, []DeviceArg{{ID: "light-1"}, {ID: "light-2"}, {ID: "light-3"}}, provider.queryReq.Devices)
+}
+
+func TestCachingProviderExecuteInvalidates(t *testing.T) {
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	cp := NewCachingProvider(provider, time.Minute)
+	cp.Update("agent-id", "light-1", NewDeviceState(true).RecordOnOff(false))
+
+	_, err := cp.Execute(context.Background(), &ExecuteRequest{
+		AgentID: "agent-id",
+		Commands: []CommandArg{{
+			TargetDevices: []DeviceArg{{ID: "light-1"}},
+		}},
+	})
+	assert.Nil(t, err)
+
+	resp, err := cp.Query(context.Background(), &QueryRequest{AgentID: "agent-id", Devices: []DeviceArg{{ID: "light-1"}}})
+	assert.Nil(t, err)
+	assert.NotNil(t, provider.queryReq)
+	assert.Equal(t, true, resp.States["light-1"].State["on"])
+}
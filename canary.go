@@ -0,0 +1,121 @@
+package action
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// CanaryStats counts the requests routed to one of the providers of a CanaryProvider, and how many of them failed.
+type CanaryStats struct {
+	Requests int
+	Errors   int
+}
+
+// CanaryProvider routes a percentage of users to a canary Provider and the rest to the stable Provider, allowing a new
+// backend version to be rolled out gradually behind a single fulfillment endpoint. Users are assigned by a hash of their
+// agent user ID, so each user is consistently served by the same provider; raising the percentage only moves
+// additional users to the canary.
+type CanaryProvider struct {
+	stable Provider
+	canary Provider
+
+	mu      sync.Mutex
+	percent int
+	stats   map[bool]CanaryStats
+}
+
+// NewCanaryProvider creates a provider which routes percent (0-100) of users to canary and the rest to stable.
+func NewCanaryProvider(stable Provider, canary Provider, percent int) *CanaryProvider {
+	return &CanaryProvider{
+		stable:  stable,
+		canary:  canary,
+		percent: clampInt(percent, 0, 100),
+		stats:   map[bool]CanaryStats{},
+	}
+}
+
+// SetPercent changes the percentage (0-100) of users routed to the canary.
+func (cp *CanaryProvider) SetPercent(percent int) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.percent = clampInt(percent, 0, 100)
+}
+
+// IsCanary returns whether the user is currently routed to the canary provider.
+func (cp *CanaryProvider) IsCanary(agentUserID string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(agentUserID))
+	bucket := int(h.Sum32() % 100)
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return bucket < cp.percent
+}
+
+// Stats returns the number of requests which have been routed to the stable and canary providers.
+func (cp *CanaryProvider) Stats() (stable CanaryStats, canary CanaryStats) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.stats[false], cp.stats[true]
+}
+
+// route returns the provider which should serve the user.
+func (cp *CanaryProvider) route(agentUserID string) (Provider, bool) {
+	if cp.IsCanary(agentUserID) {
+		return cp.canary, true
+	}
+	return cp.stable, false
+}
+
+// record counts the request against the provider which served it.
+func (cp *CanaryProvider) record(canary bool, err error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	stats := cp.stats[canary]
+	stats.Requests++
+	if err != nil {
+		stats.Errors++
+	}
+	cp.stats[canary] = stats
+}
+
+// Sync returns the devices supplied by the provider serving the user.
+func (cp *CanaryProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	provider, canary := cp.route(agentUserID)
+	resp, err := provider.Sync(ctx, agentUserID)
+	cp.record(canary, err)
+	return resp, err
+}
+
+// Disconnect informs both providers that the user has unlinked, since either may hold state for the user
+// if the percentage has changed. The error of the provider serving the user is returned.
+func (cp *CanaryProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	provider, canary := cp.route(agentUserID)
+	other := cp.stable
+	if !canary {
+		other = cp.canary
+	}
+
+	err := provider.Disconnect(ctx, agentUserID)
+	other.Disconnect(ctx, agentUserID)
+	cp.record(canary, err)
+	return err
+}
+
+// Query returns the states supplied by the provider serving the user.
+func (cp *CanaryProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	provider, canary := cp.route(req.AgentID)
+	resp, err := provider.Query(ctx, req)
+	cp.record(canary, err)
+	return resp, err
+}
+
+// Execute applies the commands using the provider serving the user.
+func (cp *CanaryProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	provider, canary := cp.route(req.AgentID)
+	resp, err := provider.Execute(ctx, req)
+	cp.record(canary, err)
+	return resp, err
+}
@@ -0,0 +1,57 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryProviderRouting(t *testing.T) {
+	cp := NewCanaryProvider(&testProvider{}, &testProvider{}, 20)
+
+	canaryUsers := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if cp.IsCanary(userID) {
+			canaryUsers[userID] = true
+		}
+		// Routing is sticky.
+		assert.Equal(t, canaryUsers[userID], cp.IsCanary(userID))
+	}
+	assert.InDelta(t, 200, len(canaryUsers), 60)
+
+	// Raising the percentage keeps the existing canary users on the canary.
+	cp.SetPercent(50)
+	for userID := range canaryUsers {
+		assert.True(t, cp.IsCanary(userID))
+	}
+
+	cp.SetPercent(0)
+	assert.False(t, cp.IsCanary("user-1"))
+	cp.SetPercent(150)
+	assert.True(t, cp.IsCanary("user-1"))
+}
+
+func TestCanaryProviderStats(t *testing.T) {
+	stable := &testProvider{}
+	canary := &testProvider{syncErr: errors.New("sync failed")}
+
+	cp := NewCanaryProvider(stable, canary, 100)
+	_, err := cp.Sync(context.Background(), "agent-id")
+	assert.NotNil(t, err)
+	_, err = cp.Query(context.Background(), &QueryRequest{AgentID: "agent-id"})
+	assert.Nil(t, err)
+	assert.NotNil(t, canary.queryReq)
+
+	cp.SetPercent(0)
+	_, err = cp.Query(context.Background(), &QueryRequest{AgentID: "agent-id"})
+	assert.Nil(t, err)
+	assert.NotNil(t, stable.queryReq)
+
+	stableStats, canaryStats := cp.Stats()
+	assert.Equal(t, CanaryStats{Requests: 1}, stableStats)
+	assert.Equal(t, CanaryStats{Requests: 2, Errors: 1}, canaryStats)
+}
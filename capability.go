@@ -0,0 +1,362 @@
+package action
+
+import (
+	"sort"
+	"strings"
+)
+
+// TraitCapability describes the attributes a trait is configured with, the commands it accepts and the state values it reports.
+// Attributes are supplied in response to SYNC, commands are received in EXECUTE and states are returned by QUERY and ReportState.
+type TraitCapability struct {
+	Trait      string
+	Attributes []string
+	Commands   []string
+	States     []string
+}
+
+// traitCapabilities maps each trait to its capabilities.
+// See https://developers.google.com/assistant/smarthome/traits for the definition of each trait.
+var traitCapabilities = map[string]TraitCapability{
+	TraitAppSelector: {
+		Attributes: []string{"availableApplications"},
+		Commands:   []string{"action.devices.commands.appInstall", "action.devices.commands.appSearch", "action.devices.commands.appSelect"},
+		States:     []string{"currentApplication"},
+	},
+	TraitArmDisarm: {
+		Attributes: []string{"availableArmLevels"},
+		Commands:   []string{"action.devices.commands.ArmDisarm"},
+		States:     []string{"isArmed", "currentArmLevel", "exitAllowance"},
+	},
+	TraitBrightness: {
+		Attributes: []string{"commandOnlyBrightness"},
+		Commands:   []string{"action.devices.commands.BrightnessAbsolute", "action.devices.commands.BrightnessRelative"},
+		States:     []string{"brightness"},
+	},
+	TraitCameraStream: {
+		Attributes: []string{"cameraStreamSupportedProtocols", "cameraStreamNeedAuthToken", "cameraStreamNeedDrmEncryption"},
+		Commands:   []string{"action.devices.commands.GetCameraStream"},
+	},
+	TraitChannel: {
+		Attributes: []string{"availableChannels", "commandOnlyChannels"},
+		Commands:   []string{"action.devices.commands.selectChannel", "action.devices.commands.relativeChannel", "action.devices.commands.returnChannel"},
+	},
+	TraitColorSetting: {
+		Attributes: []string{"commandOnlyColorSetting", "colorModel", "colorTemperatureRange"},
+		Commands:   []string{"action.devices.commands.ColorAbsolute"},
+		States:     []string{"color"},
+	},
+	TraitCook: {
+		Attributes: []string{"supportedCookingModes", "foodPresets"},
+		Commands:   []string{"action.devices.commands.Cook"},
+		States:     []string{"currentCookingMode", "currentFoodPreset", "currentFoodQuantity", "currentFoodUnit"},
+	},
+	TraitDispense: {
+		Attributes: []string{"supportedDispenseItems", "supportedDispensePresets"},
+		Commands:   []string{"action.devices.commands.Dispense"},
+		States:     []string{"dispenseItems"},
+	},
+	TraitDock: {
+		Commands: []string{"action.devices.commands.Dock"},
+		States:   []string{"isDocked"},
+	},
+	TraitEnergyStorage: {
+		Attributes: []string{"queryOnlyEnergyStorage", "energyStorageDistanceUnitForUX", "isRechargeable"},
+		Commands:   []string{"action.devices.commands.Charge"},
+		States:     []string{"descriptiveCapacityRemaining", "capacityRemaining", "capacityUntilFull", "isCharging", "isPluggedIn"},
+	},
+	TraitFanSpeed: {
+		Attributes: []string{"availableFanSpeeds", "reversible", "commandOnlyFanSpeed", "supportsFanSpeedPercent"},
+		Commands:   []string{"action.devices.commands.SetFanSpeed", "action.devices.commands.SetFanSpeedRelative", "action.devices.commands.Reverse"},
+		States:     []string{"currentFanSpeedSetting", "currentFanSpeedPercent"},
+	},
+	TraitFill: {
+		Attributes: []string{"availableFillLevels"},
+		Commands:   []string{"action.devices.commands.Fill"},
+		States:     []string{"isFilled", "currentFillLevel", "currentFillPercent"},
+	},
+	TraitHumiditySetting: {
+		Attributes: []string{"humiditySetpointRange", "commandOnlyHumiditySetting", "queryOnlyHumiditySetting"},
+		Commands:   []string{"action.devices.commands.SetHumidity", "action.devices.commands.HumidityRelative"},
+		States:     []string{"humiditySetpointPercent", "humidityAmbientPercent"},
+	},
+	TraitInputSelector: {
+		Attributes: []string{"availableInputs", "commandOnlyInputSelector", "orderedInputs"},
+		Commands:   []string{"action.devices.commands.SetInput", "action.devices.commands.NextInput", "action.devices.commands.PreviousInput"},
+		// input is the value recorded by DeviceState.RecordInput.
+		States: []string{"currentInput", "input"},
+	},
+	TraitLightEffects: {
+		Attributes: []string{"defaultColorLoopDuration", "defaultSleepDuration", "defaultWakeDuration", "supportedEffects"},
+		Commands:   []string{"action.devices.commands.ColorLoop", "action.devices.commands.Sleep", "action.devices.commands.StopEffect", "action.devices.commands.Wake"},
+		States:     []string{"activeLightEffect", "lightEffectEndUnixTimestampSec"},
+	},
+	TraitLocator: {
+		Commands: []string{"action.devices.commands.Locate"},
+	},
+	TraitLockUnlock: {
+		Commands: []string{"action.devices.commands.LockUnlock"},
+		States:   []string{"isLocked", "isJammed"},
+	},
+	TraitMediaState: {
+		Attributes: []string{"supportActivityState", "supportPlaybackState"},
+		States:     []string{"activityState", "playbackState"},
+	},
+	TraitModes: {
+		Attributes: []string{"availableModes", "commandOnlyModes", "queryOnlyModes"},
+		Commands:   []string{"action.devices.commands.SetModes"},
+		States:     []string{"currentModeSettings"},
+	},
+	TraitNetworkControl: {
+		Attributes: []string{
+			"supportsEnablingGuestNetwork",
+			"supportsDisablingGuestNetwork",
+			"supportsGettingGuestNetworkPassword",
+			"networkProfiles",
+			"supportsEnablingNetworkProfile",
+			"supportsDisablingNetworkProfile",
+			"supportsNetworkDownloadSpeedTest",
+			"supportsNetworkUploadSpeedTest",
+		},
+		Commands: []string{
+			"action.devices.commands.EnableDisableGuestNetwork",
+			"action.devices.commands.EnableDisableNetworkProfile",
+			"action.devices.commands.GetGuestNetworkPassword",
+			"action.devices.commands.TestNetworkSpeed",
+		},
+		States: []string{
+			"networkEnabled",
+			"networkSettings",
+			"guestNetworkEnabled",
+			"guestNetworkSettings",
+			"numConnectedDevices",
+			"networkUsageMB",
+			"networkUsageLimitMB",
+			"networkUsageUnlimited",
+			"lastNetworkDownloadSpeedTest",
+			"lastNetworkUploadSpeedTest",
+			"networkSpeedTestInProgress",
+			"networkProfilesState",
+		},
+	},
+	TraitObjectDetection: {},
+	TraitOccupancySensing: {
+		Attributes: []string{"occupancySensorConfiguration"},
+		States:     []string{"occupancy"},
+	},
+	TraitOnOff: {
+		Attributes: []string{"commandOnlyOnOff", "queryOnlyOnOff"},
+		Commands:   []string{"action.devices.commands.OnOff"},
+		States:     []string{"on"},
+	},
+	TraitOpenClose: {
+		Attributes: []string{"discreteOnlyOpenClose", "openDirection", "commandOnlyOpenClose", "queryOnlyOpenClose"},
+		Commands:   []string{"action.devices.commands.OpenClose", "action.devices.commands.OpenCloseRelative"},
+		States:     []string{"openPercent", "openState"},
+	},
+	TraitReboot: {
+		Commands: []string{"action.devices.commands.Reboot"},
+	},
+	TraitRotation: {
+		Attributes: []string{"supportsDegrees", "supportsPercent", "rotationDegreesRange", "supportsContinuousRotation", "commandOnlyRotation"},
+		Commands:   []string{"action.devices.commands.RotateAbsolute"},
+		States:     []string{"rotationDegrees", "rotationPercent"},
+	},
+	TraitRunCycle: {
+		States: []string{"currentRunCycle", "currentTotalRemainingTime", "currentCycleRemainingTime"},
+	},
+	TraitScene: {
+		Attributes: []string{"sceneReversible"},
+		Commands:   []string{"action.devices.commands.ActivateScene"},
+	},
+	TraitSensorState: {
+		Attributes: []string{"sensorStatesSupported"},
+		States:     []string{"currentSensorStateData"},
+	},
+	TraitSoftwareUpdate: {
+		Commands: []string{"action.devices.commands.SoftwareUpdate"},
+		States:   []string{"lastSoftwareUpdateUnixTimestampSec"},
+	},
+	TraitStartStop: {
+		Attributes: []string{"pausable", "availableZones"},
+		Commands:   []string{"action.devices.commands.StartStop", "action.devices.commands.PauseUnpause"},
+		States:     []string{"isRunning", "isPaused", "activeZones"},
+	},
+	TraitStatusReport: {
+		States: []string{"currentStatusReport"},
+	},
+	TraitTemperatureControl: {
+		Attributes: []string{
+			"temperatureRange",
+			"temperatureStepCelsius",
+			"temperatureUnitForUX",
+			"commandOnlyTemperatureControl",
+			"queryOnlyTemperatureControl",
+		},
+		Commands: []string{"action.devices.commands.SetTemperature"},
+		States:   []string{"temperatureSetpointCelsius", "temperatureAmbientCelsius"},
+	},
+	TraitTemperatureSetting: {
+		Attributes: []string{
+			"availableThermostatModes",
+			"thermostatTemperatureRange",
+			"thermostatTemperatureUnit",
+			"bufferRangeCelsius",
+			"commandOnlyTemperatureSetting",
+			"queryOnlyTemperatureSetting",
+		},
+		Commands: []string{
+			"action.devices.commands.ThermostatTemperatureSetpoint",
+			"action.devices.commands.ThermostatTemperatureSetRange",
+			"action.devices.commands.ThermostatSetMode",
+			"action.devices.commands.TemperatureRelative",
+		},
+		States: []string{
+			"activeThermostatMode",
+			"targetTempReachedEstimateUnixTimestampSec",
+			"thermostatHumidityAmbient",
+			"thermostatMode",
+			"thermostatTemperatureAmbient",
+			"thermostatTemperatureSetpoint",
+			"thermostatTemperatureSetpointHigh",
+			"thermostatTemperatureSetpointLow",
+		},
+	},
+	TraitTimer: {
+		Attributes: []string{"maxTimerLimitSec", "commandOnlyTimer"},
+		Commands: []string{
+			"action.devices.commands.TimerStart",
+			"action.devices.commands.TimerAdjust",
+			"action.devices.commands.TimerPause",
+			"action.devices.commands.TimerResume",
+			"action.devices.commands.TimerCancel",
+		},
+		States: []string{"timerRemainingSec", "timerPaused"},
+	},
+	TraitToggles: {
+		Attributes: []string{"availableToggles", "commandOnlyToggles", "queryOnlyToggles"},
+		Commands:   []string{"action.devices.commands.SetToggles"},
+		States:     []string{"currentToggleSettings"},
+	},
+	TraitTransportControl: {
+		Attributes: []string{"transportControlSupportedCommands"},
+		Commands: []string{
+			"action.devices.commands.mediaStop",
+			"action.devices.commands.mediaNext",
+			"action.devices.commands.mediaPrevious",
+			"action.devices.commands.mediaPause",
+			"action.devices.commands.mediaResume",
+			"action.devices.commands.mediaSeekRelative",
+			"action.devices.commands.mediaSeekToPosition",
+			"action.devices.commands.mediaRepeatMode",
+			"action.devices.commands.mediaShuffle",
+			"action.devices.commands.mediaClosedCaptioningOn",
+			"action.devices.commands.mediaClosedCaptioningOff",
+		},
+	},
+	TraitVolume: {
+		Attributes: []string{"volumeMaxLevel", "volumeCanMuteAndUnmute", "volumeDefaultPercentage", "levelStepSize", "commandOnlyVolume"},
+		Commands:   []string{"action.devices.commands.mute", "action.devices.commands.setVolume", "action.devices.commands.volumeRelative"},
+		States:     []string{"currentVolume", "isMuted"},
+	},
+}
+
+// TraitCapabilities returns the capabilities of every trait, sorted by trait name.
+// The returned values may be freely modified by the caller.
+func TraitCapabilities() []TraitCapability {
+	var capabilities []TraitCapability
+	for trait := range traitCapabilities {
+		capability, _ := LookupTraitCapability(trait)
+		capabilities = append(capabilities, capability)
+	}
+	sort.Slice(capabilities, func(i, j int) bool {
+		return capabilities[i].Trait < capabilities[j].Trait
+	})
+	return capabilities
+}
+
+// LookupTraitCapability returns the capabilities of the specified trait, if it is known.
+// The returned value may be freely modified by the caller.
+func LookupTraitCapability(trait string) (TraitCapability, bool) {
+	capability, found := traitCapabilities[trait]
+	if !found {
+		return TraitCapability{}, false
+	}
+
+	return TraitCapability{
+		Trait:      trait,
+		Attributes: append([]string(nil), capability.Attributes...),
+		Commands:   append([]string(nil), capability.Commands...),
+		States:     append([]string(nil), capability.States...),
+	}, true
+}
+
+// TraitForCommand returns the trait which accepts the named command, if it is known.
+func TraitForCommand(command string) (string, bool) {
+	for trait, capability := range traitCapabilities {
+		for _, c := range capability.Commands {
+			if c == command {
+				return trait, true
+			}
+		}
+	}
+	return "", false
+}
+
+// TraitForState returns the trait which reports the named state value, if it is known.
+func TraitForState(state string) (string, bool) {
+	trait, found := stateTraits[state]
+	return trait, found
+}
+
+// stateTraits maps each state value to the trait a device must have to report it.
+// State values not listed here are not validated.
+var stateTraits = buildStateTraits()
+
+func buildStateTraits() map[string]string {
+	states := map[string]string{}
+	for trait, capability := range traitCapabilities {
+		for _, state := range capability.States {
+			states[state] = trait
+		}
+	}
+	return states
+}
+
+// traitShortName returns the name of the trait without the action.devices.traits. prefix (i.e. OnOff).
+// This is the form used in attribute names such as queryOnlyOnOff.
+func traitShortName(trait string) string {
+	return strings.TrimPrefix(trait, "action.devices.traits.")
+}
+
+// SupportsCommand returns whether one of the traits of this device accepts the named command.
+// Traits which the device has marked as query only (i.e. queryOnlyOnOff) are not considered to accept commands.
+func (d *Device) SupportsCommand(name string) bool {
+	for trait := range d.Traits {
+		if queryOnly, _ := d.Attributes["queryOnly"+traitShortName(trait)].(bool); queryOnly {
+			continue
+		}
+
+		for _, command := range traitCapabilities[trait].Commands {
+			if command == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StateKeys returns the sorted set of state values this device is expected to report, as defined by its traits.
+// The online state is always included. Traits which the device has marked as command only (i.e. commandOnlyOnOff)
+// don't report state so their values are not included.
+func (d *Device) StateKeys() []string {
+	keys := []string{"online"}
+	for trait := range d.Traits {
+		if commandOnly, _ := d.Attributes["commandOnly"+traitShortName(trait)].(bool); commandOnly {
+			continue
+		}
+
+		keys = append(keys, traitCapabilities[trait].States...)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraitCapabilitiesCoverAllTraits(t *testing.T) {
+	for trait := range validTraits {
+		_, found := traitCapabilities[trait]
+		assert.True(t, found, trait)
+	}
+	for trait := range traitCapabilities {
+		assert.True(t, IsValidTrait(trait), trait)
+	}
+}
+
+func TestDeviceSupportsCommand(t *testing.T) {
+	light := NewLight("light-id").AddBrightnessTrait(false)
+	sensor := NewDevice("sensor-id", DeviceTypeSensor).AddOnOffTrait(false, true)
+
+	assert.True(t, light.SupportsCommand("action.devices.commands.OnOff"))
+	assert.True(t, light.SupportsCommand("action.devices.commands.BrightnessRelative"))
+	assert.False(t, light.SupportsCommand("action.devices.commands.setVolume"))
+	assert.False(t, light.SupportsCommand("action.devices.commands.Unknown"))
+	assert.False(t, sensor.SupportsCommand("action.devices.commands.OnOff"))
+}
+
+func TestDeviceStateKeys(t *testing.T) {
+	light := NewLight("light-id").AddBrightnessTrait(false).AddColourTrait(RGB, true)
+	assert.Equal(t, []string{"brightness", "on", "online"}, light.StateKeys())
+
+	receiver := NewSimpleAVReceiver("receiver-id", []DeviceInput{{Key: "input-1"}}, 50, true, false)
+	assert.Equal(t, []string{"currentInput", "currentVolume", "input", "isMuted", "on", "online"}, receiver.StateKeys())
+}
+
+func TestTraitCapabilities(t *testing.T) {
+	capabilities := TraitCapabilities()
+	assert.Len(t, capabilities, len(traitCapabilities))
+	for idx := 1; idx < len(capabilities); idx++ {
+		assert.Less(t, capabilities[idx-1].Trait, capabilities[idx].Trait)
+	}
+
+	onOff, found := LookupTraitCapability(TraitOnOff)
+	assert.True(t, found)
+	assert.Equal(t, TraitCapability{
+		Trait:      TraitOnOff,
+		Attributes: []string{"commandOnlyOnOff", "queryOnlyOnOff"},
+		Commands:   []string{"action.devices.commands.OnOff"},
+		States:     []string{"on"},
+	}, onOff)
+
+	// Modifying the returned capability doesn't affect the matrix.
+	onOff.States[0] = "off"
+	onOff, _ = LookupTraitCapability(TraitOnOff)
+	assert.Equal(t, []string{"on"}, onOff.States)
+
+	_, found = LookupTraitCapability("action.devices.traits.Unknown")
+	assert.False(t, found)
+}
+
+func TestTraitForCommandAndState(t *testing.T) {
+	trait, found := TraitForCommand("action.devices.commands.LockUnlock")
+	assert.True(t, found)
+	assert.Equal(t, TraitLockUnlock, trait)
+
+	_, found = TraitForCommand("action.devices.commands.Unknown")
+	assert.False(t, found)
+
+	trait, found = TraitForState("currentVolume")
+	assert.True(t, found)
+	assert.Equal(t, TraitVolume, trait)
+}
+
+func TestTraitCapabilitiesMatchDeviceAttributes(t *testing.T) {
+	devices := []*Device{
+		NewSimpleAVReceiver("receiver-id", []DeviceInput{{Key: "input-1"}}, 50, true, true),
+		NewLight("light-id").AddBrightnessTrait(true).AddColourTrait(RGB, true).AddColourTemperatureTrait(2000, 9000, true),
+		NewDevice("tv-id", DeviceTypeTV).AddAppSelectorTrait([]DeviceApp{NewApp("youtube")}),
+		NewDevice("washer-id", DeviceTypeWasher).AddModesTrait([]DeviceMode{NewMode("load", false)}, true, true),
+		NewDevice("fan-id", DeviceTypeFan).AddTogglesTrait([]DeviceToggle{NewToggle("sleep")}, true, true).AddOnOffTrait(true, true),
+	}
+
+	// Every attribute set by this package must be declared by one of the traits of the device.
+	for _, device := range devices {
+		for attribute := range device.Attributes {
+			declared := false
+			for trait := range device.Traits {
+				capability, _ := LookupTraitCapability(trait)
+				for _, a := range capability.Attributes {
+					declared = declared || a == attribute
+				}
+			}
+			assert.True(t, declared, "%s on %s", attribute, device.ID)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package action
+
+import "crypto/subtle"
+
+// ChallengeType defines which secondary user verification is required before a command can be executed.
+// See https://developers.google.com/assistant/smarthome/develop/secondary-user-verification
+const (
+	// ChallengeAckNeeded requests the user explicitly acknowledge the command before it is executed.
+	ChallengeAckNeeded = "ackNeeded"
+	// ChallengePinNeeded requests the user supply a PIN before the command is executed.
+	ChallengePinNeeded = "pinNeeded"
+	// ChallengeFailedPinNeeded indicates the supplied PIN was incorrect and the user should be asked again.
+	ChallengeFailedPinNeeded = "challengeFailedPinNeeded"
+)
+
+// CommandChallenge contains the response of the user to a previously requested secondary verification.
+// Only one of the fields will be set.
+type CommandChallenge struct {
+	Ack bool   `json:"ack,omitempty"`
+	Pin string `json:"pin,omitempty"`
+}
+
+// CheckAck returns ChallengeAckNeeded if the user has not yet acknowledged this command, or an empty string if they have.
+// The result can be supplied directly to ExecuteResponse.AddChallengeNeeded.
+func (c Command) CheckAck() string {
+	if c.Challenge == nil || !c.Challenge.Ack {
+		return ChallengeAckNeeded
+	}
+	return ""
+}
+
+// CheckPin returns ChallengePinNeeded if the user has not yet supplied a PIN for this command,
+// ChallengeFailedPinNeeded if the supplied PIN does not match the expected value,
+// or an empty string if the supplied PIN is correct.
+// The result can be supplied directly to ExecuteResponse.AddChallengeNeeded.
+func (c Command) CheckPin(expectedPin string) string {
+	if c.Challenge == nil || len(c.Challenge.Pin) < 1 {
+		return ChallengePinNeeded
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Challenge.Pin), []byte(expectedPin)) != 1 {
+		return ChallengeFailedPinNeeded
+	}
+	return ""
+}
+
+// AddChallengeNeeded records that the supplied devices require the specified challenge to be completed before the command
+// can be executed. Google will prompt the user and reissue the command with the challenge response included.
+func (er *ExecuteResponse) AddChallengeNeeded(challengeType string, ids ...string) {
+	if er.ChallengeNeeded == nil {
+		er.ChallengeNeeded = map[string][]string{}
+	}
+	er.ChallengeNeeded[challengeType] = append(er.ChallengeNeeded[challengeType], ids...)
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandCheckAck(t *testing.T) {
+	c := Command{
+		Name:  "action.devices.commands.OnOff",
+		OnOff: &CommandOnOff{On: false},
+	}
+	assert.Equal(t, ChallengeAckNeeded, c.CheckAck())
+
+	c.Challenge = &CommandChallenge{Ack: true}
+	assert.Equal(t, "", c.CheckAck())
+}
+
+func TestCommandCheckPin(t *testing.T) {
+	c := Command{
+		Name: "action.devices.commands.LockUnlock",
+	}
+	assert.Equal(t, ChallengePinNeeded, c.CheckPin("1234"))
+
+	c.Challenge = &CommandChallenge{Pin: "4321"}
+	assert.Equal(t, ChallengeFailedPinNeeded, c.CheckPin("1234"))
+
+	c.Challenge = &CommandChallenge{Pin: "1234"}
+	assert.Equal(t, "", c.CheckPin("1234"))
+}
+
+func TestExecuteResponseAddChallengeNeeded(t *testing.T) {
+	resp := &ExecuteResponse{}
+	resp.AddChallengeNeeded(ChallengePinNeeded, "123")
+	resp.AddChallengeNeeded(ChallengePinNeeded, "456")
+
+	assert.Equal(t, map[string][]string{
+		ChallengePinNeeded: {"123", "456"},
+	}, resp.ChallengeNeeded)
+}
+
+func TestDeviceCheckChallenge(t *testing.T) {
+	d := NewGarageDoor("garage-id").RequireChallenge("action.devices.commands.LockUnlock", ChallengePinNeeded)
+
+	c := Command{
+		Name: "action.devices.commands.OpenClose",
+	}
+	assert.Equal(t, ChallengeAckNeeded, d.CheckChallenge(c, ""))
+	c.Challenge = &CommandChallenge{Ack: true}
+	assert.Equal(t, "", d.CheckChallenge(c, ""))
+
+	c = Command{
+		Name:      "action.devices.commands.LockUnlock",
+		Challenge: &CommandChallenge{Pin: "4321"},
+	}
+	assert.Equal(t, ChallengeFailedPinNeeded, d.CheckChallenge(c, "1234"))
+
+	c = Command{
+		Name: "action.devices.commands.OnOff",
+	}
+	assert.Equal(t, "", d.CheckChallenge(c, ""))
+}
@@ -0,0 +1,113 @@
+package action
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock provides the current time to the Service.
+// The default uses the system time; tests may supply a fixed or manually advanced clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc allows a function to be used as a Clock.
+type ClockFunc func() time.Time
+
+// Now calls the underlying function.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// Timer is a pending call scheduled by a TimerClock. *time.Timer implements it.
+type Timer interface {
+	// Stop prevents the call from running, returning false if it has already run or been stopped.
+	Stop() bool
+}
+
+// TimerClock is a Clock which also schedules the delayed and periodic work of the Service (i.e. debounced SYNC
+// requests and the Run loops), so tests can trigger the work by advancing the clock rather than waiting for it.
+// Clocks which don't implement TimerClock use the system timers.
+type TimerClock interface {
+	Clock
+	// AfterFunc calls f on its own goroutine once the duration has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// IDGenerator provides the unique IDs the Service includes in its HomeGraph requests.
+// The default generates random UUIDs; tests may supply a deterministic sequence.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc allows a function to be used as an IDGenerator.
+type IDGeneratorFunc func() string
+
+// NewID calls the underlying function.
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// WithClock replaces the source of the current time used for events, debug records, timeouts and expiry.
+// If the clock implements TimerClock it also schedules the Run loops and debounced requests.
+func WithClock(clock Clock) ServiceOption {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithIDGenerator replaces the source of the request and event IDs sent to the HomeGraph.
+func WithIDGenerator(generator IDGenerator) ServiceOption {
+	return func(s *Service) {
+		s.idGenerator = generator
+	}
+}
+
+func systemClock() Clock {
+	return ClockFunc(time.Now)
+}
+
+func uuidGenerator() IDGenerator {
+	return IDGeneratorFunc(func() string {
+		return uuid.New().String()
+	})
+}
+
+// now returns the current time according to the configured clock.
+func (s *Service) now() time.Time {
+	return s.clock.Now()
+}
+
+// afterFunc calls f on its own goroutine once the duration has elapsed according to the configured clock.
+func (s *Service) afterFunc(d time.Duration, f func()) Timer {
+	if tc, ok := s.clock.(TimerClock); ok {
+		return tc.AfterFunc(d, f)
+	}
+	return time.AfterFunc(d, f)
+}
+
+// runEvery calls f each time the interval elapses according to the configured clock, until the context is cancelled.
+// The interval is measured from when the previous call returned, so calls never overlap.
+func (s *Service) runEvery(ctx context.Context, interval time.Duration, f func()) {
+	for {
+		elapsed := make(chan struct{})
+		timer := s.afterFunc(interval, func() {
+			close(elapsed)
+		})
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-elapsed:
+		}
+		f()
+	}
+}
+
+// newID returns a new unique ID from the configured generator.
+func (s *Service) newID() string {
+	return s.idGenerator.NewID()
+}
@@ -0,0 +1,169 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceClockAndIDGenerator(t *testing.T) {
+	thg := &testHomeGraph{}
+	fixed := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	nextID := 0
+
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithDebugRecorder(10),
+		WithClock(ClockFunc(func() time.Time {
+			return fixed
+		})),
+		WithIDGenerator(IDGeneratorFunc(func() string {
+			nextID++
+			return fmt.Sprintf("id-%d", nextID)
+		})),
+	)
+
+	var events []Event
+	svc.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": NewDeviceState(true).RecordOnOff(true),
+	})
+	assert.Nil(t, err)
+
+	var body struct {
+		RequestID string `json:"requestId"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, "id-1", body.RequestID)
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, fixed, events[0].(ReportStateSent).Time)
+	assert.Equal(t, fixed, svc.DebugSnapshot().ReportStates[0].Time)
+}
+
+// testClock is a TimerClock whose time only changes when it is advanced. Timers run synchronously within Advance,
+// in the order they are due, so their effects can be checked as soon as Advance returns.
+type testClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*testTimer
+}
+
+type testTimer struct {
+	clock *testClock
+	due   time.Time
+	f     func()
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{
+		now: now,
+	}
+}
+
+func (tc *testClock) Now() time.Time {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.now
+}
+
+func (tc *testClock) AfterFunc(d time.Duration, f func()) Timer {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	timer := &testTimer{
+		clock: tc,
+		due:   tc.now.Add(d),
+		f:     f,
+	}
+	tc.timers = append(tc.timers, timer)
+	return timer
+}
+
+func (tt *testTimer) Stop() bool {
+	tt.clock.mu.Lock()
+	defer tt.clock.mu.Unlock()
+
+	for idx, timer := range tt.clock.timers {
+		if timer == tt {
+			tt.clock.timers = append(tt.clock.timers[:idx], tt.clock.timers[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward, running each timer which becomes due.
+func (tc *testClock) Advance(d time.Duration) {
+	tc.mu.Lock()
+	end := tc.now.Add(d)
+	for {
+		var next *testTimer
+		nextIdx := -1
+		for idx, timer := range tc.timers {
+			if !timer.due.After(end) && (next == nil || timer.due.Before(next.due)) {
+				next, nextIdx = timer, idx
+			}
+		}
+		if next == nil {
+			break
+		}
+		tc.timers = append(tc.timers[:nextIdx], tc.timers[nextIdx+1:]...)
+		if next.due.After(tc.now) {
+			tc.now = next.due
+		}
+		tc.mu.Unlock()
+		next.f()
+		tc.mu.Lock()
+	}
+	tc.now = end
+	tc.mu.Unlock()
+}
+
+// waitForTimer waits until a timer has been scheduled, i.e. by a Run loop waiting for its next interval.
+func (tc *testClock) waitForTimer(t *testing.T) {
+	assert.Eventually(t, func() bool {
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		return len(tc.timers) > 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestServiceRunEvery(t *testing.T) {
+	clock := newTestClock(time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan time.Time, 2)
+	done := make(chan struct{})
+	go func() {
+		svc.runEvery(ctx, time.Minute, func() {
+			calls <- clock.Now()
+		})
+		close(done)
+	}()
+
+	clock.waitForTimer(t)
+	clock.Advance(59 * time.Second)
+	assert.Empty(t, calls)
+	clock.Advance(time.Second)
+	assert.Equal(t, time.Date(2020, 11, 1, 12, 1, 0, 0, time.UTC), <-calls)
+
+	clock.waitForTimer(t)
+	clock.Advance(time.Minute)
+	assert.Equal(t, time.Date(2020, 11, 1, 12, 2, 0, 0, time.UTC), <-calls)
+
+	clock.waitForTimer(t)
+	cancel()
+	<-done
+	clock.Advance(time.Minute)
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,39 @@
+// Command openapi writes the OpenAPI document describing the contract of a provider implemented as an HTTP webhook,
+// so it can be published to teams building providers in other languages.
+//
+// Usage:
+//
+//	openapi -out openapi.json
+//
+// If no file is specified the document is written to stdout.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+)
+
+func main() {
+	var (
+		outFile = flag.String("out", "", "The file to write the OpenAPI document to; stdout is used if not set")
+	)
+	flag.Parse()
+
+	spec, err := action.OpenAPISpec()
+	if err != nil {
+		log.Fatalf("error generating openapi document: %s", err)
+	}
+
+	if len(*outFile) > 0 {
+		err = ioutil.WriteFile(*outFile, spec, 0644)
+	} else {
+		_, err = os.Stdout.Write(spec)
+	}
+	if err != nil {
+		log.Fatalf("error writing openapi document: %s", err)
+	}
+}
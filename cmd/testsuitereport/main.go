@@ -0,0 +1,46 @@
+// Command testsuitereport reads a SYNC response and lists which Google Smart Home Test Suite scenarios apply to its
+// devices, along with the commands and states the provider must handle to pass them.
+//
+// Usage:
+//
+//	testsuitereport -sync-file sync.json
+//
+// The file may contain a complete SYNC fulfillment response, its payload, or a JSON array of devices.
+// If no file is specified the SYNC response is read from stdin.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+)
+
+func main() {
+	var (
+		syncFile = flag.String("sync-file", "", "The file containing the SYNC response; stdin is read if not set")
+	)
+	flag.Parse()
+
+	var data []byte
+	var err error
+	if len(*syncFile) > 0 {
+		data, err = ioutil.ReadFile(*syncFile)
+	} else {
+		data, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Fatalf("error reading sync response: %s", err)
+	}
+
+	devices, err := action.ParseSyncJSON(data)
+	if err != nil {
+		log.Fatalf("error parsing sync response: %s", err)
+	}
+
+	if err := action.NewTestSuiteReport(devices).Write(os.Stdout); err != nil {
+		log.Fatalf("error writing report: %s", err)
+	}
+}
@@ -11,6 +11,12 @@ type Command struct {
 	Name    string
 	Generic *CommandGeneric
 
+	// Challenge is set if the user has responded to a secondary verification request for this command.
+	Challenge *CommandChallenge
+	// FollowUpToken is set if Google would like to be informed of the final result of this command.
+	// See Service.SendFollowUp
+	FollowUpToken string
+
 	BrightnessAbsolute *CommandBrightnessAbsolute
 	BrightnessRelative *CommandBrightnessRelative
 	ColorAbsolute      *CommandColorAbsolute
@@ -21,6 +27,9 @@ type Command struct {
 	SetInput           *CommandSetInput
 	NextInput          *CommandNextInput
 	PreviousInput      *CommandPreviousInput
+	SelectChannel      *CommandSelectChannel
+	RelativeChannel    *CommandRelativeChannel
+	ReturnChannel      *CommandReturnChannel
 }
 
 // MarshalJSON is a custom JSON serializer for our Command
@@ -48,24 +57,55 @@ func (c Command) MarshalJSON() ([]byte, error) {
 		details = c.NextInput
 	case "action.devices.commands.PreviousInput":
 		details = c.PreviousInput
+	case "action.devices.commands.selectChannel":
+		details = c.SelectChannel
+	case "action.devices.commands.relativeChannel":
+		details = c.RelativeChannel
+	case "action.devices.commands.returnChannel":
+		details = c.ReturnChannel
 	default:
-		return json.Marshal(c.Generic)
+		if c.Generic == nil {
+			return json.Marshal(c.Generic)
+		}
+		// Re-use the details from the generic command so the challenge and follow-up token can be included alongside.
+		c.Name = c.Generic.Command
+		details = c.Generic.Params
 	}
 
 	var tmp struct {
-		Command string      `json:"command"`
-		Params  interface{} `json:"params"`
+		Command   string            `json:"command"`
+		Params    interface{}       `json:"params"`
+		Challenge *CommandChallenge `json:"challenge,omitempty"`
 	}
 	tmp.Command = c.Name
 	tmp.Params = details
+	tmp.Challenge = c.Challenge
+
+	if len(c.FollowUpToken) > 0 {
+		// The token is supplied alongside the command-specific params so merge it back in.
+		params, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		merged := map[string]interface{}{}
+		if err := unmarshalUseNumber(params, &merged); err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = map[string]interface{}{}
+		}
+		merged["followUpToken"] = c.FollowUpToken
+		tmp.Params = merged
+	}
 	return json.Marshal(tmp)
 }
 
 // UnmarshalJSON is a custom JSON deserializer for our Command
 func (c *Command) UnmarshalJSON(data []byte) error {
 	var tmp struct {
-		Command string          `json:"command"`
-		Params  json.RawMessage `json:"params"`
+		Command   string            `json:"command"`
+		Params    json.RawMessage   `json:"params"`
+		Challenge *CommandChallenge `json:"challenge"`
 	}
 
 	err := json.Unmarshal(data, &tmp)
@@ -74,6 +114,16 @@ func (c *Command) UnmarshalJSON(data []byte) error {
 	}
 
 	c.Name = tmp.Command
+	c.Challenge = tmp.Challenge
+
+	if len(tmp.Params) > 0 {
+		var followUp struct {
+			FollowUpToken string `json:"followUpToken"`
+		}
+		// Any errors will be caught when the params are parsed below.
+		json.Unmarshal(tmp.Params, &followUp)
+		c.FollowUpToken = followUp.FollowUpToken
+	}
 
 	var details interface{}
 	switch tmp.Command {
@@ -107,9 +157,18 @@ func (c *Command) UnmarshalJSON(data []byte) error {
 	case "action.devices.commands.PreviousInput":
 		c.PreviousInput = &CommandPreviousInput{}
 		details = c.PreviousInput
+	case "action.devices.commands.selectChannel":
+		c.SelectChannel = &CommandSelectChannel{}
+		details = c.SelectChannel
+	case "action.devices.commands.relativeChannel":
+		c.RelativeChannel = &CommandRelativeChannel{}
+		details = c.RelativeChannel
+	case "action.devices.commands.returnChannel":
+		c.ReturnChannel = &CommandReturnChannel{}
+		details = c.ReturnChannel
 	default:
 		c.Generic = &CommandGeneric{}
-		err := json.Unmarshal(data, c.Generic)
+		err := unmarshalUseNumber(data, c.Generic)
 		if err != nil {
 			return err
 		}
@@ -126,11 +185,22 @@ func (c *Command) UnmarshalJSON(data []byte) error {
 
 // CommandGeneric contains a command definition which hasn't been parsed into a specific command structure.
 // This is intended to support newly defined commands which callers of this SDK may handle but this does not yet support.
+// Numeric params are decoded as json.Number; use ParamInt or ParamFloat to read them.
 type CommandGeneric struct {
 	Command string                 `json:"command"`
 	Params  map[string]interface{} `json:"params"`
 }
 
+// ParamInt returns the named param as an int. false is returned if the param is missing or not a number.
+func (cg *CommandGeneric) ParamInt(name string) (int, bool) {
+	return NumberInt(cg.Params[name])
+}
+
+// ParamFloat returns the named param as a float64. false is returned if the param is missing or not a number.
+func (cg *CommandGeneric) ParamFloat(name string) (float64, bool) {
+	return NumberFloat(cg.Params[name])
+}
+
 // CommandBrightnessAbsolute requests to set the brightness to an absolute value
 // See https://developers.google.com/assistant/smarthome/traits/brightness
 type CommandBrightnessAbsolute struct {
@@ -138,11 +208,27 @@ type CommandBrightnessAbsolute struct {
 }
 
 // CommandBrightnessRelative requests to set the brightness to a relative level
-// Only one of the two fields will be set.
+// Only one of the two fields will be set; a field which was omitted is nil, so a relative change of 0 can be told apart.
 // See https://developers.google.com/assistant/smarthome/traits/brightness
 type CommandBrightnessRelative struct {
-	RelativePercent int `json:"brightnessRelativePercent"`
-	RelativeWeight  int `json:"brightnessRelativeWeight"`
+	RelativePercent *int `json:"brightnessRelativePercent,omitempty"`
+	RelativeWeight  *int `json:"brightnessRelativeWeight,omitempty"`
+}
+
+// Percent returns the relative percentage to change the brightness by, and whether it was supplied.
+func (c CommandBrightnessRelative) Percent() (int, bool) {
+	if c.RelativePercent == nil {
+		return 0, false
+	}
+	return *c.RelativePercent, true
+}
+
+// Weight returns the relative weight (i.e. -1 for "a little dimmer") to change the brightness by, and whether it was supplied.
+func (c CommandBrightnessRelative) Weight() (int, bool) {
+	if c.RelativeWeight == nil {
+		return 0, false
+	}
+	return *c.RelativeWeight, true
 }
 
 // CommandColorAbsolute requests to set the colour of a light to a particular value.
@@ -161,6 +247,30 @@ type CommandColorAbsolute struct {
 	} `json:"color"`
 }
 
+// MarshalJSON is a custom JSON serializer for our CommandColorAbsolute which only includes the color encoding in use.
+func (c CommandColorAbsolute) MarshalJSON() ([]byte, error) {
+	color := map[string]interface{}{}
+	if len(c.Color.Name) > 0 {
+		color["name"] = c.Color.Name
+	}
+
+	hsvSet := c.Color.HSV.Hue != 0 || c.Color.HSV.Saturation != 0 || c.Color.HSV.Value != 0
+	if c.Color.Temperature != 0 {
+		color["temperature"] = c.Color.Temperature
+	}
+	if hsvSet {
+		color["spectrumHSV"] = c.Color.HSV
+	}
+	// A spectrumRGB of 0 is black, so include it if no other encoding is in use.
+	if c.Color.RGB != 0 || (c.Color.Temperature == 0 && !hsvSet) {
+		color["spectrumRGB"] = c.Color.RGB
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"color": color,
+	})
+}
+
 // CommandOnOff requests to turn the entity on or off.
 // See https://developers.google.com/assistant/smarthome/traits/onoff
 type CommandOnOff struct {
@@ -200,3 +310,23 @@ type CommandNextInput struct {
 // See https://developers.google.com/assistant/smarthome/traits/inputselector
 type CommandPreviousInput struct {
 }
+
+// CommandSelectChannel requests the device change to the specified channel.
+// Only one of the fields will be set, depending on how the user identified the channel.
+// See https://developers.google.com/assistant/smarthome/traits/channel
+type CommandSelectChannel struct {
+	ChannelCode   string `json:"channelCode,omitempty"`
+	ChannelName   string `json:"channelName,omitempty"`
+	ChannelNumber string `json:"channelNumber,omitempty"`
+}
+
+// CommandRelativeChannel requests the device change channel by the specified number of channels (i.e. -1 for the previous channel).
+// See https://developers.google.com/assistant/smarthome/traits/channel
+type CommandRelativeChannel struct {
+	ChannelChange int `json:"relativeChannelChange"`
+}
+
+// CommandReturnChannel requests the device return to the last channel it was on.
+// See https://developers.google.com/assistant/smarthome/traits/channel
+type CommandReturnChannel struct {
+}
@@ -29,7 +29,7 @@ func TestCommandUnmarshalJSON(t *testing.T) {
 				Generic: &CommandGeneric{
 					Command: "action.devices.commands.ThermostatTemperatureSetpoint",
 					Params: map[string]interface{}{
-						"thermostatTemperatureSetpoint": 42.42,
+						"thermostatTemperatureSetpoint": json.Number("42.42"),
 					},
 				},
 			},
@@ -72,6 +72,94 @@ func TestCommandUnmarshalJSON(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "generic command with pin challenge",
+			input: `{
+				"command": "action.devices.commands.LockUnlock",
+				"params": {"lock": false},
+				"challenge": {"pin": "333222"}
+			}`,
+			want: &Command{
+				Name: "action.devices.commands.LockUnlock",
+				Generic: &CommandGeneric{
+					Command: "action.devices.commands.LockUnlock",
+					Params: map[string]interface{}{
+						"lock": false,
+					},
+				},
+				Challenge: &CommandChallenge{
+					Pin: "333222",
+				},
+			},
+		},
+		{
+			name: "onoff command with ack challenge",
+			input: `{
+				"command": "action.devices.commands.OnOff",
+				"params": {"on": false},
+				"challenge": {"ack": true}
+			}`,
+			want: &Command{
+				Name: "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{
+					On: false,
+				},
+				Challenge: &CommandChallenge{
+					Ack: true,
+				},
+			},
+		},
+		{
+			name: "onoff command with follow up token",
+			input: `{
+				"command": "action.devices.commands.OnOff",
+				"params": {"on": true, "followUpToken": "token-123"}
+			}`,
+			want: &Command{
+				Name: "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{
+					On: true,
+				},
+				FollowUpToken: "token-123",
+			},
+		},
+		{
+			name: "select channel command",
+			input: `{
+				"command": "action.devices.commands.selectChannel",
+				"params": {"channelNumber": "4"}
+			}`,
+			want: &Command{
+				Name: "action.devices.commands.selectChannel",
+				SelectChannel: &CommandSelectChannel{
+					ChannelNumber: "4",
+				},
+			},
+		},
+		{
+			name: "relative channel command",
+			input: `{
+				"command": "action.devices.commands.relativeChannel",
+				"params": {"relativeChannelChange": -1}
+			}`,
+			want: &Command{
+				Name: "action.devices.commands.relativeChannel",
+				RelativeChannel: &CommandRelativeChannel{
+					ChannelChange: -1,
+				},
+			},
+		},
+		{
+			name: "return channel command",
+			input: `{
+				"command": "action.devices.commands.returnChannel",
+				"params": {}
+			}`,
+			want: &Command{
+				Name:          "action.devices.commands.returnChannel",
+				ReturnChannel: &CommandReturnChannel{},
+			},
+		},
 	} {
 		t.Run(example.name, func(t *testing.T) {
 			got := &Command{}
@@ -154,3 +242,57 @@ func roundtripJSON(in, out interface{}) error {
 	}
 	return nil
 }
+
+func TestCommandGenericParamNumbers(t *testing.T) {
+	command := Command{}
+	err := json.Unmarshal([]byte(`{"command": "action.devices.commands.SetFanSpeedRelativeSpeed", "params": {"fanSpeedRelativeWeight": 42, "fanSpeedRelativePercent": 12.5}}`), &command)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("42"), command.Generic.Params["fanSpeedRelativeWeight"])
+
+	weight, ok := command.Generic.ParamInt("fanSpeedRelativeWeight")
+	assert.True(t, ok)
+	assert.Equal(t, 42, weight)
+	percent, ok := command.Generic.ParamFloat("fanSpeedRelativePercent")
+	assert.True(t, ok)
+	assert.Equal(t, 12.5, percent)
+	_, ok = command.Generic.ParamInt("missing")
+	assert.False(t, ok)
+}
+
+func TestCommandBrightnessRelativeOmittedFields(t *testing.T) {
+	command := Command{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"command": "action.devices.commands.BrightnessRelative", "params": {"brightnessRelativeWeight": 0}}`), &command))
+
+	weight, ok := command.BrightnessRelative.Weight()
+	assert.True(t, ok)
+	assert.Equal(t, 0, weight)
+	_, ok = command.BrightnessRelative.Percent()
+	assert.False(t, ok)
+
+	serializedBytes, err := json.Marshal(command)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"command":"action.devices.commands.BrightnessRelative","params":{"brightnessRelativeWeight":0}}`, string(serializedBytes))
+}
+
+func TestCommandGenericFollowUpToken(t *testing.T) {
+	command := Command{
+		Generic: &CommandGeneric{
+			Command: "action.devices.commands.SetFanSpeed",
+			Params:  map[string]interface{}{"fanSpeed": "high"},
+		},
+		FollowUpToken: "token-123",
+	}
+	serializedBytes, err := json.Marshal(command)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"command":"action.devices.commands.SetFanSpeed","params":{"fanSpeed":"high","followUpToken":"token-123"}}`, string(serializedBytes))
+
+	command.Challenge = &CommandChallenge{Ack: true}
+	serializedBytes, err = json.Marshal(command)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"command":"action.devices.commands.SetFanSpeed","params":{"fanSpeed":"high","followUpToken":"token-123"},"challenge":{"ack":true}}`, string(serializedBytes))
+
+	convCommand := Command{}
+	assert.Nil(t, json.Unmarshal(serializedBytes, &convCommand))
+	assert.Equal(t, "token-123", convCommand.FollowUpToken)
+	assert.Equal(t, "high", convCommand.Generic.Params["fanSpeed"])
+}
@@ -0,0 +1,199 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Divergence describes a request for which the shadow provider responded differently to the primary provider.
+type Divergence struct {
+	Intent      string
+	AgentUserID string
+	// RequestID carried by the context of the request, if any.
+	RequestID string
+
+	// Primary and Shadow contain the JSON encoding of each response, or the error returned.
+	Primary string
+	Shadow  string
+	// Differences lists the path (i.e. states.light-1.on) of each value which differs between the responses.
+	Differences []string
+}
+
+// ComparisonStats counts the requests compared for an intent, and how many of them diverged.
+type ComparisonStats struct {
+	Compared int
+	Diverged int
+}
+
+// ComparingProvider sends each request to both a primary and a shadow Provider, serving the primary's response and
+// reporting any differences in the shadow's response. This is useful to validate a rewritten provider against
+// production traffic. The shadow is called asynchronously so it doesn't delay the response; its context carries
+// the same values (i.e. the request ID) but is not cancelled with the request.
+// EXECUTE is sent to the shadow as well, so it must not control real devices.
+type ComparingProvider struct {
+	primary   Provider
+	shadow    Provider
+	onDiverge func(Divergence)
+
+	mu    sync.Mutex
+	stats map[string]ComparisonStats
+}
+
+// NewComparingProvider creates a provider which serves responses from primary and compares them against shadow.
+// onDiverge is called, on a separate goroutine, for each request the providers respond differently to; it may be nil.
+func NewComparingProvider(primary Provider, shadow Provider, onDiverge func(Divergence)) *ComparingProvider {
+	return &ComparingProvider{
+		primary:   primary,
+		shadow:    shadow,
+		onDiverge: onDiverge,
+		stats:     map[string]ComparisonStats{},
+	}
+}
+
+// Stats returns the comparison counts for each intent.
+func (cp *ComparingProvider) Stats() map[string]ComparisonStats {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	stats := map[string]ComparisonStats{}
+	for intent, s := range cp.stats {
+		stats[intent] = s
+	}
+	return stats
+}
+
+// Sync returns the devices supplied by the primary provider.
+func (cp *ComparingProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	resp, err := cp.primary.Sync(ctx, agentUserID)
+	primaryJSON, primaryValue := comparisonValue(resp, err)
+	go func() {
+		shadowResp, shadowErr := cp.shadow.Sync(detachContext(ctx), agentUserID)
+		cp.compare(ctx, IntentSync, agentUserID, primaryJSON, primaryValue, shadowResp, shadowErr)
+	}()
+	return resp, err
+}
+
+// Disconnect informs both providers the user has unlinked, returning the result of the primary.
+func (cp *ComparingProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	err := cp.primary.Disconnect(ctx, agentUserID)
+	go cp.shadow.Disconnect(detachContext(ctx), agentUserID)
+	return err
+}
+
+// Query returns the states supplied by the primary provider.
+func (cp *ComparingProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp, err := cp.primary.Query(ctx, req)
+	primaryJSON, primaryValue := comparisonValue(resp, err)
+	go func() {
+		shadowResp, shadowErr := cp.shadow.Query(detachContext(ctx), req)
+		cp.compare(ctx, IntentQuery, req.AgentID, primaryJSON, primaryValue, shadowResp, shadowErr)
+	}()
+	return resp, err
+}
+
+// Execute returns the results supplied by the primary provider.
+func (cp *ComparingProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	resp, err := cp.primary.Execute(ctx, req)
+	primaryJSON, primaryValue := comparisonValue(resp, err)
+	go func() {
+		shadowResp, shadowErr := cp.shadow.Execute(detachContext(ctx), req)
+		cp.compare(ctx, IntentExecute, req.AgentID, primaryJSON, primaryValue, shadowResp, shadowErr)
+	}()
+	return resp, err
+}
+
+// compare records the comparison of the shadow response against the encoded primary response, reporting the
+// divergence if they differ. The primary response is encoded before it is returned, as the caller may modify it
+// while the shadow is still being called.
+func (cp *ComparingProvider) compare(ctx context.Context, intent string, agentUserID string, primaryJSON string, primaryValue interface{}, shadow interface{}, shadowErr error) {
+	shadowJSON, shadowValue := comparisonValue(shadow, shadowErr)
+	differences := jsonDifferences("", primaryValue, shadowValue)
+
+	cp.mu.Lock()
+	stats := cp.stats[intent]
+	stats.Compared++
+	if len(differences) > 0 {
+		stats.Diverged++
+	}
+	cp.stats[intent] = stats
+	cp.mu.Unlock()
+
+	if len(differences) > 0 && cp.onDiverge != nil {
+		cp.onDiverge(Divergence{
+			Intent:      intent,
+			AgentUserID: agentUserID,
+			RequestID:   RequestIDFromContext(ctx),
+			Primary:     primaryJSON,
+			Shadow:      shadowJSON,
+			Differences: differences,
+		})
+	}
+}
+
+// comparisonValue returns the JSON encoding of the response (or error), along with its decoded generic form.
+func comparisonValue(resp interface{}, err error) (string, interface{}) {
+	if err != nil {
+		return fmt.Sprintf("error: %s", err.Error()), map[string]interface{}{"error": err.Error()}
+	}
+
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Sprintf("error: %s", err.Error()), map[string]interface{}{"error": err.Error()}
+	}
+	var value interface{}
+	json.Unmarshal(encoded, &value)
+	return string(encoded), value
+}
+
+// jsonDifferences returns the sorted paths of the values which differ between the two decoded JSON values.
+func jsonDifferences(path string, a interface{}, b interface{}) []string {
+	aMap, aIsMap := a.(map[string]interface{})
+	bMap, bIsMap := b.(map[string]interface{})
+	if !aIsMap || !bIsMap {
+		if reflect.DeepEqual(a, b) {
+			return nil
+		}
+		if len(path) < 1 {
+			return []string{"."}
+		}
+		return []string{path}
+	}
+
+	keys := map[string]bool{}
+	for k := range aMap {
+		keys[k] = true
+	}
+	for k := range bMap {
+		keys[k] = true
+	}
+
+	var differences []string
+	for k := range keys {
+		childPath := k
+		if len(path) > 0 {
+			childPath = path + "." + k
+		}
+		differences = append(differences, jsonDifferences(childPath, aMap[k], bMap[k])...)
+	}
+	sort.Strings(differences)
+	return differences
+}
+
+// detachedContext carries the values of its parent without being cancelled when the parent is.
+type detachedContext struct {
+	parent context.Context
+}
+
+func detachContext(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+func (dc detachedContext) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (dc detachedContext) Done() <-chan struct{}             { return nil }
+func (dc detachedContext) Err() error                        { return nil }
+func (dc detachedContext) Value(key interface{}) interface{} { return dc.parent.Value(key) }
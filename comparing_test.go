@@ -0,0 +1,120 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparingProvider(t *testing.T) {
+	primary := &testProvider{
+		syncResp: []*Device{NewLight("light-1")},
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	shadow := &testProvider{
+		syncResp: []*Device{NewLight("light-1")},
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(false),
+		},
+	}
+
+	divergences := make(chan Divergence, 1)
+	cp := NewComparingProvider(primary, shadow, func(d Divergence) {
+		divergences <- d
+	})
+
+	ctx := ContextWithRequestID(context.Background(), "request-1")
+	resp, err := cp.Query(ctx, &QueryRequest{AgentID: "agent-id", Devices: []DeviceArg{{ID: "light-1"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, true, resp.States["light-1"].State["on"])
+
+	select {
+	case d := <-divergences:
+		assert.Equal(t, IntentQuery, d.Intent)
+		assert.Equal(t, "agent-id", d.AgentUserID)
+		assert.Equal(t, "request-1", d.RequestID)
+		assert.Equal(t, []string{"States.light-1.on"}, d.Differences)
+	case <-time.After(time.Second):
+		t.Fatal("divergence not reported")
+	}
+
+	_, err = cp.Sync(ctx, "agent-id")
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return cp.Stats()[IntentSync].Compared == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[string]ComparisonStats{
+		IntentQuery: {Compared: 1, Diverged: 1},
+		IntentSync:  {Compared: 1},
+	}, cp.Stats())
+	assert.Empty(t, divergences)
+}
+
+func TestComparingProviderErrors(t *testing.T) {
+	divergences := make(chan Divergence, 1)
+	cp := NewComparingProvider(&testProvider{}, &testProvider{syncErr: errors.New("sync failed")}, func(d Divergence) {
+		divergences <- d
+	})
+
+	_, err := cp.Sync(context.Background(), "agent-id")
+	assert.Nil(t, err)
+
+	d := <-divergences
+	assert.Equal(t, "error: sync failed", d.Shadow)
+	assert.Contains(t, d.Differences, "error")
+}
+
+// gatedProvider blocks QUERY requests until the gate is closed.
+type gatedProvider struct {
+	Provider
+	gate chan struct{}
+}
+
+func (gp *gatedProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	<-gp.gate
+	return gp.Provider.Query(ctx, req)
+}
+
+func TestComparingProviderModifiedResponse(t *testing.T) {
+	primary := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	shadow := &gatedProvider{
+		Provider: &testProvider{
+			queryResp: map[string]DeviceState{
+				"light-1": NewDeviceState(true).RecordOnOff(true),
+			},
+		},
+		gate: make(chan struct{}),
+	}
+	cp := NewComparingProvider(primary, shadow, nil)
+
+	resp, err := cp.Query(context.Background(), &QueryRequest{AgentID: "agent-id", Devices: []DeviceArg{{ID: "light-1"}}})
+	assert.Nil(t, err)
+
+	// Changes made by the caller once the response is returned aren't attributed to the primary.
+	resp.States["light-1"].State["on"] = false
+	close(shadow.gate)
+
+	assert.Eventually(t, func() bool {
+		return cp.Stats()[IntentQuery].Compared == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, cp.Stats()[IntentQuery].Diverged)
+}
+
+func TestDetachContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(ContextWithRequestID(context.Background(), "request-1"))
+	detached := detachContext(ctx)
+	cancel()
+
+	assert.NotNil(t, ctx.Err())
+	assert.Nil(t, detached.Err())
+	assert.Equal(t, "request-1", RequestIDFromContext(detached))
+}
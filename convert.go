@@ -0,0 +1,155 @@
+package action
+
+import "math"
+
+// TemperatureUnit defines which unit the device displays temperatures in.
+// Google always exchanges temperatures in Celsius, the unit only affects how the temperature is presented to the user.
+// See https://developers.google.com/assistant/smarthome/traits/temperaturesetting
+const (
+	TemperatureUnitCelsius    = "C"
+	TemperatureUnitFahrenheit = "F"
+)
+
+// ClampBrightness limits the supplied brightness to the 0-100 range Google supports.
+func ClampBrightness(brightness int) int {
+	return clampInt(brightness, 0, 100)
+}
+
+// CelsiusToFahrenheit converts the supplied temperature from Celsius to Fahrenheit.
+func CelsiusToFahrenheit(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+// FahrenheitToCelsius converts the supplied temperature from Fahrenheit to Celsius.
+func FahrenheitToCelsius(fahrenheit float64) float64 {
+	return (fahrenheit - 32) * 5 / 9
+}
+
+// TemperatureToCelsius converts a temperature expressed in the device's unit into Celsius,
+// which is what Google expects in all states and commands.
+// The NativeTemperatureUnit is used if set, otherwise the thermostatTemperatureUnit attribute is used.
+// If the device has no unit defined the temperature is assumed to already be in Celsius.
+func (d *Device) TemperatureToCelsius(temperature float64) float64 {
+	if d.temperatureUnit() == TemperatureUnitFahrenheit {
+		return FahrenheitToCelsius(temperature)
+	}
+	return temperature
+}
+
+// TemperatureFromCelsius converts a temperature supplied by Google (in Celsius) into the device's unit.
+// The NativeTemperatureUnit is used if set, otherwise the thermostatTemperatureUnit attribute is used.
+// If the device has no unit defined the temperature is returned unchanged.
+func (d *Device) TemperatureFromCelsius(celsius float64) float64 {
+	if d.temperatureUnit() == TemperatureUnitFahrenheit {
+		return CelsiusToFahrenheit(celsius)
+	}
+	return celsius
+}
+
+func (d *Device) temperatureUnit() string {
+	if d.NativeTemperatureUnit != "" {
+		return d.NativeTemperatureUnit
+	}
+	unit, _ := d.Attributes["thermostatTemperatureUnit"].(string)
+	return unit
+}
+
+// ClampColorTemperature limits the supplied color temperature (in Kelvin) to the range declared on the device.
+// If the device has not declared a color temperature range the temperature is returned unchanged.
+func (d *Device) ClampColorTemperature(temperatureK int) int {
+	opts := d.colorSettingOptions()
+	if opts.TemperatureMinK == 0 && opts.TemperatureMaxK == 0 {
+		return temperatureK
+	}
+	return clampInt(temperatureK, opts.TemperatureMinK, opts.TemperatureMaxK)
+}
+
+// ColorFromRGB packs the supplied red, green and blue components into Google's spectrumRGB encoding.
+func ColorFromRGB(r, g, b uint8) int {
+	return int(r)<<16 | int(g)<<8 | int(b)
+}
+
+// ColorToRGB unpacks a color in Google's spectrumRGB encoding into its red, green and blue components.
+func ColorToRGB(spectrumRGB int) (uint8, uint8, uint8) {
+	return uint8(spectrumRGB >> 16), uint8(spectrumRGB >> 8), uint8(spectrumRGB)
+}
+
+// SpectrumRGBToHSV converts a color in Google's spectrumRGB encoding into the hue (0-360), saturation (0-1) and value (0-1)
+// used by the spectrumHSV encoding.
+func SpectrumRGBToHSV(spectrumRGB int) (float64, float64, float64) {
+	rByte, gByte, bByte := ColorToRGB(spectrumRGB)
+	r := float64(rByte) / 255
+	g := float64(gByte) / 255
+	b := float64(bByte) / 255
+
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	var hue float64
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == r:
+		hue = 60 * math.Mod((g-b)/delta, 6)
+	case max == g:
+		hue = 60 * ((b-r)/delta + 2)
+	default:
+		hue = 60 * ((r-g)/delta + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	var saturation float64
+	if max > 0 {
+		saturation = delta / max
+	}
+
+	return hue, saturation, max
+}
+
+// HSVToSpectrumRGB converts a color in Google's spectrumHSV encoding (hue 0-360, saturation 0-1, value 0-1)
+// into the spectrumRGB encoding.
+func HSVToSpectrumRGB(hue float64, saturation float64, value float64) int {
+	hue = math.Mod(hue, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	saturation = math.Max(0, math.Min(1, saturation))
+	value = math.Max(0, math.Min(1, value))
+
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := value - chroma
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = chroma, x, 0
+	case hue < 120:
+		r, g, b = x, chroma, 0
+	case hue < 180:
+		r, g, b = 0, chroma, x
+	case hue < 240:
+		r, g, b = 0, x, chroma
+	case hue < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	toByte := func(v float64) uint8 {
+		return uint8(math.Round((v + m) * 255))
+	}
+	return ColorFromRGB(toByte(r), toByte(g), toByte(b))
+}
+
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	} else if value > max {
+		return max
+	}
+	return value
+}
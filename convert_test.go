@@ -0,0 +1,96 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampBrightness(t *testing.T) {
+	assert.Equal(t, 0, ClampBrightness(-5))
+	assert.Equal(t, 42, ClampBrightness(42))
+	assert.Equal(t, 100, ClampBrightness(250))
+}
+
+func TestDeviceTemperatureConversion(t *testing.T) {
+	celsius := NewDevice("celsius-id", DeviceTypeThermostat)
+	celsius.Attributes["thermostatTemperatureUnit"] = TemperatureUnitCelsius
+	assert.Equal(t, 21.0, celsius.TemperatureToCelsius(21))
+	assert.Equal(t, 21.0, celsius.TemperatureFromCelsius(21))
+
+	fahrenheit := NewDevice("fahrenheit-id", DeviceTypeThermostat)
+	fahrenheit.Attributes["thermostatTemperatureUnit"] = TemperatureUnitFahrenheit
+	assert.Equal(t, 100.0, fahrenheit.TemperatureToCelsius(212))
+	assert.Equal(t, 32.0, fahrenheit.TemperatureFromCelsius(0))
+}
+
+func TestDeviceNativeTemperatureUnit(t *testing.T) {
+	d := NewThermostat("1", []string{ThermostatModeHeat}, TemperatureUnitCelsius, [2]float64{}).
+		SetNativeTemperatureUnit(TemperatureUnitFahrenheit)
+	assert.Equal(t, 212.0, d.TemperatureFromCelsius(100))
+	assert.Equal(t, 0.0, d.TemperatureToCelsius(32))
+
+	state := d.NewDeviceState(true).RecordThermostat(ThermostatState{
+		Mode:      ThermostatModeHeat,
+		AmbientC:  212,
+		SetpointC: 32,
+	})
+	assert.Equal(t, 100.0, state.State["thermostatTemperatureAmbient"])
+	assert.Equal(t, 0.0, state.State["thermostatTemperatureSetpoint"])
+
+	state = NewDeviceState(true).RecordTemperatureControl(212, 32)
+	assert.Equal(t, 212.0, state.State["temperatureSetpointCelsius"])
+	state = NewDeviceState(true).WithTemperatureUnit(TemperatureUnitFahrenheit).RecordTemperatureControl(212, 32)
+	assert.Equal(t, 100.0, state.State["temperatureSetpointCelsius"])
+	assert.Equal(t, 0.0, state.State["temperatureAmbientCelsius"])
+}
+
+func TestDeviceClampColorTemperature(t *testing.T) {
+	d := NewLight("light-id").AddColourTemperatureTrait(2000, 9000, false)
+	assert.Equal(t, 2000, d.ClampColorTemperature(1000))
+	assert.Equal(t, 4000, d.ClampColorTemperature(4000))
+	assert.Equal(t, 9000, d.ClampColorTemperature(10000))
+
+	assert.Equal(t, 10000, NewLight("other-id").ClampColorTemperature(10000))
+
+	decoded := &Device{}
+	assert.Nil(t, roundtripJSON(d, decoded))
+	assert.Equal(t, 2000, decoded.ClampColorTemperature(1000))
+	assert.Equal(t, 9000, decoded.ClampColorTemperature(10000))
+}
+
+func TestSpectrumRGBHSVConversion(t *testing.T) {
+	for _, tt := range []struct {
+		rgb        int
+		hue        float64
+		saturation float64
+		value      float64
+	}{
+		{0xff0000, 0, 1, 1},
+		{0x00ff00, 120, 1, 1},
+		{0x0000ff, 240, 1, 1},
+		{0xff00ff, 300, 1, 1},
+		{0xffffff, 0, 0, 1},
+		{0x000000, 0, 0, 0},
+	} {
+		hue, saturation, value := SpectrumRGBToHSV(tt.rgb)
+		assert.InDelta(t, tt.hue, hue, 0.001)
+		assert.InDelta(t, tt.saturation, saturation, 0.001)
+		assert.InDelta(t, tt.value, value, 0.001)
+
+		assert.Equal(t, tt.rgb, HSVToSpectrumRGB(tt.hue, tt.saturation, tt.value))
+	}
+
+	// Ensure arbitrary colors survive the roundtrip
+	assert.Equal(t, 31655, HSVToSpectrumRGB(SpectrumRGBToHSV(31655)))
+}
+
+func TestColorRGBPacking(t *testing.T) {
+	assert.Equal(t, 0x123456, ColorFromRGB(0x12, 0x34, 0x56))
+	assert.Equal(t, 16711935, ColorFromRGB(255, 0, 255))
+
+	r, g, b := ColorToRGB(0x123456)
+	assert.Equal(t, uint8(0x12), r)
+	assert.Equal(t, uint8(0x34), g)
+	assert.Equal(t, uint8(0x56), b)
+}
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// WithExecuteResultCorrelation checks that every device targeted by an EXECUTE request is reported exactly once
+// across the UpdatedDevices, OfflineDevices, PendingDevices, FailedDevices and ChallengeNeeded of the provider's response.
+// Devices which are missing, reported more than once, or which were not requested are logged.
+// If autoFill is set, missing devices are additionally reported to Google as failed with actionNotAvailable;
+// Google rejects responses which don't account for every device, often without a useful error.
+func WithExecuteResultCorrelation(autoFill bool) ServiceOption {
+	return func(s *Service) {
+		s.correlateExecute = true
+		s.correlateExecuteAutoFill = autoFill
+	}
+}
+
+// executeRequestDeviceIDs returns the unique IDs of the devices targeted by the supplied commands, in request order.
+func executeRequestDeviceIDs(commands []CommandArg) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, command := range commands {
+		for _, device := range command.TargetDevices {
+			if seen[device.ID] {
+				continue
+			}
+			seen[device.ID] = true
+			ids = append(ids, device.ID)
+		}
+	}
+	return ids
+}
+
+// sortByRequestOrder orders the device IDs to match the order they were requested in.
+// Any IDs which weren't requested are placed after the requested IDs, in their existing order.
+func sortByRequestOrder(ids []string, requestedIDs []string) {
+	order := map[string]int{}
+	for idx, id := range requestedIDs {
+		order[id] = idx
+	}
+	position := func(id string) int {
+		if idx, found := order[id]; found {
+			return idx
+		}
+		return len(requestedIDs)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return position(ids[i]) < position(ids[j])
+	})
+}
+
+// correlateExecuteResponse compares the devices in the response to the requested device IDs,
+// logging any discrepancies and, if enabled, filling in the missing devices.
+func (s *Service) correlateExecuteResponse(requestID string, requestedIDs []string, resp *ExecuteResponse) {
+	counts := map[string]int{}
+	for _, id := range resp.UpdatedDevices {
+		counts[id]++
+	}
+	for _, id := range resp.OfflineDevices {
+		counts[id]++
+	}
+	for _, id := range resp.PendingDevices {
+		counts[id]++
+	}
+	for _, details := range resp.FailedDevices {
+		for _, id := range details.Devices {
+			counts[id]++
+		}
+	}
+	for _, ids := range resp.ChallengeNeeded {
+		for _, id := range ids {
+			counts[id]++
+		}
+	}
+
+	requested := map[string]bool{}
+	var missing []string
+	for _, id := range requestedIDs {
+		requested[id] = true
+		if counts[id] < 1 {
+			missing = append(missing, id)
+		}
+	}
+
+	var duplicated, unexpected []string
+	for id, count := range counts {
+		if !requested[id] {
+			unexpected = append(unexpected, id)
+		} else if count > 1 {
+			duplicated = append(duplicated, id)
+		}
+	}
+	sort.Strings(duplicated)
+	sort.Strings(unexpected)
+
+	if len(missing) > 0 {
+		s.logger.Warn("execute response missing requested devices",
+			zap.String("request_id", requestID),
+			zap.Strings("device_ids", missing),
+		)
+		if s.correlateExecuteAutoFill {
+			resp.AddFailedDevices(ErrorCodeActionNotAvailable, missing...)
+		}
+	}
+	if len(duplicated) > 0 {
+		s.logger.Warn("execute response reports devices more than once",
+			zap.String("request_id", requestID),
+			zap.Strings("device_ids", duplicated),
+		)
+	}
+	if len(unexpected) > 0 {
+		s.logger.Warn("execute response reports devices which were not requested",
+			zap.String("request_id", requestID),
+			zap.Strings("device_ids", unexpected),
+		)
+	}
+}
+
+// WithQueryCompleteness checks that the provider's response to QUERY contains the state of every requested device.
+// Devices which are missing are logged.
+// If autoFill is set, missing devices are additionally reported to Google as offline with an ERROR status and deviceNotFound,
+// rather than being omitted from the payload.
+func WithQueryCompleteness(autoFill bool) ServiceOption {
+	return func(s *Service) {
+		s.checkQueryCompleteness = true
+		s.queryCompletenessAutoFill = autoFill
+	}
+}
+
+// completeQueryResponse compares the states in the response to the requested devices,
+// logging any which are missing and, if enabled, filling them in.
+func (s *Service) completeQueryResponse(requestID string, requested []DeviceArg, states map[string]DeviceState) {
+	var missing []string
+	for _, device := range requested {
+		if _, found := states[device.ID]; found {
+			continue
+		}
+		missing = append(missing, device.ID)
+		if s.queryCompletenessAutoFill {
+			state := NewDeviceState(false)
+			state.Status = "ERROR"
+			state.ErrorCode = ErrorCodeDeviceNotFound
+			states[device.ID] = state
+		}
+	}
+
+	if len(missing) > 0 {
+		s.logger.Warn("query response missing requested devices",
+			zap.String("request_id", requestID),
+			zap.Strings("device_ids", missing),
+		)
+	}
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceCorrelateExecuteResponse(t *testing.T) {
+	requestedIDs := executeRequestDeviceIDs([]CommandArg{
+		{TargetDevices: []DeviceArg{{ID: "1"}, {ID: "2"}}},
+		{TargetDevices: []DeviceArg{{ID: "2"}, {ID: "3"}, {ID: "4"}}},
+	})
+	assert.Equal(t, []string{"1", "2", "3", "4"}, requestedIDs)
+
+	newResp := func() *ExecuteResponse {
+		resp := &ExecuteResponse{
+			UpdatedDevices: []string{"1", "5"},
+			OfflineDevices: []string{"2"},
+		}
+		resp.AddFailedDevices(ErrorCodeDeviceOffline, "2")
+		resp.AddChallengeNeeded(ChallengePinNeeded, "3")
+		return resp
+	}
+
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithExecuteResultCorrelation(false))
+	resp := newResp()
+	svc.correlateExecuteResponse("req", requestedIDs, resp)
+	assert.Len(t, resp.FailedDevices, 1)
+
+	svc = NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithExecuteResultCorrelation(true))
+	resp = newResp()
+	svc.correlateExecuteResponse("req", requestedIDs, resp)
+	assert.Equal(t, []string{"4"}, resp.FailedDevices[ErrorCodeActionNotAvailable].Devices)
+}
+
+func TestGoogleFulfillmentHandlerQueryCompleteness(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"123": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, nil, WithQueryCompleteness(true))
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{
+			"intent": "action.devices.QUERY",
+			"payload": {"devices": [{"id": "123"}, {"id": "456"}]}
+		}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(svc.GoogleFulfillmentHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"devices":{"123":{"on":true,"online":true,"status":"SUCCESS"},"456":{"errorCode":"deviceNotFound","online":false,"status":"ERROR"}}}}
+`, rr.Body.String())
+}
+
+func TestSortByRequestOrder(t *testing.T) {
+	ids := []string{"c", "unknown", "a", "b"}
+	sortByRequestOrder(ids, []string{"a", "b", "c"})
+	assert.Equal(t, []string{"a", "b", "c", "unknown"}, ids)
+}
@@ -0,0 +1,99 @@
+package action
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig defines the cross-origin requests which the fulfillment handler accepts.
+// This is only needed if the fulfillment endpoint is called from a browser (i.e. a debug console);
+// Google itself doesn't make cross-origin requests.
+type CORSConfig struct {
+	// AllowedOrigins contains the origins permitted to make requests. An entry of "*" permits any origin;
+	// requests permitted only by it are answered with a literal "*", so credentials are never allowed for them.
+	AllowedOrigins []string
+	// AllowedMethods defaults to POST if empty.
+	AllowedMethods []string
+	// AllowedHeaders defaults to Authorization and Content-Type if empty.
+	AllowedHeaders []string
+	// AllowCredentials indicates whether the browser may include credentials with requests from the listed origins.
+	AllowCredentials bool
+	// MaxAge is how long the browser may cache the result of a preflight request. It is not sent if zero.
+	MaxAge time.Duration
+}
+
+// WithCORS causes the fulfillment handler to answer CORS preflight requests, and to include the CORS headers
+// on responses to requests from allowed origins.
+func WithCORS(config CORSConfig) ServiceOption {
+	return func(s *Service) {
+		s.cors = &config
+	}
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header for the supplied origin:
+// the origin itself if it is listed, "*" if any origin is allowed, or an empty string if the origin isn't allowed.
+func (c *CORSConfig) allowedOrigin(origin string) string {
+	wildcard := false
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == origin {
+			return origin
+		} else if allowed == "*" {
+			wildcard = true
+		}
+	}
+	if wildcard {
+		return "*"
+	}
+	return ""
+}
+
+// handleCORS sets the CORS headers for the request, if any are required.
+// It returns true if the request was a preflight request and has been answered.
+func (c *CORSConfig) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+
+	allowedOrigin := ""
+	if len(origin) > 0 {
+		allowedOrigin = c.allowedOrigin(origin)
+	}
+	if len(allowedOrigin) < 1 {
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+	// Browsers reject credentialed responses which allow any origin, so the wildcard is never combined with credentials.
+	if allowedOrigin != "*" {
+		w.Header().Add("Vary", "Origin")
+		if c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if !preflight {
+		return false
+	}
+
+	methods := c.AllowedMethods
+	if len(methods) < 1 {
+		methods = []string{http.MethodPost}
+	}
+	headers := c.AllowedHeaders
+	if len(headers) < 1 {
+		headers = []string{"Authorization", "Content-Type"}
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,96 @@
+package action
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGoogleFulfillmentHandlerCORS(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	svc := NewService(logger, authenticator, &testProvider{}, nil, WithCORS(CORSConfig{
+		AllowedOrigins: []string{"http://localhost:3000"},
+		MaxAge:         10 * time.Minute,
+	}))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	// Preflight from an allowed origin.
+	req := httptest.NewRequest(http.MethodOptions, GoogleFulfillmentPath, nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "http://localhost:3000", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+
+	// Preflight from a disallowed origin.
+	req = httptest.NewRequest(http.MethodOptions, GoogleFulfillmentPath, nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// Actual request from an allowed origin.
+	req = httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`)))
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "http://localhost:3000", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestGoogleFulfillmentHandlerCORSWildcard(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithCORS(CORSConfig{
+		AllowedOrigins:   []string{"*", "http://localhost:3000"},
+		AllowCredentials: true,
+	}))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	// Origins only allowed by the wildcard don't receive credentials.
+	req := httptest.NewRequest(http.MethodOptions, GoogleFulfillmentPath, nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Empty(t, rr.Header().Get("Vary"))
+
+	// Listed origins do.
+	req = httptest.NewRequest(http.MethodOptions, GoogleFulfillmentPath, nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "http://localhost:3000", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+}
@@ -0,0 +1,258 @@
+package action
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrCustomDataInvalid is returned if the customData supplied by Google could not be opened by the CustomDataCodec,
+	// either because it wasn't sealed by the codec or because it was modified.
+	ErrCustomDataInvalid = errors.New("invalid custom data")
+)
+
+// CustomDataTamperedError is returned by HMACCustomData if the signature of the customData doesn't match its contents,
+// meaning the customData was modified after it was returned in SYNC. It matches ErrCustomDataInvalid using errors.Is.
+type CustomDataTamperedError struct {
+	DeviceID string
+}
+
+// Error returns a description of the tampered device.
+func (e *CustomDataTamperedError) Error() string {
+	return fmt.Sprintf("custom data of %s has been tampered with", e.DeviceID)
+}
+
+// Is allows the error to match ErrCustomDataInvalid.
+func (e *CustomDataTamperedError) Is(target error) bool {
+	return target == ErrCustomDataInvalid
+}
+
+// CustomDataCodec transforms the customData of each device before it is sent to Google in SYNC,
+// and reverses the transformation when the customData is echoed back by Google in QUERY and EXECUTE.
+// The provider only ever sees the original customData. See WithCustomDataCodec.
+type CustomDataCodec interface {
+	// Seal transforms the customData of the device into the form sent to Google.
+	Seal(deviceID string, customData map[string]interface{}) (map[string]interface{}, error)
+	// Open recovers the original customData of the device from the form supplied by Google.
+	Open(deviceID string, customData map[string]interface{}) (map[string]interface{}, error)
+}
+
+// WithCustomDataCodec causes the customData of each device to be sealed using the codec when it is returned in SYNC,
+// and opened again before it is passed to the provider in QUERY and EXECUTE. Requests containing customData which
+// can't be opened are rejected with protocolError without being passed to the provider.
+// Devices with no customData are left as-is.
+func WithCustomDataCodec(codec CustomDataCodec) ServiceOption {
+	return func(s *Service) {
+		s.customDataCodec = codec
+	}
+}
+
+// sealCustomData replaces the customData of the supplied devices with the sealed form.
+// The devices are copied so the ones owned by the provider are left unmodified.
+func (s *Service) sealCustomData(devices []*Device) ([]*Device, error) {
+	var sealed []*Device
+	for _, device := range devices {
+		if len(device.CustomData) < 1 {
+			sealed = append(sealed, device)
+			continue
+		}
+
+		customData, err := s.customDataCodec.Seal(device.ID, device.CustomData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", device.ID, err)
+		}
+		sealedDevice := *device
+		sealedDevice.CustomData = customData
+		sealed = append(sealed, &sealedDevice)
+	}
+	return sealed, nil
+}
+
+// openCustomData replaces the sealed customData of each device in the request with the original form.
+func (s *Service) openCustomData(input *FulfillmentInput) error {
+	var handles []*DeviceHandle
+	if input.Query != nil {
+		for i := range input.Query.Devices {
+			handles = append(handles, &input.Query.Devices[i])
+		}
+	}
+	if input.Execute != nil {
+		for i := range input.Execute.Commands {
+			for j := range input.Execute.Commands[i].Devices {
+				handles = append(handles, &input.Execute.Commands[i].Devices[j])
+			}
+		}
+	}
+
+	for _, handle := range handles {
+		if len(handle.CustomData) < 1 {
+			continue
+		}
+		customData, err := s.customDataCodec.Open(handle.ID, handle.CustomData)
+		if err != nil {
+			return fmt.Errorf("%s: %w", handle.ID, err)
+		}
+		handle.CustomData = customData
+	}
+	return nil
+}
+
+// sealedCustomDataKey is the customData field the encrypted customData is stored in.
+const sealedCustomDataKey = "sealed"
+
+// AESGCMCustomData is a CustomDataCodec which encrypts customData using AES-GCM, so any sensitive details
+// (i.e. internal device addresses) are not stored in cleartext by Google. The device ID is authenticated
+// alongside the customData so the customData of one device can't be used in place of another's.
+type AESGCMCustomData struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCustomData creates a codec which encrypts customData using the supplied AES key.
+// The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewAESGCMCustomData(key []byte) (*AESGCMCustomData, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMCustomData{
+		aead: aead,
+	}, nil
+}
+
+// Seal encrypts the customData, returning a customData containing only the encrypted value.
+func (c *AESGCMCustomData) Seal(deviceID string, customData map[string]interface{}) (map[string]interface{}, error) {
+	plaintext, err := json.Marshal(customData)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, []byte(deviceID))
+
+	return map[string]interface{}{
+		sealedCustomDataKey: base64.RawURLEncoding.EncodeToString(sealed),
+	}, nil
+}
+
+// Open decrypts the customData previously encrypted by Seal.
+func (c *AESGCMCustomData) Open(deviceID string, customData map[string]interface{}) (map[string]interface{}, error) {
+	encoded, ok := customData[sealedCustomDataKey].(string)
+	if !ok {
+		return nil, ErrCustomDataInvalid
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, ErrCustomDataInvalid
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(deviceID))
+	if err != nil {
+		return nil, ErrCustomDataInvalid
+	}
+
+	opened := map[string]interface{}{}
+	if err := json.Unmarshal(plaintext, &opened); err != nil {
+		return nil, ErrCustomDataInvalid
+	}
+	return opened, nil
+}
+
+// signedCustomDataKey is the customData field the signature is stored in.
+const signedCustomDataKey = "sig"
+
+// HMACCustomData is a CustomDataCodec which signs customData using HMAC-SHA256, leaving it readable but ensuring
+// any modification of it (i.e. of an internal device address) is detected before it reaches the provider.
+// The device ID is signed alongside the customData so the customData of one device can't be used in place of another's.
+type HMACCustomData struct {
+	key []byte
+}
+
+// NewHMACCustomData creates a codec which signs customData using the supplied secret key.
+func NewHMACCustomData(key []byte) *HMACCustomData {
+	return &HMACCustomData{
+		key: key,
+	}
+}
+
+// signature calculates the signature of the customData of the device.
+func (c *HMACCustomData) signature(deviceID string, customData map[string]interface{}) ([]byte, error) {
+	// Maps are serialized with sorted keys so the encoding is stable across the round-trip through Google.
+	payload, err := json.Marshal(customData)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(deviceID))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// Seal returns a copy of the customData with a signature field added.
+// The customData is first converted to the form it will have once it is echoed back by Google (i.e. structs are
+// converted to maps), so values which don't serialize the same way after the round-trip can still be verified.
+func (c *HMACCustomData) Seal(deviceID string, customData map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(customData)
+	if err != nil {
+		return nil, err
+	}
+	signed := map[string]interface{}{}
+	if err := unmarshalUseNumber(data, &signed); err != nil {
+		return nil, err
+	}
+
+	sig, err := c.signature(deviceID, signed)
+	if err != nil {
+		return nil, err
+	}
+	signed[signedCustomDataKey] = base64.RawURLEncoding.EncodeToString(sig)
+	return signed, nil
+}
+
+// Open verifies the signature added by Seal, returning the customData without it.
+// A CustomDataTamperedError is returned if the signature doesn't match.
+func (c *HMACCustomData) Open(deviceID string, customData map[string]interface{}) (map[string]interface{}, error) {
+	encoded, ok := customData[signedCustomDataKey].(string)
+	if !ok {
+		return nil, ErrCustomDataInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrCustomDataInvalid
+	}
+
+	opened := map[string]interface{}{}
+	for k, v := range customData {
+		if k != signedCustomDataKey {
+			opened[k] = v
+		}
+	}
+
+	expected, err := c.signature(deviceID, opened)
+	if err != nil {
+		return nil, ErrCustomDataInvalid
+	}
+	if !hmac.Equal(sig, expected) {
+		return nil, &CustomDataTamperedError{
+			DeviceID: deviceID,
+		}
+	}
+	return opened, nil
+}
@@ -0,0 +1,149 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAESGCMCustomData(t *testing.T) {
+	_, err := NewAESGCMCustomData([]byte("short"))
+	assert.NotNil(t, err)
+
+	codec, err := NewAESGCMCustomData(bytes.Repeat([]byte{1}, 32))
+	assert.Nil(t, err)
+
+	sealed, err := codec.Seal("light-1", map[string]interface{}{"address": "10.0.0.5"})
+	assert.Nil(t, err)
+	assert.Len(t, sealed, 1)
+	assert.NotContains(t, sealed[sealedCustomDataKey], "10.0.0.5")
+
+	opened, err := codec.Open("light-1", sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"address": "10.0.0.5"}, opened)
+
+	// The customData of one device can't be used for another.
+	_, err = codec.Open("light-2", sealed)
+	assert.True(t, errors.Is(err, ErrCustomDataInvalid))
+
+	_, err = codec.Open("light-1", map[string]interface{}{"address": "10.0.0.5"})
+	assert.True(t, errors.Is(err, ErrCustomDataInvalid))
+}
+
+func TestGoogleFulfillmentHandlerCustomDataCodec(t *testing.T) {
+	codec, err := NewAESGCMCustomData(bytes.Repeat([]byte{1}, 16))
+	assert.Nil(t, err)
+
+	light := NewLight("light-1")
+	light.CustomData["address"] = "10.0.0.5"
+	plain := NewLight("light-2")
+	provider := &testProvider{
+		syncResp: []*Device{light, plain},
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true),
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil,
+		WithCustomDataCodec(codec),
+	)
+
+	fulfill := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(body))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		svc.GoogleFulfillmentHandler(rr, req)
+		return rr
+	}
+
+	rr := fulfill(`{"requestId": "1", "inputs": [{"intent": "action.devices.SYNC"}]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "10.0.0.5")
+	assert.Equal(t, "10.0.0.5", light.CustomData["address"])
+
+	var syncResp struct {
+		Payload struct {
+			Devices []struct {
+				ID         string                 `json:"id"`
+				CustomData map[string]interface{} `json:"customData"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &syncResp))
+	sealed, err := json.Marshal(syncResp.Payload.Devices[0].CustomData)
+	assert.Nil(t, err)
+	assert.Empty(t, syncResp.Payload.Devices[1].CustomData)
+
+	rr = fulfill(`{"requestId": "2", "inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1", "customData": ` + string(sealed) + `}]}}]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]interface{}{"address": "10.0.0.5"}, provider.queryReq.Devices[0].CustomData)
+
+	provider.queryReq = nil
+	rr = fulfill(`{"requestId": "3", "inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1", "customData": {"address": "10.0.0.6"}}]}}]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), ErrorCodeProtocolError)
+	assert.Nil(t, provider.queryReq)
+}
+
+func TestHMACCustomData(t *testing.T) {
+	codec := NewHMACCustomData([]byte("secret"))
+
+	sealed, err := codec.Seal("light-1", map[string]interface{}{"address": "10.0.0.5", "port": 80})
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.5", sealed["address"])
+	assert.Contains(t, sealed, signedCustomDataKey)
+
+	// Round-trip through JSON as Google would.
+	serialized, err := json.Marshal(sealed)
+	assert.Nil(t, err)
+	echoed := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(serialized, &echoed))
+
+	opened, err := codec.Open("light-1", echoed)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"address": "10.0.0.5", "port": 80.0}, opened)
+
+	echoed["address"] = "10.0.0.6"
+	_, err = codec.Open("light-1", echoed)
+	var tampered *CustomDataTamperedError
+	assert.True(t, errors.As(err, &tampered))
+	assert.Equal(t, "light-1", tampered.DeviceID)
+	assert.True(t, errors.Is(err, ErrCustomDataInvalid))
+
+	_, err = codec.Open("light-2", sealed)
+	assert.True(t, errors.As(err, &tampered))
+
+	_, err = codec.Open("light-1", map[string]interface{}{"address": "10.0.0.5"})
+	assert.True(t, errors.Is(err, ErrCustomDataInvalid))
+}
+
+func TestHMACCustomDataStruct(t *testing.T) {
+	codec := NewHMACCustomData([]byte("secret"))
+
+	type address struct {
+		Port int    `json:"port"`
+		Host string `json:"host"`
+		Path string `json:"path,omitempty"`
+	}
+	sealed, err := codec.Seal("light-1", map[string]interface{}{
+		"address": address{Host: "10.0.0.5", Port: 80},
+	})
+	assert.Nil(t, err)
+
+	serialized, err := json.Marshal(sealed)
+	assert.Nil(t, err)
+	echoed := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(serialized, &echoed))
+
+	opened, err := codec.Open("light-1", echoed)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"address": map[string]interface{}{"host": "10.0.0.5", "port": 80.0},
+	}, opened)
+}
@@ -0,0 +1,248 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// DebugPath is the suggested path to register the DebugHandler on.
+	DebugPath = "/debug/smarthome"
+)
+
+// DebugIntent records an intent received from Google.
+type DebugIntent struct {
+	Time        time.Time `json:"time"`
+	RequestID   string    `json:"requestId"`
+	AgentUserID string    `json:"agentUserId"`
+	Intent      string    `json:"intent"`
+}
+
+// DebugReportState records the payload of a ReportState call made to the HomeGraph.
+type DebugReportState struct {
+	Time        time.Time       `json:"time"`
+	AgentUserID string          `json:"agentUserId"`
+	States      json.RawMessage `json:"states"`
+}
+
+// DebugHomeGraphCall records the result of a call made to the HomeGraph.
+type DebugHomeGraphCall struct {
+	Time        time.Time `json:"time"`
+	Method      string    `json:"method"`
+	AgentUserID string    `json:"agentUserId"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// DebugSnapshot contains the state of the service as rendered by the DebugHandler.
+// Each of the recorded lists is ordered from oldest to newest.
+type DebugSnapshot struct {
+	// Devices contains the devices last returned by SYNC, indexed by user ID.
+	Devices        map[string][]*Device `json:"devices"`
+	Intents        []DebugIntent        `json:"intents"`
+	ReportStates   []DebugReportState   `json:"reportStates"`
+	HomeGraphCalls []DebugHomeGraphCall `json:"homeGraphCalls"`
+}
+
+// WithDebugRecorder causes the service to retain the most recent historySize intents, ReportState payloads and HomeGraph calls,
+// along with the devices last returned by SYNC for each user, so they can be inspected using DebugHandler or DebugSnapshot.
+// A historySize below 1 retains only the devices.
+// The recorded information includes user IDs and device states so it should not be exposed publicly.
+func WithDebugRecorder(historySize int) ServiceOption {
+	if historySize < 0 {
+		historySize = 0
+	}
+	return func(s *Service) {
+		s.debug = &debugRecorder{
+			size:    historySize,
+			devices: map[string][]*Device{},
+		}
+	}
+}
+
+// debugRecorder retains recent activity for the debug console.
+// All methods are safe to call on a nil recorder, in which case nothing is recorded.
+type debugRecorder struct {
+	mu    sync.Mutex
+	size  int
+	clock Clock
+
+	devices        map[string][]*Device
+	intents        []DebugIntent
+	reportStates   []DebugReportState
+	homeGraphCalls []DebugHomeGraphCall
+}
+
+// recordSync replaces the devices recorded for the user with those returned by SYNC.
+func (dr *debugRecorder) recordSync(agentUserID string, devices []*Device) {
+	if dr == nil {
+		return
+	}
+
+	sorted := append([]*Device{}, devices...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.devices[agentUserID] = sorted
+}
+
+func (dr *debugRecorder) recordIntent(requestID string, agentUserID string, intent string) {
+	if dr == nil {
+		return
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.intents = append(dr.intents, DebugIntent{
+		Time:        dr.clock.Now(),
+		RequestID:   requestID,
+		AgentUserID: agentUserID,
+		Intent:      intent,
+	})
+	if len(dr.intents) > dr.size {
+		dr.intents = dr.intents[len(dr.intents)-dr.size:]
+	}
+}
+
+func (dr *debugRecorder) recordReportState(agentUserID string, states []byte) {
+	if dr == nil {
+		return
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.reportStates = append(dr.reportStates, DebugReportState{
+		Time:        dr.clock.Now(),
+		AgentUserID: agentUserID,
+		States:      states,
+	})
+	if len(dr.reportStates) > dr.size {
+		dr.reportStates = dr.reportStates[len(dr.reportStates)-dr.size:]
+	}
+}
+
+// recordHomeGraphCall records the outcome of a HomeGraph call.
+// If the call failed the status code is taken from the error where possible.
+func (dr *debugRecorder) recordHomeGraphCall(method string, agentUserID string, statusCode int, err error) {
+	if dr == nil {
+		return
+	}
+
+	call := DebugHomeGraphCall{
+		Time:        dr.clock.Now(),
+		Method:      method,
+		AgentUserID: agentUserID,
+		StatusCode:  statusCode,
+	}
+	if err != nil {
+		call.Error = err.Error()
+
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) {
+			call.StatusCode = apiErr.Code
+		}
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.homeGraphCalls = append(dr.homeGraphCalls, call)
+	if len(dr.homeGraphCalls) > dr.size {
+		dr.homeGraphCalls = dr.homeGraphCalls[len(dr.homeGraphCalls)-dr.size:]
+	}
+}
+
+// DebugSnapshot returns a copy of the recorded activity, along with the devices last returned by SYNC.
+func (s *Service) DebugSnapshot() *DebugSnapshot {
+	snapshot := &DebugSnapshot{
+		Devices: map[string][]*Device{},
+	}
+
+	if s.debug != nil {
+		s.debug.mu.Lock()
+		for agentUserID, devices := range s.debug.devices {
+			snapshot.Devices[agentUserID] = devices
+		}
+		snapshot.Intents = append(snapshot.Intents, s.debug.intents...)
+		snapshot.ReportStates = append(snapshot.ReportStates, s.debug.reportStates...)
+		snapshot.HomeGraphCalls = append(snapshot.HomeGraphCalls, s.debug.homeGraphCalls...)
+		s.debug.mu.Unlock()
+	}
+
+	return snapshot
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Smart Home Debug</title></head>
+<body>
+<h1>Devices</h1>
+{{range $user, $devices := .Devices}}<h2>{{$user}}</h2>
+<table border="1"><tr><th>ID</th><th>Type</th><th>Name</th><th>Traits</th></tr>
+{{range $devices}}<tr><td>{{.ID}}</td><td>{{.Type}}</td><td>{{.Name.Name}}</td><td>{{range $trait, $set := .Traits}}{{$trait}} {{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h1>Intents</h1>
+<table border="1"><tr><th>Time</th><th>Request ID</th><th>User</th><th>Intent</th></tr>
+{{range .Intents}}<tr><td>{{.Time}}</td><td>{{.RequestID}}</td><td>{{.AgentUserID}}</td><td>{{.Intent}}</td></tr>
+{{end}}</table>
+<h1>Report State</h1>
+<table border="1"><tr><th>Time</th><th>User</th><th>States</th></tr>
+{{range .ReportStates}}<tr><td>{{.Time}}</td><td>{{.AgentUserID}}</td><td><code>{{printf "%s" .States}}</code></td></tr>
+{{end}}</table>
+<h1>HomeGraph Calls</h1>
+<table border="1"><tr><th>Time</th><th>Method</th><th>User</th><th>Status</th><th>Error</th></tr>
+{{range .HomeGraphCalls}}<tr><td>{{.Time}}</td><td>{{.Method}}</td><td>{{.AgentUserID}}</td><td>{{.StatusCode}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// DebugHandler returns a handler which renders the DebugSnapshot as JSON, or as HTML if the client prefers it (i.e. a browser).
+// Every request is first checked using the supplied authorize function; requests it rejects receive a 403.
+// The authorize function must be supplied as the debug information should never be publicly available.
+func (s *Service) DebugHandler(authorize func(r *http.Request) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authorize == nil || !authorize(r) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Forbidden"))
+			return
+		}
+
+		snapshot := s.DebugSnapshot()
+
+		// The page is rendered before anything is written so a failure can still be reported as an error.
+		var body bytes.Buffer
+		contentType := "application/json"
+		var err error
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			contentType = "text/html; charset=utf-8"
+			err = debugTemplate.Execute(&body, snapshot)
+		} else {
+			err = json.NewEncoder(&body).Encode(snapshot)
+		}
+		if err != nil {
+			s.logger.Info("error rendering debug snapshot",
+				zap.Error(err),
+			)
+			http.Error(w, "Error rendering debug snapshot", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
@@ -0,0 +1,97 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceDebugHandler(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("outlet-2"), NewOutlet("outlet-1")},
+	}
+	svc := NewService(logger, authenticator, provider, newTestHomeGraphService(t, thg), WithDebugRecorder(2))
+
+	for i := 0; i < 3; i++ {
+		result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+			"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+			"inputs": [{"intent": "action.devices.SYNC"}]
+		}`))
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+	}
+	assert.Nil(t, svc.ReportState(context.Background(), "1836.15267389", map[string]DeviceState{
+		"outlet-1": NewDeviceState(true).RecordOnOff(true),
+	}))
+	assert.Nil(t, svc.RequestSync(context.Background(), "1836.15267389"))
+
+	handler := svc.DebugHandler(func(r *http.Request) bool {
+		return r.Header.Get("X-Debug-Key") == "secret"
+	})
+
+	req := httptest.NewRequest(http.MethodGet, DebugPath, nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, DebugPath, nil)
+	req.Header.Set("X-Debug-Key", "secret")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	snapshot := &DebugSnapshot{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), snapshot))
+	assert.Len(t, snapshot.Devices["1836.15267389"], 2)
+	assert.Equal(t, "outlet-1", snapshot.Devices["1836.15267389"][0].ID)
+	assert.Len(t, snapshot.Intents, 2)
+	assert.Equal(t, IntentSync, snapshot.Intents[0].Intent)
+	assert.Len(t, snapshot.ReportStates, 1)
+	assert.JSONEq(t, `{"outlet-1":{"on":true,"online":true}}`, string(snapshot.ReportStates[0].States))
+	assert.Len(t, snapshot.HomeGraphCalls, 2)
+	assert.Equal(t, "reportStateAndNotification", snapshot.HomeGraphCalls[0].Method)
+	assert.Equal(t, "requestSync", snapshot.HomeGraphCalls[1].Method)
+	assert.Equal(t, http.StatusOK, snapshot.HomeGraphCalls[1].StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, DebugPath, nil)
+	req.Header.Set("X-Debug-Key", "secret")
+	req.Header.Set("Accept", "text/html")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "outlet-1")
+	assert.Contains(t, rr.Body.String(), "requestSync")
+}
+
+func TestServiceDebugRecorderNegativeSize(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("outlet-1")},
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, nil, WithDebugRecorder(-1))
+
+	result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`))
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	snapshot := svc.DebugSnapshot()
+	assert.Len(t, snapshot.Devices["1836.15267389"], 1)
+	assert.Empty(t, snapshot.Intents)
+}
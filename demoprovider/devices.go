@@ -0,0 +1,282 @@
+package demoprovider
+
+import (
+	action "github.com/rmrobinson/google-smart-home-action-go"
+)
+
+const (
+	// vacuumCycleTicks is the number of ticks a cleaning cycle lasts before the vacuum returns to its dock.
+	vacuumCycleTicks = 10
+	// lockRelockTicks is the number of ticks the lock stays unlocked before automatically locking itself again.
+	lockRelockTicks = 5
+	// washerCycleTicks is the number of ticks a wash cycle lasts.
+	washerCycleTicks = 8
+)
+
+type lightbulb struct {
+	id         string
+	name       string
+	isOn       bool
+	brightness int
+
+	color struct {
+		hue        float64
+		saturation float64
+		value      float64
+	}
+}
+
+func (l *lightbulb) device() *action.Device {
+	d := action.NewLight(l.id)
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo lamp",
+		},
+		Name: l.name,
+	}
+	d.AddBrightnessTrait(false).AddColorSettingTrait(action.ColorSettingOptions{
+		ColorModel: action.HSV,
+	})
+	return d
+}
+
+func (l *lightbulb) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordOnOff(l.isOn).RecordBrightness(l.brightness).RecordColorHSV(l.color.hue, l.color.saturation, l.color.value)
+}
+
+type receiver struct {
+	id        string
+	name      string
+	isOn      bool
+	volume    int
+	muted     bool
+	currInput string
+}
+
+func (r *receiver) device() *action.Device {
+	d := action.NewSimpleAVReceiver(r.id, []action.DeviceInput{
+		{
+			Key: "input_1",
+			Names: []action.DeviceInputName{
+				{
+					Synonyms: []string{
+						"Input 1",
+						"Google Chromecast Audio",
+					},
+					LanguageCode: "en",
+				},
+			},
+		},
+		{
+			Key: "input_2",
+			Names: []action.DeviceInputName{
+				{
+					Synonyms: []string{
+						"Input 2",
+						"Raspberry Pi",
+					},
+					LanguageCode: "en",
+				},
+			},
+		},
+	}, 100, true, false)
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo receiver",
+		},
+		Name: r.name,
+	}
+	return d
+}
+
+func (r *receiver) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordOnOff(r.isOn).RecordInput(r.currInput).RecordVolume(r.volume, r.muted)
+}
+
+type thermostat struct {
+	id       string
+	name     string
+	mode     string
+	setpoint float64
+	ambient  float64
+}
+
+func (t *thermostat) device() *action.Device {
+	d := action.NewThermostat(t.id, []string{action.ThermostatModeOff, action.ThermostatModeHeat, action.ThermostatModeCool}, action.TemperatureUnitCelsius, [2]float64{})
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo thermostat",
+		},
+		Name: t.name,
+	}
+	return d
+}
+
+func (t *thermostat) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordThermostat(action.ThermostatState{
+		Mode:      t.mode,
+		AmbientC:  t.ambient,
+		SetpointC: t.setpoint,
+	})
+}
+
+// tick moves the ambient temperature half a degree towards the setpoint if the thermostat is running.
+// It returns true if the ambient temperature changed.
+func (t *thermostat) tick() bool {
+	if t.mode == action.ThermostatModeOff {
+		return false
+	}
+
+	if t.mode == action.ThermostatModeHeat && t.ambient < t.setpoint {
+		t.ambient += 0.5
+		return true
+	} else if t.mode == action.ThermostatModeCool && t.ambient > t.setpoint {
+		t.ambient -= 0.5
+		return true
+	}
+	return false
+}
+
+type lock struct {
+	id            string
+	name          string
+	isLocked      bool
+	unlockedTicks int
+}
+
+func (l *lock) device() *action.Device {
+	d := action.NewDevice(l.id, action.DeviceTypeLock)
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo lock",
+		},
+		Name: l.name,
+	}
+	d.AddLockUnlockTrait()
+	return d
+}
+
+func (l *lock) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordLockState(l.isLocked, false)
+}
+
+// tick automatically locks the lock after it has been left unlocked for a while.
+// It returns true if the lock was locked.
+func (l *lock) tick() bool {
+	if l.isLocked {
+		return false
+	}
+
+	l.unlockedTicks++
+	if l.unlockedTicks < lockRelockTicks {
+		return false
+	}
+
+	l.isLocked = true
+	l.unlockedTicks = 0
+	return true
+}
+
+type vacuum struct {
+	id          string
+	name        string
+	isRunning   bool
+	isPaused    bool
+	isDocked    bool
+	cycleTicks  int
+	batteryPerc int
+}
+
+func (v *vacuum) device() *action.Device {
+	d := action.NewVacuum(v.id, nil, true)
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo vacuum",
+		},
+		Name: v.name,
+	}
+	d.AddEnergyStorageTrait(true, true)
+	d.NotificationSupportedByAgent = true
+	return d
+}
+
+func (v *vacuum) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordStartStop(v.isRunning, v.isPaused).RecordDocked(v.isDocked).RecordBattery(v.batteryPerc, v.isDocked && v.batteryPerc < 100, v.isDocked)
+}
+
+// tick advances the cleaning cycle of the vacuum, returning it to the dock once the cycle is complete.
+// It returns whether the state of the vacuum changed, and whether it has just finished charging.
+func (v *vacuum) tick() (changed bool, charged bool) {
+	if !v.isRunning || v.isPaused {
+		if v.isDocked && v.batteryPerc < 100 {
+			v.batteryPerc += 10
+			if v.batteryPerc >= 100 {
+				v.batteryPerc = 100
+				return true, true
+			}
+		}
+		return false, false
+	}
+
+	v.cycleTicks++
+	if v.batteryPerc > 0 {
+		v.batteryPerc -= 5
+	}
+	if v.cycleTicks < vacuumCycleTicks && v.batteryPerc > 0 {
+		return false, false
+	}
+
+	v.isRunning = false
+	v.isDocked = true
+	v.cycleTicks = 0
+	return true, false
+}
+
+type washer struct {
+	id         string
+	name       string
+	isRunning  bool
+	isPaused   bool
+	cycleTicks int
+	load       string
+	extraRinse bool
+}
+
+func (w *washer) device() *action.Device {
+	d := action.NewWasher(w.id, []action.DeviceMode{
+		action.NewMode("load", true).WithNames("en", "load", "load size").WithSettings(
+			action.NewModeSetting("small").WithNames("en", "small", "half"),
+			action.NewModeSetting("large").WithNames("en", "large", "full"),
+		),
+	}, []action.DeviceToggle{
+		action.NewToggle("extraRinse").WithNames("en", "extra rinse"),
+	})
+	d.Name = action.DeviceName{
+		DefaultNames: []string{
+			"Demo washer",
+		},
+		Name: w.name,
+	}
+	return d
+}
+
+func (w *washer) state() action.DeviceState {
+	return action.NewDeviceState(true).RecordStartStop(w.isRunning, w.isPaused).RecordMode("load", w.load).RecordToggle("extraRinse", w.extraRinse)
+}
+
+// tick advances the wash cycle, stopping the washer once the cycle is complete.
+// It returns true if the washer stopped.
+func (w *washer) tick() bool {
+	if !w.isRunning || w.isPaused {
+		return false
+	}
+
+	w.cycleTicks++
+	if w.cycleTicks < washerCycleTicks {
+		return false
+	}
+
+	w.isRunning = false
+	w.cycleTicks = 0
+	return true
+}
@@ -0,0 +1,92 @@
+package demoprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type testValidator struct{}
+
+func (tv *testValidator) Validate(_ context.Context, token string) (string, error) {
+	if token != "demo-token" {
+		return "", errors.New("invalid token")
+	}
+	return "demo-user", nil
+}
+
+// fulfill sends the intent to the service, returning the decoded response payload.
+func fulfill(t *testing.T, svc *action.Service, intent string, payload string) map[string]interface{} {
+	body := `{"requestId": "req-1", "inputs": [{"intent": "` + intent + `"`
+	if len(payload) > 0 {
+		body += `, "payload": ` + payload
+	}
+	body += `}]}`
+
+	req := httptest.NewRequest(http.MethodPost, action.GoogleFulfillmentPath, bytes.NewBufferString(body))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer demo-token")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	resp := struct {
+		Payload map[string]interface{} `json:"payload"`
+	}{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	return resp.Payload
+}
+
+// TestServiceEndToEnd exercises the demo devices through the fulfillment handler of a Service.
+func TestServiceEndToEnd(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	svc := action.NewService(logger, &testValidator{}, New(logger), nil,
+		action.WithExecuteValidation(false),
+		action.WithStrictDecoding(),
+	)
+
+	syncResp := fulfill(t, svc, action.IntentSync, "")
+	assert.Equal(t, "demo-user", syncResp["agentUserId"])
+	assert.Len(t, syncResp["devices"], 7)
+
+	unlock := `{"commands": [{"devices": [{"id": "l-1"}], "execution": [{"command": "action.devices.commands.LockUnlock", "params": {"lock": false}%s}]}]}`
+	executeResp := fulfill(t, svc, action.IntentExecute, fmtChallenge(unlock, ""))
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"ids":             []interface{}{"l-1"},
+		"status":          "ERROR",
+		"errorCode":       "challengeNeeded",
+		"challengeNeeded": map[string]interface{}{"type": action.ChallengePinNeeded},
+	}}, executeResp["commands"])
+
+	executeResp = fulfill(t, svc, action.IntentExecute, fmtChallenge(unlock, `"pin": "`+LockPin+`"`))
+	assert.Equal(t, "SUCCESS", executeResp["commands"].([]interface{})[0].(map[string]interface{})["status"])
+
+	executeResp = fulfill(t, svc, action.IntentExecute, `{"commands": [{"devices": [{"id": "w-1"}], "execution": [
+		{"command": "action.devices.commands.SetModes", "params": {"updateModeSettings": {"load": "small"}}},
+		{"command": "action.devices.commands.SetToggles", "params": {"updateToggleSettings": {"extraRinse": true}}}
+	]}]}`)
+	assert.Equal(t, "SUCCESS", executeResp["commands"].([]interface{})[0].(map[string]interface{})["status"])
+
+	queryResp := fulfill(t, svc, action.IntentQuery, `{"devices": [{"id": "l-1"}, {"id": "w-1"}]}`)
+	devices := queryResp["devices"].(map[string]interface{})
+	assert.Equal(t, false, devices["l-1"].(map[string]interface{})["isLocked"])
+	assert.Equal(t, map[string]interface{}{"load": "small"}, devices["w-1"].(map[string]interface{})["currentModeSettings"])
+	assert.Equal(t, map[string]interface{}{"extraRinse": true}, devices["w-1"].(map[string]interface{})["currentToggleSettings"])
+}
+
+// fmtChallenge adds the challenge to the execution in the payload, if one is supplied.
+func fmtChallenge(payload string, challenge string) string {
+	if len(challenge) > 0 {
+		challenge = `, "challenge": {` + challenge + `}`
+	}
+	return fmt.Sprintf(payload, challenge)
+}
@@ -0,0 +1,451 @@
+// Package demoprovider contains a virtual set of devices which implements the action.Provider interface.
+// It is intended to be used for end-to-end testing of a Smart Home Action against a real Google account
+// without needing any physical hardware. Some of the devices change state on their own while the provider
+// is running (the thermostat approaches its setpoint, the lock relocks itself, the vacuum finishes cleaning,
+// the washer finishes its cycle) which allows the ReportState flow to be exercised as well.
+// Unlocking the lock requires the user to supply LockPin, and the vacuum sends a notification once it has
+// finished charging, exercising secondary user verification and notifications.
+package demoprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+	"go.uber.org/zap"
+)
+
+// LockPin is the PIN the user must supply to unlock the virtual lock.
+const LockPin = "1234"
+
+// StateReporter is used to inform Google of state changes which occur on the virtual devices.
+// This is satisfied by action.Service.
+type StateReporter interface {
+	ReportState(ctx context.Context, agentUserID string, deviceStates map[string]action.DeviceState) error
+}
+
+// Notifier may optionally be implemented by a StateReporter to inform the user of events on the virtual devices.
+// This is satisfied by action.Service.
+type Notifier interface {
+	NotifyChargingState(ctx context.Context, agentUserID string, deviceID string, state action.DeviceState) error
+}
+
+// Provider is a set of virtual devices which can be controlled by the Google Assistant.
+type Provider struct {
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	lights     map[string]*lightbulb
+	receiver   *receiver
+	thermostat *thermostat
+	lock       *lock
+	vacuum     *vacuum
+	washer     *washer
+}
+
+// New creates a new demo provider populated with two lights, an AV receiver, a thermostat, a lock, a vacuum and a washer.
+func New(logger *zap.Logger) *Provider {
+	p := &Provider{
+		logger: logger,
+		lights: map[string]*lightbulb{
+			"123": {
+				id:         "123",
+				name:       "demo light 1",
+				brightness: 40,
+			},
+			"456": {
+				id:         "456",
+				name:       "demo light 2",
+				brightness: 40,
+			},
+		},
+		receiver: &receiver{
+			id:        "789",
+			name:      "demo receiver",
+			volume:    20,
+			currInput: "input_1",
+		},
+		thermostat: &thermostat{
+			id:       "t-1",
+			name:     "demo thermostat",
+			mode:     action.ThermostatModeHeat,
+			setpoint: 21,
+			ambient:  18,
+		},
+		lock: &lock{
+			id:       "l-1",
+			name:     "demo lock",
+			isLocked: true,
+		},
+		vacuum: &vacuum{
+			id:          "v-1",
+			name:        "demo vacuum",
+			isDocked:    true,
+			batteryPerc: 100,
+		},
+		washer: &washer{
+			id:   "w-1",
+			name: "demo washer",
+			load: "large",
+		},
+	}
+	for _, l := range p.lights {
+		l.color.hue = 100
+		l.color.saturation = 100
+		l.color.value = 10
+	}
+	return p
+}
+
+// Run advances the state of the autonomous devices once per interval until the context is cancelled.
+// The state changes which occur during each step are reported in a single batch using the supplied reporter against
+// the specified agent user ID. If the reporter implements Notifier the user is also notified once the vacuum has
+// finished charging.
+func (p *Provider) Run(ctx context.Context, reporter StateReporter, agentUserID string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.step(ctx, reporter, agentUserID)
+	}
+}
+
+// step advances the state of the autonomous devices once, reporting any changes.
+func (p *Provider) step(ctx context.Context, reporter StateReporter, agentUserID string) {
+	states, charged := p.tick()
+	if len(states) > 0 {
+		if err := reporter.ReportState(ctx, agentUserID, states); err != nil {
+			p.logger.Error("unable to report state",
+				zap.Error(err),
+			)
+		}
+	}
+
+	notifier, ok := reporter.(Notifier)
+	if !ok {
+		return
+	}
+	for id, state := range charged {
+		if err := notifier.NotifyChargingState(ctx, agentUserID, id, state); err != nil {
+			p.logger.Error("unable to send charging notification",
+				zap.String("device_id", id),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// tick advances the state of each autonomous device by one step and returns the states of the devices which changed,
+// along with the states of the devices which have just finished charging.
+func (p *Provider) tick() (map[string]action.DeviceState, map[string]action.DeviceState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := map[string]action.DeviceState{}
+	charged := map[string]action.DeviceState{}
+	if p.thermostat.tick() {
+		states[p.thermostat.id] = p.thermostat.state()
+	}
+	if p.lock.tick() {
+		states[p.lock.id] = p.lock.state()
+	}
+	if changed, fullyCharged := p.vacuum.tick(); changed {
+		states[p.vacuum.id] = p.vacuum.state()
+		if fullyCharged {
+			charged[p.vacuum.id] = p.vacuum.state()
+		}
+	}
+	if p.washer.tick() {
+		states[p.washer.id] = p.washer.state()
+	}
+	return states, charged
+}
+
+// Sync returns the full set of virtual devices.
+func (p *Provider) Sync(context.Context, string) (*action.SyncResponse, error) {
+	p.logger.Debug("sync")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := action.NewSyncResponse()
+	for _, l := range p.lights {
+		resp.Add(p.decorate(l.device()))
+	}
+	resp.Add(
+		p.decorate(p.receiver.device()),
+		p.decorate(p.thermostat.device()),
+		p.decorate(p.lock.device()),
+		p.decorate(p.vacuum.device()),
+		p.decorate(p.washer.device()),
+	)
+
+	return resp, resp.Err()
+}
+
+// decorate fills in the fields which are common to all of the virtual devices.
+func (p *Provider) decorate(d *action.Device) *action.Device {
+	d.WillReportState = true
+	d.RoomHint = "demo room"
+	d.DeviceInfo = action.DeviceInfo{
+		Manufacturer: "faltung systems",
+		Model:        "demo001",
+		HwVersion:    "0.2",
+		SwVersion:    "0.3",
+	}
+	return d
+}
+
+// Disconnect is a no-op as the virtual devices are not linked to any particular user.
+func (p *Provider) Disconnect(context.Context, string) error {
+	p.logger.Debug("disconnect")
+	return nil
+}
+
+// Query returns the current state of the requested virtual devices.
+func (p *Provider) Query(_ context.Context, req *action.QueryRequest) (*action.QueryResponse, error) {
+	p.logger.Debug("query")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := action.NewQueryResponse()
+	for _, deviceArg := range req.Devices {
+		if state, found := p.state(deviceArg.ID); found {
+			resp.Add(deviceArg.ID, state)
+		}
+	}
+
+	return resp, nil
+}
+
+func (p *Provider) state(id string) (action.DeviceState, bool) {
+	if l, found := p.lights[id]; found {
+		return l.state(), true
+	}
+
+	switch id {
+	case p.receiver.id:
+		return p.receiver.state(), true
+	case p.thermostat.id:
+		return p.thermostat.state(), true
+	case p.lock.id:
+		return p.lock.state(), true
+	case p.vacuum.id:
+		return p.vacuum.state(), true
+	case p.washer.id:
+		return p.washer.state(), true
+	}
+	return action.DeviceState{}, false
+}
+
+// Execute applies the requested commands to the virtual devices.
+func (p *Provider) Execute(_ context.Context, req *action.ExecuteRequest) (*action.ExecuteResponse, error) {
+	p.logger.Debug("execute")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := &action.ExecuteResponse{
+		UpdatedState: action.NewDeviceState(true),
+	}
+
+	for _, deviceCommand := range req.DeviceCommands() {
+		deviceID := deviceCommand.Device.ID
+		command := deviceCommand.Command
+		p.logger.Debug("received command",
+			zap.String("device_id", deviceID),
+			zap.String("command", command.Name),
+		)
+
+		if challenge := p.challenge(deviceID, command); len(challenge) > 0 {
+			resp.AddChallengeNeeded(challenge, deviceID)
+			continue
+		}
+
+		if !p.execute(deviceID, command) {
+			p.logger.Info("unsupported command",
+				zap.String("device_id", deviceID),
+				zap.String("command", command.Name),
+			)
+			continue
+		}
+
+		state, _ := p.state(deviceID)
+		for k, v := range state.State {
+			resp.UpdatedState.State[k] = v
+		}
+		resp.UpdatedDevices = append(resp.UpdatedDevices, deviceID)
+	}
+
+	return resp, nil
+}
+
+// challenge returns the secondary user verification which must be completed before the command is applied, if any.
+// Unlocking the lock requires LockPin; locking it doesn't require verification.
+func (p *Provider) challenge(id string, command action.Command) string {
+	if id != p.lock.id || command.Generic == nil || command.Name != "action.devices.commands.LockUnlock" {
+		return ""
+	}
+	if lock, _ := command.Generic.Params["lock"].(bool); lock {
+		return ""
+	}
+	return command.CheckPin(LockPin)
+}
+
+// execute applies the command to the specified device, returning false if the command or device isn't supported.
+func (p *Provider) execute(id string, command action.Command) bool {
+	if l, found := p.lights[id]; found {
+		switch {
+		case command.OnOff != nil:
+			l.isOn = command.OnOff.On
+		case command.BrightnessAbsolute != nil:
+			l.brightness = command.BrightnessAbsolute.Brightness
+		case command.BrightnessRelative != nil:
+			weight, _ := command.BrightnessRelative.Weight()
+			l.brightness += weight
+		case command.ColorAbsolute != nil:
+			l.color.hue = command.ColorAbsolute.Color.HSV.Hue
+			l.color.saturation = command.ColorAbsolute.Color.HSV.Saturation
+			l.color.value = command.ColorAbsolute.Color.HSV.Value
+		default:
+			return false
+		}
+		return true
+	}
+
+	switch id {
+	case p.receiver.id:
+		r := p.receiver
+		switch {
+		case command.OnOff != nil:
+			r.isOn = command.OnOff.On
+		case command.Mute != nil:
+			r.muted = command.Mute.Mute
+		case command.SetVolume != nil:
+			r.volume = command.SetVolume.Level
+		case command.AdjustVolume != nil:
+			r.volume += command.AdjustVolume.Amount
+		case command.SetInput != nil:
+			r.currInput = command.SetInput.NewInput
+		default:
+			return false
+		}
+		return true
+	case p.thermostat.id:
+		if command.Generic == nil {
+			return false
+		}
+		switch command.Name {
+		case "action.devices.commands.ThermostatTemperatureSetpoint":
+			setpoint, ok := command.Generic.ParamFloat("thermostatTemperatureSetpoint")
+			if !ok {
+				return false
+			}
+			p.thermostat.setpoint = setpoint
+		case "action.devices.commands.ThermostatSetMode":
+			mode, ok := command.Generic.Params["thermostatMode"].(string)
+			if !ok || !action.IsValidThermostatMode(mode) {
+				return false
+			}
+			p.thermostat.mode = mode
+		default:
+			return false
+		}
+		return true
+	case p.lock.id:
+		if command.Generic == nil || command.Name != "action.devices.commands.LockUnlock" {
+			return false
+		}
+		isLocked, ok := command.Generic.Params["lock"].(bool)
+		if !ok {
+			return false
+		}
+		p.lock.isLocked = isLocked
+		p.lock.unlockedTicks = 0
+		return true
+	case p.vacuum.id:
+		if command.Generic == nil {
+			return false
+		}
+		v := p.vacuum
+		switch command.Name {
+		case "action.devices.commands.StartStop":
+			start, ok := command.Generic.Params["start"].(bool)
+			if !ok {
+				return false
+			}
+			v.isRunning = start
+			v.isPaused = false
+			if start {
+				v.isDocked = false
+				v.cycleTicks = 0
+			}
+		case "action.devices.commands.PauseUnpause":
+			pause, ok := command.Generic.Params["pause"].(bool)
+			if !ok {
+				return false
+			}
+			v.isPaused = pause
+		case "action.devices.commands.Dock":
+			v.isRunning = false
+			v.isPaused = false
+			v.isDocked = true
+		case "action.devices.commands.Locate":
+			// The virtual vacuum has no speaker to beep with; acknowledge the request so it can be exercised.
+			p.logger.Info("locating vacuum",
+				zap.String("device_id", v.id),
+			)
+		default:
+			return false
+		}
+		return true
+	case p.washer.id:
+		if command.Generic == nil {
+			return false
+		}
+		w := p.washer
+		switch command.Name {
+		case "action.devices.commands.StartStop":
+			start, ok := command.Generic.Params["start"].(bool)
+			if !ok {
+				return false
+			}
+			w.isRunning = start
+			w.isPaused = false
+			w.cycleTicks = 0
+		case "action.devices.commands.PauseUnpause":
+			pause, ok := command.Generic.Params["pause"].(bool)
+			if !ok {
+				return false
+			}
+			w.isPaused = pause
+		case "action.devices.commands.SetModes":
+			settings, _ := command.Generic.Params["updateModeSettings"].(map[string]interface{})
+			load, ok := settings["load"].(string)
+			if !ok || (load != "small" && load != "large") {
+				return false
+			}
+			w.load = load
+		case "action.devices.commands.SetToggles":
+			settings, _ := command.Generic.Params["updateToggleSettings"].(map[string]interface{})
+			extraRinse, ok := settings["extraRinse"].(bool)
+			if !ok {
+				return false
+			}
+			w.extraRinse = extraRinse
+		default:
+			return false
+		}
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package demoprovider
+
+import (
+	"context"
+	"testing"
+
+	action "github.com/rmrobinson/google-smart-home-action-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestProviderSync(t *testing.T) {
+	p := New(zaptest.NewLogger(t))
+
+	resp, err := p.Sync(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Devices, 7)
+	for _, d := range resp.Devices {
+		assert.True(t, d.WillReportState)
+	}
+}
+
+func TestProviderExecuteQuery(t *testing.T) {
+	p := New(zaptest.NewLogger(t))
+
+	resp, err := p.Execute(context.Background(), &action.ExecuteRequest{
+		Commands: []action.CommandArg{
+			{
+				TargetDevices: []action.DeviceArg{{ID: "l-1"}},
+				Commands: []action.Command{
+					{
+						Name: "action.devices.commands.LockUnlock",
+						Generic: &action.CommandGeneric{
+							Command: "action.devices.commands.LockUnlock",
+							Params: map[string]interface{}{
+								"lock": false,
+							},
+						},
+						Challenge: &action.CommandChallenge{
+							Pin: LockPin,
+						},
+					},
+				},
+			},
+			{
+				TargetDevices: []action.DeviceArg{{ID: "123"}, {ID: "unknown"}},
+				Commands: []action.Command{
+					{
+						Name:  "action.devices.commands.OnOff",
+						OnOff: &action.CommandOnOff{On: true},
+					},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"l-1", "123"}, resp.UpdatedDevices)
+
+	queryResp, err := p.Query(context.Background(), &action.QueryRequest{
+		Devices: []action.DeviceArg{{ID: "l-1"}, {ID: "123"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, false, queryResp.States["l-1"].State["isLocked"])
+	assert.Equal(t, true, queryResp.States["123"].State["on"])
+}
+
+func TestProviderTick(t *testing.T) {
+	p := New(zaptest.NewLogger(t))
+	p.lock.isLocked = false
+	p.vacuum.isRunning = true
+	p.vacuum.isDocked = false
+
+	var relocked, docked bool
+	for i := 0; i < vacuumCycleTicks; i++ {
+		states, _ := p.tick()
+		if _, found := states[p.lock.id]; found {
+			relocked = true
+		}
+		if _, found := states[p.vacuum.id]; found {
+			docked = true
+		}
+	}
+
+	assert.True(t, relocked)
+	assert.True(t, docked)
+	assert.True(t, p.lock.isLocked)
+	assert.True(t, p.vacuum.isDocked)
+	assert.Equal(t, 21.0, p.thermostat.ambient)
+}
+
+func TestProviderExecuteLockChallenge(t *testing.T) {
+	p := New(zaptest.NewLogger(t))
+
+	unlock := func(challenge *action.CommandChallenge) *action.ExecuteResponse {
+		resp, err := p.Execute(context.Background(), &action.ExecuteRequest{
+			Commands: []action.CommandArg{
+				{
+					TargetDevices: []action.DeviceArg{{ID: "l-1"}},
+					Commands: []action.Command{
+						{
+							Name: "action.devices.commands.LockUnlock",
+							Generic: &action.CommandGeneric{
+								Command: "action.devices.commands.LockUnlock",
+								Params: map[string]interface{}{
+									"lock": false,
+								},
+							},
+							Challenge: challenge,
+						},
+					},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		return resp
+	}
+
+	assert.Equal(t, []string{"l-1"}, unlock(nil).ChallengeNeeded[action.ChallengePinNeeded])
+	assert.Equal(t, []string{"l-1"}, unlock(&action.CommandChallenge{Pin: "0000"}).ChallengeNeeded[action.ChallengeFailedPinNeeded])
+	assert.True(t, p.lock.isLocked)
+
+	assert.Equal(t, []string{"l-1"}, unlock(&action.CommandChallenge{Pin: LockPin}).UpdatedDevices)
+	assert.False(t, p.lock.isLocked)
+}
+
+type testReporter struct {
+	reports       []map[string]action.DeviceState
+	notifications []string
+}
+
+func (tr *testReporter) ReportState(_ context.Context, _ string, deviceStates map[string]action.DeviceState) error {
+	tr.reports = append(tr.reports, deviceStates)
+	return nil
+}
+
+func (tr *testReporter) NotifyChargingState(_ context.Context, _ string, deviceID string, _ action.DeviceState) error {
+	tr.notifications = append(tr.notifications, deviceID)
+	return nil
+}
+
+func TestProviderStep(t *testing.T) {
+	p := New(zaptest.NewLogger(t))
+	p.lock.isLocked = false
+	p.lock.unlockedTicks = lockRelockTicks - 1
+	p.vacuum.batteryPerc = 90
+	p.thermostat.ambient = p.thermostat.setpoint
+
+	reporter := &testReporter{}
+	p.step(context.Background(), reporter, "agent-id")
+
+	// Both changes are reported in a single batch.
+	assert.Len(t, reporter.reports, 1)
+	assert.Contains(t, reporter.reports[0], "l-1")
+	assert.Contains(t, reporter.reports[0], "v-1")
+	assert.Equal(t, []string{"v-1"}, reporter.notifications)
+}
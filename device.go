@@ -2,7 +2,19 @@ package action
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
+)
+
+var (
+	// ErrInputNotFound is returned if the requested input is not one of the available inputs of the device.
+	ErrInputNotFound = errors.New("input not found")
+	// ErrInvalidColorSetting is returned if the supplied ColorSettingOptions are not valid.
+	ErrInvalidColorSetting = errors.New("invalid color setting")
+	// ErrInvalidOtherDeviceID is returned if the supplied OtherDeviceID is not valid.
+	ErrInvalidOtherDeviceID = errors.New("invalid other device id")
 )
 
 // DeviceName contains different ways of identifying the device
@@ -33,13 +45,22 @@ type OtherDeviceID struct {
 	DeviceID string
 }
 
+// Validate checks that the identifier can be used by Google; the device ID is required, the agent ID is optional.
+// ErrInvalidOtherDeviceID is returned if it can not.
+func (o OtherDeviceID) Validate() error {
+	if len(strings.TrimSpace(o.DeviceID)) < 1 {
+		return fmt.Errorf("%w: device id is required", ErrInvalidOtherDeviceID)
+	}
+	return nil
+}
+
 // Device represents a single provider-supplied device profile.
 type Device struct {
 	// ID of the device
 	ID string
 
 	// Type of the device.
-	// See https://developers.google.com/assistant/smarthome/guides is a list of possible types
+	// See https://developers.google.com/assistant/smarthome/guides is a list of possible types, defined as the DeviceType constants
 	Type string
 
 	// Traits of the device.
@@ -53,6 +74,9 @@ type Device struct {
 	// WillReportState using the ReportState API (should be true)
 	WillReportState bool
 
+	// NotificationSupportedByAgent indicates notifications (i.e. doorbell presses) will be sent for this device.
+	NotificationSupportedByAgent bool
+
 	// RoomHint guides Google as to which room this device is in
 	RoomHint string
 
@@ -67,6 +91,14 @@ type Device struct {
 
 	// CustomData specified which will be included unmodified in subsequent requests.
 	CustomData map[string]interface{}
+
+	// Challenges contains the secondary user verification required before each command can be executed, indexed by command name.
+	// This is not sent to Google; it is used by CheckChallenge and, if execute validation is enabled, to request acknowledgement.
+	Challenges map[string]string
+
+	// NativeTemperatureUnit is the unit the provider works with temperatures in, if it differs from the display unit.
+	// This is not sent to Google; it is used by NewDeviceState and the temperature conversion helpers.
+	NativeTemperatureUnit string
 }
 
 // NewDevice creates a new device ready for setting things in.
@@ -80,6 +112,27 @@ func NewDevice(id string, typ string) *Device {
 	}
 }
 
+// Validate checks that the attributes added to the device are supported by Google.
+// The Service calls this for each device returned by the provider, failing the SYNC if any are not valid.
+func (d *Device) Validate() error {
+	if d.Traits[TraitTemperatureSetting] {
+		if err := ValidateThermostatModes(thermostatModes(d)); err != nil {
+			return fmt.Errorf("device %s: %w", d.ID, err)
+		}
+	}
+	for _, otherDeviceID := range d.OtherDeviceIDs {
+		if err := otherDeviceID.Validate(); err != nil {
+			return fmt.Errorf("device %s: %w", d.ID, err)
+		}
+	}
+	if d.Traits[TraitColorSetting] {
+		if err := d.colorSettingOptions().Validate(); err != nil {
+			return fmt.Errorf("device %s: %w", d.ID, err)
+		}
+	}
+	return nil
+}
+
 // DeviceInputName represents the human-readable name shown for an input
 type DeviceInputName struct {
 	LanguageCode string   `json:"lang"`
@@ -94,7 +147,7 @@ type DeviceInput struct {
 
 // NewSimpleAVReceiver creates a new device with the attributes for a simple AV receiver setup.
 func NewSimpleAVReceiver(id string, inputs []DeviceInput, maxLevel int, canMute bool, onlyCommand bool) *Device {
-	d := NewDevice(id, "action.devices.types.AUDIO_VIDEO_RECEIVER")
+	d := NewDevice(id, DeviceTypeAudioVideoReceiver)
 	d.AddOnOffTrait(false, false)
 	d.AddInputSelectorTrait(inputs, canMute)
 	d.AddVolumeTrait(maxLevel, canMute, onlyCommand)
@@ -104,14 +157,14 @@ func NewSimpleAVReceiver(id string, inputs []DeviceInput, maxLevel int, canMute
 // NewLight creates a new device with the attributes for an on-off light.
 // This can be customized with any of the light-related traits (Color, Brightness).
 func NewLight(id string) *Device {
-	d := NewDevice(id, "action.devices.types.LIGHT")
+	d := NewDevice(id, DeviceTypeLight)
 	d.AddOnOffTrait(false, false)
 	return d
 }
 
 // NewOutlet creates a new device with the attributes for an on-off outlet.
 func NewOutlet(id string) *Device {
-	d := NewDevice(id, "action.devices.types.OUTLET")
+	d := NewDevice(id, DeviceTypeOutlet)
 	d.AddOnOffTrait(false, false)
 	return d
 }
@@ -119,16 +172,420 @@ func NewOutlet(id string) *Device {
 // NewSwitch creates a new device with the attributes for an on-off switch.
 // This can be customized with the Brightness trait.
 func NewSwitch(id string) *Device {
-	d := NewDevice(id, "action.devices.types.SWITCH")
+	d := NewDevice(id, DeviceTypeSwitch)
 	d.AddOnOffTrait(false, false)
 	return d
 }
 
+// NewScene creates a new device representing a scene, which Google can activate (and deactivate, if reversible).
+// See https://developers.google.com/assistant/smarthome/guides/scene
+func NewScene(id string, name string, reversible bool) *Device {
+	d := NewDevice(id, DeviceTypeScene)
+	d.Name.Name = name
+	d.AddSceneTrait(reversible)
+	return d
+}
+
+// NewSensor creates a new device with the attributes for a read-only sensor reporting the supplied sensor states.
+// See https://developers.google.com/assistant/smarthome/guides/sensor
+func NewSensor(id string, states []DeviceSensorState) *Device {
+	d := NewDevice(id, DeviceTypeSensor)
+	d.AddSensorStateTrait(states)
+	return d
+}
+
+// NewContactSensor creates a new device with the attributes for a read-only open/close sensor (i.e. a door or window contact).
+// The state should be reported using RecordOpenPercent, with 0 being closed and 100 being open.
+func NewContactSensor(id string) *Device {
+	d := NewDevice(id, DeviceTypeSensor)
+	d.AddOpenCloseTrait(true, false, true)
+	return d
+}
+
+// NewMotionSensor creates a new device with the attributes for a read-only motion sensor of the specified type.
+// The state should be reported using RecordOccupancy.
+func NewMotionSensor(id string, sensorType string) *Device {
+	d := NewDevice(id, DeviceTypeSensor)
+	d.AddOccupancySensingTrait([]DeviceOccupancySensorConfig{
+		{
+			SensorType: sensorType,
+		},
+	})
+	return d
+}
+
+// NewSmokeDetector creates a new device with the attributes for a read-only smoke detector.
+// The state should be reported using RecordSensorState with SensorStateSmokeLevel.
+func NewSmokeDetector(id string) *Device {
+	d := NewDevice(id, DeviceTypeSmokeDetector)
+	d.AddSensorStateTrait([]DeviceSensorState{
+		{
+			Name: SensorStateSmokeLevel,
+			DescriptiveCapabilities: &DeviceSensorDescriptiveCapabilities{
+				AvailableStates: []string{"smoke detected", "high", "no smoke detected"},
+			},
+		},
+	})
+	return d
+}
+
+// NewThermostat creates a new device with the attributes for a thermostat supporting the specified modes.
+// The unit controls how temperatures are displayed to the user; temperatures are always exchanged with Google in Celsius.
+// rangeC is the minimum and maximum setpoint supported by the thermostat in Celsius; leave as zero if there are no limits.
+// The modes should be ThermostatMode values; Validate returns ErrInvalidThermostatMode if any are not.
+// The state should be reported using RecordThermostat.
+func NewThermostat(id string, modes []string, unit string, rangeC [2]float64) *Device {
+	d := NewDevice(id, DeviceTypeThermostat)
+	d.AddTemperatureSettingTrait(modes, unit, rangeC, false, false)
+	return d
+}
+
+// NewGarageDoor creates a new device with the attributes for a garage door which can only be fully opened or closed.
+// As opening a garage door has security implications the user is asked to acknowledge each OpenClose command.
+// See https://developers.google.com/assistant/smarthome/guides/garage
+func NewGarageDoor(id string) *Device {
+	d := NewDevice(id, DeviceTypeGarage)
+	d.AddOpenCloseTrait(true, false, false)
+	d.RequireChallenge("action.devices.commands.OpenClose", ChallengeAckNeeded)
+	return d
+}
+
+// NewBlinds creates a new device with the attributes for blinds which can be partially opened in the specified directions.
+// If no directions are supplied the blinds are assumed to open in a single direction.
+// See https://developers.google.com/assistant/smarthome/guides/blinds
+func NewBlinds(id string, directions ...string) *Device {
+	d := NewDevice(id, DeviceTypeBlinds)
+	d.AddOpenCloseTrait(false, false, false)
+	d.SetOpenDirections(directions...)
+	return d
+}
+
+// NewKettle creates a new device with the attributes for a kettle which can heat water to a temperature within rangeC (in Celsius).
+// The unit controls how temperatures are displayed to the user; temperatures are always exchanged with Google in Celsius.
+// The state should be reported using RecordTemperatureControl.
+// See https://developers.google.com/assistant/smarthome/guides/kettle
+func NewKettle(id string, unit string, rangeC [2]float64) *Device {
+	d := NewDevice(id, DeviceTypeKettle)
+	d.AddOnOffTrait(false, false)
+	d.AddTemperatureControlTrait(rangeC, 0, unit, false, false)
+	return d
+}
+
+// NewCoffeeMaker creates a new device with the attributes for a coffee maker which can heat to a temperature within rangeC (in Celsius)
+// and cook using the supplied modes (i.e. CookingModeBrew). If no modes are supplied the coffee maker is assumed to only brew.
+// The unit controls how temperatures are displayed to the user; temperatures are always exchanged with Google in Celsius.
+// The state should be reported using RecordTemperatureControl and RecordCookingMode.
+// See https://developers.google.com/assistant/smarthome/guides/coffeemaker
+func NewCoffeeMaker(id string, unit string, rangeC [2]float64, cookingModes ...string) *Device {
+	if len(cookingModes) < 1 {
+		cookingModes = []string{CookingModeBrew}
+	}
+
+	d := NewDevice(id, DeviceTypeCoffeeMaker)
+	d.AddOnOffTrait(false, false)
+	d.AddTemperatureControlTrait(rangeC, 0, unit, false, false)
+	d.AddCookTrait(cookingModes)
+	return d
+}
+
+// NewLock creates a new device with the attributes for a lock.
+// The user is asked for a PIN before each LockUnlock command is executed. When handling the command in Provider.Execute,
+// call CheckChallenge with the PIN configured for the lock; if a challenge type is returned, supply it to
+// ExecuteResponse.AddChallengeNeeded instead of executing the command. Google will prompt the user for the PIN
+// and reissue the command with the PIN included. To only require the PIN when unlocking, call CheckChallenge only
+// if the "lock" parameter of the command is false.
+// The state should be reported using RecordLockState.
+// See https://developers.google.com/assistant/smarthome/guides/lock
+func NewLock(id string) *Device {
+	d := NewDevice(id, DeviceTypeLock)
+	d.AddLockUnlockTrait()
+	d.RequireChallenge("action.devices.commands.LockUnlock", ChallengePinNeeded)
+	return d
+}
+
+// NewValve creates a new device with the attributes for a valve (i.e. a water or gas shutoff).
+// If the valve can only be fully opened or closed, set discreteOnly to true.
+// See https://developers.google.com/assistant/smarthome/guides/valve
+func NewValve(id string, discreteOnly bool) *Device {
+	d := NewDevice(id, DeviceTypeValve)
+	d.AddOpenCloseTrait(discreteOnly, false, false)
+	return d
+}
+
+// NewVacuum creates a new device with the attributes for a robot vacuum which can be started, stopped, docked and located.
+// If the vacuum can clean specific areas, supply them as zones. If cleaning can be paused, set pausable to true.
+// Vacuums with a battery should also call AddEnergyStorageTrait.
+// See https://developers.google.com/assistant/smarthome/guides/vacuum
+func NewVacuum(id string, zones []string, pausable bool) *Device {
+	d := NewDevice(id, DeviceTypeVacuum)
+	d.AddStartStopTrait(pausable, zones)
+	d.AddDockTrait()
+	d.AddLocatorTrait()
+	return d
+}
+
+// NewFan creates a new device with the attributes for a fan which can be set to the supplied speeds, in order from slowest to fastest.
+// If no speeds are supplied the fan is assumed to support being set to a percentage of its maximum speed.
+// If the direction of the fan can be reversed, set reversible to true.
+// See https://developers.google.com/assistant/smarthome/guides/fan
+func NewFan(id string, speeds []FanSpeedSetting, reversible bool) *Device {
+	d := NewDevice(id, DeviceTypeFan)
+	d.AddOnOffTrait(false, false)
+	d.AddFanSpeedTrait(speeds, true, reversible, false)
+	return d
+}
+
+// NewAirPurifier creates a new device with the attributes for an air purifier which can be set to the supplied speeds,
+// in order from slowest to fastest, and which reports the PM2.5 level of the air using the SensorStatePM25 sensor.
+// See https://developers.google.com/assistant/smarthome/guides/airpurifier
+func NewAirPurifier(id string, speeds []FanSpeedSetting) *Device {
+	d := NewDevice(id, DeviceTypeAirPurifier)
+	d.AddOnOffTrait(false, false)
+	d.AddFanSpeedTrait(speeds, true, false, false)
+	d.AddSensorStateTrait([]DeviceSensorState{
+		{
+			Name: SensorStatePM25,
+			DescriptiveCapabilities: &DeviceSensorDescriptiveCapabilities{
+				AvailableStates: []string{"healthy", "moderate", "unhealthy for sensitive groups", "unhealthy", "very unhealthy", "hazardous", "unknown"},
+			},
+			NumericCapabilities: &DeviceSensorNumericCapabilities{
+				RawValueUnit: "MICROGRAMS_PER_CUBIC_METER",
+			},
+		},
+	})
+	return d
+}
+
+// NewHumidifier creates a new device with the attributes for a humidifier whose setpoint can be set within rangePercent.
+// The FanSpeed trait is only added if speeds are supplied, in order from slowest to fastest.
+// The state should be reported using RecordHumidity.
+// See https://developers.google.com/assistant/smarthome/guides/humidifier
+func NewHumidifier(id string, rangePercent [2]int, speeds []FanSpeedSetting) *Device {
+	return newHumidityDevice(id, DeviceTypeHumidifier, rangePercent, speeds)
+}
+
+// NewDehumidifier creates a new device with the attributes for a dehumidifier whose setpoint can be set within rangePercent.
+// The FanSpeed trait is only added if speeds are supplied, in order from slowest to fastest.
+// The state should be reported using RecordHumidity.
+// See https://developers.google.com/assistant/smarthome/guides/dehumidifier
+func NewDehumidifier(id string, rangePercent [2]int, speeds []FanSpeedSetting) *Device {
+	return newHumidityDevice(id, DeviceTypeDehumidifier, rangePercent, speeds)
+}
+
+// newHumidityDevice creates a new device of the specified type which can be turned on and off and have its humidity setpoint controlled.
+func newHumidityDevice(id string, typ string, rangePercent [2]int, speeds []FanSpeedSetting) *Device {
+	d := NewDevice(id, typ)
+	d.AddOnOffTrait(false, false)
+	d.AddHumiditySettingTrait(rangePercent, false, false)
+	if len(speeds) > 0 {
+		d.AddFanSpeedTrait(speeds, true, false, false)
+	}
+	return d
+}
+
+// NewSprinkler creates a new device with the attributes for an irrigation controller which can water the supplied zones.
+// Watering can be started for a limited duration of up to maxTimerSec seconds; set it to 0 if timed watering is not supported.
+// See https://developers.google.com/assistant/smarthome/guides/sprinkler
+func NewSprinkler(id string, zones []string, maxTimerSec int) *Device {
+	d := NewDevice(id, DeviceTypeSprinkler)
+	d.AddStartStopTrait(false, zones)
+	if maxTimerSec > 0 {
+		d.AddTimerTrait(maxTimerSec, false)
+	}
+	return d
+}
+
+// NewWasher creates a new device with the attributes for a washing machine.
+// The available modes (i.e. load size, temperature) and toggles (i.e. extra rinse) are only added if supplied;
+// they can be built using NewMode and NewToggle.
+// See https://developers.google.com/assistant/smarthome/guides/washer
+func NewWasher(id string, modes []DeviceMode, toggles []DeviceToggle) *Device {
+	return newAppliance(id, DeviceTypeWasher, modes, toggles)
+}
+
+// NewDoorbell creates a new device with the attributes for a video doorbell which streams using the supplied protocols.
+// If no protocols are supplied the stream is assumed to use HLS.
+// Presses should be reported to Google using Service.NotifyDoorbellPress.
+// See https://developers.google.com/assistant/smarthome/guides/doorbell
+func NewDoorbell(id string, streamProtocols ...string) *Device {
+	if len(streamProtocols) < 1 {
+		streamProtocols = []string{CameraStreamProtocolHLS}
+	}
+
+	d := NewDevice(id, DeviceTypeDoorbell)
+	d.NotificationSupportedByAgent = true
+	d.AddObjectDetectionTrait()
+	d.AddCameraStreamTrait(streamProtocols, false)
+	return d
+}
+
+// NewDryer creates a new device with the attributes for a clothes dryer.
+// The available modes (i.e. dryness level) and toggles (i.e. wrinkle guard) are only added if supplied;
+// they can be built using NewMode and NewToggle.
+// See https://developers.google.com/assistant/smarthome/guides/dryer
+func NewDryer(id string, modes []DeviceMode, toggles []DeviceToggle) *Device {
+	return newAppliance(id, DeviceTypeDryer, modes, toggles)
+}
+
+// NewChargerEV creates a new device with the attributes for an electric vehicle charger.
+// distanceUnit is one of the EnergyStorageUnit distance constants, and controls how the range of the vehicle is presented to the user.
+// Charging can be started and stopped, and the charge level reported using RecordBattery and RecordCapacityRemaining.
+// Changes in charging state can be sent to the user using Service.NotifyChargingState.
+// See https://developers.google.com/assistant/smarthome/guides/charger
+func NewChargerEV(id string, distanceUnit string) *Device {
+	d := NewDevice(id, DeviceTypeCharger)
+	d.NotificationSupportedByAgent = true
+	d.AddEnergyStorageTrait(true, false)
+	d.SetEnergyStorageDistanceUnit(distanceUnit)
+	d.AddStartStopTrait(false, nil)
+	return d
+}
+
+// NewDishwasher creates a new device with the attributes for a dishwasher.
+// The available modes (i.e. wash cycle) and toggles (i.e. sanitize) are only added if supplied;
+// they can be built using NewMode and NewToggle.
+// See https://developers.google.com/assistant/smarthome/guides/dishwasher
+func NewDishwasher(id string, modes []DeviceMode, toggles []DeviceToggle) *Device {
+	return newAppliance(id, DeviceTypeDishwasher, modes, toggles)
+}
+
+// newAppliance creates a new device of the specified type which can be started, paused and report its run cycle.
+func newAppliance(id string, typ string, modes []DeviceMode, toggles []DeviceToggle) *Device {
+	d := NewDevice(id, typ)
+	d.AddStartStopTrait(true, nil)
+	d.AddRunCycleTrait()
+	if len(modes) > 0 {
+		d.AddModesTrait(modes, false, false)
+	}
+	if len(toggles) > 0 {
+		d.AddTogglesTrait(toggles, false, false)
+	}
+	return d
+}
+
+// NewTV creates a new device with the attributes for a TV.
+// The TV can be turned on and off, have its volume and playback controlled, and report what it is playing.
+// The InputSelector, AppSelector and Channel traits are only added if the respective inputs, apps or channels are supplied.
+// See https://developers.google.com/assistant/smarthome/guides/tv
+func NewTV(id string, inputs []DeviceInput, apps []DeviceApp, channels []DeviceChannel) *Device {
+	d := NewDevice(id, DeviceTypeTV)
+	d.AddOnOffTrait(false, false)
+	d.AddVolumeTrait(100, true, false)
+	d.AddMediaStateTrait(true, true)
+	d.AddTransportControlTrait(
+		TransportControlNext,
+		TransportControlPause,
+		TransportControlPrevious,
+		TransportControlResume,
+		TransportControlStop,
+	)
+	if len(inputs) > 0 {
+		d.AddInputSelectorTrait(inputs, false)
+	}
+	if len(apps) > 0 {
+		d.AddAppSelectorTrait(apps)
+	}
+	if len(channels) > 0 {
+		d.AddChannelTrait(channels, false)
+	}
+	return d
+}
+
+// NewRemoteControl creates a new device with the attributes for a remote control (i.e. an IR blaster) which can turn the
+// devices it controls on and off, change their volume, channel and playback, and (if inputs are supplied) select their input.
+// As remote controls generally can't observe the devices they control, all of the traits are command only.
+// See https://developers.google.com/assistant/smarthome/guides/remotecontrol
+func NewRemoteControl(id string, inputs []DeviceInput, channels []DeviceChannel) *Device {
+	d := NewDevice(id, DeviceTypeRemoteControl)
+	d.AddOnOffTrait(true, false)
+	d.AddVolumeTrait(100, true, true)
+	d.AddChannelTrait(channels, true)
+	d.AddTransportControlTrait(
+		TransportControlNext,
+		TransportControlPause,
+		TransportControlPrevious,
+		TransportControlResume,
+		TransportControlStop,
+	)
+	if len(inputs) > 0 {
+		d.AddInputSelectorTrait(inputs, false)
+		d.SetInputSelectorCommandOnly(true)
+	}
+	return d
+}
+
+// NewSetTopBox creates a new device with the attributes for a set-top box (i.e. a cable box).
+// The set-top box can be turned on and off, have its volume, channel and playback controlled, and report what it is playing.
+// The InputSelector trait is only added if inputs are supplied.
+// See https://developers.google.com/assistant/smarthome/guides/settop
+func NewSetTopBox(id string, inputs []DeviceInput, channels []DeviceChannel) *Device {
+	d := NewDevice(id, DeviceTypeSetTop)
+	d.AddOnOffTrait(false, false)
+	d.AddVolumeTrait(100, true, false)
+	d.AddChannelTrait(channels, false)
+	d.AddMediaStateTrait(true, true)
+	d.AddTransportControlTrait(
+		TransportControlNext,
+		TransportControlPause,
+		TransportControlPrevious,
+		TransportControlResume,
+		TransportControlStop,
+	)
+	if len(inputs) > 0 {
+		d.AddInputSelectorTrait(inputs, false)
+	}
+	return d
+}
+
+// AddOtherDeviceID records an alternative identifier of this device, such as the ID used for local fulfillment.
+// agentID may be empty if the identifier belongs to this agent. Identifiers which are already present are not added again.
+// Validate returns ErrInvalidOtherDeviceID if the identifier is not valid.
+// See https://developers.google.com/assistant/smarthome/develop/local#update_sync_response_in_the_cloud_fulfillment
+func (d *Device) AddOtherDeviceID(agentID string, deviceID string) *Device {
+	otherDeviceID := OtherDeviceID{
+		AgentID:  agentID,
+		DeviceID: deviceID,
+	}
+	for _, existing := range d.OtherDeviceIDs {
+		if existing == otherDeviceID {
+			return d
+		}
+	}
+	d.OtherDeviceIDs = append(d.OtherDeviceIDs, otherDeviceID)
+
+	return d
+}
+
+// RequireChallenge records that the user must complete the specified secondary verification before the named command is executed.
+// See https://developers.google.com/assistant/smarthome/develop/secondary-user-verification
+func (d *Device) RequireChallenge(commandName string, challengeType string) *Device {
+	if d.Challenges == nil {
+		d.Challenges = map[string]string{}
+	}
+	d.Challenges[commandName] = challengeType
+
+	return d
+}
+
+// CheckChallenge checks whether the secondary verification required by the device for the supplied command has been completed.
+// expectedPin is only used if the command requires a PIN.
+// The required challenge type is returned if the verification is incomplete, or an empty string if the command can be executed.
+// The result can be supplied directly to ExecuteResponse.AddChallengeNeeded.
+func (d *Device) CheckChallenge(c Command, expectedPin string) string {
+	switch d.Challenges[c.Name] {
+	case ChallengeAckNeeded:
+		return c.CheckAck()
+	case ChallengePinNeeded:
+		return c.CheckPin(expectedPin)
+	}
+	return ""
+}
+
 // AddBrightnessTrait indicates this device is capable of having its brightness controlled.
 // If the device does not support querying, set onlyCommand to true (i.e. a write-only switch).
 // See https://developers.google.com/assistant/smarthome/traits/brightness
 func (d *Device) AddBrightnessTrait(onlyCommand bool) *Device {
-	d.Traits["action.devices.traits.Brightness"] = true
+	d.Traits[TraitBrightness] = true
 	if onlyCommand {
 		d.Attributes["commandOnlyBrightness"] = true
 	}
@@ -142,57 +599,460 @@ const (
 	HSV = "hsv"
 )
 
+// ColorSettingOptions describes the ways the color of a device with the ColorSetting trait can be controlled.
+// A device may support one of the RGB and HSV color models, color temperature, or one color model alongside color temperature.
+type ColorSettingOptions struct {
+	// ColorModel is the full spectrum color model supported by the device; either RGB or HSV.
+	// Leave empty if the device only supports color temperature.
+	ColorModel string
+	// TemperatureMinK and TemperatureMaxK define the range of color temperatures (in Kelvin) supported by the device.
+	// Leave both as 0 if the device does not support color temperature.
+	TemperatureMinK int
+	TemperatureMaxK int
+	// CommandOnly should be set to true if the device does not support querying (i.e. a write-only lightbulb).
+	CommandOnly bool
+}
+
+// Validate checks that the options describe a coherent set of color capabilities.
+// ErrInvalidColorSetting is returned if they do not.
+func (o ColorSettingOptions) Validate() error {
+	switch o.ColorModel {
+	case "", RGB, HSV:
+	default:
+		return fmt.Errorf("%w: color model must be one of %s or %s, not %q", ErrInvalidColorSetting, RGB, HSV, o.ColorModel)
+	}
+
+	hasTemperature := o.TemperatureMinK != 0 || o.TemperatureMaxK != 0
+	if hasTemperature && (o.TemperatureMinK <= 0 || o.TemperatureMinK > o.TemperatureMaxK) {
+		return fmt.Errorf("%w: invalid color temperature range %d-%d", ErrInvalidColorSetting, o.TemperatureMinK, o.TemperatureMaxK)
+	}
+	if len(o.ColorModel) < 1 && !hasTemperature {
+		return fmt.Errorf("%w: a color model or color temperature range is required", ErrInvalidColorSetting)
+	}
+	return nil
+}
+
+// AddColorSettingTrait indicates this device is capable of having its color controlled as described by opts.
+// Any color settings previously added to the device are replaced.
+// Validate returns ErrInvalidColorSetting if the options are not valid.
+// See https://developers.google.com/assistant/smarthome/traits/colorsetting
+func (d *Device) AddColorSettingTrait(opts ColorSettingOptions) *Device {
+	delete(d.Attributes, "colorModel")
+	delete(d.Attributes, "colorTemperatureRange")
+	d.addColorSetting(opts)
+
+	return d
+}
+
+// colorSettingOptions returns the color settings currently declared on the device.
+func (d *Device) colorSettingOptions() ColorSettingOptions {
+	opts := ColorSettingOptions{}
+	opts.ColorModel, _ = d.Attributes["colorModel"].(string)
+	switch tempRange := d.Attributes["colorTemperatureRange"].(type) {
+	case map[string]int:
+		opts.TemperatureMinK = tempRange["temperatureMinK"]
+		opts.TemperatureMaxK = tempRange["temperatureMaxK"]
+	case map[string]interface{}:
+		opts.TemperatureMinK, _ = NumberInt(tempRange["temperatureMinK"])
+		opts.TemperatureMaxK, _ = NumberInt(tempRange["temperatureMaxK"])
+	}
+	opts.CommandOnly, _ = d.Attributes["commandOnlyColorSetting"].(bool)
+	return opts
+}
+
 // AddColourTrait indicates this device is capable of having its colour display controlled using the specified color model.
 // It is mutually exclusive to support RGB or HSV.
-// It is possible to support either one of RGB and HSV alongside color temperature. See AddColorTemperatureSetting
+// It is possible to support either one of RGB and HSV alongside color temperature. See AddColourTemperatureTrait
 // If the device does not support querying, set onlyCommand to true (i.e. a write-only lightbulb).
-// See https://developers.google.com/assistant/smarthome/traits/colorsetting
+// The device is command only if either this or AddColourTemperatureTrait requested it, regardless of call order.
+//
+// Deprecated: use AddColorSettingTrait, which describes the combined settings in one call.
 func (d *Device) AddColourTrait(model string, onlyCommand bool) *Device {
-	d.Traits["action.devices.traits.ColorSetting"] = true
-	if onlyCommand {
-		d.Attributes["commandOnlyColorSetting"] = true
-	}
-	d.Attributes["colorModel"] = model
+	opts := d.colorSettingOptions()
+	opts.ColorModel = model
+	opts.CommandOnly = opts.CommandOnly || onlyCommand
+	d.addColorSetting(opts)
 
 	return d
 }
 
 // AddColourTemperatureTrait indicates this device is capable of having its colour display controlled using the colour temperature model.
-// This can be set alongside AddColorSetting to indicate both color temperature and another algorithm are supported.
+// This can be set alongside AddColourTrait to indicate both color temperature and another algorithm are supported.
 // If the device does not support querying, set onlyCommand to true (i.e. a write-only lightbulb).
-// See https://developers.google.com/assistant/smarthome/traits/colorsetting
+// The device is command only if either this or AddColourTrait requested it, regardless of call order.
+//
+// Deprecated: use AddColorSettingTrait, which describes the combined settings in one call.
 func (d *Device) AddColourTemperatureTrait(minTempK int, maxTempK int, onlyCommand bool) *Device {
-	d.Traits["action.devices.traits.ColorSetting"] = true
+	opts := d.colorSettingOptions()
+	opts.TemperatureMinK = minTempK
+	opts.TemperatureMaxK = maxTempK
+	opts.CommandOnly = opts.CommandOnly || onlyCommand
+	d.addColorSetting(opts)
+
+	return d
+}
+
+// addColorSetting applies the options to the device without validating them.
+func (d *Device) addColorSetting(opts ColorSettingOptions) {
+	d.Traits[TraitColorSetting] = true
+	if len(opts.ColorModel) > 0 {
+		d.Attributes["colorModel"] = opts.ColorModel
+	}
+	if opts.TemperatureMinK != 0 || opts.TemperatureMaxK != 0 {
+		d.Attributes["colorTemperatureRange"] = map[string]int{
+			"temperatureMinK": opts.TemperatureMinK,
+			"temperatureMaxK": opts.TemperatureMaxK,
+		}
+	}
+	d.Attributes["commandOnlyColorSetting"] = opts.CommandOnly
+}
+
+// CameraStreamProtocol defines the media formats a device with the CameraStream trait can stream in.
+const (
+	CameraStreamProtocolHLS    = "hls"
+	CameraStreamProtocolDASH   = "dash"
+	CameraStreamProtocolSmooth = "smooth_stream"
+	CameraStreamProtocolMP4    = "progressive_mp4"
+	CameraStreamProtocolWebRTC = "webrtc"
+)
+
+// AddCameraStreamTrait indicates this device is capable of streaming its camera feed using the supplied protocols.
+// If an auth token must be supplied alongside the stream URL, set needAuthToken to true.
+// See https://developers.google.com/assistant/smarthome/traits/camerastream
+func (d *Device) AddCameraStreamTrait(protocols []string, needAuthToken bool) *Device {
+	d.Traits[TraitCameraStream] = true
+	d.Attributes["cameraStreamSupportedProtocols"] = protocols
+	d.Attributes["cameraStreamNeedAuthToken"] = needAuthToken
 
+	return d
+}
+
+// AddChannelTrait indicates this device is capable of changing to the specified channels.
+// If the channels are not known, supply none; the user will still be able to change channels by number.
+// If the device cannot report its current channel, set onlyCommand to true.
+// See https://developers.google.com/assistant/smarthome/traits/channel
+func (d *Device) AddChannelTrait(availableChannels []DeviceChannel, onlyCommand bool) *Device {
+	d.Traits[TraitChannel] = true
+	if len(availableChannels) > 0 {
+		d.Attributes["availableChannels"] = availableChannels
+	}
 	if onlyCommand {
-		d.Attributes["commandOnlyColorSetting"] = true
+		d.Attributes["commandOnlyChannels"] = true
+	}
+
+	return d
+}
+
+// CookingMode defines some of the cooking modes a device with the Cook trait can support.
+// See https://developers.google.com/assistant/smarthome/traits/cook#supported-cooking-modes
+const (
+	CookingModeBake  = "BAKE"
+	CookingModeBoil  = "BOIL"
+	CookingModeBrew  = "BREW"
+	CookingModeFroth = "FROTH"
+	CookingModeGrind = "GRIND"
+	CookingModeHeat  = "HEAT"
+	CookingModeWarm  = "WARM"
+)
+
+// AddCookTrait indicates this device is capable of cooking using the supplied modes.
+// See https://developers.google.com/assistant/smarthome/traits/cook
+func (d *Device) AddCookTrait(supportedCookingModes []string) *Device {
+	d.Traits[TraitCook] = true
+	d.Attributes["supportedCookingModes"] = supportedCookingModes
+
+	return d
+}
+
+// AddDockTrait indicates this device is capable of returning to its dock.
+// See https://developers.google.com/assistant/smarthome/traits/dock
+func (d *Device) AddDockTrait() *Device {
+	d.Traits[TraitDock] = true
+
+	return d
+}
+
+// AddEnergyStorageTrait indicates this device has a battery (or other energy storage) whose capacity can be queried.
+// If the battery can be recharged, set rechargeable to true.
+// If the device cannot be commanded to charge but only queried, set onlyQuery to true.
+// See https://developers.google.com/assistant/smarthome/traits/energystorage
+func (d *Device) AddEnergyStorageTrait(rechargeable bool, onlyQuery bool) *Device {
+	d.Traits[TraitEnergyStorage] = true
+	d.Attributes["isRechargeable"] = rechargeable
+	if onlyQuery {
+		d.Attributes["queryOnlyEnergyStorage"] = true
+	}
+
+	return d
+}
+
+// AddFanSpeedTrait indicates this device is capable of having its fan speed set to the supplied speeds.
+// If the speeds are in order from slowest to fastest, set ordered to true so the user can ask for the fan to be sped up or slowed down.
+// If no speeds are supplied the fan is assumed to support being set to a percentage of its maximum speed.
+// If the direction of the fan can be reversed, set reversible to true.
+// If the device cannot report its current speed, set onlyCommand to true.
+// See https://developers.google.com/assistant/smarthome/traits/fanspeed
+func (d *Device) AddFanSpeedTrait(speeds []FanSpeedSetting, ordered bool, reversible bool, onlyCommand bool) *Device {
+	d.Traits[TraitFanSpeed] = true
+	if len(speeds) > 0 {
+		d.Attributes["availableFanSpeeds"] = map[string]interface{}{
+			"speeds":  speeds,
+			"ordered": ordered,
+		}
 	} else {
-		d.Attributes["commandOnlyColorSetting"] = false
+		d.Attributes["supportsFanSpeedPercent"] = true
 	}
-	d.Attributes["colorTemperatureRange"] = map[string]int{
-		"temperatureMinK": minTempK,
-		"temperatureMaxK": maxTempK,
+	d.Attributes["reversible"] = reversible
+	if onlyCommand {
+		d.Attributes["commandOnlyFanSpeed"] = true
 	}
 
 	return d
 }
 
+// AddHumiditySettingTrait indicates this device is capable of controlling the humidity to a setpoint within rangePercent.
+// Leave rangePercent as zero if the setpoint can be set anywhere from 0 to 100 percent.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true (i.e. a hygrometer).
+// See https://developers.google.com/assistant/smarthome/traits/humiditysetting
+func (d *Device) AddHumiditySettingTrait(rangePercent [2]int, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitHumiditySetting] = true
+	if rangePercent[0] != 0 || rangePercent[1] != 0 {
+		d.Attributes["humiditySetpointRange"] = map[string]int{
+			"minPercent": rangePercent[0],
+			"maxPercent": rangePercent[1],
+		}
+	}
+	if onlyCommand {
+		d.Attributes["commandOnlyHumiditySetting"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyHumiditySetting"] = true
+	}
+
+	return d
+}
+
+// EnergyStorageUnit defines the units the capacity of a device with the EnergyStorage trait can be reported in.
+const (
+	EnergyStorageUnitSeconds       = "SECONDS"
+	EnergyStorageUnitMiles         = "MILES"
+	EnergyStorageUnitKilometers    = "KILOMETERS"
+	EnergyStorageUnitPercentage    = "PERCENTAGE"
+	EnergyStorageUnitKilowattHours = "KILOWATT_HOURS"
+)
+
+// SetEnergyStorageDistanceUnit sets the unit (EnergyStorageUnitMiles or EnergyStorageUnitKilometers) distances are presented to the user in.
+func (d *Device) SetEnergyStorageDistanceUnit(unit string) *Device {
+	d.Attributes["energyStorageDistanceUnitForUX"] = unit
+
+	return d
+}
+
 // AddInputSelectorTrait indicates this device is capable of having its input selected.
 // See https://developers.google.com/assistant/smarthome/traits/inputselector
 func (d *Device) AddInputSelectorTrait(availableInputs []DeviceInput, ordered bool) *Device {
-	d.Traits["action.devices.traits.InputSelector"] = true
+	d.Traits[TraitInputSelector] = true
 	d.Attributes["availableInputs"] = availableInputs
 	d.Attributes["orderedInputs"] = ordered
 
 	return d
 }
 
+// SetAvailableInputs replaces the set of inputs this device exposes through the InputSelector trait.
+// This is intended to be used when the inputs of a device change after it has been supplied in a SYNC response;
+// Google will not see the new set of inputs until a subsequent SYNC occurs. See Service.UpdateAvailableInputs
+// See https://developers.google.com/assistant/smarthome/traits/inputselector
+func (d *Device) SetAvailableInputs(availableInputs []DeviceInput) *Device {
+	d.Attributes["availableInputs"] = availableInputs
+
+	return d
+}
+
+// SetInputSelectorCommandOnly indicates whether the current input of this device can be queried.
+// If the device does not support querying, set onlyCommand to true (i.e. a write-only receiver).
+// See https://developers.google.com/assistant/smarthome/traits/inputselector
+func (d *Device) SetInputSelectorCommandOnly(onlyCommand bool) *Device {
+	if onlyCommand {
+		d.Attributes["commandOnlyInputSelector"] = true
+	} else {
+		delete(d.Attributes, "commandOnlyInputSelector")
+	}
+
+	return d
+}
+
+// AvailableInputs returns the set of inputs currently defined for the InputSelector trait of this device.
+func (d *Device) AvailableInputs() []DeviceInput {
+	switch inputs := d.Attributes["availableInputs"].(type) {
+	case nil:
+		return nil
+	case []DeviceInput:
+		return inputs
+	default:
+		// The attributes were deserialized from JSON so convert them back into the typed form.
+		data, err := json.Marshal(inputs)
+		if err != nil {
+			return nil
+		}
+		var converted []DeviceInput
+		if err := json.Unmarshal(data, &converted); err != nil {
+			return nil
+		}
+		return converted
+	}
+}
+
+// NextInputKey returns the key of the input which follows currentInput in the ordered set of available inputs.
+// The last input wraps around to the first one. This is intended to be used when handling CommandNextInput.
+// ErrInputNotFound is returned if currentInput is not one of the available inputs of the device.
+func (d *Device) NextInputKey(currentInput string) (string, error) {
+	return d.relativeInputKey(currentInput, 1)
+}
+
+// PreviousInputKey returns the key of the input which precedes currentInput in the ordered set of available inputs.
+// The first input wraps around to the last one. This is intended to be used when handling CommandPreviousInput.
+// ErrInputNotFound is returned if currentInput is not one of the available inputs of the device.
+func (d *Device) PreviousInputKey(currentInput string) (string, error) {
+	return d.relativeInputKey(currentInput, -1)
+}
+
+func (d *Device) relativeInputKey(currentInput string, offset int) (string, error) {
+	inputs := d.AvailableInputs()
+	for idx, input := range inputs {
+		if input.Key != currentInput {
+			continue
+		}
+
+		idx = (idx + offset + len(inputs)) % len(inputs)
+		return inputs[idx].Key, nil
+	}
+
+	return "", ErrInputNotFound
+}
+
+// AddAppSelectorTrait indicates this device is capable of launching the specified applications.
+// See https://developers.google.com/assistant/smarthome/traits/appselector
+func (d *Device) AddAppSelectorTrait(availableApplications []DeviceApp) *Device {
+	d.Traits[TraitAppSelector] = true
+	d.Attributes["availableApplications"] = availableApplications
+
+	return d
+}
+
+// AddLocatorTrait indicates this device is capable of making itself known (i.e. by beeping) so it can be found.
+// See https://developers.google.com/assistant/smarthome/traits/locator
+func (d *Device) AddLocatorTrait() *Device {
+	d.Traits[TraitLocator] = true
+
+	return d
+}
+
+// AddLockUnlockTrait indicates this device is capable of being locked and unlocked.
+// See https://developers.google.com/assistant/smarthome/traits/lockunlock
+func (d *Device) AddLockUnlockTrait() *Device {
+	d.Traits[TraitLockUnlock] = true
+
+	return d
+}
+
+// AddMediaStateTrait indicates this device is capable of reporting what it is doing and the state of its media playback.
+// See https://developers.google.com/assistant/smarthome/traits/mediastate
+func (d *Device) AddMediaStateTrait(supportActivityState bool, supportPlaybackState bool) *Device {
+	d.Traits[TraitMediaState] = true
+	d.Attributes["supportActivityState"] = supportActivityState
+	d.Attributes["supportPlaybackState"] = supportPlaybackState
+
+	return d
+}
+
+// AddModesTrait indicates this device has one or more multi-value settings which can be changed.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true.
+// See https://developers.google.com/assistant/smarthome/traits/modes
+func (d *Device) AddModesTrait(availableModes []DeviceMode, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitModes] = true
+	d.Attributes["availableModes"] = availableModes
+	if onlyCommand {
+		d.Attributes["commandOnlyModes"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyModes"] = true
+	}
+
+	return d
+}
+
+// AddTimerTrait indicates this device is capable of running for a set duration, of at most maxTimerLimitSec seconds.
+// If the device cannot report the time remaining, set onlyCommand to true.
+// See https://developers.google.com/assistant/smarthome/traits/timer
+func (d *Device) AddTimerTrait(maxTimerLimitSec int, onlyCommand bool) *Device {
+	d.Traits[TraitTimer] = true
+	d.Attributes["maxTimerLimitSec"] = maxTimerLimitSec
+	if onlyCommand {
+		d.Attributes["commandOnlyTimer"] = true
+	}
+
+	return d
+}
+
+// AddTogglesTrait indicates this device has one or more on/off settings which can be changed.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true.
+// See https://developers.google.com/assistant/smarthome/traits/toggles
+func (d *Device) AddTogglesTrait(availableToggles []DeviceToggle, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitToggles] = true
+	d.Attributes["availableToggles"] = availableToggles
+	if onlyCommand {
+		d.Attributes["commandOnlyToggles"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyToggles"] = true
+	}
+
+	return d
+}
+
+// OccupancySensorType defines the technology used by an occupancy sensor to detect occupancy.
+const (
+	OccupancySensorTypePIR             = "PIR"
+	OccupancySensorTypeUltrasonic      = "ULTRASONIC"
+	OccupancySensorTypePhysicalContact = "PHYSICAL_CONTACT"
+)
+
+// DeviceOccupancySensorConfig describes one of the sensors a device uses to detect occupancy.
+// The delays and threshold are optional and are omitted if left as 0.
+type DeviceOccupancySensorConfig struct {
+	SensorType                         string `json:"occupancySensorType"`
+	OccupiedToUnoccupiedDelaySec       int    `json:"occupiedToUnoccupiedDelaySec,omitempty"`
+	UnoccupiedToOccupiedDelaySec       int    `json:"unoccupiedToOccupiedDelaySec,omitempty"`
+	UnoccupiedToOccupiedEventThreshold int    `json:"unoccupiedToOccupiedEventThreshold,omitempty"`
+}
+
+// AddObjectDetectionTrait indicates this device is capable of detecting objects (i.e. people at the door) and notifying Google.
+// The device must also have NotificationSupportedByAgent set.
+// See https://developers.google.com/assistant/smarthome/traits/objectdetection
+func (d *Device) AddObjectDetectionTrait() *Device {
+	d.Traits[TraitObjectDetection] = true
+
+	return d
+}
+
+// AddOccupancySensingTrait indicates this device is capable of detecting whether an area is occupied.
+// See https://developers.google.com/assistant/smarthome/traits/occupancysensing
+func (d *Device) AddOccupancySensingTrait(configs []DeviceOccupancySensorConfig) *Device {
+	d.Traits[TraitOccupancySensing] = true
+	d.Attributes["occupancySensorConfiguration"] = configs
+
+	return d
+}
+
 // AddOnOffTrait indicates this device is capable of having its state toggled on or off.
 // If the device can be commanded but not queried, set onlyCommand to true (i.e. a write-only switch).
 // If the devie cannot be commanded but only queried, set onlyQuery to true (i.e. a sensor).
 // See https://developers.google.com/assistant/smarthome/traits/onoff
 func (d *Device) AddOnOffTrait(onlyCommand, onlyQuery bool) *Device {
-	d.Traits["action.devices.traits.OnOff"] = true
+	d.Traits[TraitOnOff] = true
 	if onlyCommand {
 		d.Attributes["commandOnlyOnOff"] = true
 	}
@@ -203,10 +1063,208 @@ func (d *Device) AddOnOffTrait(onlyCommand, onlyQuery bool) *Device {
 	return d
 }
 
+// AddOpenCloseTrait indicates this device is capable of being opened and closed.
+// If the device can only be fully opened or closed (i.e. not partially open), set discreteOnly to true.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true (i.e. a contact sensor).
+// See https://developers.google.com/assistant/smarthome/traits/openclose
+func (d *Device) AddOpenCloseTrait(discreteOnly, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitOpenClose] = true
+	if discreteOnly {
+		d.Attributes["discreteOnlyOpenClose"] = true
+	}
+	if onlyCommand {
+		d.Attributes["commandOnlyOpenClose"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyOpenClose"] = true
+	}
+
+	return d
+}
+
+// OpenDirection defines the directions a device with the OpenClose trait can be opened in.
+const (
+	OpenDirectionUp    = "UP"
+	OpenDirectionDown  = "DOWN"
+	OpenDirectionLeft  = "LEFT"
+	OpenDirectionRight = "RIGHT"
+	OpenDirectionIn    = "IN"
+	OpenDirectionOut   = "OUT"
+)
+
+// SetOpenDirections sets the directions a device with the OpenClose trait can be opened in.
+// If more than one direction is set, the state should be reported using RecordOpenPercentInDirection.
+func (d *Device) SetOpenDirections(directions ...string) *Device {
+	if len(directions) < 1 {
+		delete(d.Attributes, "openDirection")
+		return d
+	}
+	d.Attributes["openDirection"] = directions
+
+	return d
+}
+
+// AddRunCycleTrait indicates this device is capable of reporting the progress of its current cycle.
+// See https://developers.google.com/assistant/smarthome/traits/runcycle
+func (d *Device) AddRunCycleTrait() *Device {
+	d.Traits[TraitRunCycle] = true
+
+	return d
+}
+
+// AddSceneTrait indicates this device is a scene which can be activated.
+// If the scene can be undone (i.e. turning off the lights it turned on), set reversible to true.
+// See https://developers.google.com/assistant/smarthome/traits/scene
+func (d *Device) AddSceneTrait(reversible bool) *Device {
+	d.Traits[TraitScene] = true
+	d.Attributes["sceneReversible"] = reversible
+
+	return d
+}
+
+// SensorState names for some of the common sensor states supported by Google.
+// See https://developers.google.com/assistant/smarthome/traits/sensorstate#supported-sensors
+const (
+	SensorStateAirQuality          = "AirQuality"
+	SensorStateCarbonMonoxideLevel = "CarbonMonoxideLevel"
+	SensorStateFilterLifeTime      = "FilterLifeTime"
+	SensorStateHumidityLevel       = "HumidityLevel"
+	SensorStatePM25                = "PM2.5"
+	SensorStateSmokeLevel          = "SmokeLevel"
+	SensorStateWaterLeak           = "WaterLeak"
+)
+
+// DeviceSensorDescriptiveCapabilities lists the descriptive states a sensor can report.
+type DeviceSensorDescriptiveCapabilities struct {
+	AvailableStates []string `json:"availableStates"`
+}
+
+// DeviceSensorNumericCapabilities describes the unit of the raw values a sensor reports.
+type DeviceSensorNumericCapabilities struct {
+	RawValueUnit string `json:"rawValueUnit"`
+}
+
+// DeviceSensorState describes one of the readings supported by a device with the SensorState trait.
+// A sensor may report descriptive states, numeric values, or both.
+type DeviceSensorState struct {
+	Name                    string                               `json:"name"`
+	DescriptiveCapabilities *DeviceSensorDescriptiveCapabilities `json:"descriptiveCapabilities,omitempty"`
+	NumericCapabilities     *DeviceSensorNumericCapabilities     `json:"numericCapabilities,omitempty"`
+}
+
+// AddSensorStateTrait indicates this device reports the supplied sensor readings.
+// See https://developers.google.com/assistant/smarthome/traits/sensorstate
+func (d *Device) AddSensorStateTrait(states []DeviceSensorState) *Device {
+	d.Traits[TraitSensorState] = true
+	d.Attributes["sensorStatesSupported"] = states
+
+	return d
+}
+
+// AddStartStopTrait indicates this device is capable of being started and stopped.
+// If the device can be paused, set pausable to true. If the device can be started in specific zones, supply them as zones.
+// See https://developers.google.com/assistant/smarthome/traits/startstop
+func (d *Device) AddStartStopTrait(pausable bool, zones []string) *Device {
+	d.Traits[TraitStartStop] = true
+	d.Attributes["pausable"] = pausable
+	if len(zones) > 0 {
+		d.Attributes["availableZones"] = zones
+	}
+
+	return d
+}
+
+// AddTemperatureControlTrait indicates this device is capable of heating or cooling to a temperature within rangeC (in Celsius).
+// If the temperature can only be set in increments, supply the increment as stepC; otherwise leave it as 0.
+// The unit controls how temperatures are displayed to the user; temperatures are always exchanged with Google in Celsius.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true (i.e. a thermometer).
+// See https://developers.google.com/assistant/smarthome/traits/temperaturecontrol
+func (d *Device) AddTemperatureControlTrait(rangeC [2]float64, stepC float64, unit string, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitTemperatureControl] = true
+	d.Attributes["temperatureRange"] = map[string]float64{
+		"minThresholdCelsius": rangeC[0],
+		"maxThresholdCelsius": rangeC[1],
+	}
+	if stepC > 0 {
+		d.Attributes["temperatureStepCelsius"] = stepC
+	}
+	d.Attributes["temperatureUnitForUX"] = unit
+	if onlyCommand {
+		d.Attributes["commandOnlyTemperatureControl"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyTemperatureControl"] = true
+	}
+
+	return d
+}
+
+// SetNativeTemperatureUnit declares the unit the provider supplies and expects temperatures in.
+// States created using the device's NewDeviceState method will convert temperatures from this unit to Celsius,
+// and TemperatureFromCelsius will convert the temperatures in commands to this unit.
+// This allows a device to be displayed in Celsius while the provider works in Fahrenheit, or vice versa.
+func (d *Device) SetNativeTemperatureUnit(unit string) *Device {
+	d.NativeTemperatureUnit = unit
+
+	return d
+}
+
+// AddTemperatureSettingTrait indicates this device is capable of controlling the temperature using the specified modes.
+// The modes should be ThermostatMode values; Validate returns ErrInvalidThermostatMode if any are not.
+// The unit controls how temperatures are displayed to the user; temperatures are always exchanged with Google in Celsius.
+// rangeC is the minimum and maximum setpoint supported by the device in Celsius; leave as zero if there are no limits.
+// If the device can be commanded but not queried, set onlyCommand to true.
+// If the device cannot be commanded but only queried, set onlyQuery to true (i.e. a thermometer).
+// See https://developers.google.com/assistant/smarthome/traits/temperaturesetting
+func (d *Device) AddTemperatureSettingTrait(modes []string, unit string, rangeC [2]float64, onlyCommand, onlyQuery bool) *Device {
+	d.Traits[TraitTemperatureSetting] = true
+	d.Attributes["availableThermostatModes"] = modes
+	d.Attributes["thermostatTemperatureUnit"] = unit
+	if rangeC[0] != 0 || rangeC[1] != 0 {
+		d.Attributes["thermostatTemperatureRange"] = map[string]float64{
+			"minThresholdCelsius": rangeC[0],
+			"maxThresholdCelsius": rangeC[1],
+		}
+	}
+	if onlyCommand {
+		d.Attributes["commandOnlyTemperatureSetting"] = true
+	}
+	if onlyQuery {
+		d.Attributes["queryOnlyTemperatureSetting"] = true
+	}
+
+	return d
+}
+
+// TransportControl defines the media commands a device with the TransportControl trait supports.
+const (
+	TransportControlCaptionControl = "CAPTION_CONTROL"
+	TransportControlNext           = "NEXT"
+	TransportControlPause          = "PAUSE"
+	TransportControlPrevious       = "PREVIOUS"
+	TransportControlResume         = "RESUME"
+	TransportControlSeekRelative   = "SEEK_RELATIVE"
+	TransportControlSeekToPosition = "SEEK_TO_POSITION"
+	TransportControlSetRepeat      = "SET_REPEAT"
+	TransportControlShuffle        = "SHUFFLE"
+	TransportControlStop           = "STOP"
+)
+
+// AddTransportControlTrait indicates this device is capable of controlling media playback using the supplied commands.
+// See https://developers.google.com/assistant/smarthome/traits/transportcontrol
+func (d *Device) AddTransportControlTrait(supportedCommands ...string) *Device {
+	d.Traits[TraitTransportControl] = true
+	d.Attributes["transportControlSupportedCommands"] = supportedCommands
+
+	return d
+}
+
 // AddVolumeTrait indicates this device is capable of having its volume controlled
 // See https://developers.google.com/assistant/smarthome/traits/volume
 func (d *Device) AddVolumeTrait(maxLevel int, canMute bool, onlyCommand bool) *Device {
-	d.Traits["action.devices.traits.Volume"] = true
+	d.Traits[TraitVolume] = true
 	if onlyCommand {
 		d.Attributes["commandOnlyVolume"] = true
 	}
@@ -217,6 +1275,8 @@ func (d *Device) AddVolumeTrait(maxLevel int, canMute bool, onlyCommand bool) *D
 }
 
 // MarshalJSON is a custom JSON serializer for our Device
+// Traits are sorted, and the attribute and custom data maps are emitted with their keys in sorted order,
+// so the same device always serializes to the same bytes.
 func (d Device) MarshalJSON() ([]byte, error) {
 	dr := deviceRaw{}
 
@@ -230,6 +1290,7 @@ func (d Device) MarshalJSON() ([]byte, error) {
 	dr.Name.Name = d.Name.Name
 	dr.Name.Nicknames = d.Name.Nicknames
 	dr.WillReportState = d.WillReportState
+	dr.NotificationSupportedByAgent = d.NotificationSupportedByAgent
 	dr.RoomHint = d.RoomHint
 	dr.Attributes = d.Attributes
 	dr.DeviceInfo.Manufacturer = d.DeviceInfo.Manufacturer
@@ -267,12 +1328,14 @@ func (d *Device) UnmarshalJSON(data []byte) error {
 	d.Name.Name = dr.Name.Name
 	d.Name.Nicknames = dr.Name.Nicknames
 	d.WillReportState = dr.WillReportState
+	d.NotificationSupportedByAgent = dr.NotificationSupportedByAgent
 	d.RoomHint = dr.RoomHint
 	d.Attributes = dr.Attributes
 	d.DeviceInfo.Manufacturer = dr.DeviceInfo.Manufacturer
 	d.DeviceInfo.Model = dr.DeviceInfo.Model
 	d.DeviceInfo.HwVersion = dr.DeviceInfo.HwVersion
 	d.DeviceInfo.SwVersion = dr.DeviceInfo.SwVersion
+	d.OtherDeviceIDs = nil
 	for _, otherDeviceID := range dr.OtherDeviceIDs {
 		d.OtherDeviceIDs = append(d.OtherDeviceIDs, OtherDeviceID{
 			AgentID:  otherDeviceID.AgentID,
@@ -304,9 +1367,10 @@ type deviceRaw struct {
 		Nicknames    []string `json:"nicknames,omitempty"`
 	} `json:"name,omitempty"`
 
-	WillReportState bool                   `json:"willReportState"`
-	RoomHint        string                 `json:"roomHint,omitempty"`
-	Attributes      map[string]interface{} `json:"attributes,omitempty"`
+	WillReportState              bool                   `json:"willReportState"`
+	NotificationSupportedByAgent bool                   `json:"notificationSupportedByAgent,omitempty"`
+	RoomHint                     string                 `json:"roomHint,omitempty"`
+	Attributes                   map[string]interface{} `json:"attributes,omitempty"`
 
 	DeviceInfo struct {
 		Manufacturer string `json:"manufacturer,omitempty"`
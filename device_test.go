@@ -2,6 +2,7 @@ package action
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,3 +51,497 @@ func TestDeviceJSONSerializeDeserialize(t *testing.T) {
 	assert.Nil(t, reserializedErr)
 	assert.Equal(t, serializedBytes, reserializedBytes)
 }
+
+func TestDeviceJSONSerializeDeterministic(t *testing.T) {
+	d := NewLight("test-id")
+	d.AddBrightnessTrait(false).AddColourTrait(RGB, false).AddColourTemperatureTrait(2000, 9000, false)
+	d.CustomData = map[string]interface{}{
+		"zValue": 1,
+		"aValue": map[string]interface{}{
+			"y": true,
+			"b": "nested",
+		},
+		"mValue": "middle",
+	}
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"customData":{"aValue":{"b":"nested","y":true},"mValue":"middle","zValue":1}`)
+
+	for i := 0; i < 20; i++ {
+		reserializedBytes, err := json.Marshal(d)
+		assert.Nil(t, err)
+		assert.Equal(t, serializedBytes, reserializedBytes)
+	}
+}
+
+func TestDeviceSetAvailableInputs(t *testing.T) {
+	d := NewSimpleAVReceiver("test-id", nil, 100, true, false)
+	assert.Nil(t, d.AvailableInputs())
+
+	inputs := []DeviceInput{
+		{
+			Key: "input-1",
+			Names: []DeviceInputName{
+				{
+					LanguageCode: "en-US",
+					Synonyms:     []string{"First Input"},
+				},
+			},
+		},
+	}
+	d.SetAvailableInputs(inputs).SetInputSelectorCommandOnly(true)
+	assert.Equal(t, inputs, d.AvailableInputs())
+	assert.Equal(t, true, d.Attributes["commandOnlyInputSelector"])
+
+	serializedBytes, serializeErr := json.Marshal(d)
+	assert.Nil(t, serializeErr)
+
+	convDevice := Device{}
+	deserializeErr := json.Unmarshal(serializedBytes, &convDevice)
+	assert.Nil(t, deserializeErr)
+	assert.Equal(t, inputs, convDevice.AvailableInputs())
+
+	d.SetInputSelectorCommandOnly(false)
+	_, found := d.Attributes["commandOnlyInputSelector"]
+	assert.False(t, found)
+}
+
+func TestDeviceNextPreviousInputKey(t *testing.T) {
+	d := NewSimpleAVReceiver("test-id", []DeviceInput{
+		{Key: "input-1"},
+		{Key: "input-2"},
+		{Key: "input-3"},
+	}, 100, true, false)
+
+	for _, tt := range []struct {
+		current      string
+		wantNext     string
+		wantPrevious string
+	}{
+		{"input-1", "input-2", "input-3"},
+		{"input-2", "input-3", "input-1"},
+		{"input-3", "input-1", "input-2"},
+	} {
+		next, err := d.NextInputKey(tt.current)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.wantNext, next)
+
+		previous, err := d.PreviousInputKey(tt.current)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.wantPrevious, previous)
+	}
+
+	_, err := d.NextInputKey("input-4")
+	assert.Equal(t, ErrInputNotFound, err)
+	_, err = NewLight("light-id").PreviousInputKey("input-1")
+	assert.Equal(t, ErrInputNotFound, err)
+}
+
+func TestDeviceAddColorSettingTrait(t *testing.T) {
+	d := NewLight("test-id").AddColorSettingTrait(ColorSettingOptions{
+		ColorModel:      HSV,
+		TemperatureMinK: 2000,
+		TemperatureMaxK: 9000,
+		CommandOnly:     true,
+	})
+	assert.Nil(t, d.Validate())
+	assert.True(t, d.Traits[TraitColorSetting])
+	assert.Equal(t, HSV, d.Attributes["colorModel"])
+	assert.Equal(t, map[string]int{"temperatureMinK": 2000, "temperatureMaxK": 9000}, d.Attributes["colorTemperatureRange"])
+	assert.Equal(t, true, d.Attributes["commandOnlyColorSetting"])
+
+	d = d.AddColorSettingTrait(ColorSettingOptions{
+		TemperatureMinK: 2700,
+		TemperatureMaxK: 6500,
+	})
+	assert.Nil(t, d.Validate())
+	assert.NotContains(t, d.Attributes, "colorModel")
+	assert.Equal(t, false, d.Attributes["commandOnlyColorSetting"])
+
+	for _, opts := range []ColorSettingOptions{
+		{},
+		{ColorModel: "rgb,hsv"},
+		{ColorModel: RGB, TemperatureMinK: 9000, TemperatureMaxK: 2000},
+		{TemperatureMaxK: 6500},
+	} {
+		err := NewLight("test-id").AddColorSettingTrait(opts).Validate()
+		assert.True(t, errors.Is(err, ErrInvalidColorSetting))
+	}
+
+	decoded := &Device{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"1","traits":["action.devices.traits.ColorSetting"],"attributes":{"colorTemperatureRange":{"temperatureMinK":9000,"temperatureMaxK":2000}}}`), decoded))
+	assert.True(t, errors.Is(decoded.Validate(), ErrInvalidColorSetting))
+}
+
+func TestDeviceColourTraitsCommandOnly(t *testing.T) {
+	d := NewLight("test-id").AddColourTrait(RGB, true).AddColourTemperatureTrait(2000, 9000, false)
+	assert.Equal(t, true, d.Attributes["commandOnlyColorSetting"])
+	assert.Equal(t, RGB, d.Attributes["colorModel"])
+
+	d = NewLight("test-id").AddColourTemperatureTrait(2000, 9000, true).AddColourTrait(RGB, false)
+	assert.Equal(t, true, d.Attributes["commandOnlyColorSetting"])
+	assert.Contains(t, d.Attributes, "colorTemperatureRange")
+
+	d = NewLight("test-id").AddColourTrait(HSV, false)
+	assert.Equal(t, false, d.Attributes["commandOnlyColorSetting"])
+}
+
+func TestDeviceSensors(t *testing.T) {
+	d := NewContactSensor("contact-id")
+	assert.Equal(t, DeviceTypeSensor, d.Type)
+	assert.Equal(t, true, d.Attributes["queryOnlyOpenClose"])
+	assert.Equal(t, true, d.Attributes["discreteOnlyOpenClose"])
+	assert.False(t, d.SupportsCommand("action.devices.commands.OpenClose"))
+	assert.Nil(t, NewDeviceState(true).RecordOpenPercent(0).ValidateForDevice(d))
+
+	d = NewMotionSensor("motion-id", OccupancySensorTypePIR)
+	assert.Equal(t, DeviceTypeSensor, d.Type)
+	assert.True(t, d.Traits[TraitOccupancySensing])
+	assert.Nil(t, NewDeviceState(true).RecordOccupancy(true).ValidateForDevice(d))
+
+	d = NewSmokeDetector("smoke-id")
+	assert.Equal(t, DeviceTypeSmokeDetector, d.Type)
+	assert.Equal(t, []string{"currentSensorStateData", "online"}, d.StateKeys())
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"attributes":{"sensorStatesSupported":[{"name":"SmokeLevel","descriptiveCapabilities":{"availableStates":["smoke detected","high","no smoke detected"]}}]}`)
+
+	d = NewSensor("sensor-id", []DeviceSensorState{
+		{
+			Name: SensorStatePM25,
+			NumericCapabilities: &DeviceSensorNumericCapabilities{
+				RawValueUnit: "MICROGRAMS_PER_CUBIC_METER",
+			},
+		},
+	})
+	assert.True(t, d.Traits[TraitSensorState])
+	assert.Nil(t, NewDeviceState(true).RecordSensorRawValue(SensorStatePM25, 4).ValidateForDevice(d))
+	assert.NotNil(t, NewDeviceState(true).RecordOnOff(true).ValidateForDevice(d))
+}
+
+func TestDeviceNewThermostat(t *testing.T) {
+	d := NewThermostat("thermostat-id", []string{"off", "heat", "cool", "heatcool"}, TemperatureUnitFahrenheit, [2]float64{10, 32})
+	assert.Equal(t, DeviceTypeThermostat, d.Type)
+	assert.True(t, d.Traits[TraitTemperatureSetting])
+	assert.Equal(t, TemperatureUnitFahrenheit, d.Attributes["thermostatTemperatureUnit"])
+	assert.Equal(t, map[string]float64{"minThresholdCelsius": 10, "maxThresholdCelsius": 32}, d.Attributes["thermostatTemperatureRange"])
+	assert.Equal(t, 50.0, d.TemperatureFromCelsius(10))
+
+	d = NewThermostat("thermostat-id", []string{"off", "heat"}, TemperatureUnitCelsius, [2]float64{})
+	assert.NotContains(t, d.Attributes, "thermostatTemperatureRange")
+}
+
+func TestDeviceOpenCloseDevices(t *testing.T) {
+	d := NewGarageDoor("garage-id")
+	assert.Equal(t, DeviceTypeGarage, d.Type)
+	assert.Equal(t, true, d.Attributes["discreteOnlyOpenClose"])
+	assert.Equal(t, ChallengeAckNeeded, d.Challenges["action.devices.commands.OpenClose"])
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(serializedBytes), ChallengeAckNeeded)
+
+	d = NewBlinds("blinds-id", OpenDirectionUp, OpenDirectionDown)
+	assert.Equal(t, DeviceTypeBlinds, d.Type)
+	assert.NotContains(t, d.Attributes, "discreteOnlyOpenClose")
+	assert.Equal(t, []string{OpenDirectionUp, OpenDirectionDown}, d.Attributes["openDirection"])
+	assert.Nil(t, d.Challenges)
+
+	d = NewBlinds("blinds-id")
+	assert.NotContains(t, d.Attributes, "openDirection")
+}
+
+func TestDeviceNewLock(t *testing.T) {
+	d := NewLock("lock-id")
+	assert.Equal(t, DeviceTypeLock, d.Type)
+	assert.True(t, d.Traits[TraitLockUnlock])
+	assert.True(t, d.SupportsCommand("action.devices.commands.LockUnlock"))
+
+	c := Command{
+		Name: "action.devices.commands.LockUnlock",
+		Generic: &CommandGeneric{
+			Command: "action.devices.commands.LockUnlock",
+			Params: map[string]interface{}{
+				"lock": false,
+			},
+		},
+	}
+	assert.Equal(t, ChallengePinNeeded, d.CheckChallenge(c, "1234"))
+	c.Challenge = &CommandChallenge{Pin: "1234"}
+	assert.Equal(t, "", d.CheckChallenge(c, "1234"))
+
+	ds := NewDeviceState(true).RecordLockState(true, false)
+	assert.Nil(t, ds.ValidateForDevice(d))
+	assert.Equal(t, true, ds.State["isLocked"])
+	assert.Equal(t, false, ds.State["isJammed"])
+}
+
+func TestDeviceNewVacuum(t *testing.T) {
+	d := NewVacuum("vacuum-id", []string{"kitchen", "hallway"}, true)
+	assert.Equal(t, DeviceTypeVacuum, d.Type)
+	assert.True(t, d.Traits[TraitStartStop])
+	assert.True(t, d.Traits[TraitDock])
+	assert.True(t, d.Traits[TraitLocator])
+	assert.False(t, d.Traits[TraitEnergyStorage])
+	assert.Equal(t, true, d.Attributes["pausable"])
+	assert.Equal(t, []string{"kitchen", "hallway"}, d.Attributes["availableZones"])
+
+	d = NewVacuum("vacuum-id", nil, false).AddEnergyStorageTrait(true, true)
+	assert.NotContains(t, d.Attributes, "availableZones")
+	assert.Equal(t, true, d.Attributes["queryOnlyEnergyStorage"])
+	assert.False(t, d.SupportsCommand("action.devices.commands.Charge"))
+
+	ds := NewDeviceState(true).RecordStartStop(true, false, "kitchen").RecordDocked(false).RecordBattery(25, false, false)
+	assert.Nil(t, ds.ValidateForDevice(d))
+	assert.Equal(t, []string{"kitchen"}, ds.State["activeZones"])
+	assert.Equal(t, "LOW", ds.State["descriptiveCapacityRemaining"])
+}
+
+func TestDeviceNewTV(t *testing.T) {
+	d := NewTV("tv-id",
+		[]DeviceInput{NewInput("hdmi_1").WithNames("en", "HDMI 1")},
+		[]DeviceApp{NewApp("youtube").WithNames("en", "YouTube")},
+		[]DeviceChannel{NewChannel("abc1", "4", "ABC")},
+	)
+	assert.Equal(t, DeviceTypeTV, d.Type)
+	for _, trait := range []string{TraitOnOff, TraitVolume, TraitInputSelector, TraitAppSelector, TraitMediaState, TraitTransportControl, TraitChannel} {
+		assert.True(t, d.Traits[trait], trait)
+	}
+	assert.True(t, d.SupportsCommand("action.devices.commands.mediaPause"))
+	assert.True(t, d.SupportsCommand("action.devices.commands.selectChannel"))
+
+	ds := NewDeviceState(true).RecordOnOff(true).RecordApplication("youtube").RecordMediaState(ActivityStateActive, PlaybackStatePlaying)
+	assert.Nil(t, ds.ValidateForDevice(d))
+
+	d = NewTV("tv-id", nil, nil, nil)
+	assert.False(t, d.Traits[TraitInputSelector])
+	assert.False(t, d.Traits[TraitAppSelector])
+	assert.False(t, d.Traits[TraitChannel])
+	assert.NotNil(t, NewDeviceState(true).RecordApplication("youtube").ValidateForDevice(d))
+}
+
+func TestDeviceNewFan(t *testing.T) {
+	speeds := []FanSpeedSetting{
+		NewFanSpeedSetting("low").WithNames("en", "Low"),
+		NewFanSpeedSetting("high").WithNames("en", "High"),
+	}
+	d := NewFan("fan-id", speeds, true)
+	assert.Equal(t, DeviceTypeFan, d.Type)
+	assert.True(t, d.Traits[TraitOnOff])
+	assert.True(t, d.Traits[TraitFanSpeed])
+	assert.Equal(t, true, d.Attributes["reversible"])
+	assert.NotContains(t, d.Attributes, "supportsFanSpeedPercent")
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"availableFanSpeeds":{"ordered":true,"speeds":[{"speed_name":"low","speed_values":[{"lang":"en","speed_synonym":["Low"]}]},{"speed_name":"high","speed_values":[{"lang":"en","speed_synonym":["High"]}]}]}`)
+
+	d = NewFan("fan-id", nil, false)
+	assert.Equal(t, true, d.Attributes["supportsFanSpeedPercent"])
+	assert.Nil(t, NewDeviceState(true).RecordOnOff(true).RecordFanSpeedPercent(40).ValidateForDevice(d))
+
+	d = NewAirPurifier("purifier-id", speeds)
+	assert.Equal(t, DeviceTypeAirPurifier, d.Type)
+	assert.True(t, d.Traits[TraitSensorState])
+	ds := NewDeviceState(true).RecordFanSpeed("low").RecordSensorState(SensorStatePM25, "healthy").RecordSensorRawValue(SensorStatePM25, 4)
+	assert.Nil(t, ds.ValidateForDevice(d))
+}
+
+func TestDeviceIrrigation(t *testing.T) {
+	d := NewSprinkler("sprinkler-id", []string{"front lawn", "back lawn"}, 3600)
+	assert.Equal(t, DeviceTypeSprinkler, d.Type)
+	assert.True(t, d.Traits[TraitStartStop])
+	assert.True(t, d.Traits[TraitTimer])
+	assert.Equal(t, []string{"front lawn", "back lawn"}, d.Attributes["availableZones"])
+	assert.Equal(t, 3600, d.Attributes["maxTimerLimitSec"])
+
+	ds := NewDeviceState(true).RecordStartStop(true, false, "front lawn").RecordTimer(600, false)
+	assert.Nil(t, ds.ValidateForDevice(d))
+	assert.Equal(t, 600, ds.State["timerRemainingSec"])
+
+	d = NewSprinkler("sprinkler-id", nil, 0)
+	assert.False(t, d.Traits[TraitTimer])
+	assert.NotContains(t, d.Attributes, "availableZones")
+
+	d = NewValve("valve-id", true)
+	assert.Equal(t, DeviceTypeValve, d.Type)
+	assert.Equal(t, true, d.Attributes["discreteOnlyOpenClose"])
+	assert.True(t, d.SupportsCommand("action.devices.commands.OpenClose"))
+}
+
+func TestDeviceAppliances(t *testing.T) {
+	modes := []DeviceMode{
+		NewMode("load", true).WithNames("en", "load").WithSettings(
+			NewModeSetting("small").WithNames("en", "small"),
+			NewModeSetting("large").WithNames("en", "large"),
+		),
+	}
+	toggles := []DeviceToggle{
+		NewToggle("extra_rinse").WithNames("en", "extra rinse"),
+	}
+
+	for typ, d := range map[string]*Device{
+		DeviceTypeWasher:     NewWasher("appliance-id", modes, toggles),
+		DeviceTypeDryer:      NewDryer("appliance-id", modes, toggles),
+		DeviceTypeDishwasher: NewDishwasher("appliance-id", modes, toggles),
+	} {
+		assert.Equal(t, typ, d.Type)
+		assert.True(t, d.Traits[TraitStartStop])
+		assert.True(t, d.Traits[TraitRunCycle])
+		assert.True(t, d.Traits[TraitModes])
+		assert.True(t, d.Traits[TraitToggles])
+		assert.Equal(t, true, d.Attributes["pausable"])
+	}
+
+	d := NewWasher("washer-id", nil, nil)
+	assert.False(t, d.Traits[TraitModes])
+	assert.False(t, d.Traits[TraitToggles])
+
+	ds := NewDeviceState(true).RecordStartStop(true, false).RecordRunCycle("en", "rinse", "spin", 300, 900)
+	assert.Nil(t, ds.ValidateForDevice(d))
+
+	serializedBytes, err := json.Marshal(ds)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"currentCycleRemainingTime":300,"currentRunCycle":[{"currentCycle":"rinse","lang":"en","nextCycle":"spin"}],"currentTotalRemainingTime":900,"isPaused":false,"isRunning":true,"online":true}`, string(serializedBytes))
+}
+
+func TestDeviceNewDoorbell(t *testing.T) {
+	d := NewDoorbell("doorbell-id")
+	assert.Equal(t, DeviceTypeDoorbell, d.Type)
+	assert.True(t, d.Traits[TraitObjectDetection])
+	assert.True(t, d.Traits[TraitCameraStream])
+	assert.Equal(t, []string{CameraStreamProtocolHLS}, d.Attributes["cameraStreamSupportedProtocols"])
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"notificationSupportedByAgent":true`)
+
+	var reserialized Device
+	assert.Nil(t, json.Unmarshal(serializedBytes, &reserialized))
+	assert.True(t, reserialized.NotificationSupportedByAgent)
+
+	d = NewDoorbell("doorbell-id", CameraStreamProtocolWebRTC)
+	assert.Equal(t, []string{CameraStreamProtocolWebRTC}, d.Attributes["cameraStreamSupportedProtocols"])
+
+	serializedBytes, err = json.Marshal(NewLight("light-id"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(serializedBytes), "notificationSupportedByAgent")
+}
+
+func TestDeviceNewChargerEV(t *testing.T) {
+	d := NewChargerEV("charger-id", EnergyStorageUnitMiles)
+	assert.Equal(t, DeviceTypeCharger, d.Type)
+	assert.True(t, d.NotificationSupportedByAgent)
+	assert.True(t, d.Traits[TraitEnergyStorage])
+	assert.True(t, d.Traits[TraitStartStop])
+	assert.Equal(t, EnergyStorageUnitMiles, d.Attributes["energyStorageDistanceUnitForUX"])
+	assert.True(t, d.SupportsCommand("action.devices.commands.Charge"))
+
+	ds := NewDeviceState(true).
+		RecordBattery(50, true, true).
+		RecordCapacityRemaining(120, EnergyStorageUnitMiles).
+		RecordCapacityRemaining(130, EnergyStorageUnitMiles).
+		RecordCapacityUntilFull(3600, EnergyStorageUnitSeconds)
+	assert.Nil(t, ds.ValidateForDevice(d))
+	assert.Equal(t, []map[string]interface{}{
+		{"rawValue": 50, "unit": EnergyStorageUnitPercentage},
+		{"rawValue": 130, "unit": EnergyStorageUnitMiles},
+	}, ds.State["capacityRemaining"])
+}
+
+func TestDeviceHumidityDevices(t *testing.T) {
+	d := NewHumidifier("humidifier-id", [2]int{30, 60}, []FanSpeedSetting{NewFanSpeedSetting("low")})
+	assert.Equal(t, DeviceTypeHumidifier, d.Type)
+	assert.True(t, d.Traits[TraitOnOff])
+	assert.True(t, d.Traits[TraitHumiditySetting])
+	assert.True(t, d.Traits[TraitFanSpeed])
+	assert.Equal(t, map[string]int{"minPercent": 30, "maxPercent": 60}, d.Attributes["humiditySetpointRange"])
+
+	ds := NewDeviceState(true).RecordOnOff(true).RecordHumidity(HumidityState{
+		SetpointPercent: 45,
+		AmbientPercent:  38,
+	})
+	assert.Nil(t, ds.ValidateForDevice(d))
+	assert.Equal(t, 45, ds.State["humiditySetpointPercent"])
+	assert.Equal(t, 38, ds.State["humidityAmbientPercent"])
+
+	d = NewDehumidifier("dehumidifier-id", [2]int{}, nil)
+	assert.Equal(t, DeviceTypeDehumidifier, d.Type)
+	assert.False(t, d.Traits[TraitFanSpeed])
+	assert.NotContains(t, d.Attributes, "humiditySetpointRange")
+}
+
+func TestDeviceSmallAppliances(t *testing.T) {
+	d := NewKettle("kettle-id", TemperatureUnitCelsius, [2]float64{40, 100})
+	assert.Equal(t, DeviceTypeKettle, d.Type)
+	assert.True(t, d.Traits[TraitOnOff])
+	assert.True(t, d.Traits[TraitTemperatureControl])
+	assert.False(t, d.Traits[TraitCook])
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"attributes":{"temperatureRange":{"maxThresholdCelsius":100,"minThresholdCelsius":40},"temperatureUnitForUX":"C"}`)
+
+	ds := NewDeviceState(true).RecordOnOff(true).RecordTemperatureControl(95, 60.5)
+	assert.Nil(t, ds.ValidateForDevice(d))
+
+	d = NewCoffeeMaker("coffee-id", TemperatureUnitFahrenheit, [2]float64{65, 95})
+	assert.Equal(t, DeviceTypeCoffeeMaker, d.Type)
+	assert.True(t, d.Traits[TraitCook])
+	assert.Equal(t, []string{CookingModeBrew}, d.Attributes["supportedCookingModes"])
+	assert.Nil(t, ds.RecordCookingMode(CookingModeBrew).ValidateForDevice(d))
+
+	d = NewCoffeeMaker("coffee-id", TemperatureUnitCelsius, [2]float64{65, 95}, CookingModeBrew, CookingModeFroth)
+	assert.Equal(t, []string{CookingModeBrew, CookingModeFroth}, d.Attributes["supportedCookingModes"])
+
+	d.AddTemperatureControlTrait([2]float64{65, 95}, 5, TemperatureUnitCelsius, false, false)
+	assert.Equal(t, 5.0, d.Attributes["temperatureStepCelsius"])
+}
+
+func TestDeviceRemoteControlSetTopBox(t *testing.T) {
+	inputs := []DeviceInput{NewInput("hdmi_1").WithNames("en", "HDMI 1")}
+	channels := []DeviceChannel{NewChannel("abc1", "4", "ABC")}
+
+	d := NewRemoteControl("remote-id", inputs, channels)
+	assert.Equal(t, DeviceTypeRemoteControl, d.Type)
+	for _, trait := range []string{TraitOnOff, TraitVolume, TraitChannel, TraitTransportControl, TraitInputSelector} {
+		assert.True(t, d.Traits[trait], trait)
+	}
+	assert.True(t, d.SupportsCommand("action.devices.commands.relativeChannel"))
+	assert.Equal(t, []string{"online"}, d.StateKeys())
+
+	d = NewSetTopBox("settop-id", nil, channels)
+	assert.Equal(t, DeviceTypeSetTop, d.Type)
+	assert.True(t, d.Traits[TraitMediaState])
+	assert.False(t, d.Traits[TraitInputSelector])
+	assert.True(t, d.SupportsCommand("action.devices.commands.returnChannel"))
+	assert.Equal(t, channels, d.Attributes["availableChannels"])
+}
+
+func TestDeviceAddOtherDeviceID(t *testing.T) {
+	d := NewLight("light-1")
+
+	err := NewLight("light-2").AddOtherDeviceID("local-agent", " ").Validate()
+	assert.True(t, errors.Is(err, ErrInvalidOtherDeviceID))
+
+	d.AddOtherDeviceID("local-agent", "local-1").AddOtherDeviceID("", "local-2").AddOtherDeviceID("local-agent", "local-1")
+	assert.Nil(t, d.Validate())
+	assert.Equal(t, []OtherDeviceID{
+		{AgentID: "local-agent", DeviceID: "local-1"},
+		{DeviceID: "local-2"},
+	}, d.OtherDeviceIDs)
+
+	serializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Contains(t, string(serializedBytes), `"otherDeviceIds":[{"agentId":"local-agent","deviceId":"local-1"},{"deviceId":"local-2"}]`)
+
+	// Deserializing into the same device replaces the identifiers rather than appending to them.
+	assert.Nil(t, json.Unmarshal(serializedBytes, d))
+	assert.Len(t, d.OtherDeviceIDs, 2)
+
+	reserializedBytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Equal(t, serializedBytes, reserializedBytes)
+}
@@ -0,0 +1,455 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrDeviceNotFound is returned by UpdateDeviceMetadata if the provider doesn't return the device from Sync.
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrInvalidMetadataUpdate is returned by UpdateDeviceMetadata if the updated device isn't valid,
+	// or the update changes the ID, type or traits of the device.
+	ErrInvalidMetadataUpdate = errors.New("invalid metadata update")
+)
+
+const (
+	// DefaultDeviceRemovalCooldown is the default period after a device is removed during which its ID may not be reused.
+	DefaultDeviceRemovalCooldown = 24 * time.Hour
+	// DefaultMetadataSyncDebounce is the default period UpdateDeviceMetadata waits for further updates before requesting a SYNC.
+	DefaultMetadataSyncDebounce = 5 * time.Second
+)
+
+// WithDeviceRemovalCooldown sets the period after a device is removed using DeviceManager.Remove during which
+// its ID may not be reused. Google caches devices by ID, so a new device reusing the ID of one which was only
+// recently removed can inherit its stale state and settings. By default this is DefaultDeviceRemovalCooldown;
+// a cooldown of 0 or less allows IDs to be reused as soon as the device has been removed.
+func WithDeviceRemovalCooldown(cooldown time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.devices.cooldown = cooldown
+	}
+}
+
+// managedDevice identifies a device of a user.
+type managedDevice struct {
+	agentUserID string
+	deviceID    string
+}
+
+// WithDeviceMetadataStore sets the store the metadata updates made using DeviceManager.UpdateDeviceMetadata are kept in.
+// By default they are kept in memory, so they are lost when the process exits; a persistent store retains them
+// without the provider having to persist the updates itself.
+func WithDeviceMetadataStore(store DeviceMetadataStore) ServiceOption {
+	return func(s *Service) {
+		s.devices.store = store
+	}
+}
+
+// WithMetadataSyncDebounce sets how long DeviceManager.UpdateDeviceMetadata waits after an update to the devices of
+// a user before requesting a SYNC, so a burst of updates (i.e. renaming several devices) results in a single SYNC.
+// By default this is DefaultMetadataSyncDebounce.
+func WithMetadataSyncDebounce(debounce time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.devices.debounce = debounce
+	}
+}
+
+// DeviceMetadata contains the metadata of a device which can be changed using DeviceManager.UpdateDeviceMetadata.
+// Attributes only contains the attributes which were changed from those returned by the provider;
+// a nil value indicates the attribute was removed.
+type DeviceMetadata struct {
+	Name       DeviceName             `json:"name"`
+	RoomHint   string                 `json:"roomHint,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// apply replaces the name and room hint of the device with the updated values, and merges the changed attributes
+// over the attributes of the device.
+func (m DeviceMetadata) apply(device *Device) {
+	device.Name = m.Name
+	device.RoomHint = m.RoomHint
+	for attribute, value := range m.Attributes {
+		if value == nil {
+			delete(device.Attributes, attribute)
+			continue
+		}
+		device.Attributes[attribute] = value
+	}
+}
+
+// changedAttributes returns the attributes of the updated device which differ from those of the original device,
+// with a nil value for each attribute which was removed. nil is returned if no attributes changed.
+func changedAttributes(original *Device, updated *Device) map[string]interface{} {
+	var changed map[string]interface{}
+	for attribute, value := range updated.Attributes {
+		if existing, found := original.Attributes[attribute]; !found || !reflect.DeepEqual(existing, value) {
+			if changed == nil {
+				changed = map[string]interface{}{}
+			}
+			changed[attribute] = value
+		}
+	}
+	for attribute := range original.Attributes {
+		if _, found := updated.Attributes[attribute]; !found {
+			if changed == nil {
+				changed = map[string]interface{}{}
+			}
+			changed[attribute] = nil
+		}
+	}
+	return changed
+}
+
+// DeviceMetadataStore retains the latest metadata of each device updated using DeviceManager.UpdateDeviceMetadata.
+// Implementations must be safe for concurrent use.
+type DeviceMetadataStore interface {
+	// Save replaces the metadata stored for the device.
+	Save(ctx context.Context, agentUserID string, deviceID string, metadata DeviceMetadata) error
+	// Load returns the metadata stored for the devices of the user, indexed by device ID.
+	Load(ctx context.Context, agentUserID string) (map[string]DeviceMetadata, error)
+	// Delete removes any metadata stored for the device.
+	Delete(ctx context.Context, agentUserID string, deviceID string) error
+}
+
+// MemoryDeviceMetadataStore is a DeviceMetadataStore which holds the metadata in memory, so it is lost if the process exits.
+type MemoryDeviceMetadataStore struct {
+	mu       sync.Mutex
+	metadata map[string]map[string]DeviceMetadata
+}
+
+// NewMemoryDeviceMetadataStore creates a new, empty in-memory metadata store.
+func NewMemoryDeviceMetadataStore() *MemoryDeviceMetadataStore {
+	return &MemoryDeviceMetadataStore{
+		metadata: map[string]map[string]DeviceMetadata{},
+	}
+}
+
+// Save replaces the metadata stored for the device.
+func (ms *MemoryDeviceMetadataStore) Save(_ context.Context, agentUserID string, deviceID string, metadata DeviceMetadata) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.metadata[agentUserID] == nil {
+		ms.metadata[agentUserID] = map[string]DeviceMetadata{}
+	}
+	ms.metadata[agentUserID][deviceID] = metadata
+	return nil
+}
+
+// Load returns a copy of the metadata stored for the devices of the user.
+func (ms *MemoryDeviceMetadataStore) Load(_ context.Context, agentUserID string) (map[string]DeviceMetadata, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	metadata := map[string]DeviceMetadata{}
+	for deviceID, m := range ms.metadata[agentUserID] {
+		metadata[deviceID] = m
+	}
+	return metadata, nil
+}
+
+// Delete removes any metadata stored for the device.
+func (ms *MemoryDeviceMetadataStore) Delete(_ context.Context, agentUserID string, deviceID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.metadata[agentUserID], deviceID)
+	if len(ms.metadata[agentUserID]) < 1 {
+		delete(ms.metadata, agentUserID)
+	}
+	return nil
+}
+
+// DeviceManager manages the lifecycle of the devices of each user with Google. It is retrieved using Service.Devices.
+type DeviceManager struct {
+	s        *Service
+	cooldown time.Duration
+	debounce time.Duration
+	store    DeviceMetadataStore
+
+	// updateLocks serializes the metadata updates of each user, so concurrent updates aren't lost.
+	updateLocks userLocks
+
+	mu      sync.Mutex
+	removed map[managedDevice]time.Time
+	pending map[string]Timer
+}
+
+// newDeviceManager creates a device manager for the service which retains removed IDs for the default cooldown.
+func newDeviceManager(s *Service) *DeviceManager {
+	return &DeviceManager{
+		s:        s,
+		cooldown: DefaultDeviceRemovalCooldown,
+		debounce: DefaultMetadataSyncDebounce,
+		store:    NewMemoryDeviceMetadataStore(),
+		removed:  map[managedDevice]time.Time{},
+		pending:  map[string]Timer{},
+	}
+}
+
+// Devices returns the manager of the devices of the service.
+func (s *Service) Devices() *DeviceManager {
+	return s.devices
+}
+
+// Remove decommissions the device of the user, and triggers a RequestSync so Google removes it from the HomeGraph.
+// The provider should stop returning the device from Sync before this is called; until the cooldown configured by
+// WithDeviceRemovalCooldown has passed the device is omitted from SYNC responses even if the provider does return it,
+// which prevents its ID from being reused. If reportOffline is set the device is reported offline before it is removed,
+// so anything which still references it (i.e. a routine) doesn't treat it as reachable.
+// Failing to report the device offline does not prevent it from being removed; the error is returned once the
+// device has been removed and the RequestSync has completed.
+func (dm *DeviceManager) Remove(ctx context.Context, agentUserID string, deviceID string, reportOffline bool) error {
+	var reportErr error
+	if reportOffline {
+		reportErr = dm.s.ReportState(ctx, agentUserID, map[string]DeviceState{
+			deviceID: NewDeviceState(false),
+		})
+		if reportErr != nil {
+			dm.s.logger.Info("error reporting removed device offline",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceID),
+				zap.Error(reportErr),
+			)
+		}
+	}
+
+	now := dm.s.now()
+	dm.mu.Lock()
+	dm.removed[managedDevice{agentUserID, deviceID}] = now
+	dm.expire(now)
+	dm.mu.Unlock()
+
+	if err := dm.store.Delete(ctx, agentUserID, deviceID); err != nil {
+		dm.s.logger.Info("error deleting metadata of removed device",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.String("device_id", deviceID),
+			zap.Error(err),
+		)
+	}
+
+	dm.s.events.publish(DeviceRemoved{
+		Time:        now,
+		AgentUserID: agentUserID,
+		DeviceID:    deviceID,
+	})
+
+	if err := dm.s.RequestSync(ctx, agentUserID); err != nil {
+		return err
+	}
+	return reportErr
+}
+
+// IsRemoved returns whether the device of the user was removed within the cooldown, so its ID may not be reused yet.
+func (dm *DeviceManager) IsRemoved(agentUserID string, deviceID string) bool {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	removedAt, found := dm.removed[managedDevice{agentUserID, deviceID}]
+	return found && dm.s.now().Sub(removedAt) < dm.cooldown
+}
+
+// expire discards the devices whose cooldown has passed. The lock must be held.
+func (dm *DeviceManager) expire(now time.Time) {
+	for device, removedAt := range dm.removed {
+		if now.Sub(removedAt) >= dm.cooldown {
+			delete(dm.removed, device)
+		}
+	}
+}
+
+// UpdateDeviceMetadata changes the metadata (i.e. the name, room hint or attributes) of the device of the user, and
+// schedules a RequestSync so the change reaches Google without the user having to ask for their devices to be synced.
+// The update function is applied to a copy of the device returned by SYNC, which is then validated; the
+// update is rejected with ErrInvalidMetadataUpdate if it changes the ID, type or traits of the device, sets an invalid
+// room hint, or sets attributes which don't belong to the traits of the device. Changes to other fields are ignored.
+// The resulting metadata is saved in the store configured by WithDeviceMetadataStore, and replaces the name and room
+// hint of the device each time it is returned by Sync until the device is removed or the provider stops returning it.
+// Only the attributes the update changed are saved; these are merged over the attributes returned by Sync, so other
+// attributes the provider changes later are still sent to Google.
+// Updates to the devices of a user are debounced (see WithMetadataSyncDebounce) before the SYNC is requested.
+// ErrProviderFailed is returned if the provider returns an error code from Sync.
+func (dm *DeviceManager) UpdateDeviceMetadata(ctx context.Context, agentUserID string, deviceID string, update func(*Device)) error {
+	unlock := dm.updateLocks.lock(agentUserID)
+	defer unlock()
+
+	resp, err := dm.s.provider.Sync(ctx, agentUserID)
+	if err != nil {
+		return err
+	} else if len(resp.ErrorCode) > 0 {
+		return fmt.Errorf("%w: %s", ErrProviderFailed, resp.ErrorCode)
+	}
+
+	var original, current *Device
+	for _, device := range resp.Devices {
+		if device.ID == deviceID {
+			original = device
+		}
+	}
+	for _, device := range dm.syncDevices(ctx, agentUserID, resp) {
+		if device.ID == deviceID {
+			current = device
+		}
+	}
+	if original == nil || current == nil {
+		return fmt.Errorf("%w: %s", ErrDeviceNotFound, deviceID)
+	}
+
+	updated := copyDeviceMetadata(current)
+	update(updated)
+	if err := validateMetadataUpdate(current, updated); err != nil {
+		return err
+	}
+
+	metadata := DeviceMetadata{
+		Name:       updated.Name,
+		RoomHint:   updated.RoomHint,
+		Attributes: changedAttributes(original, updated),
+	}
+	if err := dm.store.Save(ctx, agentUserID, deviceID, metadata); err != nil {
+		return err
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if timer, found := dm.pending[agentUserID]; found {
+		timer.Stop()
+	}
+	syncCtx := detachContext(ctx)
+	dm.pending[agentUserID] = dm.s.afterFunc(dm.debounce, func() {
+		dm.mu.Lock()
+		delete(dm.pending, agentUserID)
+		dm.mu.Unlock()
+
+		if err := dm.s.RequestSync(syncCtx, agentUserID); err != nil {
+			dm.s.logger.Info("error requesting sync after metadata update",
+				requestIDField(syncCtx),
+				zap.String("agent_user_id", agentUserID),
+				zap.Error(err),
+			)
+		}
+	})
+	return nil
+}
+
+// validateMetadataUpdate checks the updated device only differs from the current device in its metadata, and is valid.
+func validateMetadataUpdate(current *Device, updated *Device) error {
+	if updated.ID != current.ID || updated.Type != current.Type {
+		return fmt.Errorf("%w: the ID and type of a device can't be changed", ErrInvalidMetadataUpdate)
+	}
+	if len(updated.Traits) != len(current.Traits) {
+		return fmt.Errorf("%w: the traits of a device can't be changed", ErrInvalidMetadataUpdate)
+	}
+	for trait := range current.Traits {
+		if !updated.Traits[trait] {
+			return fmt.Errorf("%w: the traits of a device can't be changed", ErrInvalidMetadataUpdate)
+		}
+	}
+	if len(updated.Name.Name) < 1 {
+		return fmt.Errorf("%w: the device must have a name", ErrInvalidMetadataUpdate)
+	}
+	if err := ValidateRoomHint(updated.RoomHint); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadataUpdate, err)
+	}
+
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadataUpdate, err)
+	}
+	if err := CheckDeviceFields(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadataUpdate, err)
+	}
+	return nil
+}
+
+// copyDeviceMetadata returns a copy of the device whose names, traits, attributes and custom data can be modified
+// without affecting the original.
+func copyDeviceMetadata(device *Device) *Device {
+	copied := *device
+	copied.Name.DefaultNames = append([]string(nil), device.Name.DefaultNames...)
+	copied.Name.Nicknames = append([]string(nil), device.Name.Nicknames...)
+	copied.Traits = map[string]bool{}
+	for trait, supported := range device.Traits {
+		copied.Traits[trait] = supported
+	}
+	copied.Attributes = map[string]interface{}{}
+	for attribute, value := range device.Attributes {
+		copied.Attributes[attribute] = value
+	}
+	if device.CustomData != nil {
+		copied.CustomData = map[string]interface{}{}
+		for key, value := range device.CustomData {
+			copied.CustomData[key] = value
+		}
+	}
+	return &copied
+}
+
+// syncDevices returns the full set of devices of the user, as returned by the provider, with any updated metadata
+// applied, omitting any which were removed within the cooldown. The metadata of devices the provider no longer
+// returns is deleted. If the provider returned an error code its devices may be incomplete, so the metadata is
+// neither applied nor deleted.
+func (dm *DeviceManager) syncDevices(ctx context.Context, agentUserID string, resp *SyncResponse) []*Device {
+	devices := resp.Devices
+	filtered := make([]*Device, 0, len(devices))
+	dm.mu.Lock()
+	dm.expire(dm.s.now())
+	for _, device := range devices {
+		if _, removed := dm.removed[managedDevice{agentUserID, device.ID}]; removed {
+			dm.s.logger.Info("omitting removed device from sync",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", device.ID),
+			)
+			continue
+		}
+		filtered = append(filtered, device)
+	}
+	dm.mu.Unlock()
+
+	if len(resp.ErrorCode) > 0 {
+		return filtered
+	}
+
+	metadata, err := dm.store.Load(ctx, agentUserID)
+	if err != nil {
+		dm.s.logger.Info("error loading device metadata",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return filtered
+	}
+
+	for idx, device := range filtered {
+		if m, found := metadata[device.ID]; found {
+			updated := copyDeviceMetadata(device)
+			m.apply(updated)
+			filtered[idx] = updated
+		}
+	}
+	for _, device := range devices {
+		delete(metadata, device.ID)
+	}
+	for deviceID := range metadata {
+		if err := dm.store.Delete(ctx, agentUserID, deviceID); err != nil {
+			dm.s.logger.Info("error deleting metadata of missing device",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceID),
+				zap.Error(err),
+			)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,233 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDeviceManagerRemove(t *testing.T) {
+	thg := &testHomeGraph{}
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	provider := &testProvider{
+		syncResp: []*Device{NewLight("light-1"), NewLight("light-2")},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, newTestHomeGraphService(t, thg),
+		WithDeviceRemovalCooldown(time.Hour),
+		WithClock(ClockFunc(func() time.Time {
+			return now
+		})),
+	)
+
+	var events []Event
+	svc.Subscribe(func(e Event) {
+		if _, ok := e.(DeviceRemoved); ok {
+			events = append(events, e)
+		}
+	})
+
+	sync := func() string {
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+			"requestId": "1",
+			"inputs": [{"intent": "action.devices.SYNC"}]
+		}`))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		svc.GoogleFulfillmentHandler(rr, req)
+		return rr.Body.String()
+	}
+
+	assert.Nil(t, svc.Devices().Remove(context.Background(), "agent-id", "light-1", true))
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification", "/v1/devices:requestSync"}, thg.paths)
+	assert.Contains(t, thg.bodies[0], `"light-1":{"online":false}`)
+	assert.Equal(t, []Event{DeviceRemoved{
+		Time:        now,
+		AgentUserID: "agent-id",
+		DeviceID:    "light-1",
+	}}, events)
+
+	// The removed ID is withheld from SYNC until the cooldown has passed, even though the provider returns it.
+	assert.True(t, svc.Devices().IsRemoved("agent-id", "light-1"))
+	assert.False(t, svc.Devices().IsRemoved("other-id", "light-1"))
+	assert.NotContains(t, sync(), `"light-1"`)
+	assert.Contains(t, sync(), `"light-2"`)
+
+	now = now.Add(time.Hour)
+	assert.False(t, svc.Devices().IsRemoved("agent-id", "light-1"))
+	assert.Contains(t, sync(), `"light-1"`)
+}
+
+func TestDeviceManagerRemoveWithoutOfflineReport(t *testing.T) {
+	thg := &testHomeGraph{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithDeviceRemovalCooldown(0),
+	)
+
+	assert.Nil(t, svc.Devices().Remove(context.Background(), "agent-id", "light-1", false))
+	assert.Equal(t, []string{"/v1/devices:requestSync"}, thg.paths)
+	assert.False(t, svc.Devices().IsRemoved("agent-id", "light-1"))
+}
+
+func TestDeviceManagerUpdateDeviceMetadata(t *testing.T) {
+	thg := &testHomeGraph{}
+	provider := &testProvider{
+		syncResp: []*Device{NewLight("light-1"), NewLight("light-2")},
+	}
+	clock := newTestClock(time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryDeviceMetadataStore()
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, provider, newTestHomeGraphService(t, thg),
+		WithMetadataSyncDebounce(time.Second),
+		WithDeviceMetadataStore(store),
+		WithClock(clock),
+	)
+
+	assert.Nil(t, svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-1", func(d *Device) {
+		d.Name.Name = "Desk Lamp"
+	}))
+	clock.Advance(500 * time.Millisecond)
+	assert.Nil(t, svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-1", func(d *Device) {
+		d.RoomHint = "Office"
+	}))
+
+	// The updates are debounced into a single SYNC.
+	clock.Advance(500 * time.Millisecond)
+	assert.Empty(t, thg.paths)
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, []string{"/v1/devices:requestSync"}, thg.paths)
+	clock.Advance(time.Minute)
+	assert.Len(t, thg.paths, 1)
+
+	// The resulting metadata is stored, rather than each update.
+	metadata, err := store.Load(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]DeviceMetadata{
+		"light-1": {
+			Name:     DeviceName{Name: "Desk Lamp"},
+			RoomHint: "Office",
+		},
+	}, metadata)
+
+	// The provider's devices are unchanged, but the updates are applied to the devices returned by SYNC.
+	assert.Empty(t, provider.syncResp[0].RoomHint)
+	devices := svc.Devices().syncDevices(context.Background(), "agent-id", &SyncResponse{Devices: provider.syncResp})
+	assert.Equal(t, "Desk Lamp", devices[0].Name.Name)
+	assert.Equal(t, "Office", devices[0].RoomHint)
+	assert.Equal(t, provider.syncResp[1], devices[1])
+
+	// The metadata is discarded once the provider no longer returns the device.
+	svc.Devices().syncDevices(context.Background(), "agent-id", &SyncResponse{Devices: provider.syncResp[1:]})
+	metadata, err = store.Load(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Empty(t, metadata)
+}
+
+func TestDeviceManagerUpdateDeviceMetadataAttributes(t *testing.T) {
+	provider := &testProvider{
+		syncResp: []*Device{
+			NewDevice("thermostat-1", DeviceTypeThermostat).AddTemperatureSettingTrait([]string{ThermostatModeOff, ThermostatModeHeat}, TemperatureUnitCelsius, [2]float64{10, 30}, false, false),
+		},
+	}
+	provider.syncResp[0].Name.Name = "Thermostat"
+	store := NewMemoryDeviceMetadataStore()
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, provider, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithDeviceMetadataStore(store),
+	)
+
+	assert.Nil(t, svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "thermostat-1", func(d *Device) {
+		d.Attributes["thermostatTemperatureUnit"] = TemperatureUnitFahrenheit
+		delete(d.Attributes, "thermostatTemperatureRange")
+	}))
+
+	// Only the changed attributes are stored.
+	metadata, err := store.Load(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"thermostatTemperatureUnit":  TemperatureUnitFahrenheit,
+		"thermostatTemperatureRange": nil,
+	}, metadata["thermostat-1"].Attributes)
+
+	// Attributes the provider changes later are merged with the updated ones.
+	updated := NewDevice("thermostat-1", DeviceTypeThermostat).AddTemperatureSettingTrait([]string{ThermostatModeOff, ThermostatModeCool}, TemperatureUnitCelsius, [2]float64{10, 30}, false, false)
+	updated.Name.Name = "Thermostat"
+	devices := svc.Devices().syncDevices(context.Background(), "agent-id", &SyncResponse{Devices: []*Device{updated}})
+	assert.Equal(t, map[string]interface{}{
+		"availableThermostatModes":  []string{ThermostatModeOff, ThermostatModeCool},
+		"thermostatTemperatureUnit": TemperatureUnitFahrenheit,
+	}, devices[0].Attributes)
+	assert.Contains(t, updated.Attributes, "thermostatTemperatureRange")
+}
+
+func TestDeviceManagerUpdateDeviceMetadataSyncErrorCode(t *testing.T) {
+	provider := &testProvider{
+		syncResp: []*Device{NewLight("light-1")},
+	}
+	store := NewMemoryDeviceMetadataStore()
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithDeviceMetadataStore(store),
+	)
+
+	assert.Nil(t, svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-1", func(d *Device) {
+		d.Name.Name = "Desk Lamp"
+	}))
+
+	// A SYNC which fails with an error code and no devices doesn't discard the metadata.
+	provider.syncResp = nil
+	provider.syncErrorCode = ErrorCodeTransientError
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+	assert.Contains(t, rr.Body.String(), `"errorCode":"transientError"`)
+
+	err := svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-1", func(d *Device) {
+		d.RoomHint = "Office"
+	})
+	assert.True(t, errors.Is(err, ErrProviderFailed))
+	assert.Contains(t, err.Error(), ErrorCodeTransientError)
+
+	metadata, err := store.Load(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Equal(t, "Desk Lamp", metadata["light-1"].Name.Name)
+	assert.Empty(t, metadata["light-1"].RoomHint)
+
+	provider.syncResp = []*Device{NewLight("light-1")}
+	provider.syncErrorCode = ""
+	devices := svc.Devices().syncDevices(context.Background(), "agent-id", &SyncResponse{Devices: provider.syncResp})
+	assert.Equal(t, "Desk Lamp", devices[0].Name.Name)
+}
+
+func TestDeviceManagerUpdateDeviceMetadataInvalid(t *testing.T) {
+	provider := &testProvider{
+		syncResp: []*Device{NewLight("light-1")},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, provider, newTestHomeGraphService(t, &testHomeGraph{}))
+
+	err := svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-2", func(d *Device) {})
+	assert.True(t, errors.Is(err, ErrDeviceNotFound))
+
+	for _, update := range []func(*Device){
+		func(d *Device) { d.ID = "light-2" },
+		func(d *Device) { d.AddBrightnessTrait(false) },
+		func(d *Device) { d.Name.Name = "" },
+		func(d *Device) { d.RoomHint = "  " },
+		func(d *Device) { d.Attributes["colorModel"] = "rgb" },
+	} {
+		err := svc.Devices().UpdateDeviceMetadata(context.Background(), "agent-id", "light-1", update)
+		assert.True(t, errors.Is(err, ErrInvalidMetadataUpdate), "%v", err)
+	}
+	metadata, err := svc.Devices().store.Load(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Empty(t, metadata)
+}
@@ -0,0 +1,175 @@
+package action
+
+// DeviceType defines what type of device is being exposed to Google.
+// The type alters how the Google Assistant refers to and visualizes the device; it does not define what can be done with it.
+// See https://developers.google.com/assistant/smarthome/guides for the full list of device types.
+const (
+	DeviceTypeACUnit                 = "action.devices.types.AC_UNIT"
+	DeviceTypeAirCooler              = "action.devices.types.AIRCOOLER"
+	DeviceTypeAirFreshener           = "action.devices.types.AIRFRESHENER"
+	DeviceTypeAirPurifier            = "action.devices.types.AIRPURIFIER"
+	DeviceTypeAudioVideoReceiver     = "action.devices.types.AUDIO_VIDEO_RECEIVER"
+	DeviceTypeAwning                 = "action.devices.types.AWNING"
+	DeviceTypeBathtub                = "action.devices.types.BATHTUB"
+	DeviceTypeBed                    = "action.devices.types.BED"
+	DeviceTypeBlender                = "action.devices.types.BLENDER"
+	DeviceTypeBlinds                 = "action.devices.types.BLINDS"
+	DeviceTypeBoiler                 = "action.devices.types.BOILER"
+	DeviceTypeCamera                 = "action.devices.types.CAMERA"
+	DeviceTypeCarbonMonoxideDetector = "action.devices.types.CARBON_MONOXIDE_DETECTOR"
+	DeviceTypeCharger                = "action.devices.types.CHARGER"
+	DeviceTypeCloset                 = "action.devices.types.CLOSET"
+	DeviceTypeCoffeeMaker            = "action.devices.types.COFFEE_MAKER"
+	DeviceTypeCooktop                = "action.devices.types.COOKTOP"
+	DeviceTypeCurtain                = "action.devices.types.CURTAIN"
+	DeviceTypeDehumidifier           = "action.devices.types.DEHUMIDIFIER"
+	DeviceTypeDehydrator             = "action.devices.types.DEHYDRATOR"
+	DeviceTypeDishwasher             = "action.devices.types.DISHWASHER"
+	DeviceTypeDoor                   = "action.devices.types.DOOR"
+	DeviceTypeDoorbell               = "action.devices.types.DOORBELL"
+	DeviceTypeDrawer                 = "action.devices.types.DRAWER"
+	DeviceTypeDryer                  = "action.devices.types.DRYER"
+	DeviceTypeFan                    = "action.devices.types.FAN"
+	DeviceTypeFaucet                 = "action.devices.types.FAUCET"
+	DeviceTypeFireplace              = "action.devices.types.FIREPLACE"
+	DeviceTypeFreezer                = "action.devices.types.FREEZER"
+	DeviceTypeFryer                  = "action.devices.types.FRYER"
+	DeviceTypeGameConsole            = "action.devices.types.GAME_CONSOLE"
+	DeviceTypeGarage                 = "action.devices.types.GARAGE"
+	DeviceTypeGate                   = "action.devices.types.GATE"
+	DeviceTypeGrill                  = "action.devices.types.GRILL"
+	DeviceTypeHeater                 = "action.devices.types.HEATER"
+	DeviceTypeHood                   = "action.devices.types.HOOD"
+	DeviceTypeHumidifier             = "action.devices.types.HUMIDIFIER"
+	DeviceTypeKettle                 = "action.devices.types.KETTLE"
+	DeviceTypeLight                  = "action.devices.types.LIGHT"
+	DeviceTypeLock                   = "action.devices.types.LOCK"
+	DeviceTypeMicrowave              = "action.devices.types.MICROWAVE"
+	DeviceTypeMop                    = "action.devices.types.MOP"
+	DeviceTypeMower                  = "action.devices.types.MOWER"
+	DeviceTypeMulticooker            = "action.devices.types.MULTICOOKER"
+	DeviceTypeNetwork                = "action.devices.types.NETWORK"
+	DeviceTypeOutlet                 = "action.devices.types.OUTLET"
+	DeviceTypeOven                   = "action.devices.types.OVEN"
+	DeviceTypePergola                = "action.devices.types.PERGOLA"
+	DeviceTypePetFeeder              = "action.devices.types.PETFEEDER"
+	DeviceTypePressureCooker         = "action.devices.types.PRESSURECOOKER"
+	DeviceTypeRadiator               = "action.devices.types.RADIATOR"
+	DeviceTypeRefrigerator           = "action.devices.types.REFRIGERATOR"
+	DeviceTypeRemoteControl          = "action.devices.types.REMOTECONTROL"
+	DeviceTypeRouter                 = "action.devices.types.ROUTER"
+	DeviceTypeScene                  = "action.devices.types.SCENE"
+	DeviceTypeSecuritySystem         = "action.devices.types.SECURITYSYSTEM"
+	DeviceTypeSensor                 = "action.devices.types.SENSOR"
+	DeviceTypeSetTop                 = "action.devices.types.SETTOP"
+	DeviceTypeShower                 = "action.devices.types.SHOWER"
+	DeviceTypeShutter                = "action.devices.types.SHUTTER"
+	DeviceTypeSmokeDetector          = "action.devices.types.SMOKE_DETECTOR"
+	DeviceTypeSoundbar               = "action.devices.types.SOUNDBAR"
+	DeviceTypeSousVide               = "action.devices.types.SOUSVIDE"
+	DeviceTypeSpeaker                = "action.devices.types.SPEAKER"
+	DeviceTypeSprinkler              = "action.devices.types.SPRINKLER"
+	DeviceTypeStandMixer             = "action.devices.types.STANDMIXER"
+	DeviceTypeStreamingBox           = "action.devices.types.STREAMING_BOX"
+	DeviceTypeStreamingSoundbar      = "action.devices.types.STREAMING_SOUNDBAR"
+	DeviceTypeStreamingStick         = "action.devices.types.STREAMING_STICK"
+	DeviceTypeSwitch                 = "action.devices.types.SWITCH"
+	DeviceTypeThermostat             = "action.devices.types.THERMOSTAT"
+	DeviceTypeTV                     = "action.devices.types.TV"
+	DeviceTypeVacuum                 = "action.devices.types.VACUUM"
+	DeviceTypeValve                  = "action.devices.types.VALVE"
+	DeviceTypeWasher                 = "action.devices.types.WASHER"
+	DeviceTypeWaterHeater            = "action.devices.types.WATERHEATER"
+	DeviceTypeWaterPurifier          = "action.devices.types.WATERPURIFIER"
+	DeviceTypeWaterSoftener          = "action.devices.types.WATERSOFTENER"
+	DeviceTypeWindow                 = "action.devices.types.WINDOW"
+	DeviceTypeYogurtMaker            = "action.devices.types.YOGURTMAKER"
+)
+
+var validDeviceTypes = map[string]bool{
+	DeviceTypeACUnit:                 true,
+	DeviceTypeAirCooler:              true,
+	DeviceTypeAirFreshener:           true,
+	DeviceTypeAirPurifier:            true,
+	DeviceTypeAudioVideoReceiver:     true,
+	DeviceTypeAwning:                 true,
+	DeviceTypeBathtub:                true,
+	DeviceTypeBed:                    true,
+	DeviceTypeBlender:                true,
+	DeviceTypeBlinds:                 true,
+	DeviceTypeBoiler:                 true,
+	DeviceTypeCamera:                 true,
+	DeviceTypeCarbonMonoxideDetector: true,
+	DeviceTypeCharger:                true,
+	DeviceTypeCloset:                 true,
+	DeviceTypeCoffeeMaker:            true,
+	DeviceTypeCooktop:                true,
+	DeviceTypeCurtain:                true,
+	DeviceTypeDehumidifier:           true,
+	DeviceTypeDehydrator:             true,
+	DeviceTypeDishwasher:             true,
+	DeviceTypeDoor:                   true,
+	DeviceTypeDoorbell:               true,
+	DeviceTypeDrawer:                 true,
+	DeviceTypeDryer:                  true,
+	DeviceTypeFan:                    true,
+	DeviceTypeFaucet:                 true,
+	DeviceTypeFireplace:              true,
+	DeviceTypeFreezer:                true,
+	DeviceTypeFryer:                  true,
+	DeviceTypeGameConsole:            true,
+	DeviceTypeGarage:                 true,
+	DeviceTypeGate:                   true,
+	DeviceTypeGrill:                  true,
+	DeviceTypeHeater:                 true,
+	DeviceTypeHood:                   true,
+	DeviceTypeHumidifier:             true,
+	DeviceTypeKettle:                 true,
+	DeviceTypeLight:                  true,
+	DeviceTypeLock:                   true,
+	DeviceTypeMicrowave:              true,
+	DeviceTypeMop:                    true,
+	DeviceTypeMower:                  true,
+	DeviceTypeMulticooker:            true,
+	DeviceTypeNetwork:                true,
+	DeviceTypeOutlet:                 true,
+	DeviceTypeOven:                   true,
+	DeviceTypePergola:                true,
+	DeviceTypePetFeeder:              true,
+	DeviceTypePressureCooker:         true,
+	DeviceTypeRadiator:               true,
+	DeviceTypeRefrigerator:           true,
+	DeviceTypeRemoteControl:          true,
+	DeviceTypeRouter:                 true,
+	DeviceTypeScene:                  true,
+	DeviceTypeSecuritySystem:         true,
+	DeviceTypeSensor:                 true,
+	DeviceTypeSetTop:                 true,
+	DeviceTypeShower:                 true,
+	DeviceTypeShutter:                true,
+	DeviceTypeSmokeDetector:          true,
+	DeviceTypeSoundbar:               true,
+	DeviceTypeSousVide:               true,
+	DeviceTypeSpeaker:                true,
+	DeviceTypeSprinkler:              true,
+	DeviceTypeStandMixer:             true,
+	DeviceTypeStreamingBox:           true,
+	DeviceTypeStreamingSoundbar:      true,
+	DeviceTypeStreamingStick:         true,
+	DeviceTypeSwitch:                 true,
+	DeviceTypeThermostat:             true,
+	DeviceTypeTV:                     true,
+	DeviceTypeVacuum:                 true,
+	DeviceTypeValve:                  true,
+	DeviceTypeWasher:                 true,
+	DeviceTypeWaterHeater:            true,
+	DeviceTypeWaterPurifier:          true,
+	DeviceTypeWaterSoftener:          true,
+	DeviceTypeWindow:                 true,
+	DeviceTypeYogurtMaker:            true,
+}
+
+// IsValidDeviceType checks whether the supplied type is one of the device types supported by Google.
+func IsValidDeviceType(typ string) bool {
+	return validDeviceTypes[typ]
+}
@@ -0,0 +1,14 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidDeviceType(t *testing.T) {
+	assert.True(t, IsValidDeviceType(DeviceTypeLight))
+	assert.True(t, IsValidDeviceType("action.devices.types.THERMOSTAT"))
+	assert.False(t, IsValidDeviceType("action.devices.types.TOASTER"))
+	assert.False(t, IsValidDeviceType("LIGHT"))
+}
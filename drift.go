@@ -0,0 +1,199 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/homegraph/v1"
+)
+
+var (
+	// ErrQueryFailed is returned if the request to HomeGraph to retrieve the stored device states failed.
+	// The log will contain more information about what occurred.
+	ErrQueryFailed = errors.New("query failed")
+)
+
+// StateDrift describes how the state stored in the HomeGraph differs from the local state of a device.
+// Local and Remote contain the values of each differing state key (including "online").
+// A key which is missing from Remote is not stored in the HomeGraph at all.
+type StateDrift struct {
+	Local  map[string]interface{}
+	Remote map[string]interface{}
+}
+
+// LocalStateFunc returns the current local state of each of the user's devices, indexed by device ID.
+type LocalStateFunc func(ctx context.Context, agentUserID string) (map[string]DeviceState, error)
+
+// QueryHomeGraph retrieves the states the HomeGraph has stored for the specified devices of the user, indexed by device ID.
+// This is the state Google will use to answer questions about the device if it doesn't issue a QUERY intent.
+func (s *Service) QueryHomeGraph(ctx context.Context, agentUserID string, deviceIDs ...string) (map[string]map[string]interface{}, error) {
+	hgRequestID := s.newID()
+	hgReq := &homegraph.QueryRequest{
+		AgentUserId: agentUserID,
+		RequestId:   hgRequestID,
+		Inputs: []*homegraph.QueryRequestInput{
+			{
+				Payload: &homegraph.QueryRequestPayload{},
+			},
+		},
+	}
+	for _, deviceID := range deviceIDs {
+		hgReq.Inputs[0].Payload.Devices = append(hgReq.Inputs[0].Payload.Devices, &homegraph.AgentDeviceId{
+			Id: deviceID,
+		})
+	}
+
+	if err := s.checkHomeGraphCircuit("query", hgRequestID); err != nil {
+		return nil, err
+	}
+
+	call := s.deviceService.Query(hgReq)
+	s.setHomeGraphHeaders(call.Header())
+	call.Context(ctx)
+	resp, err := call.Do()
+	if err != nil {
+		s.recordHomeGraphCall(ctx, "query", agentUserID, 0, err)
+		s.logger.Info("error querying homegraph",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return nil, &HomeGraphError{
+			Method:    "query",
+			RequestID: hgRequestID,
+			Err:       classifyHomeGraphError(err),
+		}
+	}
+	s.recordHomeGraphCall(ctx, "query", agentUserID, resp.ServerResponse.HTTPStatusCode, nil)
+	if resp.ServerResponse.HTTPStatusCode != http.StatusOK {
+		s.logger.Info("failed query homegraph",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.Int("status_code", resp.ServerResponse.HTTPStatusCode),
+		)
+		return nil, &HomeGraphError{
+			Method:     "query",
+			RequestID:  hgRequestID,
+			StatusCode: resp.ServerResponse.HTTPStatusCode,
+			Err:        ErrQueryFailed,
+		}
+	}
+
+	states := map[string]map[string]interface{}{}
+	if resp.Payload == nil {
+		return states, nil
+	}
+	for deviceID, rawState := range resp.Payload.Devices {
+		state := map[string]interface{}{}
+		if err := json.Unmarshal(rawState, &state); err != nil {
+			return nil, err
+		}
+		states[deviceID] = state
+	}
+	return states, nil
+}
+
+// DetectDrift compares the supplied local device states against the states stored in the HomeGraph,
+// returning the differences for each device whose state has drifted, indexed by device ID.
+// Only the state keys present in the local state are compared.
+func (s *Service) DetectDrift(ctx context.Context, agentUserID string, localStates map[string]DeviceState) (map[string]StateDrift, error) {
+	var deviceIDs []string
+	for deviceID := range localStates {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+
+	remoteStates, err := s.QueryHomeGraph(ctx, agentUserID, deviceIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := map[string]StateDrift{}
+	for _, deviceID := range deviceIDs {
+		// Round-trip the local state through JSON so the values have the same types as those decoded from the HomeGraph.
+		localJSON, err := localStates[deviceID].MarshalForReportState()
+		if err != nil {
+			return nil, err
+		}
+		local := map[string]interface{}{}
+		if err := json.Unmarshal(localJSON, &local); err != nil {
+			return nil, err
+		}
+
+		remote := remoteStates[deviceID]
+		drift := StateDrift{
+			Local:  map[string]interface{}{},
+			Remote: map[string]interface{}{},
+		}
+		for k, localValue := range local {
+			remoteValue, found := remote[k]
+			if found && reflect.DeepEqual(localValue, remoteValue) {
+				continue
+			}
+			drift.Local[k] = localValue
+			if found {
+				drift.Remote[k] = remoteValue
+			}
+		}
+		if len(drift.Local) > 0 {
+			drifts[deviceID] = drift
+		}
+	}
+	return drifts, nil
+}
+
+// CorrectDrift detects the devices whose state has drifted using DetectDrift and reports the local state of each of them.
+// The detected drift is returned, even if reporting the states failed.
+func (s *Service) CorrectDrift(ctx context.Context, agentUserID string, localStates map[string]DeviceState) (map[string]StateDrift, error) {
+	drifts, err := s.DetectDrift(ctx, agentUserID, localStates)
+	if err != nil || len(drifts) < 1 {
+		return drifts, err
+	}
+
+	drifted := map[string]DeviceState{}
+	for deviceID := range drifts {
+		drifted[deviceID] = localStates[deviceID]
+	}
+	return drifts, s.ReportState(ctx, agentUserID, drifted)
+}
+
+// RunDriftCorrection calls CorrectDrift for each of the users every interval, using the local states supplied by localStates.
+// It blocks until the context is cancelled, so it should be run on its own goroutine.
+// Failures are logged and the users are checked again at the next interval.
+func (s *Service) RunDriftCorrection(ctx context.Context, interval time.Duration, agentUserIDs []string, localStates LocalStateFunc) {
+	s.runEvery(ctx, interval, func() {
+		for _, agentUserID := range agentUserIDs {
+			states, err := localStates(ctx, agentUserID)
+			if err != nil {
+				s.logger.Info("error retrieving local states for drift correction",
+					zap.String("agent_user_id", agentUserID),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			drifts, err := s.CorrectDrift(ctx, agentUserID, states)
+			if err != nil {
+				s.logger.Info("error correcting drift",
+					zap.String("agent_user_id", agentUserID),
+					zap.Error(err),
+				)
+				continue
+			}
+			if len(drifts) > 0 {
+				s.logger.Info("corrected drifted device states",
+					zap.String("agent_user_id", agentUserID),
+					zap.Int("device_count", len(drifts)),
+				)
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newDriftTestHomeGraph() *testHomeGraph {
+	return &testHomeGraph{
+		responses: map[string]string{
+			"/v1/devices:query": `{
+				"requestId": "1",
+				"payload": {
+					"devices": {
+						"light-1": {"online": true, "on": true, "brightness": 40},
+						"light-2": {"online": true, "on": false}
+					}
+				}
+			}`,
+		},
+	}
+}
+
+func TestServiceDetectDrift(t *testing.T) {
+	thg := newDriftTestHomeGraph()
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	drifts, err := svc.DetectDrift(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true).RecordBrightness(40),
+		"light-2": NewDeviceState(true).RecordOnOff(true),
+		"light-3": NewDeviceState(false),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:query"}, thg.paths)
+	assert.Contains(t, thg.bodies[0], `"devices":[{"id":"light-1"},{"id":"light-2"},{"id":"light-3"}]`)
+
+	assert.Len(t, drifts, 2)
+	assert.Equal(t, StateDrift{
+		Local:  map[string]interface{}{"on": true},
+		Remote: map[string]interface{}{"on": false},
+	}, drifts["light-2"])
+	assert.Equal(t, StateDrift{
+		Local:  map[string]interface{}{"online": false},
+		Remote: map[string]interface{}{},
+	}, drifts["light-3"])
+}
+
+func TestServiceCorrectDrift(t *testing.T) {
+	thg := newDriftTestHomeGraph()
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	drifts, err := svc.CorrectDrift(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true).RecordBrightness(40),
+		"light-2": NewDeviceState(true).RecordOnOff(true),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, drifts, 1)
+	assert.Equal(t, []string{"/v1/devices:query", "/v1/devices:reportStateAndNotification"}, thg.paths)
+	assert.Contains(t, thg.bodies[1], "light-2")
+	assert.NotContains(t, thg.bodies[1], "light-1")
+}
+
+func TestServiceRunDriftCorrection(t *testing.T) {
+	thg := newDriftTestHomeGraph()
+	clock := newTestClock(time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan string, 2)
+	done := make(chan struct{})
+	go func() {
+		svc.RunDriftCorrection(ctx, time.Minute, []string{"agent-id"}, func(_ context.Context, agentUserID string) (map[string]DeviceState, error) {
+			calls <- agentUserID
+			return map[string]DeviceState{
+				"light-2": NewDeviceState(true).RecordOnOff(true),
+			}, nil
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		clock.waitForTimer(t)
+		clock.Advance(time.Minute)
+		assert.Equal(t, "agent-id", <-calls)
+	}
+	cancel()
+	<-done
+	assert.Empty(t, calls)
+}
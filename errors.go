@@ -0,0 +1,108 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorCode defines the error codes which can be returned to Google when an intent fails.
+// See https://developers.google.com/assistant/smarthome/reference/errors-exceptions for the full list of error codes.
+const (
+	ErrorCodeActionNotAvailable = "actionNotAvailable"
+	ErrorCodeAuthExpired        = "authExpired"
+	ErrorCodeAuthFailure        = "authFailure"
+	ErrorCodeDeviceNotFound     = "deviceNotFound"
+	ErrorCodeDeviceOffline      = "deviceOffline"
+	ErrorCodeNotSupported       = "notSupported"
+	ErrorCodeProtocolError      = "protocolError"
+	ErrorCodeRelinkRequired     = "relinkRequired"
+	ErrorCodeTimeout            = "timeout"
+	ErrorCodeTransientError     = "transientError"
+	ErrorCodeUnknownError       = "unknownError"
+)
+
+var (
+	// ErrAuthExpired may be returned by an AccessTokenValidator if the supplied token has expired.
+	// Google is informed using authExpired and a 401 status code so the token is refreshed.
+	ErrAuthExpired = errors.New("auth expired")
+	// ErrRelinkRequired may be returned by an AccessTokenValidator if the account link has been revoked and can't be refreshed.
+	// Google is informed using relinkRequired so the user is asked to link their account again.
+	ErrRelinkRequired = errors.New("relink required")
+)
+
+// IntentError is an error which can be returned by the Provider to fail an entire intent with a specific error code.
+// The error code is returned to Google in the response payload alongside the specified HTTP status code.
+// Errors which are not an IntentError cause the intent to fail with a 503 status code.
+type IntentError struct {
+	ErrorCode   string
+	DebugString string
+	StatusCode  int
+
+	Err error
+}
+
+// Error returns the error code along with the wrapped error, if any.
+func (e *IntentError) Error() string {
+	if e.Err == nil {
+		return e.ErrorCode
+	}
+	return fmt.Sprintf("%s: %s", e.ErrorCode, e.Err.Error())
+}
+
+// Unwrap returns the wrapped error.
+func (e *IntentError) Unwrap() error {
+	return e.Err
+}
+
+// NewIntentError wraps the supplied error so the intent fails with the specified error code and a 200 status code.
+// The wrapped error is logged but not returned to Google; use DebugString to supply developer-facing details.
+func NewIntentError(errorCode string, err error) *IntentError {
+	return &IntentError{
+		ErrorCode:  errorCode,
+		StatusCode: http.StatusOK,
+		Err:        err,
+	}
+}
+
+// NewTransientError wraps the supplied error so the intent fails with transientError.
+// This should be used if the failure is expected to be resolved by retrying.
+func NewTransientError(err error) *IntentError {
+	return NewIntentError(ErrorCodeTransientError, err)
+}
+
+// NewAuthExpiredError wraps the supplied error so the intent fails with authExpired and a 401 status code.
+// This causes Google to refresh the access token and retry.
+func NewAuthExpiredError(err error) *IntentError {
+	e := NewIntentError(ErrorCodeAuthExpired, err)
+	e.StatusCode = http.StatusUnauthorized
+	return e
+}
+
+// NewRelinkRequiredError wraps the supplied error so the intent fails with relinkRequired.
+// This causes Google to ask the user to link their account again.
+func NewRelinkRequiredError(err error) *IntentError {
+	return NewIntentError(ErrorCodeRelinkRequired, err)
+}
+
+// authIntentError converts an error returned by an AccessTokenValidator into the IntentError to report to Google, if any.
+// Errors which aren't an IntentError, ErrAuthExpired or ErrRelinkRequired are not converted.
+func authIntentError(err error) (*IntentError, bool) {
+	if intentErr, ok := asIntentError(err); ok {
+		return intentErr, true
+	} else if errors.Is(err, ErrAuthExpired) {
+		return NewAuthExpiredError(err), true
+	} else if errors.Is(err, ErrRelinkRequired) {
+		return NewRelinkRequiredError(err), true
+	}
+	return nil, false
+}
+
+// asIntentError returns the IntentError contained in the supplied error chain, if there is one.
+func asIntentError(err error) (*IntentError, bool) {
+	var intentErr *IntentError
+	if errors.As(err, &intentErr) {
+		return intentErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,149 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestIntentError(t *testing.T) {
+	cause := errors.New("backend unavailable")
+
+	err := NewTransientError(cause)
+	assert.Equal(t, ErrorCodeTransientError, err.ErrorCode)
+	assert.Equal(t, http.StatusOK, err.StatusCode)
+	assert.Equal(t, "transientError: backend unavailable", err.Error())
+	assert.True(t, errors.Is(err, cause))
+
+	err = NewAuthExpiredError(nil)
+	assert.Equal(t, ErrorCodeAuthExpired, err.ErrorCode)
+	assert.Equal(t, http.StatusUnauthorized, err.StatusCode)
+	assert.Equal(t, "authExpired", err.Error())
+
+	err = NewRelinkRequiredError(cause)
+	assert.Equal(t, ErrorCodeRelinkRequired, err.ErrorCode)
+	assert.Equal(t, http.StatusOK, err.StatusCode)
+}
+
+func TestGoogleFulfillmentHandlerProviderErrors(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+
+	debugErr := NewIntentError(ErrorCodeDeviceOffline, nil)
+	debugErr.DebugString = "hub unreachable"
+
+	for _, tt := range []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "plain error",
+			err:        errors.New("sync failed"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "Fail to sync",
+		},
+		{
+			name:       "transient error",
+			err:        NewTransientError(errors.New("sync failed")),
+			wantStatus: http.StatusOK,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"transientError"}}
+`,
+		},
+		{
+			name:       "wrapped auth expired error",
+			err:        fmt.Errorf("upstream: %w", NewAuthExpiredError(errors.New("token expired"))),
+			wantStatus: http.StatusUnauthorized,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"authExpired"}}
+`,
+		},
+		{
+			name:       "relink required error",
+			err:        NewRelinkRequiredError(errors.New("link revoked")),
+			wantStatus: http.StatusOK,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"relinkRequired"}}
+`,
+		},
+		{
+			name:       "debug string",
+			err:        debugErr,
+			wantStatus: http.StatusOK,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"deviceOffline","debugString":"hub unreachable"}}
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(logger, authenticator, &testProvider{syncErr: tt.err}, nil)
+
+			result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+				"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				"inputs": [{"intent": "action.devices.SYNC"}]
+			}`))
+			assert.Equal(t, tt.wantStatus, result.StatusCode)
+			assert.Equal(t, tt.wantBody, string(result.Body))
+		})
+	}
+}
+
+func TestGoogleFulfillmentHandlerValidatorErrors(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	for _, tt := range []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "invalid token",
+			err:        errors.New("unknown token"),
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Access Token Invalid",
+		},
+		{
+			name:       "expired token",
+			err:        fmt.Errorf("token issued yesterday: %w", ErrAuthExpired),
+			wantStatus: http.StatusUnauthorized,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"authExpired"}}
+`,
+		},
+		{
+			name:       "revoked link",
+			err:        ErrRelinkRequired,
+			wantStatus: http.StatusOK,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"relinkRequired"}}
+`,
+		},
+		{
+			name:       "intent error",
+			err:        NewIntentError(ErrorCodeAuthFailure, nil),
+			wantStatus: http.StatusOK,
+			wantBody: `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"authFailure"}}
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := &testAuthenticator{
+				validateErr: tt.err,
+			}
+			svc := NewService(logger, authenticator, &testProvider{}, nil)
+
+			result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+				"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				"inputs": [{"intent": "action.devices.SYNC"}]
+			}`))
+			assert.Equal(t, tt.wantStatus, result.StatusCode)
+			assert.Equal(t, tt.wantBody, string(result.Body))
+		})
+	}
+}
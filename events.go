@@ -0,0 +1,150 @@
+package action
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is implemented by each of the events published by the Service.
+// Subscribers should use a type switch to handle the events they are interested in.
+type Event interface {
+	// EventName returns the name of the event type (i.e. "IntentReceived").
+	EventName() string
+}
+
+// IntentReceived is published when an authenticated request is received from Google, before it is processed.
+type IntentReceived struct {
+	Time        time.Time
+	RequestID   string
+	AgentUserID string
+	Intent      string
+}
+
+// EventName returns the name of this event.
+func (IntentReceived) EventName() string { return "IntentReceived" }
+
+// SyncServed is published after the devices have been returned to Google in response to SYNC.
+type SyncServed struct {
+	Time        time.Time
+	RequestID   string
+	AgentUserID string
+	DeviceIDs   []string
+}
+
+// EventName returns the name of this event.
+func (SyncServed) EventName() string { return "SyncServed" }
+
+// ExecuteCompleted is published after the results of an EXECUTE have been returned to Google.
+type ExecuteCompleted struct {
+	Time        time.Time
+	RequestID   string
+	AgentUserID string
+	Results     []ExecuteCommandResult
+}
+
+// EventName returns the name of this event.
+func (ExecuteCompleted) EventName() string { return "ExecuteCompleted" }
+
+// ExecuteDeduplicated is published when a retried EXECUTE is answered with the response to the original request,
+// rather than being passed to the provider. See WithExecuteIdempotency.
+type ExecuteDeduplicated struct {
+	Time        time.Time
+	RequestID   string
+	AgentUserID string
+}
+
+// EventName returns the name of this event.
+func (ExecuteDeduplicated) EventName() string { return "ExecuteDeduplicated" }
+
+// ReportStateSent is published after a call to ReportState completes. Err is set if any of the states were invalid,
+// in which case none were reported, or if any devices failed to be reported.
+type ReportStateSent struct {
+	Time        time.Time
+	AgentUserID string
+	DeviceIDs   []string
+	Err         error
+}
+
+// EventName returns the name of this event.
+func (ReportStateSent) EventName() string { return "ReportStateSent" }
+
+// RequestSyncTriggered is published after a call to RequestSync completes. Err is set if the request failed.
+type RequestSyncTriggered struct {
+	Time        time.Time
+	AgentUserID string
+	Err         error
+}
+
+// EventName returns the name of this event.
+func (RequestSyncTriggered) EventName() string { return "RequestSyncTriggered" }
+
+// DeviceWentOffline is published when a device is flipped offline because it hasn't been seen within the liveness timeout.
+type DeviceWentOffline struct {
+	Time        time.Time
+	AgentUserID string
+	DeviceID    string
+	LastSeen    time.Time
+}
+
+// EventName returns the name of this event.
+func (DeviceWentOffline) EventName() string { return "DeviceWentOffline" }
+
+// DeviceRemoved is published when a device is removed using DeviceManager.Remove.
+type DeviceRemoved struct {
+	Time        time.Time
+	AgentUserID string
+	DeviceID    string
+}
+
+// EventName returns the name of this event.
+func (DeviceRemoved) EventName() string { return "DeviceRemoved" }
+
+// EventListener is invoked for each event published by the Service.
+// Listeners are called synchronously on the goroutine which generated the event so they must not block;
+// any long-running processing should be handed off to another goroutine.
+type EventListener func(Event)
+
+// eventBus tracks the set of subscribed listeners.
+type eventBus struct {
+	mu        sync.RWMutex
+	nextID    int
+	listeners map[int]EventListener
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		listeners: map[int]EventListener{},
+	}
+}
+
+// publish invokes each listener with the event. The lock isn't held while the listeners run,
+// so a listener may subscribe or unsubscribe without deadlocking.
+func (eb *eventBus) publish(event Event) {
+	eb.mu.RLock()
+	listeners := make([]EventListener, 0, len(eb.listeners))
+	for _, listener := range eb.listeners {
+		listeners = append(listeners, listener)
+	}
+	eb.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// Subscribe registers a listener which is invoked for every event published by the service.
+// The returned function removes the listener; it is safe to call more than once.
+func (s *Service) Subscribe(listener EventListener) func() {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+
+	id := s.events.nextID
+	s.events.nextID++
+	s.events.listeners[id] = listener
+
+	return func() {
+		s.events.mu.Lock()
+		defer s.events.mu.Unlock()
+		delete(s.events.listeners, id)
+	}
+}
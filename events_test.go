@@ -0,0 +1,106 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceSubscribe(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("outlet-1")},
+	}
+	svc := NewService(logger, authenticator, provider, newTestHomeGraphService(t, thg))
+
+	var events []Event
+	unsubscribe := svc.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`))
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	result = svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+				"commands": [{
+					"devices": [{"id": "outlet-1"}],
+					"execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+				}]
+			}
+		}]
+	}`))
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	assert.Nil(t, svc.ReportState(context.Background(), "1836.15267389", map[string]DeviceState{
+		"outlet-1": NewDeviceState(true).RecordOnOff(true),
+	}))
+	assert.Nil(t, svc.RequestSync(context.Background(), "1836.15267389"))
+
+	var names []string
+	for _, event := range events {
+		names = append(names, event.EventName())
+	}
+	assert.Equal(t, []string{
+		"IntentReceived",
+		"SyncServed",
+		"IntentReceived",
+		"ExecuteCompleted",
+		"ReportStateSent",
+		"RequestSyncTriggered",
+	}, names)
+
+	served := events[1].(SyncServed)
+	assert.Equal(t, "1836.15267389", served.AgentUserID)
+	assert.Equal(t, []string{"outlet-1"}, served.DeviceIDs)
+
+	sent := events[4].(ReportStateSent)
+	assert.Equal(t, []string{"outlet-1"}, sent.DeviceIDs)
+	assert.Nil(t, sent.Err)
+
+	unsubscribe()
+	unsubscribe()
+	assert.Nil(t, svc.RequestSync(context.Background(), "1836.15267389"))
+	assert.Len(t, events, 6)
+}
+
+func TestServiceSubscribeUnsubscribeFromListener(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, &testHomeGraph{}))
+
+	var events []Event
+	var unsubscribe func()
+	unsubscribe = svc.Subscribe(func(event Event) {
+		events = append(events, event)
+		unsubscribe()
+	})
+
+	// States which can't be serialized are still reported as an event.
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true),
+		"light-2": {State: map[string]interface{}{"on": make(chan int)}},
+	})
+	assert.True(t, errors.Is(err, ErrStateSerialization))
+	assert.Nil(t, svc.RequestSync(context.Background(), "agent-id"))
+
+	assert.Len(t, events, 1)
+	sent := events[0].(ReportStateSent)
+	assert.Equal(t, []string{"light-1", "light-2"}, sent.DeviceIDs)
+	assert.Equal(t, err, sent.Err)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	action "github.com/rmrobinson/google-smart-home-action-go"
 	"go.uber.org/zap"
 )
 
@@ -41,7 +42,10 @@ func (a *auth0Authenticator) Validate(ctx context.Context, token string) (string
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Let Google know to refresh the token.
+		return "", action.ErrAuthExpired
+	} else if resp.StatusCode != http.StatusOK {
 		return "", nil
 	}
 
@@ -68,3 +72,12 @@ func (a *auth0Authenticator) Validate(ctx context.Context, token string) (string
 
 	return respPayload.Sub, nil
 }
+
+func (a *auth0Authenticator) RevokeTokens(_ context.Context, userID string) error {
+	for token, tokenUserID := range a.tokens {
+		if tokenUserID == userID {
+			delete(a.tokens, token)
+		}
+	}
+	return nil
+}
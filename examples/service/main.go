@@ -2,15 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"flag"
 	"log"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
 	action "github.com/rmrobinson/google-smart-home-action-go"
+	"github.com/rmrobinson/google-smart-home-action-go/demoprovider"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/api/homegraph/v1"
@@ -23,6 +23,8 @@ func main() {
 		letsEncryptHost = flag.String("letsencrypt-host", "", "The host name that LetsEncrypt will generate the cert for")
 		agentUserID     = flag.String("agent-user-id", "", "The HomeGraph account user ID to synchronize state with")
 		credsFile       = flag.String("creds-file", "", "The Google Service Account key file path")
+		tickInterval    = flag.Duration("tick-interval", time.Minute, "How often the virtual devices change state on their own")
+		debugKey        = flag.String("debug-key", "", "If set, the debug console is exposed to requests supplying this value in the X-Debug-Key header")
 	)
 	flag.Parse()
 
@@ -37,51 +39,8 @@ func main() {
 		tokens: map[string]string{},
 	}
 
-	// Setup the 'mock' service
-	es := &echoService{
-		logger: logger,
-		lights: map[string]lightbulb{
-			"123": {
-				"123",
-				"test light 1",
-				false,
-				40,
-				struct {
-					hue        float64
-					saturation float64
-					value      float64
-				}{
-					100,
-					100,
-					10,
-				},
-			},
-			"456": {
-				"456",
-				"test light 2",
-				false,
-				40,
-				struct {
-					hue        float64
-					saturation float64
-					value      float64
-				}{
-					100,
-					100,
-					10,
-				},
-			},
-		},
-		receiver: receiver{
-			"789",
-			"test receiver",
-			false,
-			20,
-			false,
-			"input_1",
-		},
-		agentID: *agentUserID,
-	}
+	// Setup the virtual devices
+	dp := demoprovider.New(logger)
 
 	// Setup Google Assistant info
 	ctx := context.Background()
@@ -92,21 +51,23 @@ func main() {
 		)
 	}
 
-	svc := action.NewService(logger, auth, es, hgService)
-	es.service = svc
+	var opts []action.ServiceOption
+	if len(*debugKey) > 0 {
+		opts = append(opts, action.WithDebugRecorder(50))
+	}
+	svc := action.NewService(logger, auth, dp, hgService, opts...)
 
 	// Register callback from Google
 	http.HandleFunc(action.GoogleFulfillmentPath, svc.GoogleFulfillmentHandler)
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGUSR1)
-	go func() {
-		for {
-			<-c
-			logger.Debug("toggling light 1 via signal")
-			es.toggleLight1()
-		}
-	}()
+	if len(*debugKey) > 0 {
+		http.HandleFunc(action.DebugPath, svc.DebugHandler(func(r *http.Request) bool {
+			return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Debug-Key")), []byte(*debugKey)) == 1
+		}))
+	}
+
+	// Let the virtual devices change state on their own, reporting the changes to Google
+	go dp.Run(ctx, svc, *agentUserID, *tickInterval)
 
 	// Setup LetsEncrypt
 	certManager := autocert.Manager{
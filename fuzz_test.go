@@ -0,0 +1,114 @@
+//go:build go1.18
+// +build go1.18
+
+package action
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// addGoldenSeeds seeds the fuzzer with the individual messages contained in the golden corpus.
+func addGoldenSeeds(f *testing.F, extract func(data []byte) [][]byte) {
+	for _, name := range []string{"sync_response.json", "query_response.json", "execute_request.json"} {
+		data, err := ioutil.ReadFile(filepath.Join(goldenDir, name))
+		if err != nil {
+			f.Fatalf("unable to read golden file %s: %v", name, err)
+		}
+		for _, seed := range extract(data) {
+			f.Add(seed)
+		}
+	}
+}
+
+// fuzzRoundtrip ensures that anything which can be unmarshaled can also be marshaled and unmarshaled again.
+func fuzzRoundtrip(t *testing.T, data []byte, newMsg func() interface{}) {
+	msg := newMsg()
+	if err := json.Unmarshal(data, msg); err != nil {
+		return
+	}
+
+	serializedBytes, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("error marshaling %q: %v", data, err)
+	}
+	if err := json.Unmarshal(serializedBytes, newMsg()); err != nil {
+		t.Fatalf("error unmarshaling %q: %v", serializedBytes, err)
+	}
+}
+
+func FuzzCommandUnmarshalJSON(f *testing.F) {
+	addGoldenSeeds(f, func(data []byte) [][]byte {
+		var req struct {
+			Inputs []struct {
+				Payload struct {
+					Commands []struct {
+						Execution []json.RawMessage `json:"execution"`
+					} `json:"commands"`
+				} `json:"payload"`
+			} `json:"inputs"`
+		}
+		json.Unmarshal(data, &req)
+
+		var seeds [][]byte
+		for _, input := range req.Inputs {
+			for _, command := range input.Payload.Commands {
+				for _, execution := range command.Execution {
+					seeds = append(seeds, execution)
+				}
+			}
+		}
+		return seeds
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundtrip(t, data, func() interface{} { return &Command{} })
+	})
+}
+
+func FuzzDeviceUnmarshalJSON(f *testing.F) {
+	addGoldenSeeds(f, func(data []byte) [][]byte {
+		var resp struct {
+			Payload struct {
+				Devices []json.RawMessage `json:"devices"`
+			} `json:"payload"`
+		}
+		json.Unmarshal(data, &resp)
+		return toSeeds(resp.Payload.Devices)
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundtrip(t, data, func() interface{} { return &Device{} })
+	})
+}
+
+func FuzzDeviceStateUnmarshalJSON(f *testing.F) {
+	addGoldenSeeds(f, func(data []byte) [][]byte {
+		var resp struct {
+			Payload struct {
+				Devices map[string]json.RawMessage `json:"devices"`
+			} `json:"payload"`
+		}
+		json.Unmarshal(data, &resp)
+
+		var seeds [][]byte
+		for _, state := range resp.Payload.Devices {
+			seeds = append(seeds, state)
+		}
+		return seeds
+	})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundtrip(t, data, func() interface{} { return &DeviceState{} })
+	})
+}
+
+func toSeeds(msgs []json.RawMessage) [][]byte {
+	var seeds [][]byte
+	for _, msg := range msgs {
+		seeds = append(seeds, msg)
+	}
+	return seeds
+}
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// goldenDir contains real intent payloads, taken from the Google documentation and the Smart Home Test Suite.
+const goldenDir = "testdata/golden"
+
+func readGolden(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join(goldenDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGoldenRoundtrip(t *testing.T) {
+	for _, tt := range []struct {
+		file string
+		msg  func() interface{}
+	}{
+		{"sync_request.json", func() interface{} { return &FulfillmentRequest{} }},
+		{"sync_response.json", func() interface{} { return &SyncFulfillmentResponse{} }},
+		{"query_request.json", func() interface{} { return &FulfillmentRequest{} }},
+		{"query_response.json", func() interface{} { return &QueryFulfillmentResponse{} }},
+		{"execute_request.json", func() interface{} { return &FulfillmentRequest{} }},
+		{"execute_response.json", func() interface{} { return &ExecuteFulfillmentResponse{} }},
+		{"disconnect_request.json", func() interface{} { return &FulfillmentRequest{} }},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			golden := readGolden(t, tt.file)
+
+			msg := tt.msg()
+			if err := json.Unmarshal(golden, msg); err != nil {
+				t.Fatalf("error unmarshaling golden file: %v", err)
+			}
+
+			serializedBytes, err := json.Marshal(msg)
+			if err != nil {
+				t.Fatalf("error marshaling golden file: %v", err)
+			}
+			assert.JSONEq(t, string(golden), string(serializedBytes))
+		})
+	}
+}
+
+func TestGoldenExecuteRequestParsing(t *testing.T) {
+	req := &FulfillmentRequest{}
+	if err := json.Unmarshal(readGolden(t, "execute_request.json"), req); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := req.Inputs[0].Execute.Commands
+	assert.Len(t, commands, 3)
+	assert.True(t, commands[0].Execution[0].OnOff.On)
+	assert.Equal(t, 60, commands[1].Execution[0].BrightnessAbsolute.Brightness)
+	assert.Equal(t, 300.0, commands[1].Execution[1].ColorAbsolute.Color.HSV.Hue)
+	assert.Equal(t, "333222", commands[2].Execution[0].Challenge.Pin)
+	assert.Equal(t, "PLACEHOLDER", commands[2].Execution[0].FollowUpToken)
+}
@@ -1,337 +1,678 @@
 package action
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"strings"
+	"sort"
 
 	"go.uber.org/zap"
 )
 
+// Intent defines which operation Google is requesting be performed.
+// See https://developers.google.com/assistant/smarthome/reference/intent/sync for details on each intent.
+const (
+	IntentSync       = "action.devices.SYNC"
+	IntentQuery      = "action.devices.QUERY"
+	IntentExecute    = "action.devices.EXECUTE"
+	IntentDisconnect = "action.devices.DISCONNECT"
+)
+
+// intentHandlerFunc processes a single intent on behalf of the authenticated user, writing the response to w.
+type intentHandlerFunc func(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest)
+
+// defaultIntentHandlers returns the dispatch table for the intents this library handles.
+func (s *Service) defaultIntentHandlers() map[string]intentHandlerFunc {
+	return map[string]intentHandlerFunc{
+		IntentSync:       s.handleSync,
+		IntentQuery:      s.handleQuery,
+		IntentExecute:    s.handleExecute,
+		IntentDisconnect: s.handleDisconnect,
+	}
+}
+
+// IntentHandler processes an intent which is not handled by this library (i.e. action.devices.IDENTIFY).
+// The raw payload of the intent is supplied; the returned value is serialized as the payload of the response.
+// Returning an error will cause the request to fail.
+type IntentHandler func(ctx context.Context, agentUserID string, payload json.RawMessage) (interface{}, error)
+
+// RegisterIntentHandler causes requests for the named intent to be dispatched to the supplied handler instead of being rejected.
+// Registering a handler for one of the intents handled by this library replaces the built-in behaviour.
+// This is not safe to call while requests are being processed; handlers should be registered before the service is exposed.
+func (s *Service) RegisterIntentHandler(intent string, handler IntentHandler) {
+	s.intentHandlers[intent] = func(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+		payload, err := handler(r.Context(), agentUserID, req.Inputs[0].Payload)
+		if err != nil {
+			s.logger.Info("custom intent error",
+				requestIDField(r.Context()),
+				zap.String("intent", intent),
+				zap.Error(err),
+			)
+
+			s.writeProviderError(r.Context(), w, req, err, "Fail to process intent")
+			return
+		}
+
+		resp := &CustomFulfillmentResponse{
+			RequestID: req.RequestID,
+			Payload:   payload,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			s.logger.Info("error serializing after writing ok",
+				requestIDField(r.Context()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// SupportedIntents returns the names of the intents which this service is able to process, sorted by name.
+func (s *Service) SupportedIntents() []string {
+	var intents []string
+	for intent := range s.intentHandlers {
+		intents = append(intents, intent)
+	}
+	sort.Strings(intents)
+	return intents
+}
+
 // GoogleFulfillmentHandler must be registered on an HTTPS endpoint at the path specified by GoogleFulfillmentPath
 // This HTTPS endpoint needs to be registered on the Smart Home Actions fulfillment path.
 // See https://developers.google.com/assistant/smarthome/concepts/fulfillment-authentication or https://developers.google.com/assistant/smarthome/develop/process-intents for details.
+// If CORS handling is enabled preflight requests are answered here as well.
 func (s *Service) GoogleFulfillmentHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	if s.cors != nil && s.cors.handleCORS(w, r) {
+		return
+	}
+
+	r.Body = s.limitRequestBody(r.Body)
+
 	// Check if we have a valid request.
-	contentType := r.Header.Get("content-type")
-	if !strings.Contains(contentType, "application/json") {
+	if !s.contentTypePolicy.acceptsContentType(r.Header.Get("content-type")) {
 		w.WriteHeader(http.StatusUnsupportedMediaType)
 		w.Write([]byte("Request not JSON"))
 		return
 	}
 
-	authHeader := r.Header.Get("Authorization")
-	if len(authHeader) < 1 {
+	token, err := s.tokenExtractor.ExtractToken(r)
+	if errors.Is(err, ErrAccessTokenMissing) {
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte("Access Token Required"))
 		return
-	}
-
-	authTokenParts := strings.Split(authHeader, " ")
-	if len(authTokenParts) != 2 || strings.ToLower(authTokenParts[0]) != "bearer" {
+	} else if errors.Is(err, ErrAccessTokenNotBearer) {
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte("Access Token Must Be Bearer"))
 		return
+	} else if err != nil {
+		s.logger.Info("error extracting token",
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Access Token Invalid"))
+		return
 	}
 
-	userID, err := s.atValidator.Validate(r.Context(), authTokenParts[1])
+	principal, err := s.validatePrincipal(r.Context(), token)
 	if err != nil {
 		s.logger.Info("error validating token",
-			zap.String("token", authTokenParts[1]),
+			zap.String("token", token),
 			zap.Error(err),
 		)
+
+		if intentErr, ok := authIntentError(err); ok {
+			// The request ID is only used to correlate the response so a body which can't be parsed isn't an error here.
+			fulfillmentReq := &FulfillmentRequest{}
+			json.NewDecoder(r.Body).Decode(fulfillmentReq)
+
+			s.writeIntentError(ContextWithRequestID(r.Context(), fulfillmentReq.RequestID), w, fulfillmentReq.RequestID, intentErr)
+			return
+		}
+
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte("Access Token Invalid"))
 		return
 
 	}
 
+	userID := principal.UserID
 	if len(userID) < 1 {
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte("Access Token Invalid"))
 		return
 	}
+	r = r.WithContext(ContextWithPrincipal(r.Context(), principal))
 
 	// We have a valid request. Let's deserialize then do something with it.
 
-	fulfillmentReq := &fulfillmentRequest{}
-	err = json.NewDecoder(r.Body).Decode(fulfillmentReq)
+	fulfillmentReq := &FulfillmentRequest{}
+	var rawReq bytes.Buffer
+	body := io.Reader(r.Body)
+	if s.strictDecoding {
+		body = io.TeeReader(r.Body, &rawReq)
+	}
+	err = json.NewDecoder(body).Decode(fulfillmentReq)
 	if err != nil {
 		s.logger.Info("error deserializing body",
 			zap.Error(err),
 		)
 
+		if errors.Is(err, ErrRequestTooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte("Request Too Large"))
+			return
+		} else if errors.Is(err, ErrRequestReadTimeout) {
+			w.WriteHeader(http.StatusRequestTimeout)
+			w.Write([]byte("Request Read Timed Out"))
+			return
+		}
+
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("JSON Deserialization Failed"))
 		return
 	}
 
+	r = r.WithContext(ContextWithRequestID(r.Context(), fulfillmentReq.RequestID))
+
+	if s.strictDecoding {
+		if err := CheckFulfillmentRequestFields(rawReq.Bytes()); err != nil {
+			s.logger.Info("request contains unknown fields",
+				zap.String("request_id", fulfillmentReq.RequestID),
+				zap.Error(err),
+			)
+
+			s.writeIntentError(r.Context(), w, fulfillmentReq.RequestID, &IntentError{
+				ErrorCode:   ErrorCodeProtocolError,
+				DebugString: err.Error(),
+			})
+			return
+		}
+	}
+
 	if len(fulfillmentReq.Inputs) != 1 {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("Unsupported number of inputs"))
 		return
 	}
 
+	if s.maxRequestDevices > 0 && requestDeviceCount(fulfillmentReq.Inputs[0]) > s.maxRequestDevices {
+		s.logger.Info("request targets too many devices",
+			zap.String("request_id", fulfillmentReq.RequestID),
+			zap.Int("device_count", requestDeviceCount(fulfillmentReq.Inputs[0])),
+		)
+
+		s.writeIntentError(r.Context(), w, fulfillmentReq.RequestID, &IntentError{
+			ErrorCode:   ErrorCodeProtocolError,
+			DebugString: "request targets too many devices",
+		})
+		return
+	}
+
+	if s.customDataCodec != nil {
+		if err := s.openCustomData(&fulfillmentReq.Inputs[0]); err != nil {
+			s.logger.Info("error opening custom data",
+				zap.String("request_id", fulfillmentReq.RequestID),
+				zap.Error(err),
+			)
+
+			s.writeIntentError(r.Context(), w, fulfillmentReq.RequestID, &IntentError{
+				ErrorCode:   ErrorCodeProtocolError,
+				DebugString: "invalid custom data",
+			})
+			return
+		}
+	}
+
+	s.debug.recordIntent(fulfillmentReq.RequestID, userID, fulfillmentReq.Inputs[0].Intent)
+	s.events.publish(IntentReceived{
+		Time:        s.now(),
+		RequestID:   fulfillmentReq.RequestID,
+		AgentUserID: userID,
+		Intent:      fulfillmentReq.Inputs[0].Intent,
+	})
+
 	// Actually do something and get the response
 	s.logger.Debug("processing intent",
 		zap.String("request_id", fulfillmentReq.RequestID),
 		zap.String("intent", fulfillmentReq.Inputs[0].Intent),
 	)
 
-	switch fulfillmentReq.Inputs[0].Intent {
-	case "action.devices.SYNC":
-		pSyncResp, err := s.provider.Sync(r.Context(), userID)
-		if err != nil {
-			s.logger.Info("sync error",
+	handler, found := s.intentHandlers[fulfillmentReq.Inputs[0].Intent]
+	if found {
+		handler(w, r, userID, fulfillmentReq)
+		return
+	}
+
+	s.logger.Info("unsupported intent name specified",
+		zap.String("request_id", fulfillmentReq.RequestID),
+		zap.String("intent", fulfillmentReq.Inputs[0].Intent),
+	)
+
+	if s.unknownIntentPolicy == UnknownIntentNotSupported {
+		s.writeIntentError(r.Context(), w, fulfillmentReq.RequestID, &IntentError{
+			ErrorCode:   ErrorCodeNotSupported,
+			DebugString: "unsupported intent " + fulfillmentReq.Inputs[0].Intent,
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte("Unsupported intent name specified"))
+}
+
+// writeProviderError writes the response for an error returned while processing an intent.
+// If the error is an IntentError its error code is returned to Google in the payload;
+// otherwise the request fails with a 503 and the supplied message.
+func (s *Service) writeProviderError(ctx context.Context, w http.ResponseWriter, req *FulfillmentRequest, err error, msg string) {
+	intentErr, ok := asIntentError(err)
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(msg))
+		return
+	}
+
+	s.writeIntentError(ctx, w, req.RequestID, intentErr)
+}
+
+// writeIntentError reports the error code contained in the supplied error to Google.
+func (s *Service) writeIntentError(ctx context.Context, w http.ResponseWriter, requestID string, intentErr *IntentError) {
+	resp := &ErrorFulfillmentResponse{
+		RequestID: requestID,
+	}
+	resp.Payload.ErrorCode = s.checkErrorCode(ctx, intentErr.ErrorCode)
+	resp.Payload.DebugString = intentErr.DebugString
+	s.logErrorCode(ctx, resp.Payload.ErrorCode)
+
+	statusCode := intentErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		s.logger.Info("error serializing after writing error",
+			requestIDField(ctx),
+			zap.Error(err),
+		)
+	}
+}
+
+// handleSync returns the full set of devices supplied by the provider.
+func (s *Service) handleSync(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+	pSyncResp, err := s.provider.Sync(r.Context(), agentUserID)
+	if err != nil {
+		s.logger.Info("sync error",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+
+		s.writeProviderError(r.Context(), w, req, err, "Fail to sync")
+		return
+	}
+
+	for _, device := range pSyncResp.Devices {
+		if err := device.Validate(); err != nil {
+			s.logger.Info("sync returned invalid device",
+				requestIDField(r.Context()),
 				zap.Error(err),
 			)
 
-			// TODO: clean this up possibly using better error handling.
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("Fail to sync"))
+			s.writeProviderError(r.Context(), w, req, err, "Fail to sync")
 			return
 		}
+	}
 
-		syncResp := &syncResponse{
-			RequestID: fulfillmentReq.RequestID,
+	syncResp := &SyncFulfillmentResponse{
+		RequestID: req.RequestID,
+	}
+	syncResp.Payload.UserID = agentUserID
+	syncResp.Payload.Devices = s.devices.syncDevices(r.Context(), agentUserID, pSyncResp)
+	if s.normalizeSync {
+		devices := syncResp.Payload.Devices
+		syncResp.Payload.Devices = nil
+		for _, device := range devices {
+			normalized := *device
+			syncResp.Payload.Devices = append(syncResp.Payload.Devices, normalized.Normalize())
 		}
-		syncResp.Payload.UserID = userID
-		syncResp.Payload.Devices = pSyncResp.Devices
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(syncResp)
+	}
+	if s.customDataCodec != nil {
+		syncResp.Payload.Devices, err = s.sealCustomData(syncResp.Payload.Devices)
 		if err != nil {
-			s.logger.Info("error serializing after writing ok",
+			s.logger.Info("error sealing custom data",
+				requestIDField(r.Context()),
 				zap.Error(err),
 			)
+
+			s.writeProviderError(r.Context(), w, req, err, "Fail to sync")
+			return
 		}
+	}
+	syncResp.Payload.ErrorCode = s.checkErrorCode(r.Context(), pSyncResp.ErrorCode)
+	syncResp.Payload.DebugString = pSyncResp.DebugString
+	s.truncateSync(syncResp)
+
+	if s.registry != nil {
+		s.registry.record(agentUserID, syncResp.Payload.Devices)
+	}
+	s.debug.recordSync(agentUserID, syncResp.Payload.Devices)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(syncResp)
+	if err != nil {
+		s.logger.Info("error serializing after writing ok",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+	}
+
+	served := SyncServed{
+		Time:        s.now(),
+		RequestID:   req.RequestID,
+		AgentUserID: agentUserID,
+	}
+	for _, device := range syncResp.Payload.Devices {
+		served.DeviceIDs = append(served.DeviceIDs, device.ID)
+	}
+	s.events.publish(served)
+}
+
+// handleQuery returns the current state of the requested devices as supplied by the provider.
+func (s *Service) handleQuery(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+	pQueryReq := &QueryRequest{
+		AgentID: agentUserID,
+	}
+	for _, device := range req.Inputs[0].Query.Devices {
+		pQueryReq.Devices = append(pQueryReq.Devices, DeviceArg{
+			ID:         device.ID,
+			CustomData: device.CustomData,
+		})
+	}
+
+	pQueryResp, err := s.provider.Query(r.Context(), pQueryReq)
+	if err != nil {
+		s.logger.Info("query error",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+
+		s.writeProviderError(r.Context(), w, req, err, "Fail to query")
 		return
-	case "action.devices.QUERY":
-		pQueryReq := &QueryRequest{
-			AgentID: userID,
+	}
+
+	queryResp := &QueryFulfillmentResponse{
+		RequestID: req.RequestID,
+	}
+	queryResp.Payload.ErrorCode = s.checkErrorCode(r.Context(), pQueryResp.ErrorCode)
+	queryResp.Payload.DebugString = pQueryResp.DebugString
+	queryResp.Payload.Devices = map[string]DeviceState{}
+	for deviceID, state := range pQueryResp.States {
+		if len(state.Status) < 1 {
+			state.Status = "SUCCESS"
 		}
-		for _, device := range fulfillmentReq.Inputs[0].Query.Devices {
-			pQueryReq.Devices = append(pQueryReq.Devices, DeviceArg{
-				ID:         device.ID,
-				CustomData: device.CustomData,
-			})
+		state.ErrorCode = s.checkErrorCode(r.Context(), state.ErrorCode)
+		if s.liveness != nil && s.liveness.isOffline(agentUserID, deviceID) {
+			state.Online = false
 		}
+		queryResp.Payload.Devices[deviceID] = state
+	}
+	if s.checkQueryCompleteness {
+		s.completeQueryResponse(req.RequestID, pQueryReq.Devices, queryResp.Payload.Devices)
+	}
+	if s.registry != nil {
+		devices, _ := s.registry.lookup(agentUserID)
+		s.warnCommandOnlyStates(r.Context(), agentUserID, devices, pQueryResp.States)
+	}
+	s.recordStateHistory(r.Context(), agentUserID, StateSourceQuery, queryResp.Payload.Devices)
 
-		pQueryResp, err := s.provider.Query(r.Context(), pQueryReq)
-		if err != nil {
-			s.logger.Info("query error",
-				zap.Error(err),
-			)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(queryResp)
+	if err != nil {
+		s.logger.Info("error serializing after writing ok",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+	}
+}
 
-			// TODO: clean this up possibly using better error handling.
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("Fail to query"))
-			return
-		}
+// handleExecute applies the requested commands using the provider and returns the results.
+// If WithExecuteIdempotency is set, retries of a request are answered with the original response.
+func (s *Service) handleExecute(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+	if s.idempotency == nil || len(req.RequestID) < 1 {
+		s.executeCommands(w, r, agentUserID, req)
+		return
+	}
 
-		queryResp := &queryResponse{
-			RequestID: fulfillmentReq.RequestID,
-		}
-		queryResp.Payload.Devices = map[string]DeviceState{}
-		for deviceID, state := range pQueryResp.States {
-			state.Status = "SUCCESS"
-			queryResp.Payload.Devices[deviceID] = state
-		}
+	key := agentUserID + "/" + req.RequestID
+	entry, err := s.idempotency.begin(r.Context(), key)
+	if err != nil {
+		s.logger.Info("request cancelled waiting for original execute",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+		return
+	} else if entry != nil {
+		s.logger.Info("answering retried execute from cache",
+			zap.String("request_id", req.RequestID),
+		)
+		entry.write(w)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(queryResp)
-		if err != nil {
-			s.logger.Info("error serializing after writing ok",
-				zap.Error(err),
-			)
-		}
+		s.events.publish(ExecuteDeduplicated{
+			Time:        s.now(),
+			RequestID:   req.RequestID,
+			AgentUserID: agentUserID,
+		})
 		return
-	case "action.devices.EXECUTE":
-		pExecuteReq := &ExecuteRequest{
-			AgentID: userID,
+	}
+
+	rec := newResponseRecorder(w)
+	completed := false
+	defer func() {
+		s.idempotency.finish(key, rec, completed)
+	}()
+	s.executeCommands(rec, r, agentUserID, req)
+	completed = true
+}
+
+// executeCommands passes the requested commands to the provider and writes the results.
+func (s *Service) executeCommands(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+	pExecuteReq := &ExecuteRequest{
+		AgentID: agentUserID,
+	}
+	for _, command := range req.Inputs[0].Execute.Commands {
+		devices := []DeviceArg{}
+		for _, device := range command.Devices {
+			devices = append(devices, DeviceArg{
+				ID:         device.ID,
+				CustomData: device.CustomData,
+			})
+		}
+		commandArg := CommandArg{
+			TargetDevices: devices,
+			Commands:      command.Execution,
 		}
-		for _, command := range fulfillmentReq.Inputs[0].Execute.Commands {
-			devices := []DeviceArg{}
-			for _, device := range command.Devices {
-				devices = append(devices, DeviceArg{
-					ID:         device.ID,
-					CustomData: device.CustomData,
-				})
+		for _, execution := range command.Execution {
+			if len(execution.FollowUpToken) > 0 {
+				commandArg.FollowUpToken = execution.FollowUpToken
+				break
 			}
-			pExecuteReq.Commands = append(pExecuteReq.Commands, CommandArg{
-				TargetDevices: devices,
-				Commands:      command.Execution,
-			})
 		}
+		pExecuteReq.Commands = append(pExecuteReq.Commands, commandArg)
+	}
+
+	requestedIDs := executeRequestDeviceIDs(pExecuteReq.Commands)
 
-		pExecuteResp, err := s.provider.Execute(r.Context(), pExecuteReq)
+	var validationFailures map[string][]string
+	if s.registry != nil {
+		pExecuteReq.Commands, validationFailures = s.validateExecute(r.Context(), agentUserID, pExecuteReq.Commands)
+	}
+	if s.authorizer != nil {
+		var authFailures map[string][]string
+		pExecuteReq.Commands, authFailures = s.authorizeExecute(r.Context(), agentUserID, pExecuteReq.Commands)
+		if validationFailures == nil {
+			validationFailures = map[string][]string{}
+		}
+		for errCode, ids := range authFailures {
+			validationFailures[errCode] = append(validationFailures[errCode], ids...)
+		}
+	}
+
+	// If every device failed validation or authorization there is nothing left for the provider to do.
+	pExecuteResp := &ExecuteResponse{}
+	var err error
+	if s.shadowMode {
+		pExecuteResp = s.shadowExecute(r.Context(), pExecuteReq)
+	} else if len(pExecuteReq.Commands) > 0 {
+		pExecuteResp, err = s.provider.Execute(r.Context(), pExecuteReq)
 		if err != nil {
 			s.logger.Info("execute error",
+				requestIDField(r.Context()),
 				zap.Error(err),
 			)
 
-			// TODO: clean this up possibly using better error handling.
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("Fail to execute"))
+			s.writeProviderError(r.Context(), w, req, err, "Fail to execute")
 			return
 		}
+	}
 
-		executeResp := &executeResponse{
-			RequestID: fulfillmentReq.RequestID,
+	for errCode, ids := range validationFailures {
+		if isChallengeType(errCode) {
+			pExecuteResp.AddChallengeNeeded(errCode, ids...)
+			continue
 		}
+		pExecuteResp.AddFailedDevices(errCode, ids...)
+	}
 
-		if len(pExecuteResp.UpdatedDevices) > 0 {
-			commandSuccessResp := executeRespPayload{
-				Status: "SUCCESS",
-				States: pExecuteResp.UpdatedState.State,
-			}
-			commandSuccessResp.States["online"] = true
-			for _, id := range pExecuteResp.UpdatedDevices {
-				commandSuccessResp.IDs = append(commandSuccessResp.IDs, id)
-			}
-
-			executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandSuccessResp)
-		}
+	if s.correlateExecute {
+		s.correlateExecuteResponse(req.RequestID, requestedIDs, pExecuteResp)
+	}
 
-		if len(pExecuteResp.OfflineDevices) > 0 {
-			commandOfflineResp := executeRespPayload{
-				Status: "OFFLINE",
-			}
-			for _, id := range pExecuteResp.OfflineDevices {
-				commandOfflineResp.IDs = append(commandOfflineResp.IDs, id)
-			}
+	executeResp := &ExecuteFulfillmentResponse{
+		RequestID: req.RequestID,
+	}
+	executeResp.Payload.ErrorCode = s.checkErrorCode(r.Context(), pExecuteResp.ErrorCode)
+	executeResp.Payload.DebugString = pExecuteResp.DebugString
 
-			executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandOfflineResp)
+	if len(pExecuteResp.UpdatedDevices) > 0 {
+		commandSuccessResp := ExecuteCommandResult{
+			Status: "SUCCESS",
+			States: map[string]interface{}{},
 		}
-
-		for errCode, details := range pExecuteResp.FailedDevices {
-			commandFailResp := executeRespPayload{
-				Status:    "ERROR",
-				ErrorCode: errCode,
-			}
-			for _, id := range details.Devices {
-				commandFailResp.IDs = append(commandFailResp.IDs, id)
-			}
-
-			executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandFailResp)
+		for k, v := range pExecuteResp.UpdatedState.State {
+			commandSuccessResp.States[k] = v
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(executeResp)
-		if err != nil {
-			s.logger.Info("error serializing after writing ok",
-				zap.Error(err),
-			)
+		commandSuccessResp.States["online"] = true
+		for _, id := range pExecuteResp.UpdatedDevices {
+			commandSuccessResp.IDs = append(commandSuccessResp.IDs, id)
 		}
-		return
-	case "action.devices.DISCONNECT":
-		s.provider.Disconnect(r.Context(), userID)
 
-		w.Write([]byte("{}"))
-		return
+		executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandSuccessResp)
 	}
 
-	s.logger.Info("unsupported intent name specified",
-		zap.String("request_id", fulfillmentReq.RequestID),
-		zap.String("intent", fulfillmentReq.Inputs[0].Intent),
-	)
+	if len(pExecuteResp.PendingDevices) > 0 {
+		s.recordPendingExecutions(agentUserID, pExecuteReq.Commands, pExecuteResp)
 
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write([]byte("Unsupported intent name specified"))
-}
+		commandPendingResp := ExecuteCommandResult{
+			Status: "PENDING",
+			States: map[string]interface{}{},
+		}
+		for k, v := range pExecuteResp.UpdatedState.State {
+			commandPendingResp.States[k] = v
+		}
+		commandPendingResp.States["online"] = true
+		for _, id := range pExecuteResp.PendingDevices {
+			commandPendingResp.IDs = append(commandPendingResp.IDs, id)
+		}
 
-// fulfillmentRequest matches the request format documented at https://developers.google.com/assistant/smarthome/develop/process-intents
-// It appears to be generated from a protobuf file but I was unable to locate the proper one.
-type fulfillmentRequest struct {
-	RequestID string             `json:"requestId"`
-	Inputs    []fulfillmentInput `json:"inputs"`
-}
+		executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandPendingResp)
+	}
 
-// fulfillmentInput matches the intent format documented at https://developers.google.com/assistant/smarthome/reference/intent/sync (first of the 4 intents)
-type fulfillmentInput struct {
-	Intent string
+	if len(pExecuteResp.OfflineDevices) > 0 {
+		commandOfflineResp := ExecuteCommandResult{
+			Status: "OFFLINE",
+		}
+		for _, id := range pExecuteResp.OfflineDevices {
+			commandOfflineResp.IDs = append(commandOfflineResp.IDs, id)
+		}
 
-	// based on the supplied intent one of the 2 below fields may be set
-	Query   *queryPayload
-	Execute *executePayload
-}
+		executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandOfflineResp)
+	}
 
-func (i *fulfillmentInput) UnmarshalJSON(data []byte) error {
-	var tmp struct {
-		Intent  string          `json:"intent"`
-		Payload json.RawMessage `json:"payload"`
+	// Iterate the challenges and failures in a stable order so the response is deterministic.
+	var challengeTypes []string
+	for challengeType := range pExecuteResp.ChallengeNeeded {
+		challengeTypes = append(challengeTypes, challengeType)
 	}
-	err := json.Unmarshal(data, &tmp)
-	if err != nil {
-		return err
+	sort.Strings(challengeTypes)
+	for _, challengeType := range challengeTypes {
+		ids := pExecuteResp.ChallengeNeeded[challengeType]
+		commandChallengeResp := ExecuteCommandResult{
+			Status:    "ERROR",
+			ErrorCode: "challengeNeeded",
+			ChallengeNeeded: &ChallengeNeededPayload{
+				Type: challengeType,
+			},
+		}
+		commandChallengeResp.IDs = append(commandChallengeResp.IDs, ids...)
+
+		executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandChallengeResp)
 	}
 
-	i.Intent = tmp.Intent
-	switch tmp.Intent {
-	case "action.devices.QUERY":
-		payload := &queryPayload{}
-		err = json.Unmarshal(tmp.Payload, payload)
-		if err != nil {
-			return err
+	var errCodes []string
+	for errCode := range pExecuteResp.FailedDevices {
+		errCodes = append(errCodes, errCode)
+	}
+	sort.Strings(errCodes)
+	for _, errCode := range errCodes {
+		details := pExecuteResp.FailedDevices[errCode]
+		commandFailResp := ExecuteCommandResult{
+			Status:    "ERROR",
+			ErrorCode: s.checkErrorCode(r.Context(), errCode),
 		}
-		i.Query = payload
-	case "action.devices.EXECUTE":
-		payload := &executePayload{}
-		err = json.Unmarshal(tmp.Payload, payload)
-		if err != nil {
-			return err
+		for _, id := range details.Devices {
+			commandFailResp.IDs = append(commandFailResp.IDs, id)
 		}
-		i.Execute = payload
 
+		executeResp.Payload.Commands = append(executeResp.Payload.Commands, commandFailResp)
 	}
 
-	return nil
-}
+	// The IDs in each result are listed in the order the devices were requested, regardless of the order the provider used.
+	for _, result := range executeResp.Payload.Commands {
+		sortByRequestOrder(result.IDs, requestedIDs)
+	}
 
-type deviceHandle struct {
-	ID         string                 `json:"id"`
-	CustomData map[string]interface{} `json:"customData"`
-}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(executeResp)
+	if err != nil {
+		s.logger.Info("error serializing after writing ok",
+			requestIDField(r.Context()),
+			zap.Error(err),
+		)
+	}
 
-type queryPayload struct {
-	Devices []deviceHandle `json:"devices"`
-}
-type executePayload struct {
-	Commands []struct {
-		Devices   []deviceHandle `json:"devices"`
-		Execution []Command      `json:"execution"`
-	} `json:"commands"`
-}
-type executeRespPayload struct {
-	IDs       []string               `json:"ids,omitempty"`
-	Status    string                 `json:"status,omitempty"`
-	ErrorCode string                 `json:"errorCode,omitempty"`
-	States    map[string]interface{} `json:"states,omitempty"`
+	s.events.publish(ExecuteCompleted{
+		Time:        s.now(),
+		RequestID:   req.RequestID,
+		AgentUserID: agentUserID,
+		Results:     executeResp.Payload.Commands,
+	})
 }
 
-type syncResponse struct {
-	RequestID string `json:"requestId,omitempty"`
-	Payload   struct {
-		UserID    string    `json:"agentUserId,omitempty"`
-		ErrorCode string    `json:"errorCode,omitempty"`
-		DebugInfo string    `json:"debugString,omitempty"`
-		Devices   []*Device `json:"devices,omitempty"`
-	} `json:"payload"`
-}
-type queryResponse struct {
-	RequestID string `json:"requestId,omitempty"`
-	Payload   struct {
-		Devices map[string]DeviceState `json:"devices"`
-	} `json:"payload"`
-}
-type executeResponse struct {
-	RequestID string `json:"requestId,omitempty"`
-	Payload   struct {
-		Commands []executeRespPayload `json:"commands"`
-	} `json:"payload"`
+// handleDisconnect informs the provider, and any other interested parties, that the user has unlinked their account.
+func (s *Service) handleDisconnect(w http.ResponseWriter, r *http.Request, agentUserID string, req *FulfillmentRequest) {
+	s.unlink(r.Context(), agentUserID)
+
+	w.Write([]byte("{}"))
 }
@@ -3,6 +3,7 @@ package action
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,20 +13,41 @@ import (
 )
 
 type testAuthenticator struct {
-	validToken string
-	userID     string
+	validToken  string
+	userID      string
+	validateErr error
+
+	revokedUserID string
 }
 
 func (ta *testAuthenticator) Validate(_ context.Context, token string) (string, error) {
+	if ta.validateErr != nil {
+		return "", ta.validateErr
+	}
 	if token == ta.validToken {
 		return ta.userID, nil
 	}
 	return "", nil
 }
 
+func (ta *testAuthenticator) RevokeTokens(_ context.Context, userID string) error {
+	ta.revokedUserID = userID
+	return nil
+}
+
+type testUnlinkListener struct {
+	unlinkedUserID string
+}
+
+func (tul *testUnlinkListener) Unlinked(_ context.Context, userID string) {
+	tul.unlinkedUserID = userID
+}
+
 type testProvider struct {
-	syncResp []*Device
-	syncErr  error
+	syncResp        []*Device
+	syncErrorCode   string
+	syncDebugString string
+	syncErr         error
 
 	queryReq  *QueryRequest
 	queryResp map[string]DeviceState
@@ -37,12 +59,16 @@ type testProvider struct {
 	executeRespOffline      []string
 	executeRespFailed       []string
 	executeRespFailedReason string
+	executeRespChallenge    map[string][]string
+	executeRespPending      map[string]string
 	executeErr              error
 }
 
 func (tp *testProvider) Sync(context.Context, string) (*SyncResponse, error) {
 	return &SyncResponse{
-		Devices: tp.syncResp,
+		Devices:     tp.syncResp,
+		ErrorCode:   tp.syncErrorCode,
+		DebugString: tp.syncDebugString,
 	}, tp.syncErr
 }
 
@@ -72,6 +98,8 @@ func (tp *testProvider) Execute(_ context.Context, req *ExecuteRequest) (*Execut
 				Devices: tp.executeRespFailed,
 			},
 		},
+		ChallengeNeeded: tp.executeRespChallenge,
+		PendingDevices:  tp.executeRespPending,
 	}, tp.executeErr
 }
 
@@ -166,6 +194,78 @@ func TestGoogleFulfillmentHandlerSync(t *testing.T) {
 `, rr.Body.String())
 }
 
+func TestGoogleFulfillmentHandlerSyncErrorCode(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncErrorCode:   "deviceOffline",
+		syncDebugString: "the bridge is unreachable",
+	}
+
+	svc := NewService(logger, authenticator, provider, nil)
+
+	req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.SYNC"
+		  }
+		]
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"agentUserId":"1836.15267389","errorCode":"deviceOffline","debugString":"the bridge is unreachable"}}
+`, rr.Body.String())
+}
+
+func TestGoogleFulfillmentHandlerSyncInvalidDevice(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{
+			NewLight("123"),
+			NewThermostat("456", []string{ThermostatModeOff, "warm"}, TemperatureUnitCelsius, [2]float64{}),
+		},
+	}
+
+	svc := NewService(logger, authenticator, provider, nil)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.SYNC"
+		  }
+		]
+	}`)))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(svc.GoogleFulfillmentHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "Fail to sync", rr.Body.String())
+}
+
 func TestGoogleFulfillmentHandlerQuery(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 
@@ -335,6 +435,222 @@ func TestGoogleFulfillmentHandlerExecute(t *testing.T) {
 `, rr.Body.String())
 }
 
+func TestGoogleFulfillmentHandlerExecuteChallenge(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespFailed:       []string{"789"},
+		executeRespFailedReason: "deviceJammingDetected",
+		executeRespChallenge: map[string][]string{
+			ChallengePinNeeded: {"123"},
+		},
+	}
+
+	svc := NewService(logger, authenticator, provider, nil)
+
+	req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+			  "commands": [
+				{
+				  "devices": [
+					{
+					  "id": "123"
+					},
+					{
+					  "id": "789"
+					}
+				  ],
+				  "execution": [
+					{
+					  "command": "action.devices.commands.LockUnlock",
+					  "params": {
+						"lock": false
+					  }
+					}
+				  ]
+				}
+			  ]
+			}
+		  }
+		]
+	  }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"commands":[{"ids":["123"],"status":"ERROR","errorCode":"challengeNeeded","challengeNeeded":{"type":"pinNeeded"}},{"ids":["789"],"status":"ERROR","errorCode":"deviceJammingDetected"}]}}
+`, rr.Body.String())
+}
+
+func TestGoogleFulfillmentHandlerExecuteDeterministic(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespFailed:       []string{"789"},
+		executeRespFailedReason: "deviceJammingDetected",
+		executeRespChallenge: map[string][]string{
+			ChallengePinNeeded:       {"123"},
+			ChallengeAckNeeded:       {"456"},
+			ChallengeFailedPinNeeded: {"012"},
+		},
+	}
+
+	svc := NewService(logger, authenticator, provider, nil)
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	for i := 0; i < 10; i++ {
+		req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+			"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+			"inputs": [
+			  {
+				"intent": "action.devices.EXECUTE",
+				"payload": {
+				  "commands": [
+					{
+					  "devices": [{"id": "012"}, {"id": "123"}, {"id": "456"}, {"id": "789"}],
+					  "execution": [
+						{
+						  "command": "action.devices.commands.LockUnlock",
+						  "params": {
+							"lock": false
+						  }
+						}
+					  ]
+					}
+				  ]
+				}
+			  }
+			]
+		  }`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"commands":[{"ids":["456"],"status":"ERROR","errorCode":"challengeNeeded","challengeNeeded":{"type":"ackNeeded"}},{"ids":["012"],"status":"ERROR","errorCode":"challengeNeeded","challengeNeeded":{"type":"challengeFailedPinNeeded"}},{"ids":["123"],"status":"ERROR","errorCode":"challengeNeeded","challengeNeeded":{"type":"pinNeeded"}},{"ids":["789"],"status":"ERROR","errorCode":"deviceJammingDetected"}]}}
+`, rr.Body.String())
+	}
+}
+
+func TestGoogleFulfillmentHandlerExecuteRequestOrder(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespDeviceState:  NewDeviceState(true).RecordOnOff(true),
+		executeRespUpdated:      []string{"789", "123", "456"},
+		executeRespFailed:       []string{"999", "012"},
+		executeRespFailedReason: "turnedOff",
+		executeRespPending:      map[string]string{"token-b": "abc", "token-a": "def"},
+	}
+
+	svc := NewService(logger, authenticator, provider, nil)
+
+	req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+			  "commands": [
+				{
+				  "devices": [{"id": "456"}, {"id": "def"}, {"id": "012"}],
+				  "execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+				},
+				{
+				  "devices": [{"id": "123"}, {"id": "abc"}, {"id": "999"}, {"id": "789"}],
+				  "execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+				}
+			  ]
+			}
+		  }
+		]
+	  }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(svc.GoogleFulfillmentHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"commands":[{"ids":["456","123","789"],"status":"SUCCESS","states":{"on":true,"online":true}},{"ids":["def","abc"],"status":"PENDING","states":{"on":true,"online":true}},{"ids":["012","999"],"status":"ERROR","errorCode":"turnedOff"}]}}
+`, rr.Body.String())
+}
+
+// blockingProvider blocks in Query until the request context is cancelled.
+type blockingProvider struct {
+	testProvider
+	started chan struct{}
+	err     chan error
+}
+
+func (bp *blockingProvider) Query(ctx context.Context, _ *QueryRequest) (*QueryResponse, error) {
+	close(bp.started)
+	<-ctx.Done()
+	bp.err <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestGoogleFulfillmentHandlerCancelled(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &blockingProvider{
+		started: make(chan struct{}),
+		err:     make(chan error, 1),
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{"requestId": "req-1", "inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "123"}]}}]}`))).WithContext(ctx)
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	done := make(chan struct{})
+	go func() {
+		svc.GoogleFulfillmentHandler(httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	// Aborting the request cancels the context supplied to the provider.
+	<-provider.started
+	cancel()
+	assert.Equal(t, context.Canceled, <-provider.err)
+	<-done
+}
+
 func TestGoogleFulfillmentHandlerDisconnect(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 
@@ -369,6 +685,48 @@ func TestGoogleFulfillmentHandlerDisconnect(t *testing.T) {
 	assert.Equal(t, `{}`, rr.Body.String())
 }
 
+func TestGoogleFulfillmentHandlerDisconnectUnlink(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{}
+	listener := &testUnlinkListener{}
+
+	svc := NewService(logger, authenticator, provider, newTestHomeGraphService(t, thg),
+		WithUnlinkListener(listener),
+		WithDeleteAgentUserOnUnlink(),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.DISCONNECT"
+		  }
+		]
+	  }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{}`, rr.Body.String())
+	assert.Equal(t, "1836.15267389", authenticator.revokedUserID)
+	assert.Equal(t, "1836.15267389", listener.unlinkedUserID)
+	assert.Equal(t, []string{"/v1/agentUsers/1836.15267389"}, thg.paths)
+}
+
 var badInputTests = []struct {
 	name        string
 	contentType string
@@ -474,3 +832,73 @@ func TestGoogleFulfillmentHandlerBadInput(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceSupportedIntents(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil)
+
+	assert.Equal(t, []string{
+		IntentDisconnect,
+		IntentExecute,
+		IntentQuery,
+		IntentSync,
+	}, svc.SupportedIntents())
+}
+
+func TestGoogleFulfillmentHandlerCustomIntent(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	svc := NewService(logger, authenticator, &testProvider{}, nil)
+
+	var receivedUserID string
+	var receivedPayload struct {
+		Target struct {
+			DeviceID string `json:"deviceId"`
+		} `json:"target"`
+	}
+	svc.RegisterIntentHandler("action.devices.IDENTIFY", func(_ context.Context, agentUserID string, payload json.RawMessage) (interface{}, error) {
+		receivedUserID = agentUserID
+		if err := json.Unmarshal(payload, &receivedPayload); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"device": map[string]interface{}{
+				"id": "123",
+			},
+		}, nil
+	})
+	assert.Contains(t, svc.SupportedIntents(), "action.devices.IDENTIFY")
+
+	req, err := http.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.IDENTIFY",
+			"payload": {
+			  "target": {
+				"deviceId": "local-device-id"
+			  }
+			}
+		  }
+		]
+	  }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"device":{"id":"123"}}}
+`, rr.Body.String())
+	assert.Equal(t, "1836.15267389", receivedUserID)
+	assert.Equal(t, "local-device-id", receivedPayload.Target.DeviceID)
+}
@@ -0,0 +1,147 @@
+package action
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// The sources a state in the history can be recorded from.
+const (
+	StateSourceReportState = "REPORT_STATE"
+	StateSourceQuery       = "QUERY"
+)
+
+// StateRecord is a single entry in the state history of a device.
+type StateRecord struct {
+	Time        time.Time
+	AgentUserID string
+	DeviceID    string
+	// Source of the state; either reported to Google (StateSourceReportState) or returned in response to a QUERY (StateSourceQuery).
+	Source string
+	State  DeviceState
+}
+
+// StateHistoryStore is an append-only log of the states of each device, which can be used to find out what
+// Google was told about a device at a given time. Implementations must be safe for concurrent use.
+type StateHistoryStore interface {
+	// Append adds the record to the history of the device.
+	Append(ctx context.Context, record StateRecord) error
+	// Last returns up to the n most recent records of the device, newest first.
+	Last(ctx context.Context, agentUserID string, deviceID string, n int) ([]StateRecord, error)
+	// At returns the most recent record of the device at or before the specified time, or nil if there is none.
+	At(ctx context.Context, agentUserID string, deviceID string, t time.Time) (*StateRecord, error)
+}
+
+// WithStateHistory records every state reported to Google using ReportState, or returned to Google in response
+// to a QUERY, in the supplied store. Failures to append to the store are logged but don't fail the request.
+func WithStateHistory(store StateHistoryStore) ServiceOption {
+	return func(s *Service) {
+		s.history = store
+	}
+}
+
+// recordStateHistory appends the supplied states to the history store, if one is configured.
+func (s *Service) recordStateHistory(ctx context.Context, agentUserID string, source string, states map[string]DeviceState) {
+	if s.history == nil {
+		return
+	}
+
+	now := s.now()
+	for deviceID, state := range states {
+		err := s.history.Append(ctx, StateRecord{
+			Time:        now,
+			AgentUserID: agentUserID,
+			DeviceID:    deviceID,
+			Source:      source,
+			State:       state,
+		})
+		if err != nil {
+			s.logger.Info("error appending state history",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// MemoryStateHistory is a StateHistoryStore which retains a bounded number of records per device in memory.
+type MemoryStateHistory struct {
+	maxPerDevice int
+
+	mu      sync.RWMutex
+	records map[string][]StateRecord
+}
+
+// NewMemoryStateHistory creates a new in-memory history which retains up to maxPerDevice records for each device.
+// The oldest records are discarded once the limit is reached; a limit of zero or less retains every record.
+func NewMemoryStateHistory(maxPerDevice int) *MemoryStateHistory {
+	return &MemoryStateHistory{
+		maxPerDevice: maxPerDevice,
+		records:      map[string][]StateRecord{},
+	}
+}
+
+// Append adds the record to the history of the device.
+func (h *MemoryStateHistory) Append(_ context.Context, record StateRecord) error {
+	// Copy the state values so later changes by the caller aren't reflected in the history.
+	state := record.State
+	state.State = map[string]interface{}{}
+	for k, v := range record.State.State {
+		state.State[k] = v
+	}
+	record.State = state
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := record.AgentUserID + "/" + record.DeviceID
+	records := h.records[key]
+	// Keep the records sorted by time, even if they are appended slightly out of order.
+	idx := sort.Search(len(records), func(i int) bool {
+		return records[i].Time.After(record.Time)
+	})
+	records = append(records, StateRecord{})
+	copy(records[idx+1:], records[idx:])
+	records[idx] = record
+
+	if h.maxPerDevice > 0 && len(records) > h.maxPerDevice {
+		records = records[len(records)-h.maxPerDevice:]
+	}
+	h.records[key] = records
+	return nil
+}
+
+// Last returns up to the n most recent records of the device, newest first.
+func (h *MemoryStateHistory) Last(_ context.Context, agentUserID string, deviceID string, n int) ([]StateRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := h.records[agentUserID+"/"+deviceID]
+	var last []StateRecord
+	for i := len(records) - 1; i >= 0 && len(last) < n; i-- {
+		last = append(last, records[i])
+	}
+	return last, nil
+}
+
+// At returns the most recent record of the device at or before the specified time, or nil if there is none.
+func (h *MemoryStateHistory) At(_ context.Context, agentUserID string, deviceID string, t time.Time) (*StateRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := h.records[agentUserID+"/"+deviceID]
+	idx := sort.Search(len(records), func(i int) bool {
+		return records[i].Time.After(t)
+	})
+	if idx < 1 {
+		return nil, nil
+	}
+	record := records[idx-1]
+	return &record, nil
+}
@@ -0,0 +1,97 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMemoryStateHistory(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemoryStateHistory(3)
+	start := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, h.Append(ctx, StateRecord{
+			Time:        start.Add(time.Duration(i) * time.Minute),
+			AgentUserID: "agent-id",
+			DeviceID:    "light-1",
+			State:       NewDeviceState(true).RecordBrightness(i),
+		}))
+	}
+
+	last, err := h.Last(ctx, "agent-id", "light-1", 2)
+	assert.Nil(t, err)
+	assert.Len(t, last, 2)
+	assert.Equal(t, 3, last[0].State.State["brightness"])
+	assert.Equal(t, 2, last[1].State.State["brightness"])
+
+	// The oldest record was discarded.
+	last, err = h.Last(ctx, "agent-id", "light-1", 10)
+	assert.Nil(t, err)
+	assert.Len(t, last, 3)
+
+	record, err := h.At(ctx, "agent-id", "light-1", start.Add(150*time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, record.State.State["brightness"])
+
+	record, err = h.At(ctx, "agent-id", "light-1", start.Add(30*time.Second))
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	record, err = h.At(ctx, "agent-id", "light-2", start.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+}
+
+func TestMemoryStateHistoryCopiesState(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemoryStateHistory(0)
+
+	state := NewDeviceState(true).RecordOnOff(true)
+	assert.Nil(t, h.Append(ctx, StateRecord{AgentUserID: "agent-id", DeviceID: "light-1", State: state}))
+	state.RecordOnOff(false)
+
+	last, err := h.Last(ctx, "agent-id", "light-1", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, true, last[0].State.State["on"])
+}
+
+func TestServiceStateHistory(t *testing.T) {
+	ctx := context.Background()
+	h := NewMemoryStateHistory(0)
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(false),
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithStateHistory(h),
+	)
+
+	assert.Nil(t, svc.ReportState(ctx, "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true),
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1"}]}}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	svc.GoogleFulfillmentHandler(httptest.NewRecorder(), req)
+
+	last, err := h.Last(ctx, "agent-id", "light-1", 10)
+	assert.Nil(t, err)
+	assert.Len(t, last, 2)
+	assert.Equal(t, StateSourceQuery, last[0].Source)
+	assert.Equal(t, false, last[0].State.State["on"])
+	assert.Equal(t, StateSourceReportState, last[1].Source)
+	assert.Equal(t, true, last[1].State.State["on"])
+}
@@ -0,0 +1,172 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/homegraph/v1"
+	"google.golang.org/api/option"
+)
+
+var (
+	// ErrHomeGraphRejected is matched by a HomeGraphRejectedError, so errors.Is can be used to check for any rejection.
+	ErrHomeGraphRejected = errors.New("homegraph rejected request")
+)
+
+// HomeGraphError is returned if a call to the HomeGraph API fails.
+// RequestID is the requestId which was sent to Google, so the call can be identified when contacting Google support.
+// It is empty for methods which don't accept a request ID (i.e. requestSync).
+// Err is the error returned by the HomeGraph client, which is a *HomeGraphRejectedError if Google responded with an error status.
+// If Google responded without an error but with a status other than 200, Err is one of ErrSyncFailed, ErrReportStateFailed,
+// ErrNotificationFailed or ErrDeleteAgentUserFailed.
+type HomeGraphError struct {
+	Method     string
+	RequestID  string
+	StatusCode int
+	Err        error
+}
+
+// Error includes the method and request ID in the message of the underlying error.
+func (e *HomeGraphError) Error() string {
+	if len(e.RequestID) < 1 {
+		return fmt.Sprintf("homegraph %s: %v", e.Method, e.Err)
+	}
+	return fmt.Sprintf("homegraph %s (request %s): %v", e.Method, e.RequestID, e.Err)
+}
+
+// Unwrap returns the underlying error, so callers can use errors.Is against it.
+func (e *HomeGraphError) Unwrap() error {
+	return e.Err
+}
+
+// HomeGraphRejectedError is the Err of a HomeGraphError if the HomeGraph responded with an error status.
+// Status and Body contain the response from Google, which usually explains why the request was rejected
+// (i.e. a state which doesn't match the traits of the device).
+type HomeGraphRejectedError struct {
+	Status int
+	Body   string
+
+	err error
+}
+
+// Error includes the status code and response body.
+func (e *HomeGraphRejectedError) Error() string {
+	return fmt.Sprintf("%s with status %d: %s", ErrHomeGraphRejected, e.Status, e.Body)
+}
+
+// Is allows the error to match ErrHomeGraphRejected.
+func (e *HomeGraphRejectedError) Is(target error) bool {
+	return target == ErrHomeGraphRejected
+}
+
+// Unwrap returns the error returned by the HomeGraph client.
+func (e *HomeGraphRejectedError) Unwrap() error {
+	return e.err
+}
+
+// classifyHomeGraphError converts errors reporting an error status from the HomeGraph into a HomeGraphRejectedError.
+// Other errors (i.e. network failures) are returned unchanged.
+func classifyHomeGraphError(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	return &HomeGraphRejectedError{
+		Status: apiErr.Code,
+		Body:   apiErr.Body,
+		err:    err,
+	}
+}
+
+// WithHomeGraphHTTPClient causes the HomeGraph calls to be made using the supplied HTTP client,
+// which must handle authenticating with Google (i.e. one created using golang.org/x/oauth2/google).
+// The endpoint of the supplied *homegraph.Service is retained.
+func WithHomeGraphHTTPClient(client *http.Client) ServiceOption {
+	return func(s *Service) {
+		s.homeGraphClient = client
+	}
+}
+
+// WithHomeGraphUserAgent appends the supplied value to the User-Agent of each HomeGraph call.
+// This modifies the supplied *homegraph.Service.
+func WithHomeGraphUserAgent(userAgent string) ServiceOption {
+	return func(s *Service) {
+		s.homeGraphUserAgent = userAgent
+	}
+}
+
+// WithHomeGraphQuotaProject causes the HomeGraph calls to be billed and rate limited against the specified GCP project,
+// rather than the project of the credentials making the calls.
+// The credentials must have the serviceusage.services.use permission on the project.
+func WithHomeGraphQuotaProject(projectID string) ServiceOption {
+	return func(s *Service) {
+		s.homeGraphQuotaProject = projectID
+	}
+}
+
+// configureHomeGraph creates the HomeGraph API clients, applying the HomeGraph options.
+func (s *Service) configureHomeGraph(hgService *homegraph.Service) {
+	if s.homeGraphClient != nil {
+		opts := []option.ClientOption{
+			option.WithHTTPClient(s.homeGraphClient),
+		}
+		if hgService != nil {
+			opts = append(opts, option.WithEndpoint(hgService.BasePath))
+		}
+
+		var err error
+		hgService, err = homegraph.NewService(context.Background(), opts...)
+		if err != nil {
+			s.logger.Fatal("unable to create homegraph service with the supplied http client",
+				zap.Error(err),
+			)
+		}
+	}
+	if hgService != nil && len(s.homeGraphUserAgent) > 0 {
+		hgService.UserAgent = s.homeGraphUserAgent
+	}
+
+	s.deviceService = homegraph.NewDevicesService(hgService)
+	s.agentUserService = homegraph.NewAgentUsersService(hgService)
+}
+
+// setHomeGraphHeaders adds the headers configured by the HomeGraph options to a call.
+func (s *Service) setHomeGraphHeaders(header http.Header) {
+	if len(s.homeGraphQuotaProject) > 0 {
+		header.Set("X-Goog-User-Project", s.homeGraphQuotaProject)
+	}
+}
+
+// WithDryRun prevents the service from calling the HomeGraph. Instead the request which would have been sent by
+// RequestSync, ReportState, DeleteAgentUser or any of the notifications is logged, pretty-printed, and the call succeeds.
+// This allows development against a production set of devices without affecting what Google sees.
+func WithDryRun() ServiceOption {
+	return func(s *Service) {
+		s.dryRun = true
+	}
+}
+
+// logDryRun logs the request which would have been sent to the HomeGraph.
+func (s *Service) logDryRun(ctx context.Context, method string, agentUserID string, req interface{}) {
+	payload, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		s.logger.Info("error serializing dry run request",
+			requestIDField(ctx),
+			zap.String("method", method),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.logger.Info("dry run homegraph call",
+		requestIDField(ctx),
+		zap.String("method", method),
+		zap.String("agent_user_id", agentUserID),
+		zap.String("payload", string(payload)),
+	)
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/api/homegraph/v1"
+	"google.golang.org/api/option"
+)
+
+type testTransport struct {
+	requests int
+}
+
+func (tt *testTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	tt.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestServiceHomeGraphOptions(t *testing.T) {
+	thg := &testHomeGraph{}
+	server := httptest.NewServer(thg)
+	t.Cleanup(server.Close)
+
+	hgService, err := homegraph.NewService(context.Background(),
+		option.WithEndpoint(server.URL),
+		option.WithHTTPClient(server.Client()),
+	)
+	assert.Nil(t, err)
+
+	transport := &testTransport{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, hgService,
+		WithHomeGraphHTTPClient(&http.Client{Transport: transport}),
+		WithHomeGraphUserAgent("smart-home-test/1.0"),
+		WithHomeGraphQuotaProject("quota-project"),
+	)
+
+	assert.Nil(t, svc.RequestSync(context.Background(), "agent-id"))
+	assert.Nil(t, svc.DeleteAgentUser(context.Background(), "agent-id"))
+	assert.Equal(t, 2, transport.requests)
+
+	assert.Len(t, thg.headers, 2)
+	for _, header := range thg.headers {
+		assert.Equal(t, "quota-project", header.Get("X-Goog-User-Project"))
+		assert.Contains(t, header.Get("User-Agent"), "smart-home-test/1.0")
+	}
+}
+
+func TestServiceDryRun(t *testing.T) {
+	thg := &testHomeGraph{}
+	core, logs := observer.New(zap.InfoLevel)
+
+	svc := NewService(zap.New(core), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithDryRun())
+
+	assert.Nil(t, svc.RequestSync(context.Background(), "agent-id"))
+	assert.Nil(t, svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": NewDeviceState(true).RecordOnOff(true),
+	}))
+	assert.Nil(t, svc.NotifyDoorbellPress(context.Background(), "agent-id", "doorbell-1", DetectedObjects{}))
+	assert.Nil(t, svc.DeleteAgentUser(context.Background(), "agent-id"))
+	assert.Empty(t, thg.paths)
+
+	entries := logs.FilterMessage("dry run homegraph call").All()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "requestSync", entries[0].ContextMap()["method"])
+	assert.Contains(t, entries[1].ContextMap()["payload"], `"on": true`)
+	assert.Equal(t, "deleteAgentUser", entries[3].ContextMap()["method"])
+}
@@ -0,0 +1,154 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithExecuteIdempotency causes retried EXECUTE requests to be answered with the original response rather than being
+// passed to the provider again, preventing a retry from (for example) toggling a device twice.
+// Requests are identified by the user and the requestId supplied by Google, and responses are retained for the ttl.
+// If a retry arrives while the original request is still executing it waits for the original to complete.
+// Only successful responses are retained; a request which failed outright (including one answered with a
+// payload.errorCode, or one whose handling panicked) is executed again when retried.
+// An ExecuteDeduplicated event is published each time a retry is answered from the cache.
+func WithExecuteIdempotency(ttl time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.idempotency = newIdempotencyCache(ttl)
+	}
+}
+
+// idempotencyEntry contains the response to a single request.
+// done is closed once the response has been recorded; cached is set if the response was retained.
+type idempotencyEntry struct {
+	done   chan struct{}
+	cached bool
+
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// idempotencyCache tracks the responses to recent requests, indexed by user and request ID.
+type idempotencyCache struct {
+	ttl   time.Duration
+	clock Clock
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		clock:   systemClock(),
+		entries: map[string]*idempotencyEntry{},
+	}
+}
+
+// begin returns the completed entry for the key if one exists, waiting for it if it is in progress.
+// If there is no entry one is created and nil is returned; the caller must then call finish once it has responded.
+// An error is returned if the context is cancelled while waiting.
+func (ic *idempotencyCache) begin(ctx context.Context, key string) (*idempotencyEntry, error) {
+	for {
+		ic.mu.Lock()
+		now := ic.clock.Now()
+		for k, entry := range ic.entries {
+			if !entry.expires.IsZero() && now.After(entry.expires) {
+				delete(ic.entries, k)
+			}
+		}
+
+		entry, found := ic.entries[key]
+		if !found {
+			ic.entries[key] = &idempotencyEntry{
+				done: make(chan struct{}),
+			}
+			ic.mu.Unlock()
+			return nil, nil
+		}
+		ic.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.cached {
+			return entry, nil
+		}
+		// The original request failed so it was removed; try to become the one executing it.
+	}
+}
+
+// finish records the response for the key and releases any requests waiting on it.
+// Responses which weren't completed, weren't a 200, or contain an error code are discarded so the request can be retried.
+func (ic *idempotencyCache) finish(key string, rec *responseRecorder, completed bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	entry := ic.entries[key]
+	entry.statusCode = rec.statusCode
+	entry.header = rec.Header().Clone()
+	entry.body = rec.body.Bytes()
+	entry.expires = ic.clock.Now().Add(ic.ttl)
+	entry.cached = completed && entry.statusCode == http.StatusOK && !hasPayloadErrorCode(entry.body)
+	if !entry.cached {
+		delete(ic.entries, key)
+	}
+	close(entry.done)
+}
+
+// hasPayloadErrorCode checks whether the response body reports an error for the entire request.
+func hasPayloadErrorCode(body []byte) bool {
+	var resp struct {
+		Payload struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	return len(resp.Payload.ErrorCode) > 0
+}
+
+// write replays the recorded response.
+func (entry *idempotencyEntry) write(w http.ResponseWriter) {
+	for k, v := range entry.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(entry.statusCode)
+	w.Write(entry.body)
+}
+
+// responseRecorder passes the response through to the underlying writer while retaining a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+}
+
+// WriteHeader records the status code before passing it through.
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	rr.statusCode = statusCode
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the body before passing it through.
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGoogleFulfillmentHandlerExecuteIdempotency(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespUpdated:     []string{"123"},
+		executeRespDeviceState: NewDeviceState(true).RecordOnOff(true),
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, nil, WithExecuteIdempotency(time.Minute))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	var deduplicated []Event
+	svc.Subscribe(func(e Event) {
+		if _, ok := e.(ExecuteDeduplicated); ok {
+			deduplicated = append(deduplicated, e)
+		}
+	})
+
+	execute := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+			"requestId": "`+requestID+`",
+			"inputs": [{
+				"intent": "action.devices.EXECUTE",
+				"payload": {
+					"commands": [{
+						"devices": [{"id": "123"}],
+						"execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+					}]
+				}
+			}]
+		}`))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := execute("request-1")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.NotNil(t, provider.executeReq)
+
+	provider.executeReq = nil
+	retry := execute("request-1")
+	assert.Nil(t, provider.executeReq)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Len(t, deduplicated, 1)
+
+	execute("request-2")
+	assert.NotNil(t, provider.executeReq)
+	assert.Len(t, deduplicated, 1)
+}
+
+func TestIdempotencyCacheFailedRequestRetried(t *testing.T) {
+	ic := newIdempotencyCache(time.Minute)
+	ctx := context.Background()
+
+	entry, err := ic.begin(ctx, "key")
+	assert.Nil(t, entry)
+	assert.Nil(t, err)
+	rec := newResponseRecorder(httptest.NewRecorder())
+	rec.WriteHeader(http.StatusServiceUnavailable)
+	ic.finish("key", rec, true)
+
+	entry, _ = ic.begin(ctx, "key")
+	assert.Nil(t, entry)
+	rec = newResponseRecorder(httptest.NewRecorder())
+	rec.Write([]byte(`{"requestId":"request-1","payload":{"errorCode":"transientError"}}`))
+	ic.finish("key", rec, true)
+
+	entry, _ = ic.begin(ctx, "key")
+	assert.Nil(t, entry)
+	rec = newResponseRecorder(httptest.NewRecorder())
+	rec.Write([]byte("{"))
+	ic.finish("key", rec, false)
+
+	entry, _ = ic.begin(ctx, "key")
+	assert.Nil(t, entry)
+	rec = newResponseRecorder(httptest.NewRecorder())
+	rec.Write([]byte("{}"))
+	ic.finish("key", rec, true)
+
+	entry, err = ic.begin(ctx, "key")
+	assert.Nil(t, err)
+	assert.NotNil(t, entry)
+	assert.Equal(t, "{}", string(entry.body))
+}
+
+func TestIdempotencyCacheWaitCancelled(t *testing.T) {
+	ic := newIdempotencyCache(time.Minute)
+
+	entry, _ := ic.begin(context.Background(), "key")
+	assert.Nil(t, entry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	entry, err := ic.begin(ctx, "key")
+	assert.Nil(t, entry)
+	assert.Equal(t, context.Canceled, err)
+}
+
+// panickingProvider panics when executing commands.
+type panickingProvider struct {
+	testProvider
+}
+
+func (pp *panickingProvider) Execute(context.Context, *ExecuteRequest) (*ExecuteResponse, error) {
+	panic("execute failed")
+}
+
+func TestGoogleFulfillmentHandlerExecuteIdempotencyPanic(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, &panickingProvider{}, nil, WithExecuteIdempotency(time.Minute))
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "request-1",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+				"commands": [{
+					"devices": [{"id": "123"}],
+					"execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+				}]
+			}
+		}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	assert.Panics(t, func() {
+		svc.GoogleFulfillmentHandler(httptest.NewRecorder(), req)
+	})
+
+	entry, err := svc.idempotency.begin(context.Background(), "1836.15267389/request-1")
+	assert.Nil(t, err)
+	assert.Nil(t, entry)
+}
@@ -0,0 +1,107 @@
+package action
+
+import (
+	"context"
+)
+
+// InverseCommand computes the command which restores the device to its prior state after command is applied,
+// supporting rollback (see TransactionalExecutor) and undo. prior must be the state of the device before the command
+// was applied. false is returned if the inverse is not well-defined for the command, or if prior doesn't contain
+// the state needed to compute it. The following commands are supported:
+// - OnOff, restoring on
+// - BrightnessAbsolute and BrightnessRelative, restoring brightness
+// - ColorAbsolute, restoring color as an RGB, HSV or temperature value
+// - mute, restoring isMuted
+// - setVolume and volumeRelative, restoring currentVolume
+// - SetInput, NextInput and PreviousInput, restoring the input
+func InverseCommand(command Command, prior DeviceState) (Command, bool) {
+	inverse := Command{
+		Name: command.Name,
+	}
+
+	switch command.Name {
+	case "action.devices.commands.OnOff":
+		on, ok := prior.State["on"].(bool)
+		if !ok {
+			return Command{}, false
+		}
+		inverse.OnOff = &CommandOnOff{On: on}
+	case "action.devices.commands.BrightnessAbsolute", "action.devices.commands.BrightnessRelative":
+		brightness, ok := NumberFloat(prior.State["brightness"])
+		if !ok {
+			return Command{}, false
+		}
+		inverse.Name = "action.devices.commands.BrightnessAbsolute"
+		inverse.BrightnessAbsolute = &CommandBrightnessAbsolute{Brightness: int(brightness)}
+	case "action.devices.commands.ColorAbsolute":
+		color, ok := priorColor(prior)
+		if !ok {
+			return Command{}, false
+		}
+		inverse.ColorAbsolute = color
+	case "action.devices.commands.mute":
+		isMuted, ok := prior.State["isMuted"].(bool)
+		if !ok {
+			return Command{}, false
+		}
+		inverse.Mute = &CommandMute{Mute: isMuted}
+	case "action.devices.commands.setVolume", "action.devices.commands.volumeRelative":
+		volume, ok := NumberFloat(prior.State["currentVolume"])
+		if !ok {
+			return Command{}, false
+		}
+		inverse.Name = "action.devices.commands.setVolume"
+		inverse.SetVolume = &CommandSetVolume{Level: int(volume)}
+	case "action.devices.commands.SetInput", "action.devices.commands.NextInput", "action.devices.commands.PreviousInput":
+		input, ok := prior.State["input"].(string)
+		if !ok || len(input) < 1 {
+			return Command{}, false
+		}
+		inverse.Name = "action.devices.commands.SetInput"
+		inverse.SetInput = &CommandSetInput{NewInput: input}
+	default:
+		return Command{}, false
+	}
+	return inverse, true
+}
+
+// InverseFromState adapts InverseCommand for use as TransactionalExecutor.Inverse.
+// state is called to retrieve the current state of each device before a command is applied to it.
+func InverseFromState(state func(ctx context.Context, device DeviceArg) (DeviceState, error)) func(context.Context, DeviceArg, Command) (Command, bool, error) {
+	return func(ctx context.Context, device DeviceArg, command Command) (Command, bool, error) {
+		prior, err := state(ctx, device)
+		if err != nil {
+			return Command{}, false, err
+		}
+		inverse, ok := InverseCommand(command, prior)
+		return inverse, ok, nil
+	}
+}
+
+// priorColor converts the color recorded in the state into the command which sets it.
+func priorColor(prior DeviceState) (*CommandColorAbsolute, bool) {
+	color, ok := prior.State["color"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	cmd := &CommandColorAbsolute{}
+	if rgb, ok := NumberFloat(color["spectrumRgb"]); ok {
+		cmd.Color.RGB = int(rgb)
+		return cmd, true
+	} else if temperatureK, ok := NumberFloat(color["temperatureK"]); ok {
+		cmd.Color.Temperature = int(temperatureK)
+		return cmd, true
+	} else if hsv, ok := color["spectrumHsv"].(map[string]interface{}); ok {
+		hue, hueOK := NumberFloat(hsv["hue"])
+		saturation, saturationOK := NumberFloat(hsv["saturation"])
+		value, valueOK := NumberFloat(hsv["value"])
+		if hueOK && saturationOK && valueOK {
+			cmd.Color.HSV.Hue = hue
+			cmd.Color.HSV.Saturation = saturation
+			cmd.Color.HSV.Value = value
+			return cmd, true
+		}
+	}
+	return nil, false
+}
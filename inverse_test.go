@@ -0,0 +1,73 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInverseCommand(t *testing.T) {
+	prior := NewDeviceState(true).RecordOnOff(false).RecordBrightness(40).RecordVolume(7, true).RecordInput("hdmi_1")
+
+	inverse, ok := InverseCommand(Command{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: true}}, prior)
+	assert.True(t, ok)
+	assert.Equal(t, Command{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: false}}, inverse)
+
+	percent := 10
+	inverse, ok = InverseCommand(Command{Name: "action.devices.commands.BrightnessRelative", BrightnessRelative: &CommandBrightnessRelative{RelativePercent: &percent}}, prior)
+	assert.True(t, ok)
+	assert.Equal(t, Command{Name: "action.devices.commands.BrightnessAbsolute", BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 40}}, inverse)
+
+	inverse, ok = InverseCommand(Command{Name: "action.devices.commands.volumeRelative", AdjustVolume: &CommandSetVolumeRelative{Amount: 2}}, prior)
+	assert.True(t, ok)
+	assert.Equal(t, &CommandSetVolume{Level: 7}, inverse.SetVolume)
+
+	inverse, ok = InverseCommand(Command{Name: "action.devices.commands.mute", Mute: &CommandMute{Mute: false}}, prior)
+	assert.True(t, ok)
+	assert.Equal(t, &CommandMute{Mute: true}, inverse.Mute)
+
+	inverse, ok = InverseCommand(Command{Name: "action.devices.commands.NextInput", NextInput: &CommandNextInput{}}, prior)
+	assert.True(t, ok)
+	assert.Equal(t, Command{Name: "action.devices.commands.SetInput", SetInput: &CommandSetInput{NewInput: "hdmi_1"}}, inverse)
+
+	_, ok = InverseCommand(Command{Name: "action.devices.commands.relativeChannel", RelativeChannel: &CommandRelativeChannel{ChannelChange: 1}}, prior)
+	assert.False(t, ok)
+	_, ok = InverseCommand(Command{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: true}}, NewDeviceState(true))
+	assert.False(t, ok)
+}
+
+func TestInverseCommandColor(t *testing.T) {
+	colorCommand := Command{Name: "action.devices.commands.ColorAbsolute", ColorAbsolute: &CommandColorAbsolute{}}
+
+	inverse, ok := InverseCommand(colorCommand, NewDeviceState(true).RecordColorTemperature(2700))
+	assert.True(t, ok)
+	assert.Equal(t, 2700, inverse.ColorAbsolute.Color.Temperature)
+
+	inverse, ok = InverseCommand(colorCommand, NewDeviceState(true).RecordColorHSV(120, 0.5, 1))
+	assert.True(t, ok)
+	assert.Equal(t, 120.0, inverse.ColorAbsolute.Color.HSV.Hue)
+	assert.Equal(t, 0.5, inverse.ColorAbsolute.Color.HSV.Saturation)
+
+	// States deserialized from JSON (i.e. from a state store) are supported as well.
+	prior := DeviceState{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"online":true,"color":{"spectrumRgb":31655}}`), &prior))
+	inverse, ok = InverseCommand(colorCommand, prior)
+	assert.True(t, ok)
+	assert.Equal(t, 31655, inverse.ColorAbsolute.Color.RGB)
+}
+
+func TestInverseFromState(t *testing.T) {
+	inverseFunc := InverseFromState(func(_ context.Context, device DeviceArg) (DeviceState, error) {
+		return NewDeviceState(true).RecordBrightness(25), nil
+	})
+
+	inverse, ok, err := inverseFunc(context.Background(), DeviceArg{ID: "light-1"}, Command{
+		Name:               "action.devices.commands.BrightnessAbsolute",
+		BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 80},
+	})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 25, inverse.BrightnessAbsolute.Brightness)
+}
@@ -0,0 +1,115 @@
+package action
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrRequestTooLarge is returned while reading a fulfillment request body which exceeds the size set using WithMaxRequestBodyBytes.
+	ErrRequestTooLarge = errors.New("request body too large")
+	// ErrRequestReadTimeout is returned while reading a fulfillment request body which takes longer than the timeout set using WithRequestReadTimeout.
+	ErrRequestReadTimeout = errors.New("request body read timed out")
+)
+
+// WithMaxRequestBodyBytes limits the size of the fulfillment request bodies which will be read.
+// Larger requests are rejected with a 413 rather than being buffered in memory.
+// A limit of 0 or less disables the check.
+func WithMaxRequestBodyBytes(maxBytes int64) ServiceOption {
+	return func(s *Service) {
+		s.maxRequestBodyBytes = maxBytes
+	}
+}
+
+// WithRequestReadTimeout limits how long reading the body of a fulfillment request may take.
+// Requests which trickle in slower than this are rejected with a 408.
+// The check is applied between reads, so http.Server.ReadTimeout should also be set to cut off clients which stall completely.
+// A timeout of 0 or less disables the check.
+func WithRequestReadTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.requestReadTimeout = timeout
+	}
+}
+
+// WithMaxRequestDevices limits the number of devices which a single QUERY or EXECUTE request may target.
+// Requests targeting more devices are rejected with protocolError without being passed to the provider.
+// Each device of each command in an EXECUTE request is counted.
+// A limit of 0 or less disables the check.
+func WithMaxRequestDevices(maxDevices int) ServiceOption {
+	return func(s *Service) {
+		s.maxRequestDevices = maxDevices
+	}
+}
+
+// limitedBody enforces the size and read time limits on a request body.
+type limitedBody struct {
+	body io.ReadCloser
+
+	limited   bool
+	remaining int64
+	deadline  time.Time
+	clock     Clock
+}
+
+// limitRequestBody wraps the body with the configured limits, if any are set.
+func (s *Service) limitRequestBody(body io.ReadCloser) io.ReadCloser {
+	if s.maxRequestBodyBytes <= 0 && s.requestReadTimeout <= 0 {
+		return body
+	}
+
+	lb := &limitedBody{
+		body:  body,
+		clock: s.clock,
+	}
+	if s.maxRequestBodyBytes > 0 {
+		lb.limited = true
+		lb.remaining = s.maxRequestBodyBytes
+	}
+	if s.requestReadTimeout > 0 {
+		lb.deadline = s.now().Add(s.requestReadTimeout)
+	}
+	return lb
+}
+
+// Read reads from the underlying body, failing once either of the limits is exceeded.
+func (lb *limitedBody) Read(p []byte) (int, error) {
+	if !lb.deadline.IsZero() && lb.clock.Now().After(lb.deadline) {
+		return 0, ErrRequestReadTimeout
+	}
+	if !lb.limited {
+		return lb.body.Read(p)
+	}
+
+	// Read one byte past the limit so a body of exactly the limit isn't rejected.
+	if int64(len(p)) > lb.remaining+1 {
+		p = p[:lb.remaining+1]
+	}
+	n, err := lb.body.Read(p)
+	if int64(n) > lb.remaining {
+		n = int(lb.remaining)
+		lb.remaining = 0
+		return n, ErrRequestTooLarge
+	}
+	lb.remaining -= int64(n)
+	return n, err
+}
+
+// Close closes the underlying body.
+func (lb *limitedBody) Close() error {
+	return lb.body.Close()
+}
+
+// requestDeviceCount returns the number of devices targeted by the supplied QUERY or EXECUTE input.
+func requestDeviceCount(input FulfillmentInput) int {
+	count := 0
+	if input.Query != nil {
+		count += len(input.Query.Devices)
+	}
+	if input.Execute != nil {
+		for _, command := range input.Execute.Commands {
+			count += len(command.Devices)
+		}
+	}
+	return count
+}
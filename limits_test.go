@@ -0,0 +1,111 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGoogleFulfillmentHandlerMaxRequestBodyBytes(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	body := `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","inputs":[{"intent":"action.devices.SYNC"}]}`
+
+	svc := NewService(zaptest.NewLogger(t), authenticator, &testProvider{}, nil, WithMaxRequestBodyBytes(int64(len(body))))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(body))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(body+strings.Repeat(" ", 64)))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{"requestId":"`+strings.Repeat("a", 200)+`"}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestLimitedBodyReadTimeout(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithRequestReadTimeout(time.Minute))
+	body := svc.limitRequestBody(ioutil.NopCloser(bytes.NewBufferString("{}")))
+
+	data, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, "{}", string(data))
+
+	expired := &limitedBody{
+		body:     ioutil.NopCloser(bytes.NewBufferString("{}")),
+		deadline: time.Now().Add(-time.Second),
+		clock:    systemClock(),
+	}
+	_, err = ioutil.ReadAll(expired)
+	assert.Equal(t, ErrRequestReadTimeout, err)
+}
+
+func TestGoogleFulfillmentHandlerMaxRequestDevices(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespUpdated:     []string{"1", "2"},
+		executeRespDeviceState: NewDeviceState(true).RecordOnOff(true),
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, nil, WithMaxRequestDevices(2))
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	execute := func(ids ...string) *httptest.ResponseRecorder {
+		var devices []string
+		for _, id := range ids {
+			devices = append(devices, `{"id":"`+id+`"}`)
+		}
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+			"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+			"inputs": [{
+				"intent": "action.devices.EXECUTE",
+				"payload": {
+					"commands": [{
+						"devices": [`+strings.Join(devices, ",")+`],
+						"execution": [{"command": "action.devices.commands.OnOff", "params": {"on": true}}]
+					}]
+				}
+			}]
+		}`))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := execute("1", "2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotNil(t, provider.executeReq)
+
+	provider.executeReq = nil
+	rr = execute("1", "2", "3")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, provider.executeReq)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"protocolError","debugString":"request targets too many devices"}}
+`, rr.Body.String())
+}
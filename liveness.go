@@ -0,0 +1,144 @@
+package action
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WithLivenessTimeout enables tracking whether devices are online based on when they were last seen (see MarkSeen).
+// A device which hasn't been seen for longer than the timeout is flipped offline by CheckLiveness: the offline state is
+// reported to Google, and QUERY responses for the device are answered with online set to false until it is seen again.
+// Only devices which have been passed to MarkSeen are tracked; the online state of any other device is left to the provider.
+func WithLivenessTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.liveness = newLivenessTracker(timeout)
+	}
+}
+
+// livenessDevice identifies a single device of a user.
+type livenessDevice struct {
+	agentUserID string
+	deviceID    string
+}
+
+// livenessEntry tracks when a device was last seen, and whether it has been flipped offline.
+type livenessEntry struct {
+	lastSeen time.Time
+	offline  bool
+}
+
+// livenessTracker tracks when each device was last seen.
+type livenessTracker struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	devices map[livenessDevice]*livenessEntry
+}
+
+func newLivenessTracker(timeout time.Duration) *livenessTracker {
+	return &livenessTracker{
+		timeout: timeout,
+		devices: map[livenessDevice]*livenessEntry{},
+	}
+}
+
+// seen records the device as being seen at the specified time.
+func (lt *livenessTracker) seen(agentUserID string, deviceID string, now time.Time) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	lt.devices[livenessDevice{agentUserID, deviceID}] = &livenessEntry{
+		lastSeen: now,
+	}
+}
+
+// isOffline returns whether the device has been flipped offline.
+func (lt *livenessTracker) isOffline(agentUserID string, deviceID string) bool {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	entry, found := lt.devices[livenessDevice{agentUserID, deviceID}]
+	return found && entry.offline
+}
+
+// expire flips every device which hasn't been seen within the timeout offline, returning the newly offline devices.
+func (lt *livenessTracker) expire(now time.Time) map[livenessDevice]time.Time {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	expired := map[livenessDevice]time.Time{}
+	for device, entry := range lt.devices {
+		if entry.offline || now.Sub(entry.lastSeen) <= lt.timeout {
+			continue
+		}
+		entry.offline = true
+		expired[device] = entry.lastSeen
+	}
+	return expired
+}
+
+// MarkSeen records that the device of the user is alive; providers should call this whenever they hear from the device.
+// A device which was previously flipped offline is treated as online again. Has no effect unless WithLivenessTimeout is set.
+func (s *Service) MarkSeen(agentUserID string, deviceID string) {
+	if s.liveness == nil {
+		return
+	}
+	s.liveness.seen(agentUserID, deviceID, s.now())
+}
+
+// CheckLiveness flips any device which hasn't been seen within the liveness timeout offline and reports the offline
+// state of those devices to Google. Has no effect unless WithLivenessTimeout is set.
+func (s *Service) CheckLiveness(ctx context.Context) error {
+	if s.liveness == nil {
+		return nil
+	}
+
+	now := s.now()
+	expired := s.liveness.expire(now)
+
+	states := map[string]map[string]DeviceState{}
+	for device, lastSeen := range expired {
+		if states[device.agentUserID] == nil {
+			states[device.agentUserID] = map[string]DeviceState{}
+		}
+		states[device.agentUserID][device.deviceID] = NewDeviceState(false)
+
+		s.events.publish(DeviceWentOffline{
+			Time:        now,
+			AgentUserID: device.agentUserID,
+			DeviceID:    device.deviceID,
+			LastSeen:    lastSeen,
+		})
+	}
+
+	var agentUserIDs []string
+	for agentUserID := range states {
+		agentUserIDs = append(agentUserIDs, agentUserID)
+	}
+	sort.Strings(agentUserIDs)
+
+	var reportErr error
+	for _, agentUserID := range agentUserIDs {
+		if err := s.ReportState(ctx, agentUserID, states[agentUserID]); err != nil {
+			reportErr = err
+		}
+	}
+	return reportErr
+}
+
+// RunLivenessCheck calls CheckLiveness every interval.
+// It blocks until the context is cancelled, so it should be run on its own goroutine.
+// Failures are logged and the devices are checked again at the next interval.
+func (s *Service) RunLivenessCheck(ctx context.Context, interval time.Duration) {
+	s.runEvery(ctx, interval, func() {
+		if err := s.CheckLiveness(ctx); err != nil {
+			s.logger.Info("error reporting offline devices",
+				zap.Error(err),
+			)
+		}
+	})
+}
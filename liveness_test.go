@@ -0,0 +1,92 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceCheckLiveness(t *testing.T) {
+	thg := &testHomeGraph{}
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithLivenessTimeout(time.Minute),
+		WithClock(ClockFunc(func() time.Time {
+			return now
+		})),
+	)
+
+	var events []Event
+	svc.Subscribe(func(e Event) {
+		if _, ok := e.(DeviceWentOffline); ok {
+			events = append(events, e)
+		}
+	})
+
+	svc.MarkSeen("agent-id", "light-1")
+	svc.MarkSeen("agent-id", "light-2")
+
+	now = now.Add(30 * time.Second)
+	svc.MarkSeen("agent-id", "light-2")
+	assert.Nil(t, svc.CheckLiveness(context.Background()))
+	assert.Empty(t, thg.paths)
+
+	now = now.Add(45 * time.Second)
+	assert.Nil(t, svc.CheckLiveness(context.Background()))
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+	assert.Contains(t, thg.bodies[0], `"light-1":{"online":false}`)
+	assert.NotContains(t, thg.bodies[0], "light-2")
+	assert.Equal(t, []Event{DeviceWentOffline{
+		Time:        now,
+		AgentUserID: "agent-id",
+		DeviceID:    "light-1",
+		LastSeen:    now.Add(-75 * time.Second),
+	}}, events)
+
+	// An offline device is only reported once.
+	assert.Nil(t, svc.CheckLiveness(context.Background()))
+	assert.Len(t, thg.paths, 1)
+}
+
+func TestGoogleFulfillmentHandlerQueryLiveness(t *testing.T) {
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithLivenessTimeout(time.Minute),
+		WithClock(ClockFunc(func() time.Time {
+			return now
+		})),
+	)
+
+	query := func() string {
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+			"requestId": "1",
+			"inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1"}]}}]
+		}`))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		svc.GoogleFulfillmentHandler(rr, req)
+		return rr.Body.String()
+	}
+
+	svc.MarkSeen("agent-id", "light-1")
+	now = now.Add(2 * time.Minute)
+	assert.Contains(t, query(), `"online":true`)
+
+	assert.Nil(t, svc.CheckLiveness(context.Background()))
+	assert.Contains(t, query(), `"online":false`)
+
+	svc.MarkSeen("agent-id", "light-1")
+	assert.Contains(t, query(), `"online":true`)
+}
@@ -0,0 +1,165 @@
+package action
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// validErrorCodes contains every error code defined by Google.
+// See https://developers.google.com/assistant/smarthome/reference/errors-exceptions
+var validErrorCodes = map[string]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		aboveMaximumLightEffectsDuration aboveMaximumTimerDuration actionNotAvailable actionUnavailableWhileRunning
+		alreadyArmed alreadyAtMax alreadyAtMin alreadyClosed alreadyDisarmed alreadyDocked alreadyInState alreadyLocked
+		alreadyOff alreadyOn alreadyOpen alreadyPaused alreadyStarted alreadyStopped alreadyUnlocked ambiguousZoneName
+		amountAboveLimit appLaunchFailed armFailure armLevelNeeded authExpired authFailure bagFull
+		belowMinimumLightEffectsDuration belowMinimumTimerDuration binFull cancelArmingRestricted cancelTooLate
+		challengeNeeded channelSwitchFailed chargerIssue commandInsertFailed deadBattery degreesOutOfRange
+		deviceAlertNeedsAssistance deviceAtExtremeTemperature deviceBusy deviceCharging deviceClogged
+		deviceCurrentlyDispensing deviceDoorOpen deviceHandleClosed deviceJammingDetected deviceLidOpen deviceNeedsRepair
+		deviceNotDocked deviceNotFound deviceNotMounted deviceNotReady deviceOffline deviceStuck deviceTampered
+		deviceThermalShutdown directResponseOnlyUnreachable disarmFailure discreteOnlyOpenClose dispenseAmountAboveLimit
+		dispenseAmountBelowLimit dispenseAmountRemainingExceeded dispenseFractionalAmountNotSupported
+		dispenseFractionalUnitNotSupported dispenseUnitNotSupported doorClosedTooLong emergencyHeatOn faultyBattery
+		floorUnreachable functionNotSupported genericDispenseNotSupported hardError hardwareFailure inAutoMode inAwayMode
+		inDryMode inEcoMode inFanOnlyMode inHeatOrCool inHumidifierMode inOffMode inPurifierMode inSleepMode
+		inSoftwareUpdate lockFailure lockState lockedState lockedToRange lowBattery maxSettingReached maxSpeedReached
+		minSettingReached minSpeedReached monitoringServiceConnectionLost needsAttachment needsBin needsPads
+		needsSoftwareUpdate needsWater networkProfileNotRecognized networkSpeedTestInProgress noAvailableApp
+		noAvailableChannel noChannelSubscription noTimerExists notSupported obstructionDetected offline onRequiresMode
+		passphraseIncorrect percentOutOfRange pinIncorrect protocolError rainDetected rangeTooClose relinkRequired
+		remoteSetDisabled roomsOnDifferentFloors safetyShutOff sceneCannotBeApplied securityRestriction
+		softwareUpdateNotAvailable startRequiresTime stillCoolingDown stillWarmingUp streamUnavailable streamUnplayable
+		tankEmpty targetAlreadyReached timeout timerValueOutOfRange tooManyFailedAttempts transientError turnedOff
+		unableToLocateDevice unknownError unknownFoodPreset unlockFailure unpausableState userCancelled valueOutOfRange
+	`) {
+		validErrorCodes[code] = true
+	}
+}
+
+// IsValidErrorCode returns whether the error code is one of the codes defined by Google.
+// Google only recognizes these codes; any other code is treated as a generic failure.
+func IsValidErrorCode(errorCode string) bool {
+	return validErrorCodes[errorCode]
+}
+
+// WithErrorCodeValidation ensures only the error codes defined by Google (see IsValidErrorCode) are sent to Google.
+// Any other error code supplied by the provider is logged and replaced with unknownError.
+func WithErrorCodeValidation() ServiceOption {
+	return func(s *Service) {
+		s.validateErrorCodes = true
+	}
+}
+
+// checkErrorCode returns the error code which should be sent to Google in place of the supplied one.
+func (s *Service) checkErrorCode(ctx context.Context, errorCode string) string {
+	if !s.validateErrorCodes || len(errorCode) < 1 || IsValidErrorCode(errorCode) {
+		return errorCode
+	}
+
+	s.logger.Info("replacing unrecognized error code",
+		requestIDField(ctx),
+		zap.String("error_code", errorCode),
+	)
+	return ErrorCodeUnknownError
+}
+
+// WithMessageCatalog causes the message for each error code reported to Google for an entire request to be logged,
+// using the catalog's messages in the specified language.
+func WithMessageCatalog(catalog *MessageCatalog, languageCode string) ServiceOption {
+	return func(s *Service) {
+		s.messageCatalog = catalog
+		s.messageLanguage = languageCode
+	}
+}
+
+// logErrorCode logs the error code being reported to Google, along with its message if a catalog was supplied.
+func (s *Service) logErrorCode(ctx context.Context, errorCode string) {
+	if s.messageCatalog == nil || len(errorCode) < 1 {
+		return
+	}
+
+	s.logger.Info("reporting error code",
+		requestIDField(ctx),
+		zap.String("error_code", errorCode),
+		zap.String("error_message", s.messageCatalog.Message(s.messageLanguage, errorCode)),
+	)
+}
+
+// DefaultLanguage is the language the messages of a MessageCatalog fall back to.
+const DefaultLanguage = "en"
+
+// MessageCatalog contains human-readable descriptions of error codes in different languages, for use in logs and dashboards.
+// WithMessageCatalog includes these in the logs of the Service.
+// These are not sent to Google; the message spoken to the user is chosen by Google based on the error code.
+// A MessageCatalog is safe for concurrent use.
+type MessageCatalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// NewMessageCatalog creates a catalog containing English messages for the error codes used by this library.
+func NewMessageCatalog() *MessageCatalog {
+	mc := &MessageCatalog{
+		messages: map[string]map[string]string{},
+	}
+	for errorCode, message := range map[string]string{
+		ErrorCodeActionNotAvailable: "The action is not available right now.",
+		ErrorCodeAuthExpired:        "The access token has expired.",
+		ErrorCodeAuthFailure:        "The device could not be reached due to an authentication failure.",
+		ErrorCodeDeviceNotFound:     "The device could not be found.",
+		ErrorCodeDeviceOffline:      "The device is offline.",
+		ErrorCodeNotSupported:       "The request is not supported.",
+		ErrorCodeProtocolError:      "The request could not be processed.",
+		ErrorCodeRelinkRequired:     "The account needs to be linked again.",
+		ErrorCodeTimeout:            "The device took too long to respond.",
+		ErrorCodeTransientError:     "A temporary error occurred; try again.",
+		ErrorCodeUnknownError:       "An unknown error occurred.",
+		"challengeNeeded":           "Additional verification is required.",
+		"functionNotSupported":      "The device doesn't support that function.",
+		"valueOutOfRange":           "The value is out of range for the device.",
+		"pinIncorrect":              "The PIN was incorrect.",
+	} {
+		mc.Add(DefaultLanguage, errorCode, message)
+	}
+	return mc
+}
+
+// Add records the message for the error code in the specified language (i.e. "fr" or "fr-CA").
+func (mc *MessageCatalog) Add(languageCode string, errorCode string, message string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	languageCode = strings.ToLower(languageCode)
+	if mc.messages[languageCode] == nil {
+		mc.messages[languageCode] = map[string]string{}
+	}
+	mc.messages[languageCode][errorCode] = message
+}
+
+// Message returns the message for the error code in the specified language.
+// If there is no message for a regional language (i.e. "fr-CA") the base language ("fr") is used,
+// followed by DefaultLanguage. The error code itself is returned if no message is found.
+func (mc *MessageCatalog) Message(languageCode string, errorCode string) string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	languageCode = strings.ToLower(languageCode)
+	candidates := []string{languageCode}
+	if idx := strings.IndexAny(languageCode, "-_"); idx > 0 {
+		candidates = append(candidates, languageCode[:idx])
+	}
+	candidates = append(candidates, DefaultLanguage)
+
+	for _, candidate := range candidates {
+		if message, found := mc.messages[candidate][errorCode]; found {
+			return message
+		}
+	}
+	return errorCode
+}
@@ -0,0 +1,120 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestIsValidErrorCode(t *testing.T) {
+	for _, code := range []string{ErrorCodeActionNotAvailable, ErrorCodeAuthExpired, ErrorCodeAuthFailure,
+		ErrorCodeDeviceNotFound, ErrorCodeDeviceOffline, ErrorCodeNotSupported, ErrorCodeProtocolError,
+		ErrorCodeRelinkRequired, ErrorCodeTimeout, ErrorCodeTransientError, ErrorCodeUnknownError, "challengeNeeded"} {
+		assert.True(t, IsValidErrorCode(code), code)
+	}
+	assert.False(t, IsValidErrorCode("bulbExploded"))
+	assert.False(t, IsValidErrorCode(""))
+}
+
+func TestMessageCatalog(t *testing.T) {
+	mc := NewMessageCatalog()
+	mc.Add("fr", ErrorCodeDeviceOffline, "L'appareil est hors ligne.")
+	mc.Add("fr-CA", ErrorCodeDeviceOffline, "L'appareil est déconnecté.")
+
+	assert.Equal(t, "The device is offline.", mc.Message("en-US", ErrorCodeDeviceOffline))
+	assert.Equal(t, "L'appareil est hors ligne.", mc.Message("fr-FR", ErrorCodeDeviceOffline))
+	assert.Equal(t, "L'appareil est déconnecté.", mc.Message("fr-CA", ErrorCodeDeviceOffline))
+	assert.Equal(t, "The device could not be found.", mc.Message("fr", ErrorCodeDeviceNotFound))
+	assert.Equal(t, "bulbExploded", mc.Message("en", "bulbExploded"))
+}
+
+func TestGoogleFulfillmentHandlerErrorCodeValidation(t *testing.T) {
+	provider := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": {Status: "ERROR", ErrorCode: "bulbExploded"},
+			"light-2": {Status: "ERROR", ErrorCode: ErrorCodeDeviceOffline},
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil,
+		WithErrorCodeValidation(),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1"}, {"id": "light-2"}]}}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, `{"requestId":"1","payload":{"devices":{"light-1":{"errorCode":"unknownError","online":false,"status":"ERROR"},"light-2":{"errorCode":"deviceOffline","online":false,"status":"ERROR"}}}}
+`, rr.Body.String())
+}
+
+func TestGoogleFulfillmentHandlerMessageCatalog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	provider := &testProvider{
+		queryErr: NewIntentError(ErrorCodeDeviceOffline, errors.New("hub unreachable")),
+	}
+	mc := NewMessageCatalog()
+	mc.Add("fr", ErrorCodeDeviceOffline, "L'appareil est hors ligne.")
+	svc := NewService(zap.New(core), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil,
+		WithMessageCatalog(mc, "fr-CA"),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "light-1"}]}}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, `{"requestId":"1","payload":{"errorCode":"deviceOffline"}}
+`, rr.Body.String())
+	entries := logs.FilterMessage("reporting error code").AllUntimed()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, map[string]interface{}{
+			"request_id":    "1",
+			"error_code":    ErrorCodeDeviceOffline,
+			"error_message": "L'appareil est hors ligne.",
+		}, entries[0].ContextMap())
+	}
+}
+
+func TestGoogleFulfillmentHandlerErrorCodeValidationRequestContext(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	svc := NewService(zap.New(core), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, &testProvider{}, nil,
+		WithErrorCodeValidation(),
+	)
+	svc.RegisterIntentHandler("action.devices.IDENTIFY", func(ctx context.Context, agentUserID string, payload json.RawMessage) (interface{}, error) {
+		return nil, NewIntentError("bulbExploded", errors.New("bulb exploded"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "2",
+		"inputs": [{"intent": "action.devices.IDENTIFY"}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, `{"requestId":"2","payload":{"errorCode":"unknownError"}}
+`, rr.Body.String())
+	entries := logs.FilterMessage("replacing unrecognized error code").AllUntimed()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "2", entries[0].ContextMap()["request_id"])
+	}
+}
@@ -0,0 +1,109 @@
+package action
+
+import (
+	"context"
+	"sync"
+)
+
+// MetadataCache is a Provider wrapper which remembers the devices returned by Sync for each user, so the wrapped
+// provider's Query and Execute can resolve device IDs to their backend details (i.e. the address in CustomData)
+// without another lookup against its backing store. The devices of the user are available from the context passed
+// to Query and Execute using DeviceMetadataFromContext. If no Sync has been seen for the user (i.e. after a restart)
+// the wrapped provider's Sync is called to populate the cache first.
+type MetadataCache struct {
+	provider Provider
+
+	mu      sync.RWMutex
+	devices map[string]map[string]*Device
+}
+
+// NewMetadataCache creates a provider which caches the device metadata returned by provider.
+func NewMetadataCache(provider Provider) *MetadataCache {
+	return &MetadataCache{
+		provider: provider,
+		devices:  map[string]map[string]*Device{},
+	}
+}
+
+type metadataKey struct{}
+
+// DeviceMetadataFromContext returns the device most recently returned by Sync with the specified ID.
+// The context must be the one supplied to Query or Execute by a MetadataCache.
+func DeviceMetadataFromContext(ctx context.Context, deviceID string) (*Device, bool) {
+	devices, _ := ctx.Value(metadataKey{}).(map[string]*Device)
+	device, found := devices[deviceID]
+	return device, found
+}
+
+// Lookup returns the device of the user with the specified ID, populating the cache using Sync if required.
+func (mc *MetadataCache) Lookup(ctx context.Context, agentUserID string, deviceID string) (*Device, bool, error) {
+	devices, err := mc.userDevices(ctx, agentUserID)
+	if err != nil {
+		return nil, false, err
+	}
+	device, found := devices[deviceID]
+	return device, found, nil
+}
+
+// userDevices returns the cached devices of the user, populating the cache using Sync if required.
+func (mc *MetadataCache) userDevices(ctx context.Context, agentUserID string) (map[string]*Device, error) {
+	mc.mu.RLock()
+	devices, found := mc.devices[agentUserID]
+	mc.mu.RUnlock()
+	if found {
+		return devices, nil
+	}
+
+	if _, err := mc.Sync(ctx, agentUserID); err != nil {
+		return nil, err
+	}
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.devices[agentUserID], nil
+}
+
+// Sync calls the wrapped provider, caching the returned devices.
+func (mc *MetadataCache) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	resp, err := mc.provider.Sync(ctx, agentUserID)
+	if err != nil || len(resp.ErrorCode) > 0 {
+		return resp, err
+	}
+
+	devices := map[string]*Device{}
+	for _, device := range resp.Devices {
+		devices[device.ID] = device
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.devices[agentUserID] = devices
+	return resp, nil
+}
+
+// Disconnect discards the cached devices of the user, then calls the wrapped provider.
+func (mc *MetadataCache) Disconnect(ctx context.Context, agentUserID string) error {
+	mc.mu.Lock()
+	delete(mc.devices, agentUserID)
+	mc.mu.Unlock()
+
+	return mc.provider.Disconnect(ctx, agentUserID)
+}
+
+// Query calls the wrapped provider with the devices of the user in the context.
+func (mc *MetadataCache) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	devices, err := mc.userDevices(ctx, req.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	return mc.provider.Query(context.WithValue(ctx, metadataKey{}, devices), req)
+}
+
+// Execute calls the wrapped provider with the devices of the user in the context.
+func (mc *MetadataCache) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	devices, err := mc.userDevices(ctx, req.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	return mc.provider.Execute(context.WithValue(ctx, metadataKey{}, devices), req)
+}
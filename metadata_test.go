@@ -0,0 +1,57 @@
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metadataProvider counts calls to Sync and records the metadata available to Query.
+type metadataProvider struct {
+	testProvider
+	syncs    int
+	metadata *Device
+}
+
+func (mp *metadataProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	mp.syncs++
+	return mp.testProvider.Sync(ctx, agentUserID)
+}
+
+func (mp *metadataProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	mp.metadata, _ = DeviceMetadataFromContext(ctx, req.Devices[0].ID)
+	return mp.testProvider.Query(ctx, req)
+}
+
+func TestMetadataCache(t *testing.T) {
+	light := NewLight("light-1")
+	light.CustomData["address"] = "10.0.0.5"
+	provider := &metadataProvider{
+		testProvider: testProvider{syncResp: []*Device{light}},
+	}
+	mc := NewMetadataCache(provider)
+
+	// The cache is populated on demand if no Sync has been seen.
+	_, err := mc.Query(context.Background(), &QueryRequest{AgentID: "agent-id", Devices: []DeviceArg{{ID: "light-1"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, provider.syncs)
+	assert.Equal(t, "10.0.0.5", provider.metadata.CustomData["address"])
+
+	_, err = mc.Query(context.Background(), &QueryRequest{AgentID: "agent-id", Devices: []DeviceArg{{ID: "light-1"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, provider.syncs)
+
+	device, found, err := mc.Lookup(context.Background(), "agent-id", "light-1")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, light, device)
+	_, found, err = mc.Lookup(context.Background(), "agent-id", "light-2")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, mc.Disconnect(context.Background(), "agent-id"))
+	_, _, err = mc.Lookup(context.Background(), "agent-id", "light-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, provider.syncs)
+}
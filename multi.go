@@ -0,0 +1,111 @@
+package action
+
+import (
+	"context"
+)
+
+// NewMultiProvider creates a provider which combines the devices of several child providers, indexed by namespace
+// (i.e. one for a Hue bridge and one for MQTT devices). The ID of each device returned by a child in SYNC is prefixed
+// with the child's namespace, so devices with the same ID from different children don't collide, and QUERY and
+// EXECUTE requests are routed back to the child which owns the device with the prefix removed.
+// Devices whose ID doesn't name a known child are reported as deviceNotFound.
+// ErrInvalidNamespace is returned if any of the namespaces is invalid; see Namespace.Validate.
+func NewMultiProvider(children map[string]Provider) (*ShardedProvider, error) {
+	shards := map[string]Provider{}
+	for ns, provider := range children {
+		if err := Namespace(ns).Validate(); err != nil {
+			return nil, err
+		}
+		shards[ns] = &namespacedProvider{
+			ns:       Namespace(ns),
+			provider: provider,
+		}
+	}
+	return NewShardedProvider(shards, func(_ string, device DeviceArg) string {
+		ns, _, _ := SplitNamespacedID(device.ID)
+		return string(ns)
+	}), nil
+}
+
+// namespacedProvider translates between the namespaced device IDs seen by Google and the device IDs of a child provider.
+type namespacedProvider struct {
+	ns       Namespace
+	provider Provider
+}
+
+// Sync returns copies of the child's devices with their IDs namespaced.
+func (np *namespacedProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	resp, err := np.provider.Sync(ctx, agentUserID)
+	if err != nil || len(resp.ErrorCode) > 0 {
+		return resp, err
+	}
+
+	return &SyncResponse{
+		Devices: np.ns.Devices(resp.Devices),
+	}, nil
+}
+
+func (np *namespacedProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	return np.provider.Disconnect(ctx, agentUserID)
+}
+
+// unnamespaceDevices returns the devices with the namespace removed from their IDs.
+func (np *namespacedProvider) unnamespaceDevices(devices []DeviceArg) []DeviceArg {
+	var childDevices []DeviceArg
+	for _, device := range devices {
+		_, device.ID, _ = SplitNamespacedID(device.ID)
+		childDevices = append(childDevices, device)
+	}
+	return childDevices
+}
+
+// Query calls the child with the namespace removed from the requested device IDs.
+func (np *namespacedProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp, err := np.provider.Query(ctx, &QueryRequest{
+		AgentID: req.AgentID,
+		Devices: np.unnamespaceDevices(req.Devices),
+	})
+	if err != nil || len(resp.ErrorCode) > 0 {
+		return resp, err
+	}
+
+	nsResp := NewQueryResponse()
+	for id, state := range resp.States {
+		nsResp.Add(np.ns.ID(id), state)
+	}
+	return nsResp, nil
+}
+
+// Execute calls the child with the namespace removed from the targeted device IDs.
+func (np *namespacedProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	childReq := &ExecuteRequest{
+		AgentID: req.AgentID,
+	}
+	for _, commandArg := range req.Commands {
+		commandArg.TargetDevices = np.unnamespaceDevices(commandArg.TargetDevices)
+		childReq.Commands = append(childReq.Commands, commandArg)
+	}
+
+	resp, err := np.provider.Execute(ctx, childReq)
+	if err != nil || len(resp.ErrorCode) > 0 {
+		return resp, err
+	}
+
+	nsResp := &ExecuteResponse{
+		UpdatedState:   resp.UpdatedState,
+		UpdatedDevices: np.ns.IDs(resp.UpdatedDevices),
+		OfflineDevices: np.ns.IDs(resp.OfflineDevices),
+	}
+	for token, id := range resp.PendingDevices {
+		nsResp.AddPendingDevice(token, np.ns.ID(id))
+	}
+	for errCode, details := range resp.FailedDevices {
+		if len(details.Devices) > 0 {
+			nsResp.AddFailedDevices(errCode, np.ns.IDs(details.Devices)...)
+		}
+	}
+	for challengeType, ids := range resp.ChallengeNeeded {
+		nsResp.AddChallengeNeeded(challengeType, np.ns.IDs(ids)...)
+	}
+	return nsResp, nil
+}
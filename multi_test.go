@@ -0,0 +1,54 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiProvider(t *testing.T) {
+	hue := &testProvider{
+		syncResp:  []*Device{NewLight("1")},
+		queryResp: map[string]DeviceState{"1": NewDeviceState(true).RecordOnOff(true)},
+	}
+	mqtt := &testProvider{
+		syncResp:           []*Device{NewOutlet("1")},
+		executeRespUpdated: []string{"1"},
+	}
+	mp, err := NewMultiProvider(map[string]Provider{"hue": hue, "mqtt": mqtt})
+	assert.Nil(t, err)
+
+	syncResp, err := mp.Sync(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Len(t, syncResp.Devices, 2)
+	assert.Equal(t, "hue/1", syncResp.Devices[0].ID)
+	assert.Equal(t, "mqtt/1", syncResp.Devices[1].ID)
+	assert.Equal(t, "1", hue.syncResp[0].ID)
+
+	queryResp, err := mp.Query(context.Background(), &QueryRequest{
+		AgentID: "agent-id",
+		Devices: []DeviceArg{{ID: "hue/1"}, {ID: "zigbee/1"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []DeviceArg{{ID: "1"}}, hue.queryReq.Devices)
+	assert.Equal(t, true, queryResp.States["hue/1"].State["on"])
+	assert.Equal(t, ErrorCodeDeviceNotFound, queryResp.States["zigbee/1"].ErrorCode)
+
+	executeResp, err := mp.Execute(context.Background(), &ExecuteRequest{
+		AgentID: "agent-id",
+		Commands: []CommandArg{{
+			TargetDevices: []DeviceArg{{ID: "mqtt/1"}},
+			Commands:      []Command{{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: true}}},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []DeviceArg{{ID: "1"}}, mqtt.executeReq.Commands[0].TargetDevices)
+	assert.Equal(t, []string{"mqtt/1"}, executeResp.UpdatedDevices)
+}
+
+func TestMultiProviderInvalidNamespace(t *testing.T) {
+	_, err := NewMultiProvider(map[string]Provider{"hue/1": &testProvider{}})
+	assert.True(t, errors.Is(err, ErrInvalidNamespace))
+}
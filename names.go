@@ -0,0 +1,169 @@
+package action
+
+// NewInput creates a new input with the specified key, ready to have its localized names added.
+// This is intended to be used with AddInputSelectorTrait, i.e.
+//
+//	NewInput("hdmi_1").WithNames("en", "HDMI 1", "DVD player").WithNames("de", "HDMI 1", "DVD-Player")
+func NewInput(key string) DeviceInput {
+	return DeviceInput{
+		Key: key,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the input.
+func (di DeviceInput) WithNames(lang string, synonyms ...string) DeviceInput {
+	di.Names = appendNames(di.Names, lang, synonyms)
+	return di
+}
+
+// DeviceApp represents a single application which can be launched on a device.
+// See https://developers.google.com/assistant/smarthome/traits/appselector
+type DeviceApp struct {
+	Key   string            `json:"key"`
+	Names []DeviceInputName `json:"names"`
+}
+
+// NewApp creates a new application with the specified key, ready to have its localized names added.
+func NewApp(key string) DeviceApp {
+	return DeviceApp{
+		Key: key,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the application.
+func (da DeviceApp) WithNames(lang string, synonyms ...string) DeviceApp {
+	da.Names = appendNames(da.Names, lang, synonyms)
+	return da
+}
+
+// DeviceChannel represents a single channel which can be selected on a device.
+// See https://developers.google.com/assistant/smarthome/traits/channel
+type DeviceChannel struct {
+	Key    string   `json:"key"`
+	Names  []string `json:"names"`
+	Number string   `json:"number,omitempty"`
+}
+
+// NewChannel creates a new channel with the specified key and (optional) number, known to the user by the supplied names.
+func NewChannel(key string, number string, names ...string) DeviceChannel {
+	return DeviceChannel{
+		Key:    key,
+		Names:  names,
+		Number: number,
+	}
+}
+
+// FanSpeedName represents the human-readable names of a single fan speed in one language.
+type FanSpeedName struct {
+	LanguageCode string   `json:"lang"`
+	Synonyms     []string `json:"speed_synonym"`
+}
+
+// FanSpeedSetting represents a single speed a fan can be set to.
+// See https://developers.google.com/assistant/smarthome/traits/fanspeed
+type FanSpeedSetting struct {
+	Name  string         `json:"speed_name"`
+	Names []FanSpeedName `json:"speed_values"`
+}
+
+// NewFanSpeedSetting creates a new fan speed with the specified name, ready to have its localized names added.
+func NewFanSpeedSetting(name string) FanSpeedSetting {
+	return FanSpeedSetting{
+		Name: name,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the fan speed.
+func (fss FanSpeedSetting) WithNames(lang string, synonyms ...string) FanSpeedSetting {
+	fss.Names = append(append([]FanSpeedName{}, fss.Names...), FanSpeedName{
+		LanguageCode: lang,
+		Synonyms:     synonyms,
+	})
+	return fss
+}
+
+// DeviceToggle represents a single on/off setting of a device.
+// See https://developers.google.com/assistant/smarthome/traits/toggles
+type DeviceToggle struct {
+	Name  string            `json:"name"`
+	Names []DeviceInputName `json:"name_values"`
+}
+
+// NewToggle creates a new toggle with the specified name, ready to have its localized names added.
+func NewToggle(name string) DeviceToggle {
+	return DeviceToggle{
+		Name: name,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the toggle.
+func (dt DeviceToggle) WithNames(lang string, synonyms ...string) DeviceToggle {
+	dt.Names = appendNames(dt.Names, lang, synonyms)
+	return dt
+}
+
+// DeviceModeSettingName represents the human-readable name of a single mode setting.
+type DeviceModeSettingName struct {
+	LanguageCode string   `json:"lang"`
+	Synonyms     []string `json:"setting_synonym"`
+}
+
+// DeviceModeSetting represents a single value a mode can be set to.
+type DeviceModeSetting struct {
+	Name  string                  `json:"setting_name"`
+	Names []DeviceModeSettingName `json:"setting_values"`
+}
+
+// NewModeSetting creates a new mode setting with the specified name, ready to have its localized names added.
+func NewModeSetting(name string) DeviceModeSetting {
+	return DeviceModeSetting{
+		Name: name,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the mode setting.
+func (dms DeviceModeSetting) WithNames(lang string, synonyms ...string) DeviceModeSetting {
+	dms.Names = append(append([]DeviceModeSettingName{}, dms.Names...), DeviceModeSettingName{
+		LanguageCode: lang,
+		Synonyms:     synonyms,
+	})
+	return dms
+}
+
+// DeviceMode represents a single multi-value setting of a device.
+// See https://developers.google.com/assistant/smarthome/traits/modes
+type DeviceMode struct {
+	Name     string              `json:"name"`
+	Names    []DeviceInputName   `json:"name_values"`
+	Settings []DeviceModeSetting `json:"settings"`
+	Ordered  bool                `json:"ordered"`
+}
+
+// NewMode creates a new mode with the specified name, ready to have its localized names and settings added.
+func NewMode(name string, ordered bool) DeviceMode {
+	return DeviceMode{
+		Name:    name,
+		Ordered: ordered,
+	}
+}
+
+// WithNames adds the synonyms for the specified language to the mode.
+func (dm DeviceMode) WithNames(lang string, synonyms ...string) DeviceMode {
+	dm.Names = appendNames(dm.Names, lang, synonyms)
+	return dm
+}
+
+// WithSettings adds the supplied settings to the mode.
+func (dm DeviceMode) WithSettings(settings ...DeviceModeSetting) DeviceMode {
+	dm.Settings = append(append([]DeviceModeSetting{}, dm.Settings...), settings...)
+	return dm
+}
+
+// appendNames returns a copy of names with the synonyms for the specified language added.
+// A copy is made so builders derived from the same value don't share the underlying array.
+func appendNames(names []DeviceInputName, lang string, synonyms []string) []DeviceInputName {
+	return append(append([]DeviceInputName{}, names...), DeviceInputName{
+		LanguageCode: lang,
+		Synonyms:     synonyms,
+	})
+}
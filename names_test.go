@@ -0,0 +1,70 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInputWithNames(t *testing.T) {
+	base := NewInput("hdmi_1").WithNames("en", "HDMI 1", "DVD player")
+	german := base.WithNames("de", "HDMI 1", "DVD-Spieler")
+	french := base.WithNames("fr", "HDMI 1", "Lecteur DVD")
+
+	assert.Equal(t, DeviceInput{
+		Key: "hdmi_1",
+		Names: []DeviceInputName{
+			{LanguageCode: "en", Synonyms: []string{"HDMI 1", "DVD player"}},
+			{LanguageCode: "de", Synonyms: []string{"HDMI 1", "DVD-Spieler"}},
+		},
+	}, german)
+	assert.Equal(t, "fr", french.Names[1].LanguageCode)
+}
+
+func TestNewModeJSON(t *testing.T) {
+	mode := NewMode("load", true).WithNames("en", "load", "size").WithSettings(
+		NewModeSetting("small_load").WithNames("en", "small", "half"),
+		NewModeSetting("large_load").WithNames("en", "large", "full"),
+	)
+
+	serializedBytes, err := json.Marshal(mode)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"load","name_values":[{"lang":"en","name_synonym":["load","size"]}],"settings":[{"setting_name":"small_load","setting_values":[{"lang":"en","setting_synonym":["small","half"]}]},{"setting_name":"large_load","setting_values":[{"lang":"en","setting_synonym":["large","full"]}]}],"ordered":true}`, string(serializedBytes))
+}
+
+func TestNewToggleJSON(t *testing.T) {
+	toggle := NewToggle("sterilization").WithNames("en", "Sterilization", "Bio-clean")
+
+	serializedBytes, err := json.Marshal(toggle)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"sterilization","name_values":[{"lang":"en","name_synonym":["Sterilization","Bio-clean"]}]}`, string(serializedBytes))
+}
+
+func TestNewAppJSON(t *testing.T) {
+	app := NewApp("youtube").WithNames("en", "YouTube")
+
+	serializedBytes, err := json.Marshal(app)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"key":"youtube","names":[{"lang":"en","name_synonym":["YouTube"]}]}`, string(serializedBytes))
+}
+
+func TestNewChannelJSON(t *testing.T) {
+	channel := NewChannel("abc1", "4", "ABC", "ABC East")
+
+	serializedBytes, err := json.Marshal(channel)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"key":"abc1","names":["ABC","ABC East"],"number":"4"}`, string(serializedBytes))
+
+	serializedBytes, err = json.Marshal(NewChannel("news", "", "News"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"key":"news","names":["News"]}`, string(serializedBytes))
+}
+
+func TestNewFanSpeedSettingJSON(t *testing.T) {
+	speed := NewFanSpeedSetting("low").WithNames("en", "Low", "Slow")
+
+	serializedBytes, err := json.Marshal(speed)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"speed_name":"low","speed_values":[{"lang":"en","speed_synonym":["Low","Slow"]}]}`, string(serializedBytes))
+}
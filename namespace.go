@@ -0,0 +1,88 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NamespaceSeparator separates the namespace from the device ID in a namespaced device ID.
+const NamespaceSeparator = "/"
+
+var (
+	// ErrInvalidNamespace is returned if a namespace is empty or contains the NamespaceSeparator.
+	// Such a namespace could produce the same namespaced ID as another namespace and device ID.
+	ErrInvalidNamespace = errors.New("invalid device namespace")
+)
+
+// Namespace prefixes the device IDs of one source of devices (i.e. a bridge or child provider) so they don't collide
+// with the IDs of devices from other sources. The same namespace should be applied to the IDs returned in SYNC,
+// QUERY and EXECUTE, and to the states supplied to ReportState, so Google only ever sees the namespaced IDs.
+type Namespace string
+
+// Validate returns ErrInvalidNamespace if the namespace can't be used to unambiguously prefix device IDs.
+func (ns Namespace) Validate() error {
+	if len(ns) < 1 || strings.Contains(string(ns), NamespaceSeparator) {
+		return fmt.Errorf("%w: %q", ErrInvalidNamespace, string(ns))
+	}
+	return nil
+}
+
+// ID returns the device ID prefixed with the namespace.
+func (ns Namespace) ID(id string) string {
+	return string(ns) + NamespaceSeparator + id
+}
+
+// IDs returns the device IDs prefixed with the namespace.
+func (ns Namespace) IDs(ids []string) []string {
+	var nsIDs []string
+	for _, id := range ids {
+		nsIDs = append(nsIDs, ns.ID(id))
+	}
+	return nsIDs
+}
+
+// Devices returns copies of the devices with their IDs prefixed with the namespace.
+// The supplied devices are not modified.
+func (ns Namespace) Devices(devices []*Device) []*Device {
+	var nsDevices []*Device
+	for _, device := range devices {
+		nsDevice := *device
+		nsDevice.ID = ns.ID(device.ID)
+		nsDevices = append(nsDevices, &nsDevice)
+	}
+	return nsDevices
+}
+
+// States returns the device states indexed by namespaced device ID, i.e. for use with ReportState.
+func (ns Namespace) States(states map[string]DeviceState) map[string]DeviceState {
+	nsStates := map[string]DeviceState{}
+	for id, state := range states {
+		nsStates[ns.ID(id)] = state
+	}
+	return nsStates
+}
+
+// SplitNamespacedID returns the namespace and original device ID of a namespaced device ID.
+// If the ID has no namespace found is false and the ID is returned unmodified.
+func SplitNamespacedID(nsID string) (ns Namespace, id string, found bool) {
+	parts := strings.SplitN(nsID, NamespaceSeparator, 2)
+	if len(parts) != 2 {
+		return "", nsID, false
+	}
+	return Namespace(parts[0]), parts[1], true
+}
+
+// CheckIDCollisions returns ErrDuplicateDeviceID listing the IDs which are shared by more than one of the devices,
+// i.e. when combining the devices of several bridges without namespacing them.
+func CheckIDCollisions(devices []*Device) error {
+	seen := map[string]bool{}
+	var duplicateIDs []string
+	for _, device := range devices {
+		if seen[device.ID] {
+			duplicateIDs = append(duplicateIDs, device.ID)
+		}
+		seen[device.ID] = true
+	}
+	return duplicateIDsErr(duplicateIDs)
+}
@@ -0,0 +1,40 @@
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	ns := Namespace("hue")
+	assert.Nil(t, ns.Validate())
+	assert.True(t, errors.Is(Namespace("").Validate(), ErrInvalidNamespace))
+	assert.True(t, errors.Is(Namespace("hue/2").Validate(), ErrInvalidNamespace))
+
+	assert.Equal(t, "hue/light/1", ns.ID("light/1"))
+	splitNS, id, found := SplitNamespacedID("hue/light/1")
+	assert.True(t, found)
+	assert.Equal(t, ns, splitNS)
+	assert.Equal(t, "light/1", id)
+	_, id, found = SplitNamespacedID("light")
+	assert.False(t, found)
+	assert.Equal(t, "light", id)
+
+	light := NewLight("1")
+	devices := ns.Devices([]*Device{light})
+	assert.Equal(t, "hue/1", devices[0].ID)
+	assert.Equal(t, "1", light.ID)
+
+	states := ns.States(map[string]DeviceState{"1": NewDeviceState(true)})
+	assert.Contains(t, states, "hue/1")
+}
+
+func TestCheckIDCollisions(t *testing.T) {
+	assert.Nil(t, CheckIDCollisions([]*Device{NewLight("1"), NewLight("2")}))
+
+	err := CheckIDCollisions([]*Device{NewLight("1"), NewOutlet("1")})
+	assert.True(t, errors.Is(err, ErrDuplicateDeviceID))
+	assert.Contains(t, err.Error(), "1")
+}
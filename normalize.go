@@ -0,0 +1,84 @@
+package action
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrInvalidRoomHint is returned if the room hint contains no usable characters once normalized.
+	ErrInvalidRoomHint = errors.New("invalid room hint")
+)
+
+// WithSyncNormalization causes the room hint and nicknames of each device to be normalized before being sent in response to SYNC.
+// See Device.Normalize for the rules which are applied. The devices supplied by the provider are not modified.
+func WithSyncNormalization() ServiceOption {
+	return func(s *Service) {
+		s.normalizeSync = true
+	}
+}
+
+// NormalizeRoomHint cleans up a room hint so that rooms which only differ by case or spacing are treated as the same room.
+// Characters other than letters, digits, spaces, apostrophes, ampersands and hyphens are removed, repeated spaces are
+// collapsed and each word is capitalized (i.e. "  living   ROOM!" becomes "Living Room").
+// As the result doesn't depend on the case of the supplied hint, acronyms are capitalized like any other word ("TV room"
+// becomes "Tv Room").
+func NormalizeRoomHint(roomHint string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '&' || r == '-' {
+			return r
+		} else if unicode.IsSpace(r) {
+			return ' '
+		}
+		return -1
+	}, roomHint)
+
+	words := strings.Fields(cleaned)
+	for idx, word := range words {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[idx] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// ValidateRoomHint checks whether the supplied room hint is usable by Google.
+// An empty room hint is valid as the field is optional.
+func ValidateRoomHint(roomHint string) error {
+	if len(roomHint) > 0 && len(NormalizeRoomHint(roomHint)) < 1 {
+		return ErrInvalidRoomHint
+	}
+	return nil
+}
+
+// DedupeNicknames removes empty and duplicate nicknames, ignoring differences in case and surrounding whitespace.
+// The first occurrence of each nickname is retained, in the original order.
+func DedupeNicknames(nicknames []string) []string {
+	if nicknames == nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	deduped := []string{}
+	for _, nickname := range nicknames {
+		nickname = strings.TrimSpace(nickname)
+		key := strings.ToLower(nickname)
+		if len(nickname) < 1 || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		deduped = append(deduped, nickname)
+	}
+	return deduped
+}
+
+// Normalize cleans up the user-visible identifiers of the device to avoid issues in the Google Smart Home Test Suite.
+// The room hint is normalized using NormalizeRoomHint and the nicknames are deduplicated using DedupeNicknames.
+func (d *Device) Normalize() *Device {
+	d.RoomHint = NormalizeRoomHint(d.RoomHint)
+	d.Name.Nicknames = DedupeNicknames(d.Name.Nicknames)
+
+	return d
+}
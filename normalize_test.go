@@ -0,0 +1,46 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRoomHint(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"kitchen", "Kitchen"},
+		{"  living   ROOM!", "Living Room"},
+		{"LIVING ROOM", "Living Room"},
+		{"Living ROOM", "Living Room"},
+		{"TV room", "Tv Room"},
+		{"Kid's room #2", "Kid's Room 2"},
+		{"salle à manger", "Salle À Manger"},
+		{"", ""},
+	} {
+		assert.Equal(t, tt.want, NormalizeRoomHint(tt.input))
+	}
+}
+
+func TestValidateRoomHint(t *testing.T) {
+	assert.Nil(t, ValidateRoomHint(""))
+	assert.Nil(t, ValidateRoomHint("office"))
+	assert.Equal(t, ErrInvalidRoomHint, ValidateRoomHint("!!!"))
+}
+
+func TestDedupeNicknames(t *testing.T) {
+	assert.Nil(t, DedupeNicknames(nil))
+	assert.Equal(t, []string{"reading lamp", "Desk Lamp"}, DedupeNicknames([]string{"reading lamp", " Reading Lamp", "", "Desk Lamp", "desk lamp"}))
+}
+
+func TestDeviceNormalize(t *testing.T) {
+	d := NewLight("light-id")
+	d.RoomHint = "living room"
+	d.Name.Nicknames = []string{"lamp", "Lamp"}
+	d.Normalize()
+
+	assert.Equal(t, "Living Room", d.RoomHint)
+	assert.Equal(t, []string{"lamp"}, d.Name.Nicknames)
+}
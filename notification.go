@@ -0,0 +1,184 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/homegraph/v1"
+)
+
+var (
+	// ErrNotificationFailed is returned if the request to HomeGraph to send a notification failed.
+	// The log will contain more information about what occurred.
+	ErrNotificationFailed = errors.New("notification failed")
+)
+
+// FollowUpResponse contains the final result of a command which Google requested a follow-up for.
+// See https://developers.google.com/assistant/smarthome/develop/notifications#follow-up-response
+type FollowUpResponse struct {
+	// DeviceID the command was executed against.
+	DeviceID string
+	// Trait the command belongs to, either in short (LockUnlock) or full (action.devices.traits.LockUnlock) form.
+	Trait string
+	// Status of the command; either SUCCESS or FAILURE.
+	Status string
+	// ErrorCode explaining the failure, if the status is FAILURE.
+	ErrorCode string
+	// States of the device after the command completed (i.e. isLocked for LockUnlock).
+	States map[string]interface{}
+}
+
+// SendFollowUp is used to inform Google of the final result of a command which took a while to complete.
+// The token must be the FollowUpToken supplied with the original command.
+// This is useful for commands (i.e. closing a garage door) which are acknowledged quickly but which complete later.
+func (s *Service) SendFollowUp(ctx context.Context, agentUserID string, token string, resp FollowUpResponse) error {
+	followUp := map[string]interface{}{}
+	for k, v := range resp.States {
+		followUp[k] = v
+	}
+	followUp["status"] = resp.Status
+	followUp["followUpToken"] = token
+	if len(resp.ErrorCode) > 0 {
+		followUp["errorCode"] = resp.ErrorCode
+	}
+
+	return s.sendNotification(ctx, agentUserID, resp.DeviceID, resp.Trait, map[string]interface{}{
+		"priority":         0,
+		"followUpResponse": followUp,
+	}, token)
+}
+
+// DetectedObjects describes who was detected by a device with the ObjectDetection trait.
+// See https://developers.google.com/assistant/smarthome/traits/objectdetection
+type DetectedObjects struct {
+	// Named contains the names of any recognized people.
+	Named []string
+	// Familiar is the number of people detected who have been seen before but aren't named.
+	Familiar int
+	// Unfamiliar is the number of people detected who have not been seen before.
+	Unfamiliar int
+	// Unclassified is the number of objects detected which could not be classified.
+	Unclassified int
+}
+
+// NotifyDoorbellPress informs Google that the doorbell was pressed, and who was detected at the door when it was.
+// Google will announce the press on the user's devices; the doorbell must have been returned in SYNC with
+// NotificationSupportedByAgent set (see NewDoorbell).
+func (s *Service) NotifyDoorbellPress(ctx context.Context, agentUserID string, deviceID string, objects DetectedObjects) error {
+	detected := map[string]interface{}{}
+	if len(objects.Named) > 0 {
+		detected["named"] = objects.Named
+	}
+	if objects.Familiar > 0 {
+		detected["familiar"] = objects.Familiar
+	}
+	if objects.Unfamiliar > 0 {
+		detected["unfamiliar"] = objects.Unfamiliar
+	}
+	if objects.Unclassified > 0 {
+		detected["unclassified"] = objects.Unclassified
+	}
+
+	return s.sendNotification(ctx, agentUserID, deviceID, TraitObjectDetection, map[string]interface{}{
+		"priority":           0,
+		"detectionTimestamp": s.now().UnixNano() / int64(time.Millisecond),
+		"objects":            detected,
+	}, "")
+}
+
+// NotifyChargingState informs the user of a change in the charging state of a device with the EnergyStorage trait
+// (i.e. an electric vehicle finishing charging). The EnergyStorage states recorded in state (see RecordBattery,
+// RecordCapacityRemaining and RecordCapacityUntilFull) are included in the notification; any other states are ignored.
+// The device must have been returned in SYNC with NotificationSupportedByAgent set (see NewChargerEV).
+func (s *Service) NotifyChargingState(ctx context.Context, agentUserID string, deviceID string, state DeviceState) error {
+	notification := map[string]interface{}{
+		"priority": 0,
+	}
+	for _, key := range traitCapabilities[TraitEnergyStorage].States {
+		if value, found := state.State[key]; found {
+			notification[key] = value
+		}
+	}
+
+	return s.sendNotification(ctx, agentUserID, deviceID, TraitEnergyStorage, notification, "")
+}
+
+// sendNotification reports the supplied notification for the device trait to the Google HomeGraph.
+func (s *Service) sendNotification(ctx context.Context, agentUserID string, deviceID string, trait string, notification map[string]interface{}, followUpToken string) error {
+	jsonNotification, err := json.Marshal(map[string]interface{}{
+		deviceID: map[string]interface{}{
+			strings.TrimPrefix(trait, "action.devices.traits."): notification,
+		},
+	})
+	if err != nil {
+		s.logger.Info("error serializing notification to json",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	hgRequestID := s.newID()
+	hgReq := &homegraph.ReportStateAndNotificationRequest{
+		AgentUserId:   agentUserID,
+		RequestId:     hgRequestID,
+		EventId:       s.newID(),
+		FollowUpToken: followUpToken,
+		Payload: &homegraph.StateAndNotificationPayload{
+			Devices: &homegraph.ReportStateAndNotificationDevice{
+				Notifications: jsonNotification,
+			},
+		},
+	}
+	if s.dryRun {
+		s.logDryRun(ctx, "reportStateAndNotification", agentUserID, hgReq)
+		return nil
+	}
+
+	if err := s.checkHomeGraphCircuit("reportStateAndNotification", hgRequestID); err != nil {
+		return err
+	}
+
+	call := s.deviceService.ReportStateAndNotification(hgReq)
+	s.setHomeGraphHeaders(call.Header())
+	call.Context(ctx)
+	resp, err := call.Do()
+	if err != nil {
+		s.recordHomeGraphCall(ctx, "reportStateAndNotification", agentUserID, 0, err)
+		s.logger.Info("error sending notification",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.String("device_id", deviceID),
+			zap.Error(err),
+		)
+		return &HomeGraphError{
+			Method:    "reportStateAndNotification",
+			RequestID: hgRequestID,
+			Err:       classifyHomeGraphError(err),
+		}
+	}
+	s.recordHomeGraphCall(ctx, "reportStateAndNotification", agentUserID, resp.ServerResponse.HTTPStatusCode, nil)
+	if resp.ServerResponse.HTTPStatusCode != http.StatusOK {
+		s.logger.Info("failed send notification",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.String("device_id", deviceID),
+			zap.Int("status_code", resp.ServerResponse.HTTPStatusCode),
+		)
+		return &HomeGraphError{
+			Method:     "reportStateAndNotification",
+			RequestID:  hgRequestID,
+			StatusCode: resp.ServerResponse.HTTPStatusCode,
+			Err:        ErrNotificationFailed,
+		}
+	}
+	return nil
+}
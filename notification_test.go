@@ -0,0 +1,114 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceSendFollowUp(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	err := svc.SendFollowUp(context.Background(), "agent-id", "token-123", FollowUpResponse{
+		DeviceID: "garage-id",
+		Trait:    "action.devices.traits.OpenClose",
+		Status:   "SUCCESS",
+		States: map[string]interface{}{
+			"openPercent": 0,
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+
+	var body struct {
+		AgentUserID   string `json:"agentUserId"`
+		FollowUpToken string `json:"followUpToken"`
+		Payload       struct {
+			Devices struct {
+				Notifications map[string]interface{} `json:"notifications"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, "agent-id", body.AgentUserID)
+	assert.Equal(t, "token-123", body.FollowUpToken)
+	assert.Equal(t, map[string]interface{}{
+		"garage-id": map[string]interface{}{
+			"OpenClose": map[string]interface{}{
+				"priority": 0.0,
+				"followUpResponse": map[string]interface{}{
+					"status":        "SUCCESS",
+					"followUpToken": "token-123",
+					"openPercent":   0.0,
+				},
+			},
+		},
+	}, body.Payload.Devices.Notifications)
+}
+
+func TestServiceNotifyDoorbellPress(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	err := svc.NotifyDoorbellPress(context.Background(), "agent-id", "doorbell-id", DetectedObjects{
+		Named:      []string{"Alice"},
+		Unfamiliar: 1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+
+	var body struct {
+		Payload struct {
+			Devices struct {
+				Notifications map[string]map[string]map[string]interface{} `json:"notifications"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+
+	notification := body.Payload.Devices.Notifications["doorbell-id"]["ObjectDetection"]
+	assert.Equal(t, 0.0, notification["priority"])
+	assert.NotZero(t, notification["detectionTimestamp"])
+	assert.Equal(t, map[string]interface{}{
+		"named":      []interface{}{"Alice"},
+		"unfamiliar": 1.0,
+	}, notification["objects"])
+}
+
+func TestServiceNotifyChargingState(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	state := NewDeviceState(true).RecordBattery(100, false, true).RecordCapacityRemaining(400, EnergyStorageUnitKilometers).RecordStartStop(false, false)
+	err := svc.NotifyChargingState(context.Background(), "agent-id", "charger-id", state)
+	assert.Nil(t, err)
+
+	var body struct {
+		Payload struct {
+			Devices struct {
+				Notifications map[string]map[string]map[string]interface{} `json:"notifications"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, map[string]interface{}{
+		"priority":                     0.0,
+		"descriptiveCapacityRemaining": "FULL",
+		"capacityRemaining": []interface{}{
+			map[string]interface{}{"rawValue": 100.0, "unit": "PERCENTAGE"},
+			map[string]interface{}{"rawValue": 400.0, "unit": "KILOMETERS"},
+		},
+		"isCharging":  false,
+		"isPluggedIn": true,
+	}, body.Payload.Devices.Notifications["charger-id"]["EnergyStorage"])
+}
@@ -0,0 +1,54 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Values decoded into an interface{} (i.e. the Params of a CommandGeneric or the State of a DeviceState) keep numbers
+// as json.Number rather than float64, so integers such as a brightness of 42 aren't turned into 42.0.
+// NumberInt and NumberFloat convert such values, as well as values recorded directly, into the required type.
+
+// NumberInt returns the supplied number as an int, regardless of whether it was recorded directly or decoded from JSON.
+// A fractional number is truncated. false is returned if the value is not a number.
+func NumberInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), true
+		}
+		f, err := v.Float64()
+		return int(f), err == nil
+	}
+	return 0, false
+}
+
+// NumberFloat returns the supplied number as a float64, regardless of whether it was recorded directly or decoded from JSON.
+// false is returned if the value is not a number.
+func NumberFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// unmarshalUseNumber decodes the JSON into v, keeping any numbers decoded into an interface{} as json.Number.
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
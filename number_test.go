@@ -0,0 +1,35 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberConversions(t *testing.T) {
+	for _, value := range []interface{}{42, int64(42), 42.0, json.Number("42"), json.Number("42.0")} {
+		i, ok := NumberInt(value)
+		assert.True(t, ok)
+		assert.Equal(t, 42, i)
+
+		f, ok := NumberFloat(value)
+		assert.True(t, ok)
+		assert.Equal(t, 42.0, f)
+	}
+
+	_, ok := NumberInt("42")
+	assert.False(t, ok)
+	_, ok = NumberFloat(json.Number("forty-two"))
+	assert.False(t, ok)
+}
+
+func TestDeviceStateUnmarshalJSONNumbers(t *testing.T) {
+	state := DeviceState{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"online":true,"brightness":42}`), &state))
+	assert.Equal(t, json.Number("42"), state.State["brightness"])
+
+	serializedBytes, err := json.Marshal(state)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"brightness":42,"online":true}`, string(serializedBytes))
+}
@@ -0,0 +1,305 @@
+package action
+
+import (
+	"encoding/json"
+	"go/ast"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// AgentUserIDHeader is the header which identifies the user whose devices a provider webhook request is for,
+// as described by OpenAPISpec.
+const AgentUserIDHeader = "Agent-User-Id"
+
+// OpenAPIVersion is the version of the provider webhook contract described by OpenAPISpec.
+const OpenAPIVersion = "1.0.0"
+
+// webhookOperation describes an intent forwarded to a provider webhook.
+type webhookOperation struct {
+	path        string
+	intent      string
+	summary     string
+	response    reflect.Type
+	description string
+}
+
+// webhookOperations are the operations of the provider webhook, one for each method of Provider.
+var webhookOperations = []webhookOperation{
+	{"/sync", IntentSync, "Return the devices of the user", reflect.TypeOf(SyncFulfillmentResponse{}), "The devices of the user."},
+	{"/query", IntentQuery, "Return the current state of the requested devices", reflect.TypeOf(QueryFulfillmentResponse{}), "The state of each requested device."},
+	{"/execute", IntentExecute, "Apply the commands to the requested devices", reflect.TypeOf(ExecuteFulfillmentResponse{}), "The result of the commands."},
+	{"/disconnect", IntentDisconnect, "Unlink the user", nil, "The user was unlinked."},
+}
+
+// openAPISubstitutes contains the types whose JSON form is produced by a custom serializer, along with a type whose
+// fields match that form. The schema keeps the name of the original type.
+var openAPISubstitutes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(Device{}): reflect.TypeOf(deviceRaw{}),
+}
+
+// openAPIRequired contains the fields Google requires of the substituted types, which are omitted from their JSON
+// form when empty.
+var openAPIRequired = map[reflect.Type][]string{
+	reflect.TypeOf(Device{}): {"id", "type", "traits", "name", "willReportState"},
+}
+
+// schemaGenerator derives JSON schemas from the JSON serialization of Go types, collecting the named types as components.
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+// explicitSchema returns a function building the schema of the type if its JSON form is too dynamic to be derived
+// from its fields, or nil otherwise.
+func (g *schemaGenerator) explicitSchema(t reflect.Type) func() map[string]interface{} {
+	switch t {
+	case reflect.TypeOf(FulfillmentInput{}):
+		return func() map[string]interface{} {
+			return map[string]interface{}{
+				"type":     "object",
+				"required": []string{"intent"},
+				"properties": map[string]interface{}{
+					"intent": map[string]interface{}{
+						"type": "string",
+						"enum": []string{IntentSync, IntentQuery, IntentExecute, IntentDisconnect},
+					},
+					"payload": map[string]interface{}{
+						"description": "Only set for QUERY and EXECUTE.",
+						"oneOf": []interface{}{
+							g.schema(reflect.TypeOf(QueryPayload{})),
+							g.schema(reflect.TypeOf(ExecutePayload{})),
+						},
+					},
+				},
+			}
+		}
+	case reflect.TypeOf(Command{}):
+		return func() map[string]interface{} {
+			return map[string]interface{}{
+				"type":     "object",
+				"required": []string{"command"},
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"description": "The name of the command, i.e. action.devices.commands.OnOff.",
+					},
+					"params": map[string]interface{}{
+						"type":                 "object",
+						"description":          "The parameters of the command, as documented for the trait it belongs to.",
+						"additionalProperties": true,
+					},
+				},
+			}
+		}
+	case reflect.TypeOf(DeviceState{}):
+		return func() map[string]interface{} {
+			return map[string]interface{}{
+				"type":        "object",
+				"description": "The state of the device; the properties other than those listed are defined by its traits.",
+				"required":    []string{"online"},
+				"properties": map[string]interface{}{
+					"online": map[string]interface{}{
+						"type": "boolean",
+					},
+					"status": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"SUCCESS", "PENDING", "OFFLINE", "EXCEPTIONS", "ERROR"},
+					},
+					"errorCode": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"additionalProperties": true,
+			}
+		}
+	}
+	return nil
+}
+
+// schema returns the schema of the type, or a reference to it if the type is named.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if build := g.explicitSchema(t); build != nil {
+		return g.ref(t.Name(), build)
+	} else if substitute, found := openAPISubstitutes[t]; found {
+		return g.ref(t.Name(), func() map[string]interface{} {
+			schema := g.structSchema(substitute)
+			if required, found := openAPIRequired[t]; found {
+				schema["required"] = required
+			}
+			return schema
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t == rawMessageType {
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.schema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schema(t.Elem()),
+		}
+	case reflect.Struct:
+		// Unexported types only describe the JSON form of another type, so they aren't published as components.
+		if len(t.Name()) < 1 || !ast.IsExported(t.Name()) {
+			return g.structSchema(t)
+		}
+		return g.ref(t.Name(), func() map[string]interface{} { return g.structSchema(t) })
+	}
+	// Interfaces may contain any value.
+	return map[string]interface{}{}
+}
+
+// ref adds the schema of the named type to the components if it isn't already present, and returns a reference to it.
+func (g *schemaGenerator) ref(name string, build func() map[string]interface{}) map[string]interface{} {
+	if _, found := g.components[name]; !found {
+		// Reserve the name first so recursive types terminate.
+		g.components[name] = nil
+		g.components[name] = build()
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// structSchema returns the schema of the exported fields of the struct, named according to their json tags.
+// Fields without omitempty are required.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		} else if len(name) < 1 {
+			name = field.Name
+		}
+
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(field.Tag.Get("json"), ",omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// OpenAPISpec returns an OpenAPI 3 document describing how a provider can be implemented as an HTTP webhook,
+// so backends not written in Go have a contract to code against; WebhookProvider calls a webhook implementing it.
+// There is an operation for each method of Provider, which receives the fulfillment request Google sent for the
+// intent, along with the user it is for in the AgentUserIDHeader header, and responds in the format Google expects
+// for the intent.
+// The schemas are generated from the types in this package, so they always match what the library sends and accepts.
+func OpenAPISpec() ([]byte, error) {
+	g := &schemaGenerator{
+		components: map[string]interface{}{},
+	}
+	errorResponse := map[string]interface{}{
+		"description": "The entire request failed.",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": g.schema(reflect.TypeOf(ErrorFulfillmentResponse{})),
+			},
+		},
+	}
+
+	paths := map[string]interface{}{}
+	for _, op := range webhookOperations {
+		respSchema := map[string]interface{}{"type": "object"}
+		if op.response != nil {
+			respSchema = g.schema(op.response)
+		}
+
+		paths[op.path] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": op.path[1:],
+				"summary":     op.summary,
+				"description": "Handles the " + op.intent + " intent.",
+				"parameters": []interface{}{
+					map[string]interface{}{"$ref": "#/components/parameters/AgentUserID"},
+				},
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": g.schema(reflect.TypeOf(FulfillmentRequest{})),
+						},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": op.description,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": respSchema,
+							},
+						},
+					},
+					"default": errorResponse,
+				},
+			},
+		}
+	}
+
+	spec := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Google Smart Home provider webhook",
+			"description": "The contract between github.com/rmrobinson/google-smart-home-action-go and a provider implemented as an HTTP service.",
+			"version":     OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"parameters": map[string]interface{}{
+				"AgentUserID": map[string]interface{}{
+					"name":        AgentUserIDHeader,
+					"in":          "header",
+					"required":    true,
+					"description": "The ID of the user the request is for.",
+					"schema":      map[string]interface{}{"type": "string"},
+				},
+			},
+			"schemas": g.components,
+		},
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// OpenAPIHandler serves the document returned by OpenAPISpec.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := OpenAPISpec()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
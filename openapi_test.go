@@ -0,0 +1,55 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	data, err := OpenAPISpec()
+	assert.Nil(t, err)
+
+	var spec struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.Nil(t, json.Unmarshal(data, &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	for _, path := range []string{"/sync", "/query", "/execute", "/disconnect"} {
+		assert.Contains(t, spec.Paths[path], "post", path)
+	}
+
+	// Every reference resolves to a component.
+	for _, ref := range strings.Split(string(data), `"$ref": "#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		assert.NotNil(t, spec.Components.Schemas[name], name)
+	}
+
+	// The schemas follow the JSON form of the types, rather than their Go fields.
+	device := spec.Components.Schemas["Device"]["properties"].(map[string]interface{})
+	assert.Contains(t, device, "id")
+	assert.Contains(t, device, "otherDeviceIds")
+	assert.NotContains(t, device, "Challenges")
+	assert.Equal(t, []interface{}{"id", "type", "traits", "name", "willReportState"}, spec.Components.Schemas["Device"]["required"])
+
+	handle := spec.Components.Schemas["DeviceHandle"]
+	assert.Equal(t, []interface{}{"id"}, handle["required"])
+	assert.Contains(t, spec.Components.Schemas["DeviceState"]["properties"], "online")
+	assert.Contains(t, spec.Components.Schemas["Command"]["properties"], "params")
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	OpenAPIHandler(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), AgentUserIDHeader)
+}
@@ -0,0 +1,391 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OutboxStore durably queues the serialized states which could not be reported to the HomeGraph while it was unavailable.
+// Only the latest state of each device is retained: saving a state replaces any state already queued for the device.
+// Implementations must be safe for concurrent use.
+type OutboxStore interface {
+	// Save queues the supplied states, indexed by device ID, replacing any states already queued for the same devices.
+	Save(ctx context.Context, agentUserID string, states map[string]json.RawMessage) error
+	// Load returns the queued states, indexed by agent user ID and then device ID.
+	Load(ctx context.Context) (map[string]map[string]json.RawMessage, error)
+	// Delete removes any states queued for the supplied devices.
+	Delete(ctx context.Context, agentUserID string, deviceIDs []string) error
+}
+
+// WithReportStateOutbox queues the states of any ReportState request which fails because the HomeGraph is unavailable
+// (i.e. it could not be reached, responded with a 5xx or 429 status, or the circuit breaker is open) in the supplied store.
+// The queued states are sent by DrainOutbox, or periodically by RunOutboxDrain, once the HomeGraph is reachable again.
+// A state which is successfully reported removes any older state queued for the same device, so a queued state
+// never overwrites a newer one. To ensure this, calls to ReportState for the same user are serialized while an outbox
+// is configured; calls for different users proceed concurrently.
+func WithReportStateOutbox(store OutboxStore) ServiceOption {
+	return func(s *Service) {
+		s.outbox = store
+	}
+}
+
+// homeGraphUnavailable returns true if the error indicates the HomeGraph could not handle the request, rather than
+// rejecting it, in the same way as the HomeGraph circuit breaker counts failures.
+func homeGraphUnavailable(err error) bool {
+	var hgErr *HomeGraphError
+	if !errors.As(err, &hgErr) {
+		return false
+	}
+	if errors.Is(hgErr.Err, ErrCircuitOpen) {
+		return true
+	}
+
+	statusCode := hgErr.StatusCode
+	var rejected *HomeGraphRejectedError
+	if errors.As(hgErr.Err, &rejected) {
+		statusCode = rejected.Status
+	} else if statusCode == 0 {
+		// The request never received a response.
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
+// userLocks provides a mutex for each user, so the states of one user are reported in order without blocking other users.
+// Entries are removed once no goroutine holds or is waiting for them.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[string]*userLock
+}
+
+type userLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock acquires the mutex of the user, returning the function which releases it.
+func (ul *userLocks) lock(agentUserID string) func() {
+	ul.mu.Lock()
+	if ul.locks == nil {
+		ul.locks = map[string]*userLock{}
+	}
+	l, found := ul.locks[agentUserID]
+	if !found {
+		l = &userLock{}
+		ul.locks[agentUserID] = l
+	}
+	l.refs++
+	ul.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		ul.mu.Lock()
+		defer ul.mu.Unlock()
+		l.refs--
+		if l.refs < 1 {
+			delete(ul.locks, agentUserID)
+		}
+	}
+}
+
+// reportStateWithOutbox sends the chunk of states to the HomeGraph and updates the outbox with the result.
+func (s *Service) reportStateWithOutbox(ctx context.Context, agentUserID string, chunk map[string]json.RawMessage) error {
+	unlock := s.outboxLocks.lock(agentUserID)
+	defer unlock()
+
+	err := s.reportStateChunk(ctx, agentUserID, chunk)
+	if err != nil && !homeGraphUnavailable(err) {
+		return err
+	}
+
+	var outboxErr error
+	if err != nil {
+		outboxErr = s.outbox.Save(ctx, agentUserID, chunk)
+	} else {
+		outboxErr = s.outbox.Delete(ctx, agentUserID, sortedStateIDs(chunk))
+	}
+	if outboxErr != nil {
+		s.logger.Info("error updating report state outbox",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(outboxErr),
+		)
+	}
+	return err
+}
+
+// DrainOutbox reports the states queued in the outbox configured by WithReportStateOutbox, removing each once reported.
+// Draining stops at the first user whose states fail because the HomeGraph is still unavailable, and that error is returned;
+// the states remain queued to be retried later. States the HomeGraph rejects are removed from the outbox and supplied
+// to the function registered with WithReportStateDeadLetter, as retrying them would not succeed.
+// If no outbox is configured nothing is done.
+func (s *Service) DrainOutbox(ctx context.Context) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	queued, err := s.outbox.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var agentUserIDs []string
+	for agentUserID := range queued {
+		agentUserIDs = append(agentUserIDs, agentUserID)
+	}
+	sort.Strings(agentUserIDs)
+
+	for _, agentUserID := range agentUserIDs {
+		if err := s.drainUserOutbox(ctx, agentUserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drainUserOutbox reports the states queued for the user. The states are loaded again once the user's lock is held,
+// as a newer state may have been reported (and the queued one removed) since they were last loaded.
+func (s *Service) drainUserOutbox(ctx context.Context, agentUserID string) error {
+	unlock := s.outboxLocks.lock(agentUserID)
+	defer unlock()
+
+	queued, err := s.outbox.Load(ctx)
+	if err != nil {
+		return err
+	}
+	states := queued[agentUserID]
+
+	deviceIDs := sortedStateIDs(states)
+	for _, chunkIDs := range chunkDeviceStates(deviceIDs, states, s.reportStateChunkSize, s.reportStatePayloadBytes) {
+		chunk := map[string]json.RawMessage{}
+		for _, deviceID := range chunkIDs {
+			chunk[deviceID] = states[deviceID]
+		}
+
+		err := s.reportStateChunk(ctx, agentUserID, chunk)
+		if homeGraphUnavailable(err) {
+			return err
+		} else if err != nil {
+			s.logger.Info("queued state rejected",
+				zap.String("agent_user_id", agentUserID),
+				zap.Strings("device_ids", chunkIDs),
+				zap.Error(err),
+			)
+			if s.reportStateDeadLetter != nil {
+				s.reportStateDeadLetter(ctx, agentUserID, chunk, err)
+			}
+		}
+
+		if err := s.outbox.Delete(ctx, agentUserID, chunkIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunOutboxDrain calls DrainOutbox every interval, so states queued while the HomeGraph was unavailable are
+// reported once it becomes reachable again. This blocks until the supplied context is cancelled.
+func (s *Service) RunOutboxDrain(ctx context.Context, interval time.Duration) {
+	s.runEvery(ctx, interval, func() {
+		if err := s.DrainOutbox(ctx); err != nil {
+			s.logger.Info("error draining report state outbox",
+				zap.Error(err),
+			)
+		}
+	})
+}
+
+// sortedStateIDs returns the sorted device IDs of the supplied states.
+func sortedStateIDs(states map[string]json.RawMessage) []string {
+	var deviceIDs []string
+	for deviceID := range states {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+	return deviceIDs
+}
+
+// MemoryOutbox is an OutboxStore which holds the queued states in memory, so they are lost if the process exits.
+type MemoryOutbox struct {
+	mu     sync.Mutex
+	states map[string]map[string]json.RawMessage
+}
+
+// NewMemoryOutbox creates a new, empty in-memory outbox.
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{
+		states: map[string]map[string]json.RawMessage{},
+	}
+}
+
+// Save queues the supplied states, replacing any states already queued for the same devices.
+func (o *MemoryOutbox) Save(_ context.Context, agentUserID string, states map[string]json.RawMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	saveOutboxStates(o.states, agentUserID, states)
+	return nil
+}
+
+// Load returns a copy of the queued states.
+func (o *MemoryOutbox) Load(_ context.Context) (map[string]map[string]json.RawMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queued := map[string]map[string]json.RawMessage{}
+	for agentUserID, states := range o.states {
+		saveOutboxStates(queued, agentUserID, states)
+	}
+	return queued, nil
+}
+
+// Delete removes any states queued for the supplied devices.
+func (o *MemoryOutbox) Delete(_ context.Context, agentUserID string, deviceIDs []string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	deleteOutboxStates(o.states, agentUserID, deviceIDs)
+	return nil
+}
+
+// FileOutbox is an OutboxStore which persists the queued states as a JSON file, so they survive a restart.
+// The file is rewritten atomically each time the queued states change.
+type FileOutbox struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewFileOutbox creates an outbox persisted to the file at path. The file is created when a state is first queued.
+func NewFileOutbox(path string) *FileOutbox {
+	return &FileOutbox{
+		path: path,
+	}
+}
+
+// Save queues the supplied states, replacing any states already queued for the same devices.
+func (o *FileOutbox) Save(_ context.Context, agentUserID string, states map[string]json.RawMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queued, err := o.read()
+	if err != nil {
+		return err
+	}
+	saveOutboxStates(queued, agentUserID, states)
+	return o.write(queued)
+}
+
+// Load returns the queued states. If the file doesn't exist no states are queued.
+func (o *FileOutbox) Load(_ context.Context) (map[string]map[string]json.RawMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.read()
+}
+
+// Delete removes any states queued for the supplied devices. The file is only rewritten if a state was removed.
+func (o *FileOutbox) Delete(_ context.Context, agentUserID string, deviceIDs []string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queued, err := o.read()
+	if err != nil {
+		return err
+	}
+	if !deleteOutboxStates(queued, agentUserID, deviceIDs) {
+		return nil
+	}
+	return o.write(queued)
+}
+
+// read loads the queued states from the file.
+func (o *FileOutbox) read() (map[string]map[string]json.RawMessage, error) {
+	queued := map[string]map[string]json.RawMessage{}
+
+	data, err := ioutil.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return queued, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &queued); err != nil {
+		return nil, err
+	}
+	return queued, nil
+}
+
+// write replaces the file with the supplied states, by writing them to a temporary file which is renamed over it.
+func (o *FileOutbox) write(queued map[string]map[string]json.RawMessage) error {
+	data, err := json.Marshal(queued)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(o.path), filepath.Base(o.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// saveOutboxStates copies the states of the user into queued, replacing any existing states of the same devices.
+func saveOutboxStates(queued map[string]map[string]json.RawMessage, agentUserID string, states map[string]json.RawMessage) {
+	if len(states) < 1 {
+		return
+	}
+	userStates, found := queued[agentUserID]
+	if !found {
+		userStates = map[string]json.RawMessage{}
+		queued[agentUserID] = userStates
+	}
+	for deviceID, state := range states {
+		userStates[deviceID] = append(json.RawMessage(nil), state...)
+	}
+}
+
+// deleteOutboxStates removes the states of the devices of the user from queued, returning true if any were removed.
+func deleteOutboxStates(queued map[string]map[string]json.RawMessage, agentUserID string, deviceIDs []string) bool {
+	userStates, found := queued[agentUserID]
+	if !found {
+		return false
+	}
+
+	removed := false
+	for _, deviceID := range deviceIDs {
+		if _, found := userStates[deviceID]; found {
+			delete(userStates, deviceID)
+			removed = true
+		}
+	}
+	if len(userStates) < 1 {
+		delete(queued, agentUserID)
+	}
+	return removed
+}
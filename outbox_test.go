@@ -0,0 +1,184 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceReportStateOutbox(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{
+		failOn: "agent-id",
+	}
+	outbox := NewMemoryOutbox()
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithReportStateOutbox(outbox))
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true),
+		"light-2": NewDeviceState(true).RecordOnOff(true),
+	})
+	var reportErr *ReportStateError
+	assert.True(t, errors.As(err, &reportErr))
+
+	queued, err := outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, queued["agent-id"], 2)
+
+	// A newer state which fails replaces the queued one, and a newer state which succeeds removes it.
+	err = svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(false),
+	})
+	assert.NotNil(t, err)
+	queued, err = outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"online":true,"on":false}`, string(queued["agent-id"]["light-1"]))
+
+	thg.failOn = ""
+	err = svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"light-2": NewDeviceState(true).RecordOnOff(false),
+	})
+	assert.Nil(t, err)
+	queued, err = outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"light-1"}, sortedStateIDs(queued["agent-id"]))
+
+	thg.bodies = nil
+	assert.Nil(t, svc.DrainOutbox(context.Background()))
+	assert.Len(t, thg.bodies, 1)
+
+	var body struct {
+		AgentUserID string `json:"agentUserId"`
+		Payload     struct {
+			Devices struct {
+				States map[string]interface{} `json:"states"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, "agent-id", body.AgentUserID)
+	assert.Equal(t, map[string]interface{}{
+		"light-1": map[string]interface{}{
+			"online": true,
+			"on":     false,
+		},
+	}, body.Payload.Devices.States)
+
+	queued, err = outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, queued)
+}
+
+func TestServiceDrainOutboxUnavailable(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{
+		failOn: "agent-id",
+	}
+	outbox := NewMemoryOutbox()
+	assert.Nil(t, outbox.Save(context.Background(), "agent-id", map[string]json.RawMessage{
+		"light-1": json.RawMessage(`{"online":true}`),
+	}))
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithReportStateOutbox(outbox))
+
+	err := svc.DrainOutbox(context.Background())
+	assert.True(t, homeGraphUnavailable(err))
+
+	queued, err := outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, queued["agent-id"], 1)
+}
+
+func TestServiceReportStateOutboxRejected(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+	outbox := NewMemoryOutbox()
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithReportStateOutbox(outbox))
+
+	assert.False(t, homeGraphUnavailable(nil))
+	assert.False(t, homeGraphUnavailable(ErrStateSerialization))
+	assert.True(t, homeGraphUnavailable(&HomeGraphError{Err: ErrCircuitOpen}))
+	assert.True(t, homeGraphUnavailable(&HomeGraphError{Err: errors.New("connection refused")}))
+	assert.True(t, homeGraphUnavailable(&HomeGraphError{Err: &HomeGraphRejectedError{Status: http.StatusTooManyRequests}}))
+	assert.False(t, homeGraphUnavailable(&HomeGraphError{Err: &HomeGraphRejectedError{Status: http.StatusBadRequest}}))
+	assert.False(t, homeGraphUnavailable(&HomeGraphError{StatusCode: http.StatusNoContent, Err: ErrReportStateFailed}))
+
+	assert.Nil(t, svc.DrainOutbox(context.Background()))
+	assert.Empty(t, thg.paths)
+}
+
+func TestUserLocks(t *testing.T) {
+	var ul userLocks
+
+	unlock1 := ul.lock("user-1")
+	// Other users aren't blocked.
+	unlock2 := ul.lock("user-2")
+	unlock2()
+
+	locked := make(chan struct{})
+	unlocked := make(chan struct{})
+	go func() {
+		unlock := ul.lock("user-1")
+		close(locked)
+		unlock()
+		close(unlocked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("lock of the same user acquired twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlock1()
+	<-unlocked
+
+	ul.mu.Lock()
+	assert.Empty(t, ul.locks)
+	ul.mu.Unlock()
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "outbox.json")
+	outbox := NewFileOutbox(path)
+
+	queued, err := outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, queued)
+
+	assert.Nil(t, outbox.Save(context.Background(), "agent-id", map[string]json.RawMessage{
+		"light-1": json.RawMessage(`{"on":true}`),
+		"light-2": json.RawMessage(`{"on":true}`),
+	}))
+	assert.Nil(t, outbox.Save(context.Background(), "agent-id", map[string]json.RawMessage{
+		"light-1": json.RawMessage(`{"on":false}`),
+	}))
+	assert.Nil(t, outbox.Delete(context.Background(), "agent-id", []string{"light-2", "light-3"}))
+	assert.Nil(t, outbox.Delete(context.Background(), "other-id", []string{"light-1"}))
+
+	// The states survive a restart.
+	queued, err = NewFileOutbox(path).Load(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]map[string]json.RawMessage{
+		"agent-id": {
+			"light-1": json.RawMessage(`{"on":false}`),
+		},
+	}, queued)
+
+	assert.Nil(t, outbox.Delete(context.Background(), "agent-id", []string{"light-1"}))
+	queued, err = outbox.Load(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, queued)
+}
@@ -0,0 +1,177 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnknownExecution is returned by CompleteExecution if the token doesn't belong to a pending execution.
+	// This may be because the execution has already been completed, or has expired.
+	ErrUnknownExecution = errors.New("unknown execution")
+)
+
+// DefaultPendingExecutionTTL is the default period a pending execution can be completed within before it is discarded.
+const DefaultPendingExecutionTTL = time.Hour
+
+// WithPendingExecutionTTL sets how long a device reported as pending using AddPendingDevice may take to finish.
+// Executions which aren't completed using CompleteExecution within the ttl are discarded, so devices which never
+// finish don't accumulate. By default this is DefaultPendingExecutionTTL.
+func WithPendingExecutionTTL(ttl time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.pending.ttl = ttl
+	}
+}
+
+// pendingExecution contains the details of a command which a device is still executing.
+type pendingExecution struct {
+	agentUserID   string
+	deviceID      string
+	trait         string
+	followUpToken string
+	expires       time.Time
+}
+
+// pendingExecutions tracks the commands which are still executing, indexed by the completion token supplied by the provider.
+// Executions are discarded once they expire.
+type pendingExecutions struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	executions map[string]pendingExecution
+}
+
+func newPendingExecutions() *pendingExecutions {
+	return &pendingExecutions{
+		ttl:        DefaultPendingExecutionTTL,
+		executions: map[string]pendingExecution{},
+	}
+}
+
+func (pe *pendingExecutions) add(now time.Time, token string, execution pendingExecution) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.expire(now)
+	execution.expires = now.Add(pe.ttl)
+	pe.executions[token] = execution
+}
+
+func (pe *pendingExecutions) remove(now time.Time, token string) (pendingExecution, bool) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.expire(now)
+	execution, found := pe.executions[token]
+	delete(pe.executions, token)
+	return execution, found
+}
+
+// expire discards the executions which have expired. The lock must be held.
+func (pe *pendingExecutions) expire(now time.Time) {
+	for token, execution := range pe.executions {
+		if !now.Before(execution.expires) {
+			delete(pe.executions, token)
+		}
+	}
+}
+
+// AddPendingDevice records that the device has accepted the command but will take a while to finish executing it
+// (i.e. a garage door which is closing, or an oven which is preheating).
+// Google is told the command is PENDING. The token is chosen by the provider and must be unique; once the device has
+// finished the provider should call Service.CompleteExecution with the same token.
+func (er *ExecuteResponse) AddPendingDevice(token string, id string) {
+	if er.PendingDevices == nil {
+		er.PendingDevices = map[string]string{}
+	}
+	er.PendingDevices[token] = id
+}
+
+// CompleteExecution reports the final state of a device which was reported as pending using AddPendingDevice.
+// The state is sent to Google using ReportState and, if Google requested a follow-up for the command, a follow-up
+// notification is sent as well. If finalState has an ErrorCode set the follow-up reports the command as having failed.
+// ErrUnknownExecution is returned if the token is not associated with a pending execution, including one which
+// has expired (see WithPendingExecutionTTL).
+func (s *Service) CompleteExecution(ctx context.Context, token string, finalState DeviceState) error {
+	execution, found := s.pending.remove(s.now(), token)
+	if !found {
+		return ErrUnknownExecution
+	}
+
+	err := s.ReportState(ctx, execution.agentUserID, map[string]DeviceState{
+		execution.deviceID: finalState,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(execution.followUpToken) < 1 {
+		return nil
+	}
+	if len(execution.trait) < 1 {
+		s.logger.Info("unable to send follow-up for command with unknown trait",
+			requestIDField(ctx),
+			zap.String("agent_user_id", execution.agentUserID),
+			zap.String("device_id", execution.deviceID),
+		)
+		return nil
+	}
+
+	followUp := FollowUpResponse{
+		DeviceID: execution.deviceID,
+		Trait:    execution.trait,
+		Status:   "SUCCESS",
+		States:   finalState.State,
+	}
+	if len(finalState.ErrorCode) > 0 {
+		followUp.Status = "FAILURE"
+		followUp.ErrorCode = finalState.ErrorCode
+	}
+	return s.SendFollowUp(ctx, execution.agentUserID, execution.followUpToken, followUp)
+}
+
+// recordPendingExecutions tracks each pending device in the response so it can later be completed.
+// The trait and follow-up token are taken from the command which targeted the device.
+func (s *Service) recordPendingExecutions(agentUserID string, commands []CommandArg, resp *ExecuteResponse) {
+	for token, deviceID := range resp.PendingDevices {
+		execution := pendingExecution{
+			agentUserID: agentUserID,
+			deviceID:    deviceID,
+		}
+		for _, commandArg := range commands {
+			if !targetsDevice(commandArg, deviceID) || len(commandArg.Commands) < 1 {
+				continue
+			}
+			execution.trait = commandTrait(commandArg.Commands[0].Name)
+			execution.followUpToken = commandArg.FollowUpToken
+		}
+		s.pending.add(s.now(), token, execution)
+	}
+}
+
+func targetsDevice(commandArg CommandArg, deviceID string) bool {
+	for _, device := range commandArg.TargetDevices {
+		if device.ID == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// commandTrait returns the trait the named command belongs to.
+// Commands which are not parsed by this library are assumed to share the name of their trait (i.e. OpenClose).
+func commandTrait(name string) string {
+	if trait, known := commandTraits[name]; known {
+		return trait
+	}
+	trait := strings.Replace(name, "action.devices.commands.", "action.devices.traits.", 1)
+	if IsValidTrait(trait) {
+		return trait
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceCompleteExecution(t *testing.T) {
+	thg := &testHomeGraph{}
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		executeRespDeviceState: NewDeviceState(true).RecordOpenPercent(100),
+		executeRespPending: map[string]string{
+			"completion-1": "garage-id",
+		},
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, provider, newTestHomeGraphService(t, thg))
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+				"commands": [{
+					"devices": [{"id": "garage-id"}],
+					"execution": [{
+						"command": "action.devices.commands.OpenClose",
+						"params": {"openPercent": 0, "followUpToken": "follow-up-1"}
+					}]
+				}]
+			}
+		}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(svc.GoogleFulfillmentHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `{"ids":["garage-id"],"status":"PENDING","states":{"online":true,"openPercent":100}}`)
+
+	err := svc.CompleteExecution(context.Background(), "completion-1", NewDeviceState(true).RecordOpenPercent(0))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"/v1/devices:reportStateAndNotification",
+		"/v1/devices:reportStateAndNotification",
+	}, thg.paths)
+	assert.Contains(t, thg.bodies[1], `"followUpToken":"follow-up-1"`)
+	assert.Contains(t, thg.bodies[1], `"OpenClose"`)
+
+	err = svc.CompleteExecution(context.Background(), "completion-1", NewDeviceState(true))
+	assert.Equal(t, ErrUnknownExecution, err)
+}
+
+func TestPendingExecutionsExpire(t *testing.T) {
+	now := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, &testHomeGraph{}),
+		WithPendingExecutionTTL(time.Minute),
+		WithClock(ClockFunc(func() time.Time {
+			return now
+		})),
+	)
+
+	resp := &ExecuteResponse{}
+	resp.AddPendingDevice("completion-1", "garage-id")
+	resp.AddPendingDevice("completion-2", "gate-id")
+	svc.recordPendingExecutions("agent-id", nil, resp)
+
+	now = now.Add(59 * time.Second)
+	assert.Nil(t, svc.CompleteExecution(context.Background(), "completion-1", NewDeviceState(true)))
+
+	now = now.Add(time.Second)
+	err := svc.CompleteExecution(context.Background(), "completion-2", NewDeviceState(true))
+	assert.Equal(t, ErrUnknownExecution, err)
+	assert.Empty(t, svc.pending.executions)
+}
+
+func TestCommandTrait(t *testing.T) {
+	assert.Equal(t, TraitOnOff, commandTrait("action.devices.commands.OnOff"))
+	assert.Equal(t, TraitOpenClose, commandTrait("action.devices.commands.OpenClose"))
+	assert.Equal(t, "", commandTrait("action.devices.commands.Toast"))
+}
@@ -0,0 +1,78 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrProviderFailed is returned if the provider returned an error code instead of the requested devices or states.
+	ErrProviderFailed = errors.New("provider failed")
+)
+
+// ReportFullState retrieves every device of the user from the provider and reports the complete state
+// of each device with WillReportState set. Google recommends periodically reporting the full state
+// so that any inconsistencies in the HomeGraph (i.e. from a missed update) are corrected.
+func (s *Service) ReportFullState(ctx context.Context, agentUserID string) error {
+	syncResp, err := s.provider.Sync(ctx, agentUserID)
+	if err != nil {
+		return err
+	} else if len(syncResp.ErrorCode) > 0 {
+		return ErrProviderFailed
+	}
+
+	queryReq := &QueryRequest{
+		AgentID: agentUserID,
+	}
+	for _, device := range syncResp.Devices {
+		if !device.WillReportState {
+			continue
+		}
+		queryReq.Devices = append(queryReq.Devices, DeviceArg{
+			ID:         device.ID,
+			CustomData: device.CustomData,
+		})
+	}
+	if len(queryReq.Devices) < 1 {
+		return nil
+	}
+
+	queryResp, err := s.provider.Query(ctx, queryReq)
+	if err != nil {
+		return err
+	} else if len(queryResp.ErrorCode) > 0 {
+		return ErrProviderFailed
+	}
+
+	states := map[string]DeviceState{}
+	for _, device := range queryReq.Devices {
+		state, found := queryResp.States[device.ID]
+		if !found || len(state.ErrorCode) > 0 {
+			continue
+		}
+		states[device.ID] = state
+	}
+	if len(states) < 1 {
+		return nil
+	}
+	return s.ReportState(ctx, agentUserID, states)
+}
+
+// RunPeriodicStateReport calls ReportFullState for each of the users every interval.
+// It blocks until the context is cancelled, so it should be run on its own goroutine.
+// Failures are logged and the users are reported again at the next interval.
+func (s *Service) RunPeriodicStateReport(ctx context.Context, interval time.Duration, agentUserIDs []string) {
+	s.runEvery(ctx, interval, func() {
+		for _, agentUserID := range agentUserIDs {
+			if err := s.ReportFullState(ctx, agentUserID); err != nil {
+				s.logger.Info("error reporting full state",
+					zap.String("agent_user_id", agentUserID),
+					zap.Error(err),
+				)
+			}
+		}
+	})
+}
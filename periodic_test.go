@@ -0,0 +1,90 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceReportFullState(t *testing.T) {
+	reporting := NewLight("light-1")
+	reporting.WillReportState = true
+	reporting.CustomData["hub"] = "a"
+	silent := NewLight("light-2")
+	failing := NewLight("light-3")
+	failing.WillReportState = true
+
+	tp := &testProvider{
+		syncResp: []*Device{reporting, silent, failing},
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+			"light-3": {ErrorCode: ErrorCodeDeviceNotFound},
+		},
+	}
+	thg := &testHomeGraph{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, tp, newTestHomeGraphService(t, thg))
+
+	assert.Nil(t, svc.ReportFullState(context.Background(), "agent-id"))
+	assert.Equal(t, []DeviceArg{
+		{ID: "light-1", CustomData: map[string]interface{}{"hub": "a"}},
+		{ID: "light-3", CustomData: map[string]interface{}{}},
+	}, tp.queryReq.Devices)
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+	assert.Contains(t, thg.bodies[0], "light-1")
+	assert.NotContains(t, thg.bodies[0], "light-3")
+}
+
+func TestServiceReportFullStateProviderErrors(t *testing.T) {
+	thg := &testHomeGraph{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{syncErrorCode: ErrorCodeDeviceNotFound}, newTestHomeGraphService(t, thg))
+	assert.True(t, errors.Is(svc.ReportFullState(context.Background(), "agent-id"), ErrProviderFailed))
+
+	queryErr := errors.New("query error")
+	light := NewLight("light-1")
+	light.WillReportState = true
+	svc = NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{syncResp: []*Device{light}, queryErr: queryErr}, newTestHomeGraphService(t, thg))
+	assert.Equal(t, queryErr, svc.ReportFullState(context.Background(), "agent-id"))
+	assert.Empty(t, thg.paths)
+}
+
+func TestServiceRunPeriodicStateReport(t *testing.T) {
+	light := NewLight("light-1")
+	light.WillReportState = true
+	tp := &testProvider{
+		syncResp: []*Device{light},
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	thg := &testHomeGraph{}
+	clock := newTestClock(time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, tp, newTestHomeGraphService(t, thg), WithClock(clock))
+
+	reported := make(chan ReportStateSent, 1)
+	svc.Subscribe(func(e Event) {
+		if sent, ok := e.(ReportStateSent); ok {
+			reported <- sent
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunPeriodicStateReport(ctx, time.Minute, []string{"agent-id"})
+		close(done)
+	}()
+
+	clock.waitForTimer(t)
+	clock.Advance(time.Minute)
+	sent := <-reported
+	assert.Nil(t, sent.Err)
+	assert.Equal(t, []string{"light-1"}, sent.DeviceIDs)
+
+	cancel()
+	<-done
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+}
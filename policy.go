@@ -0,0 +1,96 @@
+package action
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContentTypePolicy defines which Content-Type headers the fulfillment handler accepts.
+type ContentTypePolicy int
+
+const (
+	// ContentTypeStrict requires the request to declare an application/json content type, rejecting it with a 415 otherwise.
+	// This is the default.
+	ContentTypeStrict ContentTypePolicy = iota
+	// ContentTypeLenient attempts to decode every request as JSON regardless of the declared content type.
+	// This is intended for proxies or test tools which don't set the header correctly.
+	ContentTypeLenient
+)
+
+// UnknownIntentPolicy defines how the fulfillment handler responds to intents which have no registered handler.
+type UnknownIntentPolicy int
+
+const (
+	// UnknownIntentReject fails the request with a 400. This is the default.
+	UnknownIntentReject UnknownIntentPolicy = iota
+	// UnknownIntentNotSupported responds with a 200 and the notSupported error code in the payload,
+	// which allows Google to gracefully handle intents introduced after this library was written.
+	UnknownIntentNotSupported
+)
+
+// AuthSchemePolicy defines which Authorization header schemes the default token extraction accepts.
+type AuthSchemePolicy int
+
+const (
+	// AuthSchemeBearer requires the Authorization header to use the Bearer scheme, as Google sends it. This is the default.
+	AuthSchemeBearer AuthSchemePolicy = iota
+	// AuthSchemeLenient additionally accepts an Authorization header containing only the token, without a scheme.
+	AuthSchemeLenient
+)
+
+// WithContentTypePolicy controls which Content-Type headers the fulfillment handler accepts.
+// The default is ContentTypeStrict.
+func WithContentTypePolicy(policy ContentTypePolicy) ServiceOption {
+	return func(s *Service) {
+		s.contentTypePolicy = policy
+	}
+}
+
+// WithUnknownIntentPolicy controls how the fulfillment handler responds to intents which have no registered handler.
+// The default is UnknownIntentReject.
+func WithUnknownIntentPolicy(policy UnknownIntentPolicy) ServiceOption {
+	return func(s *Service) {
+		s.unknownIntentPolicy = policy
+	}
+}
+
+// WithAuthSchemePolicy controls which Authorization header schemes are accepted.
+// The default is AuthSchemeBearer.
+// This replaces the TokenExtractor, so it should not be combined with WithTokenExtractor.
+func WithAuthSchemePolicy(policy AuthSchemePolicy) ServiceOption {
+	return func(s *Service) {
+		if policy == AuthSchemeLenient {
+			s.tokenExtractor = LenientBearerTokenExtractor()
+		} else {
+			s.tokenExtractor = BearerTokenExtractor()
+		}
+	}
+}
+
+// LenientBearerTokenExtractor retrieves the token from the Authorization header using the Bearer scheme,
+// or from the full value of the header if no scheme is supplied.
+func LenientBearerTokenExtractor() TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) (string, error) {
+		authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
+		if len(authHeader) < 1 {
+			return "", ErrAccessTokenMissing
+		}
+
+		authTokenParts := strings.Fields(authHeader)
+		switch {
+		case len(authTokenParts) == 1:
+			return authTokenParts[0], nil
+		case len(authTokenParts) == 2 && strings.ToLower(authTokenParts[0]) == "bearer":
+			return authTokenParts[1], nil
+		}
+		return "", ErrAccessTokenNotBearer
+	})
+}
+
+// acceptsContentType checks whether the supplied Content-Type header is permitted by the policy.
+func (p ContentTypePolicy) acceptsContentType(contentType string) bool {
+	if p == ContentTypeLenient {
+		return true
+	}
+	return strings.Contains(contentType, "application/json")
+}
@@ -0,0 +1,70 @@
+package action
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newPolicyTestRequest(contentType string, auth string, intent string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "`+intent+`"}]
+	}`)))
+	req.Header.Set("content-type", contentType)
+	req.Header.Set("authorization", auth)
+	return req
+}
+
+func TestGoogleFulfillmentHandlerPolicies(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+
+	// Defaults reject everything outside of the Google contract.
+	svc := NewService(zaptest.NewLogger(t), authenticator, &testProvider{}, nil)
+	handler := http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("text/plain", "bearer asdf", IntentSync))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("application/json", "asdf", IntentSync))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("application/json", "bearer asdf", "action.devices.GOOGLE"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Lenient policies accept them.
+	svc = NewService(zaptest.NewLogger(t), authenticator, &testProvider{}, nil,
+		WithContentTypePolicy(ContentTypeLenient),
+		WithUnknownIntentPolicy(UnknownIntentNotSupported),
+		WithAuthSchemePolicy(AuthSchemeLenient),
+	)
+	handler = http.HandlerFunc(svc.GoogleFulfillmentHandler)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("text/plain", "bearer asdf", IntentSync))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("application/json", "asdf", IntentSync))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("application/json", "Basic asdf", IntentSync))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newPolicyTestRequest("application/json", "bearer asdf", "action.devices.GOOGLE"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"errorCode":"notSupported","debugString":"unsupported intent action.devices.GOOGLE"}}
+`, rr.Body.String())
+}
@@ -0,0 +1,68 @@
+package action
+
+import (
+	"context"
+)
+
+// Principal describes the user an access token was issued to, along with any scopes or claims the token carries.
+type Principal struct {
+	UserID string
+	// Scopes granted to the token (i.e. restricting which devices may be controlled).
+	Scopes []string
+	// Claims contains any other details from the token the provider may need.
+	Claims map[string]interface{}
+}
+
+// HasScope returns whether the scope was granted to the token.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalValidator may optionally be implemented by an AccessTokenValidator whose tokens carry scopes or claims.
+// If implemented, ValidatePrincipal is used in place of Validate; the returned Principal must have its UserID set.
+// The same errors as Validate may be returned.
+type PrincipalValidator interface {
+	ValidatePrincipal(context.Context, string) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of the context carrying the supplied principal.
+// The fulfillment handler does this with the principal of the validated access token, so the context passed to the
+// Provider carries it; this allows the provider to filter the devices returned by Sync or authorize each command in Execute.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal carried by the context, if there is one.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// validatePrincipal validates the token using the configured AccessTokenValidator.
+// Validators which don't implement PrincipalValidator result in a principal with only the user ID set.
+func (s *Service) validatePrincipal(ctx context.Context, token string) (*Principal, error) {
+	if validator, ok := s.atValidator.(PrincipalValidator); ok {
+		principal, err := validator.ValidatePrincipal(ctx, token)
+		if err != nil {
+			return nil, err
+		} else if principal == nil {
+			return &Principal{}, nil
+		}
+		return principal, nil
+	}
+
+	userID, err := s.atValidator.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		UserID: userID,
+	}, nil
+}
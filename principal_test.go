@@ -0,0 +1,80 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type testPrincipalAuthenticator struct {
+	testAuthenticator
+	scopes []string
+}
+
+func (ta *testPrincipalAuthenticator) ValidatePrincipal(ctx context.Context, token string) (*Principal, error) {
+	userID, err := ta.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		UserID: userID,
+		Scopes: ta.scopes,
+	}, nil
+}
+
+// principalProvider records the principal carried by the context passed to Sync.
+type principalProvider struct {
+	testProvider
+	principal *Principal
+}
+
+func (pp *principalProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	pp.principal, _ = PrincipalFromContext(ctx)
+	return pp.testProvider.Sync(ctx, agentUserID)
+}
+
+func TestGoogleFulfillmentHandlerPrincipal(t *testing.T) {
+	sync := func(svc *Service) int {
+		req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{"requestId": "1", "inputs": [{"intent": "action.devices.SYNC"}]}`))
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("authorization", "bearer asdf")
+		rr := httptest.NewRecorder()
+		svc.GoogleFulfillmentHandler(rr, req)
+		return rr.Code
+	}
+
+	provider := &principalProvider{}
+	svc := NewService(zaptest.NewLogger(t), &testPrincipalAuthenticator{
+		testAuthenticator: testAuthenticator{validToken: "asdf", userID: "agent-id"},
+		scopes:            []string{"lights:read"},
+	}, provider, nil)
+	assert.Equal(t, http.StatusOK, sync(svc))
+	assert.Equal(t, "agent-id", provider.principal.UserID)
+	assert.True(t, provider.principal.HasScope("lights:read"))
+	assert.False(t, provider.principal.HasScope("locks:write"))
+
+	// Validators which don't supply a principal still result in one carrying the user ID.
+	provider = &principalProvider{}
+	svc = NewService(zaptest.NewLogger(t), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil)
+	assert.Equal(t, http.StatusOK, sync(svc))
+	assert.Equal(t, &Principal{UserID: "agent-id"}, provider.principal)
+
+	provider = &principalProvider{}
+	svc = NewService(zaptest.NewLogger(t), &testPrincipalAuthenticator{}, provider, nil)
+	assert.Equal(t, http.StatusUnauthorized, sync(svc))
+	assert.Nil(t, provider.principal)
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	principal, ok := PrincipalFromContext(ContextWithPrincipal(context.Background(), &Principal{UserID: "agent-id"}))
+	assert.True(t, ok)
+	assert.Equal(t, "agent-id", principal.UserID)
+}
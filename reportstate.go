@@ -0,0 +1,316 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/homegraph/v1"
+)
+
+// ReportStateError is returned by ReportState if the state of one or more devices could not be reported.
+// The states of devices not contained in FailedDevices were successfully reported, so only the failed devices need to be retried.
+type ReportStateError struct {
+	// FailedDevices contains the error encountered reporting each failed device, indexed by device ID.
+	FailedDevices map[string]error
+}
+
+// Error returns a summary of the devices which failed.
+func (e *ReportStateError) Error() string {
+	return fmt.Sprintf("report state failed for %d device(s): %v", len(e.FailedDevices), e.FailedDeviceIDs())
+}
+
+// Unwrap returns the error encountered by the first failed device, so callers can use errors.Is against it.
+func (e *ReportStateError) Unwrap() error {
+	ids := e.FailedDeviceIDs()
+	if len(ids) < 1 {
+		return nil
+	}
+	return e.FailedDevices[ids[0]]
+}
+
+// FailedDeviceIDs returns the sorted set of device IDs whose state was not reported.
+func (e *ReportStateError) FailedDeviceIDs() []string {
+	var ids []string
+	for id := range e.FailedDevices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+var (
+	// ErrStateSerialization is returned by ReportState if a device state could not be serialized to JSON.
+	// Nothing is sent to the HomeGraph when this occurs.
+	ErrStateSerialization = errors.New("state serialization failed")
+)
+
+// DefaultReportStatePayloadBytes is the default limit on the size of the device states sent in a single ReportState request.
+// It is comfortably below the size of the payloads the HomeGraph accepts.
+const DefaultReportStatePayloadBytes = 256 * 1024
+
+// WithReportStatePayloadBytes limits the size of the serialized device states sent in a single HomeGraph request.
+// Calls to ReportState whose states exceed this are split into multiple requests, in the same way as WithReportStateChunkSize;
+// a device whose state alone exceeds the limit is sent in a request of its own.
+// By default the limit is DefaultReportStatePayloadBytes. A limit of 0 or less disables the check.
+func WithReportStatePayloadBytes(maxBytes int) ServiceOption {
+	return func(s *Service) {
+		s.reportStatePayloadBytes = maxBytes
+	}
+}
+
+// chunkDeviceStates splits the sorted device IDs into chunks containing at most maxDevices devices,
+// whose serialized states total at most maxBytes. Either limit is ignored if it is 0 or less.
+func chunkDeviceStates(deviceIDs []string, states map[string]json.RawMessage, maxDevices int, maxBytes int) [][]string {
+	var chunks [][]string
+	var chunk []string
+	chunkBytes := 0
+	for _, deviceID := range deviceIDs {
+		// Each entry is serialized as "id":state, along with a separating comma.
+		entryBytes := len(deviceID) + len(states[deviceID]) + 4
+
+		full := maxDevices > 0 && len(chunk) >= maxDevices
+		oversized := maxBytes > 0 && chunkBytes+entryBytes > maxBytes
+		if len(chunk) > 0 && (full || oversized) {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			chunkBytes = 0
+		}
+
+		chunk = append(chunk, deviceID)
+		chunkBytes += entryBytes
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// ReportStateDeadLetterFunc is invoked with the serialized states, indexed by device ID, which the HomeGraph failed to accept.
+// This allows the states to be stored and retried later, or inspected to diagnose why they were rejected.
+type ReportStateDeadLetterFunc func(ctx context.Context, agentUserID string, states map[string]json.RawMessage, err error)
+
+// WithReportStateDeadLetter registers a function which receives the states of any ReportState request which fails.
+// If the states are split into chunks (see WithReportStateChunkSize) the function is invoked once for each failed chunk.
+// The function is called synchronously before ReportState returns.
+func WithReportStateDeadLetter(deadLetter ReportStateDeadLetterFunc) ServiceOption {
+	return func(s *Service) {
+		s.reportStateDeadLetter = deadLetter
+	}
+}
+
+// WithReportStateChunkSize splits calls to ReportState into multiple HomeGraph requests of at most size devices each.
+// A failure reporting one chunk does not prevent the remaining chunks from being reported;
+// the devices in any failed chunks are included in the returned ReportStateError.
+// By default all device states are reported in a single request, unless they exceed the limit set by WithReportStatePayloadBytes.
+func WithReportStateChunkSize(size int) ServiceOption {
+	return func(s *Service) {
+		s.reportStateChunkSize = size
+	}
+}
+
+// WithDetachedReportState reports state independently of the context supplied to ReportState.
+// The context supplied while handling a fulfillment request is cancelled as soon as Google aborts the request, which
+// abandons any HomeGraph calls made with it; this allows a provider to report state using the request context (i.e.
+// from Execute) and have the report complete after the request has ended. The values of the context (i.e. the
+// request ID) are retained. If timeout is positive each detached report is abandoned after that long.
+func WithDetachedReportState(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.detachReportState = true
+		s.detachedReportTimeout = timeout
+	}
+}
+
+// ReportState is used to report a state change which occurred on a device to the Google HomeGraph.
+// This should be called whenever a local action triggers a change, as well as after receiving an Execute callback.
+// The supplied state argument should have a complete definition of the device state (i.e. do not perform incremental updates).
+// The deviceStates map is indexed by device ID.
+// This library does not attempt to report on state changes automatically as it is possible that the action
+// triggers a change on the device that is not reflected in the initial request. It is best if the underlying
+// service ensures that the Google HomeGraph is kept in sync through an explicit state update after execution.
+// If execute validation is enabled each state is checked against the traits of the device last returned by SYNC,
+// and ErrStateNotSupported is returned if a state is reported for a trait the device does not have.
+// If the HomeGraph rejects the states of any devices a *ReportStateError is returned describing which devices failed.
+// ErrStateSerialization is returned, and nothing is reported, if any of the states cannot be serialized.
+// If ctx is cancelled any HomeGraph calls are abandoned, unless WithDetachedReportState is set.
+// If an outbox is configured (see WithReportStateOutbox) states which fail because the HomeGraph is unavailable are queued
+// to be reported later, although they are still included in the returned *ReportStateError.
+func (s *Service) ReportState(ctx context.Context, agentUserID string, deviceStates map[string]DeviceState) error {
+	if s.detachReportState {
+		ctx = detachContext(ctx)
+		if s.detachedReportTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.detachedReportTimeout)
+			defer cancel()
+		}
+	}
+
+	var devices map[string]*Device
+	if s.registry != nil {
+		devices, _ = s.registry.lookup(agentUserID)
+	}
+
+	s.warnCommandOnlyStates(ctx, agentUserID, devices, deviceStates)
+
+	var deviceIDs []string
+	for deviceID := range deviceStates {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+
+	states := map[string]json.RawMessage{}
+	for _, deviceID := range deviceIDs {
+		deviceState := deviceStates[deviceID]
+		if device, found := devices[deviceID]; found {
+			if err := deviceState.ValidateForDevice(device); err != nil {
+				s.logger.Info("invalid device state",
+					requestIDField(ctx),
+					zap.String("agent_user_id", agentUserID),
+					zap.String("device_id", deviceID),
+					zap.Error(err),
+				)
+				s.publishReportStateSent(agentUserID, deviceIDs, err)
+				return err
+			}
+		}
+
+		state, err := deviceState.MarshalForReportState()
+		if err != nil {
+			s.logger.Info("error serializing device state to json",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.String("device_id", deviceID),
+				zap.Error(err),
+			)
+			err = fmt.Errorf("%w: %s: %v", ErrStateSerialization, deviceID, err)
+			s.publishReportStateSent(agentUserID, deviceIDs, err)
+			return err
+		}
+		states[deviceID] = state
+	}
+
+	failures := map[string]error{}
+	for _, chunkIDs := range chunkDeviceStates(deviceIDs, states, s.reportStateChunkSize, s.reportStatePayloadBytes) {
+		chunk := map[string]json.RawMessage{}
+		for _, deviceID := range chunkIDs {
+			chunk[deviceID] = states[deviceID]
+		}
+
+		report := s.reportStateChunk
+		if s.outbox != nil {
+			report = s.reportStateWithOutbox
+		}
+		if err := report(ctx, agentUserID, chunk); err != nil {
+			for deviceID := range chunk {
+				failures[deviceID] = err
+			}
+			if s.reportStateDeadLetter != nil {
+				s.reportStateDeadLetter(ctx, agentUserID, chunk, err)
+			}
+		}
+	}
+
+	if s.history != nil {
+		reported := map[string]DeviceState{}
+		for deviceID, deviceState := range deviceStates {
+			if _, failed := failures[deviceID]; !failed {
+				reported[deviceID] = deviceState
+			}
+		}
+		s.recordStateHistory(ctx, agentUserID, StateSourceReportState, reported)
+	}
+
+	if len(failures) > 0 {
+		err := &ReportStateError{
+			FailedDevices: failures,
+		}
+		s.publishReportStateSent(agentUserID, deviceIDs, err)
+		return err
+	}
+	s.publishReportStateSent(agentUserID, deviceIDs, nil)
+	return nil
+}
+
+// publishReportStateSent publishes the outcome of a call to ReportState.
+func (s *Service) publishReportStateSent(agentUserID string, deviceIDs []string, err error) {
+	s.events.publish(ReportStateSent{
+		Time:        s.now(),
+		AgentUserID: agentUserID,
+		DeviceIDs:   deviceIDs,
+		Err:         err,
+	})
+}
+
+// reportStateChunk sends the supplied serialized device states to the HomeGraph in a single request.
+func (s *Service) reportStateChunk(ctx context.Context, agentUserID string, states map[string]json.RawMessage) error {
+	jsonState, err := json.Marshal(states)
+	if err != nil {
+		s.logger.Info("error serializing device states to json",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%w: %v", ErrStateSerialization, err)
+	}
+
+	s.debug.recordReportState(agentUserID, jsonState)
+
+	hgRequestID := s.newID()
+	hgReq := &homegraph.ReportStateAndNotificationRequest{
+		AgentUserId: agentUserID,
+		RequestId:   hgRequestID,
+		Payload: &homegraph.StateAndNotificationPayload{
+			Devices: &homegraph.ReportStateAndNotificationDevice{
+				States: jsonState,
+			},
+		},
+	}
+	if s.dryRun {
+		s.logDryRun(ctx, "reportStateAndNotification", agentUserID, hgReq)
+		return nil
+	}
+
+	if err := s.checkHomeGraphCircuit("reportStateAndNotification", hgRequestID); err != nil {
+		return err
+	}
+
+	call := s.deviceService.ReportStateAndNotification(hgReq)
+	s.setHomeGraphHeaders(call.Header())
+	call.Context(ctx)
+	resp, err := call.Do()
+	if err != nil {
+		s.recordHomeGraphCall(ctx, "reportStateAndNotification", agentUserID, 0, err)
+		s.logger.Info("error reporting state",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return &HomeGraphError{
+			Method:    "reportStateAndNotification",
+			RequestID: hgRequestID,
+			Err:       classifyHomeGraphError(err),
+		}
+	}
+	s.recordHomeGraphCall(ctx, "reportStateAndNotification", agentUserID, resp.ServerResponse.HTTPStatusCode, nil)
+	if resp.ServerResponse.HTTPStatusCode != http.StatusOK {
+		s.logger.Info("failed report state",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
+			zap.String("agent_user_id", agentUserID),
+			zap.Int("status_code", resp.ServerResponse.HTTPStatusCode),
+		)
+		return &HomeGraphError{
+			Method:     "reportStateAndNotification",
+			RequestID:  hgRequestID,
+			StatusCode: resp.ServerResponse.HTTPStatusCode,
+			Err:        ErrReportStateFailed,
+		}
+	}
+	return nil
+}
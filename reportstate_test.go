@@ -0,0 +1,184 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceReportState(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	state := NewDeviceState(true).RecordOnOff(true)
+	state.Status = "SUCCESS"
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"test-id": state,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+
+	var body struct {
+		Payload struct {
+			Devices struct {
+				States map[string]interface{} `json:"states"`
+			} `json:"devices"`
+		} `json:"payload"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, map[string]interface{}{
+		"test-id": map[string]interface{}{
+			"online": true,
+			"on":     true,
+		},
+	}, body.Payload.Devices.States)
+}
+
+func TestServiceReportStateValidation(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("outlet-id")},
+	}
+	svc := NewService(logger, &testAuthenticator{}, provider, newTestHomeGraphService(t, thg), WithExecuteValidation(false))
+	_, err := svc.registeredDevices(context.Background(), "agent-id")
+	assert.Nil(t, err)
+
+	err = svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"outlet-id": NewDeviceState(true).RecordBrightness(50),
+	})
+	assert.True(t, errors.Is(err, ErrStateNotSupported))
+	assert.Empty(t, thg.paths)
+
+	err = svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"outlet-id": NewDeviceState(true).RecordOnOff(false),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+}
+
+func TestServiceReportStateChunked(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{
+		failOn: "device-3",
+	}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithReportStateChunkSize(2))
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": NewDeviceState(true).RecordOnOff(true),
+		"device-2": NewDeviceState(true).RecordOnOff(false),
+		"device-3": NewDeviceState(true).RecordOnOff(true),
+		"device-4": NewDeviceState(false),
+		"device-5": NewDeviceState(true).RecordOnOff(true),
+	})
+	assert.Len(t, thg.paths, 3)
+
+	var rsErr *ReportStateError
+	assert.True(t, errors.As(err, &rsErr))
+	assert.Equal(t, []string{"device-3", "device-4"}, rsErr.FailedDeviceIDs())
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestServiceReportStateDeadLetter(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{
+		failOn: "device-2",
+	}
+
+	var deadLetters []map[string]json.RawMessage
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg),
+		WithReportStateChunkSize(1),
+		WithReportStateDeadLetter(func(_ context.Context, agentUserID string, states map[string]json.RawMessage, err error) {
+			assert.Equal(t, "agent-id", agentUserID)
+			assert.True(t, errors.Is(err, ErrHomeGraphRejected))
+			deadLetters = append(deadLetters, states)
+		}),
+	)
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": NewDeviceState(true).RecordOnOff(true),
+		"device-2": NewDeviceState(true).RecordOnOff(false),
+	})
+	assert.True(t, errors.Is(err, ErrHomeGraphRejected))
+
+	var rejected *HomeGraphRejectedError
+	assert.True(t, errors.As(err, &rejected))
+	assert.Equal(t, 500, rejected.Status)
+
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, `{"on":false,"online":true}`, string(deadLetters[0]["device-2"]))
+}
+
+func TestServiceReportStateSerializationError(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	state := NewDeviceState(true)
+	state.State["bad"] = make(chan int)
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": state,
+	})
+	assert.True(t, errors.Is(err, ErrStateSerialization))
+	assert.Empty(t, thg.paths)
+}
+
+func TestChunkDeviceStates(t *testing.T) {
+	states := map[string]json.RawMessage{
+		"device-1": json.RawMessage(`{"online":true,"on":true}`),
+		"device-2": json.RawMessage(`{"online":true,"on":false}`),
+		"device-3": json.RawMessage(`{"online":false}`),
+	}
+	ids := []string{"device-1", "device-2", "device-3"}
+
+	assert.Equal(t, [][]string{ids}, chunkDeviceStates(ids, states, 0, 0))
+	assert.Equal(t, [][]string{{"device-1", "device-2"}, {"device-3"}}, chunkDeviceStates(ids, states, 2, 0))
+	assert.Equal(t, [][]string{{"device-1"}, {"device-2", "device-3"}}, chunkDeviceStates(ids, states, 0, 70))
+	assert.Equal(t, [][]string{{"device-1"}, {"device-2"}, {"device-3"}}, chunkDeviceStates(ids, states, 0, 10))
+}
+
+func TestServiceReportStatePayloadBytes(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithReportStatePayloadBytes(40))
+
+	err := svc.ReportState(context.Background(), "agent-id", map[string]DeviceState{
+		"device-1": NewDeviceState(true).RecordOnOff(true),
+		"device-2": NewDeviceState(true).RecordOnOff(false),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, thg.paths, 2)
+}
+
+func TestServiceReportStateCancelled(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	states := map[string]DeviceState{
+		"test-id": NewDeviceState(true).RecordOnOff(true),
+	}
+	ctx, cancel := context.WithCancel(ContextWithRequestID(context.Background(), "req-1"))
+	cancel()
+
+	// The HomeGraph call is abandoned once the context is cancelled.
+	thg := &testHomeGraph{}
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+	assert.NotNil(t, svc.ReportState(ctx, "agent-id", states))
+	assert.Empty(t, thg.paths)
+
+	// A detached report outlives the context.
+	thg = &testHomeGraph{}
+	svc = NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithDetachedReportState(time.Second))
+	assert.Nil(t, svc.ReportState(ctx, "agent-id", states))
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+}
@@ -0,0 +1,27 @@
+package action
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of the context carrying the supplied request ID.
+// The fulfillment handler does this with the requestId supplied by Google, so the context passed to the Provider
+// carries it; supplying that context to the Service methods (i.e. ReportState) includes the ID in their logs.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// requestIDField returns the logging field for the request ID carried by the context.
+func requestIDField(ctx context.Context) zap.Field {
+	return zap.String("request_id", RequestIDFromContext(ctx))
+}
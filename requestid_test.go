@@ -0,0 +1,41 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestContextRequestID(t *testing.T) {
+	assert.Equal(t, "", RequestIDFromContext(context.Background()))
+
+	ctx := ContextWithRequestID(context.Background(), "ff36a3cc-ec34-11e6-b1a0-64510650abcf")
+	assert.Equal(t, "ff36a3cc-ec34-11e6-b1a0-64510650abcf", RequestIDFromContext(ctx))
+}
+
+func TestServiceReportStateHomeGraphError(t *testing.T) {
+	thg := &testHomeGraph{
+		failOn: "light-1",
+	}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	err := svc.ReportState(ContextWithRequestID(context.Background(), "request-1"), "agent-id", map[string]DeviceState{
+		"light-1": NewDeviceState(true).RecordOnOff(true),
+	})
+
+	var hgErr *HomeGraphError
+	assert.True(t, errors.As(err, &hgErr))
+	assert.Equal(t, "reportStateAndNotification", hgErr.Method)
+	assert.NotEmpty(t, hgErr.RequestID)
+	assert.Contains(t, hgErr.Error(), hgErr.RequestID)
+
+	var body struct {
+		RequestID string `json:"requestId"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(thg.bodies[0]), &body))
+	assert.Equal(t, body.RequestID, hgErr.RequestID)
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrDuplicateDeviceID is returned if the same device ID is added to a response more than once.
+	ErrDuplicateDeviceID = errors.New("duplicate device ID")
+)
+
+// NewSyncResponse creates an empty response to SYNC which devices can be added to.
+func NewSyncResponse() *SyncResponse {
+	return &SyncResponse{}
+}
+
+// Add appends the supplied devices to the response.
+// Google rejects responses containing the same device more than once, so a device with the ID of one already added is skipped
+// and reported by Err.
+func (sr *SyncResponse) Add(devices ...*Device) *SyncResponse {
+	for _, device := range devices {
+		duplicate := false
+		for _, existing := range sr.Devices {
+			if existing.ID == device.ID {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			sr.duplicateIDs = append(sr.duplicateIDs, device.ID)
+			continue
+		}
+		sr.Devices = append(sr.Devices, device)
+	}
+	return sr
+}
+
+// Err returns ErrDuplicateDeviceID if a device was added to the response more than once.
+func (sr *SyncResponse) Err() error {
+	return duplicateIDsErr(sr.duplicateIDs)
+}
+
+// NewQueryResponse creates an empty response to QUERY which device states can be added to.
+func NewQueryResponse() *QueryResponse {
+	return &QueryResponse{
+		States: map[string]DeviceState{},
+	}
+}
+
+// Add records the state of the specified device in the response.
+// If a state has already been added for the device the original state is retained and the duplicate is reported by Err.
+func (qr *QueryResponse) Add(id string, state DeviceState) *QueryResponse {
+	if qr.States == nil {
+		qr.States = map[string]DeviceState{}
+	}
+	if _, found := qr.States[id]; found {
+		qr.duplicateIDs = append(qr.duplicateIDs, id)
+		return qr
+	}
+	qr.States[id] = state
+	return qr
+}
+
+// Err returns ErrDuplicateDeviceID if a device was added to the response more than once.
+func (qr *QueryResponse) Err() error {
+	return duplicateIDsErr(qr.duplicateIDs)
+}
+
+func duplicateIDsErr(ids []string) error {
+	if len(ids) < 1 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrDuplicateDeviceID, strings.Join(ids, ", "))
+}
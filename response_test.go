@@ -0,0 +1,38 @@
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncResponseAdd(t *testing.T) {
+	resp := NewSyncResponse().Add(NewLight("1"), NewOutlet("2"))
+	assert.Nil(t, resp.Err())
+	assert.Len(t, resp.Devices, 2)
+
+	resp.Add(NewSwitch("1"))
+	assert.Len(t, resp.Devices, 2)
+	assert.Equal(t, DeviceTypeLight, resp.Devices[0].Type)
+
+	err := resp.Err()
+	assert.True(t, errors.Is(err, ErrDuplicateDeviceID))
+	assert.Contains(t, err.Error(), "1")
+}
+
+func TestQueryResponseAdd(t *testing.T) {
+	resp := NewQueryResponse().
+		Add("1", NewDeviceState(true).RecordOnOff(true)).
+		Add("2", NewDeviceState(false))
+	assert.Nil(t, resp.Err())
+	assert.Len(t, resp.States, 2)
+
+	resp.Add("2", NewDeviceState(true))
+	assert.False(t, resp.States["2"].Online)
+	assert.True(t, errors.Is(resp.Err(), ErrDuplicateDeviceID))
+
+	empty := &QueryResponse{}
+	empty.Add("1", NewDeviceState(true))
+	assert.Len(t, empty.States, 1)
+}
@@ -0,0 +1,346 @@
+package action
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// CommandRouter is responsible for executing commands against devices.
+// Provider implementations satisfy this interface.
+type CommandRouter interface {
+	Execute(context.Context, *ExecuteRequest) (*ExecuteResponse, error)
+}
+
+// Scene defines a set of device states which are applied together when the scene is activated.
+type Scene struct {
+	ID   string
+	Name string
+
+	// States contains the state each device is put into when the scene is activated, indexed by device ID.
+	States map[string]DeviceState
+	// DeactivateStates contains the state each device is put into when the scene is deactivated, indexed by device ID.
+	// If this is empty the scene is not reversible.
+	DeactivateStates map[string]DeviceState
+}
+
+// SceneController wraps a Provider to add support for scenes.
+// The scenes registered for a user are returned alongside the provider's devices in response to SYNC,
+// and requests to activate a scene are translated into commands for each of the scene's devices which are executed by the router.
+// All other requests are passed through to the wrapped provider unmodified.
+type SceneController struct {
+	provider Provider
+	router   CommandRouter
+
+	mu     sync.RWMutex
+	scenes map[string]map[string]Scene
+}
+
+// NewSceneController creates a new scene controller wrapping the supplied provider.
+// If router is nil the provider is used to execute the scene commands.
+func NewSceneController(provider Provider, router CommandRouter) *SceneController {
+	if router == nil {
+		router = provider
+	}
+	return &SceneController{
+		provider: provider,
+		router:   router,
+		scenes:   map[string]map[string]Scene{},
+	}
+}
+
+// AddScene registers the scene for the specified user, replacing any existing scene with the same ID.
+// Service.RequestSync should be called afterwards so Google learns of the new scene.
+func (sc *SceneController) AddScene(agentUserID string, scene Scene) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.scenes[agentUserID] == nil {
+		sc.scenes[agentUserID] = map[string]Scene{}
+	}
+	sc.scenes[agentUserID][scene.ID] = scene
+}
+
+// RemoveScene removes the scene from the specified user.
+// Service.RequestSync should be called afterwards so Google learns the scene was removed.
+func (sc *SceneController) RemoveScene(agentUserID string, sceneID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	delete(sc.scenes[agentUserID], sceneID)
+}
+
+func (sc *SceneController) lookupScene(agentUserID string, sceneID string) (Scene, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	scene, found := sc.scenes[agentUserID][sceneID]
+	return scene, found
+}
+
+// Sync returns the devices of the wrapped provider along with the scenes registered for the user, sorted by ID.
+func (sc *SceneController) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	resp, err := sc.provider.Sync(ctx, agentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.RLock()
+	var scenes []*Device
+	for _, scene := range sc.scenes[agentUserID] {
+		scenes = append(scenes, NewScene(scene.ID, scene.Name, len(scene.DeactivateStates) > 0))
+	}
+	sc.mu.RUnlock()
+
+	sort.Slice(scenes, func(i, j int) bool {
+		return scenes[i].ID < scenes[j].ID
+	})
+	resp.Devices = append(resp.Devices, scenes...)
+	return resp, nil
+}
+
+// Disconnect is passed through to the wrapped provider.
+func (sc *SceneController) Disconnect(ctx context.Context, agentUserID string) error {
+	return sc.provider.Disconnect(ctx, agentUserID)
+}
+
+// Query reports scenes as online; all other devices are queried using the wrapped provider.
+func (sc *SceneController) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	states := map[string]DeviceState{}
+	pReq := &QueryRequest{
+		AgentID: req.AgentID,
+	}
+	for _, device := range req.Devices {
+		if _, found := sc.lookupScene(req.AgentID, device.ID); found {
+			states[device.ID] = NewDeviceState(true)
+			continue
+		}
+		pReq.Devices = append(pReq.Devices, device)
+	}
+
+	if len(pReq.Devices) < 1 {
+		return &QueryResponse{
+			States: states,
+		}, nil
+	}
+
+	resp, err := sc.provider.Query(ctx, pReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.States == nil {
+		resp.States = map[string]DeviceState{}
+	}
+	for id, state := range states {
+		resp.States[id] = state
+	}
+	return resp, nil
+}
+
+// Execute activates any targeted scenes, and passes the remaining commands through to the wrapped provider.
+// A scene is reported as successful only if every device in the scene was successfully updated.
+func (sc *SceneController) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	resp := &ExecuteResponse{UpdatedState: NewDeviceState(true)}
+
+	pReq := &ExecuteRequest{
+		AgentID: req.AgentID,
+	}
+	for _, commandArg := range req.Commands {
+		passthrough := CommandArg{
+			Commands:      commandArg.Commands,
+			FollowUpToken: commandArg.FollowUpToken,
+		}
+
+		for _, deviceArg := range commandArg.TargetDevices {
+			scene, found := sc.lookupScene(req.AgentID, deviceArg.ID)
+			if !found {
+				passthrough.TargetDevices = append(passthrough.TargetDevices, deviceArg)
+				continue
+			}
+
+			for _, command := range commandArg.Commands {
+				sc.activateScene(ctx, req.AgentID, scene, command, resp)
+			}
+		}
+
+		if len(passthrough.TargetDevices) > 0 {
+			pReq.Commands = append(pReq.Commands, passthrough)
+		}
+	}
+
+	if len(pReq.Commands) < 1 {
+		return resp, nil
+	}
+
+	pResp, err := sc.provider.Execute(ctx, pReq)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeExecuteResponse(pResp, resp)
+	return pResp, nil
+}
+
+// activateScene applies the states of the scene using the router, recording the outcome against the scene ID in resp.
+func (sc *SceneController) activateScene(ctx context.Context, agentUserID string, scene Scene, command Command, resp *ExecuteResponse) {
+	if command.Name != "action.devices.commands.ActivateScene" {
+		resp.AddFailedDevices("functionNotSupported", scene.ID)
+		return
+	}
+
+	states := scene.States
+	if command.Generic != nil {
+		if deactivate, _ := command.Generic.Params["deactivate"].(bool); deactivate {
+			states = scene.DeactivateStates
+			if len(states) < 1 {
+				resp.AddFailedDevices(ErrorCodeActionNotAvailable, scene.ID)
+				return
+			}
+		}
+	}
+
+	var deviceIDs []string
+	for deviceID := range states {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+
+	sceneReq := &ExecuteRequest{
+		AgentID: agentUserID,
+	}
+	for _, deviceID := range deviceIDs {
+		commands := commandsForState(states[deviceID])
+		if len(commands) < 1 {
+			continue
+		}
+		// Any challenge the user completed for the scene applies to each of the devices in the scene.
+		for idx := range commands {
+			commands[idx].Challenge = command.Challenge
+		}
+
+		sceneReq.Commands = append(sceneReq.Commands, CommandArg{
+			TargetDevices: []DeviceArg{{ID: deviceID}},
+			Commands:      commands,
+		})
+	}
+
+	if len(sceneReq.Commands) < 1 {
+		resp.UpdatedDevices = append(resp.UpdatedDevices, scene.ID)
+		return
+	}
+
+	sceneResp, err := sc.router.Execute(ctx, sceneReq)
+	if err != nil {
+		errCode := ErrorCodeUnknownError
+		if intentErr, ok := asIntentError(err); ok {
+			errCode = intentErr.ErrorCode
+		}
+		resp.AddFailedDevices(errCode, scene.ID)
+		return
+	}
+
+	var errCodes []string
+	for errCode, details := range sceneResp.FailedDevices {
+		if len(details.Devices) > 0 {
+			errCodes = append(errCodes, errCode)
+		}
+	}
+	sort.Strings(errCodes)
+
+	var challengeTypes []string
+	for challengeType, ids := range sceneResp.ChallengeNeeded {
+		if len(ids) > 0 {
+			challengeTypes = append(challengeTypes, challengeType)
+		}
+	}
+	sort.Strings(challengeTypes)
+
+	if len(errCodes) > 0 {
+		resp.AddFailedDevices(errCodes[0], scene.ID)
+	} else if len(challengeTypes) > 0 {
+		resp.AddChallengeNeeded(challengeTypes[0], scene.ID)
+	} else if len(sceneResp.OfflineDevices) > 0 {
+		resp.AddFailedDevices(ErrorCodeDeviceOffline, scene.ID)
+	} else {
+		resp.UpdatedDevices = append(resp.UpdatedDevices, scene.ID)
+	}
+}
+
+// mergeExecuteResponse adds the device outcomes recorded in src to dst.
+// The updated state of dst is retained.
+func mergeExecuteResponse(dst *ExecuteResponse, src *ExecuteResponse) {
+	dst.UpdatedDevices = append(dst.UpdatedDevices, src.UpdatedDevices...)
+	dst.OfflineDevices = append(dst.OfflineDevices, src.OfflineDevices...)
+	for token, id := range src.PendingDevices {
+		dst.AddPendingDevice(token, id)
+	}
+	for errCode, details := range src.FailedDevices {
+		dst.AddFailedDevices(errCode, details.Devices...)
+	}
+	for challengeType, ids := range src.ChallengeNeeded {
+		dst.AddChallengeNeeded(challengeType, ids...)
+	}
+}
+
+// commandsForState returns the commands required to put a device into the supplied state.
+// Only the states recorded by the DeviceState helpers are translated; any other state values are ignored.
+// If the state has the device turned off no other commands are generated.
+func commandsForState(state DeviceState) []Command {
+	var commands []Command
+
+	if on, ok := state.State["on"].(bool); ok {
+		commands = append(commands, Command{
+			Name:  "action.devices.commands.OnOff",
+			OnOff: &CommandOnOff{On: on},
+		})
+		if !on {
+			return commands
+		}
+	}
+
+	if brightness, ok := NumberInt(state.State["brightness"]); ok {
+		commands = append(commands, Command{
+			Name:               "action.devices.commands.BrightnessAbsolute",
+			BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: brightness},
+		})
+	}
+
+	if color, ok := state.State["color"].(map[string]interface{}); ok {
+		cmd := &CommandColorAbsolute{}
+		if temperature, ok := NumberInt(color["temperatureK"]); ok {
+			cmd.Color.Temperature = temperature
+		} else if rgb, ok := NumberInt(color["spectrumRgb"]); ok {
+			cmd.Color.RGB = rgb
+		} else if hsv, ok := color["spectrumHsv"].(map[string]interface{}); ok {
+			cmd.Color.HSV.Hue, _ = NumberFloat(hsv["hue"])
+			cmd.Color.HSV.Saturation, _ = NumberFloat(hsv["saturation"])
+			cmd.Color.HSV.Value, _ = NumberFloat(hsv["value"])
+		}
+		commands = append(commands, Command{
+			Name:          "action.devices.commands.ColorAbsolute",
+			ColorAbsolute: cmd,
+		})
+	}
+
+	if input, ok := state.State["input"].(string); ok {
+		commands = append(commands, Command{
+			Name:     "action.devices.commands.SetInput",
+			SetInput: &CommandSetInput{NewInput: input},
+		})
+	}
+
+	if volume, ok := NumberInt(state.State["currentVolume"]); ok {
+		commands = append(commands, Command{
+			Name:      "action.devices.commands.setVolume",
+			SetVolume: &CommandSetVolume{Level: volume},
+		})
+	}
+	if muted, ok := state.State["isMuted"].(bool); ok {
+		commands = append(commands, Command{
+			Name: "action.devices.commands.mute",
+			Mute: &CommandMute{Mute: muted},
+		})
+	}
+
+	return commands
+}
@@ -0,0 +1,256 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestSceneController(tp *testProvider) *SceneController {
+	sc := NewSceneController(tp, nil)
+	sc.AddScene("test-user", Scene{
+		ID:   "scene-1",
+		Name: "Movie Night",
+		States: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true).RecordBrightness(20),
+			"light-2": NewDeviceState(true).RecordOnOff(false).RecordBrightness(80),
+			"tv-1":    NewDeviceState(true).RecordInput("hdmi1").RecordVolume(30, false),
+		},
+		DeactivateStates: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true).RecordBrightness(100),
+		},
+	})
+	sc.AddScene("test-user", Scene{
+		ID:   "scene-2",
+		Name: "Warm",
+		States: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordColorTemperature(2700),
+		},
+	})
+	return sc
+}
+
+func activateSceneRequest(deactivate bool, ids ...string) *ExecuteRequest {
+	req := &ExecuteRequest{
+		AgentID: "test-user",
+		Commands: []CommandArg{
+			{
+				Commands: []Command{
+					{
+						Name: "action.devices.commands.ActivateScene",
+						Generic: &CommandGeneric{
+							Command: "action.devices.commands.ActivateScene",
+							Params: map[string]interface{}{
+								"deactivate": deactivate,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, id := range ids {
+		req.Commands[0].TargetDevices = append(req.Commands[0].TargetDevices, DeviceArg{ID: id})
+	}
+	return req
+}
+
+func TestSceneControllerSync(t *testing.T) {
+	tp := &testProvider{
+		syncResp: []*Device{NewLight("light-1")},
+	}
+	sc := newTestSceneController(tp)
+
+	resp, err := sc.Sync(context.Background(), "test-user")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Devices, 3)
+	assert.Equal(t, "light-1", resp.Devices[0].ID)
+	assert.Equal(t, "scene-1", resp.Devices[1].ID)
+	assert.Equal(t, "action.devices.types.SCENE", resp.Devices[1].Type)
+	assert.Equal(t, true, resp.Devices[1].Attributes["sceneReversible"])
+	assert.Equal(t, "scene-2", resp.Devices[2].ID)
+	assert.Equal(t, false, resp.Devices[2].Attributes["sceneReversible"])
+
+	resp, err = sc.Sync(context.Background(), "other-user")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Devices, 1)
+}
+
+func TestSceneControllerQuery(t *testing.T) {
+	tp := &testProvider{
+		queryResp: map[string]DeviceState{
+			"light-1": NewDeviceState(true).RecordOnOff(true),
+		},
+	}
+	sc := newTestSceneController(tp)
+
+	resp, err := sc.Query(context.Background(), &QueryRequest{
+		AgentID: "test-user",
+		Devices: []DeviceArg{{ID: "light-1"}, {ID: "scene-1"}},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, tp.queryReq.Devices, 1)
+	assert.Equal(t, "light-1", tp.queryReq.Devices[0].ID)
+	assert.Len(t, resp.States, 2)
+	assert.True(t, resp.States["scene-1"].Online)
+
+	tp.queryReq = nil
+	resp, err = sc.Query(context.Background(), &QueryRequest{
+		AgentID: "test-user",
+		Devices: []DeviceArg{{ID: "scene-2"}},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, tp.queryReq)
+	assert.Len(t, resp.States, 1)
+}
+
+func TestSceneControllerActivate(t *testing.T) {
+	tp := &testProvider{
+		executeRespUpdated: []string{"light-1", "light-2", "tv-1"},
+	}
+	sc := newTestSceneController(tp)
+
+	resp, err := sc.Execute(context.Background(), activateSceneRequest(false, "scene-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"scene-1"}, resp.UpdatedDevices)
+
+	assert.Len(t, tp.executeReq.Commands, 3)
+	assert.Equal(t, "light-1", tp.executeReq.Commands[0].TargetDevices[0].ID)
+	assert.Len(t, tp.executeReq.Commands[0].Commands, 2)
+	assert.True(t, tp.executeReq.Commands[0].Commands[0].OnOff.On)
+	assert.Equal(t, 20, tp.executeReq.Commands[0].Commands[1].BrightnessAbsolute.Brightness)
+
+	assert.Equal(t, "light-2", tp.executeReq.Commands[1].TargetDevices[0].ID)
+	assert.Len(t, tp.executeReq.Commands[1].Commands, 1)
+	assert.False(t, tp.executeReq.Commands[1].Commands[0].OnOff.On)
+
+	assert.Equal(t, "tv-1", tp.executeReq.Commands[2].TargetDevices[0].ID)
+	assert.Len(t, tp.executeReq.Commands[2].Commands, 3)
+	assert.Equal(t, "hdmi1", tp.executeReq.Commands[2].Commands[0].SetInput.NewInput)
+	assert.Equal(t, 30, tp.executeReq.Commands[2].Commands[1].SetVolume.Level)
+	assert.False(t, tp.executeReq.Commands[2].Commands[2].Mute.Mute)
+}
+
+func TestSceneControllerDeactivate(t *testing.T) {
+	tp := &testProvider{
+		executeRespUpdated: []string{"light-1"},
+	}
+	sc := newTestSceneController(tp)
+
+	resp, err := sc.Execute(context.Background(), activateSceneRequest(true, "scene-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"scene-1"}, resp.UpdatedDevices)
+	assert.Len(t, tp.executeReq.Commands, 1)
+	assert.Equal(t, 100, tp.executeReq.Commands[0].Commands[1].BrightnessAbsolute.Brightness)
+
+	tp.executeReq = nil
+	resp, err = sc.Execute(context.Background(), activateSceneRequest(true, "scene-2"))
+	assert.Nil(t, err)
+	assert.Nil(t, tp.executeReq)
+	assert.Equal(t, []string{"scene-2"}, resp.FailedDevices["actionNotAvailable"].Devices)
+}
+
+func TestSceneControllerActivatePartialFailure(t *testing.T) {
+	tp := &testProvider{
+		executeRespUpdated:      []string{"light-1"},
+		executeRespFailed:       []string{"light-2"},
+		executeRespFailedReason: "hardError",
+	}
+	sc := newTestSceneController(tp)
+
+	resp, err := sc.Execute(context.Background(), activateSceneRequest(false, "scene-1"))
+	assert.Nil(t, err)
+	assert.Empty(t, resp.UpdatedDevices)
+	assert.Equal(t, []string{"scene-1"}, resp.FailedDevices["hardError"].Devices)
+
+	tp = &testProvider{
+		executeRespOffline: []string{"light-1"},
+	}
+	sc = newTestSceneController(tp)
+
+	resp, err = sc.Execute(context.Background(), activateSceneRequest(false, "scene-2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"scene-2"}, resp.FailedDevices[ErrorCodeDeviceOffline].Devices)
+	assert.Equal(t, 2700, tp.executeReq.Commands[0].Commands[0].ColorAbsolute.Color.Temperature)
+
+	tp = &testProvider{
+		executeErr: NewTransientError(assert.AnError),
+	}
+	sc = newTestSceneController(tp)
+
+	resp, err = sc.Execute(context.Background(), activateSceneRequest(false, "scene-2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"scene-2"}, resp.FailedDevices[ErrorCodeTransientError].Devices)
+}
+
+func TestSceneControllerExecuteMixed(t *testing.T) {
+	tp := &testProvider{
+		executeRespUpdated: []string{"light-1"},
+	}
+	sc := newTestSceneController(tp)
+
+	req := activateSceneRequest(false, "scene-2")
+	req.Commands = append(req.Commands, CommandArg{
+		TargetDevices: []DeviceArg{{ID: "light-1"}},
+		Commands: []Command{
+			{
+				Name:  "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{On: true},
+			},
+		},
+	})
+
+	resp, err := sc.Execute(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Len(t, tp.executeReq.Commands, 1)
+	assert.Equal(t, "light-1", tp.executeReq.Commands[0].TargetDevices[0].ID)
+	assert.Equal(t, []string{"light-1", "scene-2"}, resp.UpdatedDevices)
+}
+
+func TestSceneControllerFulfillmentHandler(t *testing.T) {
+	tp := &testProvider{
+		executeRespUpdated: []string{"light-1", "light-2", "tv-1"},
+	}
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "test-user",
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, newTestSceneController(tp), nil)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "scene-request",
+		"inputs": [
+		  {
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+			  "commands": [
+				{
+				  "devices": [{"id": "scene-1"}],
+				  "execution": [
+					{
+					  "command": "action.devices.commands.ActivateScene",
+					  "params": {"deactivate": false}
+					}
+				  ]
+				}
+			  ]
+			}
+		  }
+		]
+	  }`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"scene-request","payload":{"commands":[{"ids":["scene-1"],"status":"SUCCESS","states":{"online":true}}]}}
+`, rr.Body.String())
+	assert.Len(t, tp.executeReq.Commands, 3)
+}
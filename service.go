@@ -2,11 +2,10 @@ package action
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/api/homegraph/v1"
 )
@@ -23,6 +22,9 @@ var (
 	// ErrReportStateFailed is returned if the request to HomeGraph to update a device failed.
 	// The log will contain more information about what occurred.
 	ErrReportStateFailed = errors.New("report state failed")
+	// ErrDeleteAgentUserFailed is returned if the request to HomeGraph to delete a user failed.
+	// The log will contain more information about what occurred.
+	ErrDeleteAgentUserFailed = errors.New("delete agent user failed")
 )
 
 // DeviceArg contains the common fields used when executing requests against a device.
@@ -36,14 +38,45 @@ type DeviceArg struct {
 
 // CommandArg contains the fields used to execute a change on a set of devices.
 // Only one of the various pointers in Command should be set per command.
+// If Google requested a follow-up response the FollowUpToken will be set; see Service.SendFollowUp.
 type CommandArg struct {
 	TargetDevices []DeviceArg
 	Commands      []Command
+	FollowUpToken string
+}
+
+// DeviceCommand pairs a single command with a single device it targets, including the device's customData.
+type DeviceCommand struct {
+	Device  DeviceArg
+	Command Command
+}
+
+// DeviceCommands expands the CommandArg into each command to apply to each device.
+// The commands for the first device are returned, in order, before those of the second device and so on.
+func (ca CommandArg) DeviceCommands() []DeviceCommand {
+	var deviceCommands []DeviceCommand
+	for _, device := range ca.TargetDevices {
+		for _, command := range ca.Commands {
+			deviceCommands = append(deviceCommands, DeviceCommand{
+				Device:  device,
+				Command: command,
+			})
+		}
+	}
+	return deviceCommands
 }
 
 // SyncResponse contains the set of devices to supply to the Google Smart Home Action when setting up.
+// ErrorCode and DebugString may optionally be set to indicate the entire request failed; see
+// https://developers.google.com/assistant/smarthome/reference/errors-exceptions for the list of error codes.
+// DebugString is only shown to developers (i.e. in the Google Smart Home Test Suite), never to users.
 type SyncResponse struct {
 	Devices []*Device
+
+	ErrorCode   string
+	DebugString string
+
+	duplicateIDs []string
 }
 
 // QueryRequest includes what is being asked for by the Google Smart Home Action when querying.
@@ -54,8 +87,14 @@ type QueryRequest struct {
 
 // QueryResponse includes what should be returned in response to the query to the Google Home Smart Action.
 // The States map should have the same IDs supplied in the request.
+// ErrorCode and DebugString may optionally be set to indicate the entire request failed.
 type QueryResponse struct {
 	States map[string]DeviceState
+
+	ErrorCode   string
+	DebugString string
+
+	duplicateIDs []string
 }
 
 // ExecuteRequest includes what is being asked for by the Google Assistant when making a change.
@@ -65,6 +104,15 @@ type ExecuteRequest struct {
 	AgentID  string
 }
 
+// DeviceCommands expands each of the CommandArgs in the request into each command to apply to each device, in request order.
+func (er *ExecuteRequest) DeviceCommands() []DeviceCommand {
+	var deviceCommands []DeviceCommand
+	for _, commandArg := range er.Commands {
+		deviceCommands = append(deviceCommands, commandArg.DeviceCommands()...)
+	}
+	return deviceCommands
+}
+
 // ExecuteResponse includes the results of an Execute command to be sent back to the Google home graph after an execute.
 // Between the UpdatedDevices and FailedDevices maps all device IDs in the Execute request should be accounted for.
 type ExecuteResponse struct {
@@ -74,16 +122,59 @@ type ExecuteResponse struct {
 	FailedDevices  map[string]struct {
 		Devices []string
 	}
+
+	// PendingDevices contains the devices which are still executing the command, indexed by completion token.
+	// See AddPendingDevice
+	PendingDevices map[string]string
+
+	// ChallengeNeeded contains the devices which require secondary user verification, indexed by challenge type.
+	// See AddChallengeNeeded
+	ChallengeNeeded map[string][]string
+
+	// ErrorCode and DebugString may optionally be set to indicate the entire request failed.
+	ErrorCode   string
+	DebugString string
+}
+
+// AddFailedDevices records that the command could not be executed against the supplied devices for the specified reason.
+// See https://developers.google.com/assistant/smarthome/reference/errors-exceptions for the list of error codes.
+func (er *ExecuteResponse) AddFailedDevices(errCode string, ids ...string) {
+	if er.FailedDevices == nil {
+		er.FailedDevices = map[string]struct {
+			Devices []string
+		}{}
+	}
+	details := er.FailedDevices[errCode]
+	details.Devices = append(details.Devices, ids...)
+	er.FailedDevices[errCode] = details
 }
 
 // AccessTokenValidator allows for the auth token supplied by Google to be validated.
 type AccessTokenValidator interface {
 	// Validate performs the actual token validation. Returning an error will force validation to fail.
 	// The user ID that corresponds to the token should be returned on success.
+	// Returning ErrAuthExpired (or an error wrapping it) causes Google to refresh the token, while returning
+	// ErrRelinkRequired causes Google to ask the user to link their account again. Any other error results in a 401.
+	// Validators whose tokens carry scopes or claims may implement PrincipalValidator instead.
 	Validate(context.Context, string) (string, error)
 }
 
+// TokenRevoker may optionally be implemented by an AccessTokenValidator which caches tokens.
+// When a user unlinks their account any tokens cached for the user should no longer be considered valid.
+type TokenRevoker interface {
+	// RevokeTokens removes any cached tokens which belong to the specified user.
+	RevokeTokens(context.Context, string) error
+}
+
+// UnlinkListener is notified once a user has unlinked their account from the Google Smart Home Action.
+type UnlinkListener interface {
+	// Unlinked is called with the ID of the user who unlinked their account after the provider has been informed.
+	Unlinked(context.Context, string)
+}
+
 // Provider exposes methods that can be invoked by the Google Smart Home Action intents
+// The context supplied to each method is cancelled if Google aborts the fulfillment request, so any work started for
+// the request (including HomeGraph calls made with the context) should be abandoned.
 type Provider interface {
 	Sync(context.Context, string) (*SyncResponse, error)
 	Disconnect(context.Context, string) error
@@ -98,17 +189,102 @@ type Provider interface {
 type Service struct {
 	logger *zap.Logger
 
-	atValidator AccessTokenValidator
+	atValidator    AccessTokenValidator
+	tokenExtractor TokenExtractor
+	cors           *CORSConfig
+
+	contentTypePolicy   ContentTypePolicy
+	unknownIntentPolicy UnknownIntentPolicy
+
+	maxRequestBodyBytes int64
+	requestReadTimeout  time.Duration
+	maxRequestDevices   int
+
+	debug  *debugRecorder
+	events *eventBus
+
+	clock       Clock
+	idGenerator IDGenerator
 
 	provider Provider
 
-	deviceService *homegraph.DevicesService
+	unlinkListener          UnlinkListener
+	deleteAgentUserOnUnlink bool
+
+	registry           *deviceRegistry
+	clampExecuteValues bool
+	authorizer         Authorizer
+	shadowMode         bool
+
+	correlateExecute         bool
+	correlateExecuteAutoFill bool
+
+	checkQueryCompleteness    bool
+	queryCompletenessAutoFill bool
+
+	normalizeSync bool
+
+	customDataCodec CustomDataCodec
+
+	validateErrorCodes bool
+	strictDecoding     bool
+	messageCatalog     *MessageCatalog
+	messageLanguage    string
+
+	maxSyncDevices      int
+	maxSyncPayloadBytes int
+	onSyncTruncated     SyncTruncatedFunc
+
+	reportStateChunkSize    int
+	reportStatePayloadBytes int
+	reportStateDeadLetter   ReportStateDeadLetterFunc
+	detachReportState       bool
+	detachedReportTimeout   time.Duration
+	outbox                  OutboxStore
+	outboxLocks             userLocks
+
+	pending     *pendingExecutions
+	idempotency *idempotencyCache
+	liveness    *livenessTracker
+	history     StateHistoryStore
+	devices     *DeviceManager
+
+	intentHandlers map[string]intentHandlerFunc
+
+	homeGraphClient       *http.Client
+	homeGraphUserAgent    string
+	homeGraphQuotaProject string
+	dryRun                bool
+	homeGraphBreaker      *CircuitBreaker
+	homeGraphQueue        *homeGraphQueue
+
+	deviceService    *homegraph.DevicesService
+	agentUserService *homegraph.AgentUsersService
+}
+
+// ServiceOption allows for optional behaviour of the Service to be configured.
+type ServiceOption func(*Service)
+
+// WithUnlinkListener registers a listener which is notified whenever a user unlinks their account.
+func WithUnlinkListener(listener UnlinkListener) ServiceOption {
+	return func(s *Service) {
+		s.unlinkListener = listener
+	}
+}
+
+// WithDeleteAgentUserOnUnlink causes the user to be removed from the Google HomeGraph when they unlink their account.
+// This is not required by Google, but it ensures no stale device information is retained once a user has unlinked.
+func WithDeleteAgentUserOnUnlink() ServiceOption {
+	return func(s *Service) {
+		s.deleteAgentUserOnUnlink = true
+	}
 }
 
 // NewService creates a new service to handle Google Action operations.
 // It is required that an access token validator be specified to properly process requests.
 // This access token validator should be pointed to the same data source as the OAuth2 server configured in the Google Smart Home Actions portal in the OAuth2 account linking section.
-func NewService(logger *zap.Logger, atValidator AccessTokenValidator, provider Provider, hgService *homegraph.Service) *Service {
+// Any supplied options are applied in order after the defaults are set.
+func NewService(logger *zap.Logger, atValidator AccessTokenValidator, provider Provider, hgService *homegraph.Service, opts ...ServiceOption) *Service {
 	if atValidator == nil {
 		logger.Fatal("empty access token validator not allowed")
 	}
@@ -116,12 +292,35 @@ func NewService(logger *zap.Logger, atValidator AccessTokenValidator, provider P
 		logger.Fatal("empty provider not allowed")
 	}
 
-	return &Service{
-		logger:        logger,
-		atValidator:   atValidator,
-		provider:      provider,
-		deviceService: homegraph.NewDevicesService(hgService),
+	s := &Service{
+		logger:                  logger,
+		atValidator:             atValidator,
+		tokenExtractor:          BearerTokenExtractor(),
+		events:                  newEventBus(),
+		clock:                   systemClock(),
+		idGenerator:             uuidGenerator(),
+		pending:                 newPendingExecutions(),
+		provider:                provider,
+		reportStatePayloadBytes: DefaultReportStatePayloadBytes,
 	}
+	s.intentHandlers = s.defaultIntentHandlers()
+	s.devices = newDeviceManager(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.configureHomeGraph(hgService)
+
+	// The subsystems created by options share the clock, regardless of the order the options were supplied in.
+	if s.debug != nil {
+		s.debug.clock = s.clock
+	}
+	if s.idempotency != nil {
+		s.idempotency.clock = s.clock
+	}
+	if s.homeGraphBreaker != nil {
+		s.homeGraphBreaker.clock = s.clock
+	}
+	return s
 }
 
 // RequestSync is used to trigger a Google HomeGraph sync operation.
@@ -129,68 +328,181 @@ func NewService(logger *zap.Logger, atValidator AccessTokenValidator, provider P
 // This will request a sync occur synchronously, so make sure that the Sync method is not
 // blocked on anything this method may be doing.
 func (s *Service) RequestSync(ctx context.Context, agentUserID string) error {
-	call := s.deviceService.RequestSync(&homegraph.RequestSyncDevicesRequest{
-		AgentUserId: agentUserID,
+	err := s.requestSync(ctx, agentUserID)
+	s.events.publish(RequestSyncTriggered{
+		Time:        s.now(),
+		AgentUserID: agentUserID,
+		Err:         err,
 	})
+	return err
+}
+
+// requestSync performs the HomeGraph call on behalf of RequestSync.
+func (s *Service) requestSync(ctx context.Context, agentUserID string) error {
+	hgReq := &homegraph.RequestSyncDevicesRequest{
+		AgentUserId: agentUserID,
+	}
+	if s.dryRun {
+		s.logDryRun(ctx, "requestSync", agentUserID, hgReq)
+		return nil
+	}
+
+	if err := s.checkHomeGraphCircuit("requestSync", ""); err != nil {
+		return err
+	}
+
+	call := s.deviceService.RequestSync(hgReq)
+	s.setHomeGraphHeaders(call.Header())
 	call.Context(ctx)
 	resp, err := call.Do()
 	if err != nil {
+		s.recordHomeGraphCall(ctx, "requestSync", agentUserID, 0, err)
 		s.logger.Info("error requesting sync",
+			requestIDField(ctx),
 			zap.String("agent_user_id", agentUserID),
 			zap.Error(err),
 		)
-		return err
+		return &HomeGraphError{
+			Method: "requestSync",
+			Err:    classifyHomeGraphError(err),
+		}
 	}
+	s.recordHomeGraphCall(ctx, "requestSync", agentUserID, resp.ServerResponse.HTTPStatusCode, nil)
 	if resp.ServerResponse.HTTPStatusCode != http.StatusOK {
 		s.logger.Info("failed request sync",
+			requestIDField(ctx),
 			zap.String("agent_user_id", agentUserID),
 			zap.Int("status_code", resp.ServerResponse.HTTPStatusCode),
 		)
-		return ErrSyncFailed
+		return &HomeGraphError{
+			Method:     "requestSync",
+			StatusCode: resp.ServerResponse.HTTPStatusCode,
+			Err:        ErrSyncFailed,
+		}
 	}
 	return nil
 }
 
-// ReportState is used to report a state change which occurred on a device to the Google HomeGraph.
-// This should be called whenever a local action triggers a change, as well as after receiving an Execute callback.
-// The supplied state argument should have a complete definition of the device state (i.e. do not perform incremental updates).
-// The deviceStates map is indexed by device ID.
-// This library does not attempt to report on state changes automatically as it is possible that the action
-// triggers a change on the device that is not reflected in the initial request. It is best if the underlying
-// service ensures that the Google HomeGraph is kept in sync through an explicit state update after execution.
-func (s *Service) ReportState(ctx context.Context, agentUserID string, deviceStates map[string]DeviceState) error {
-	jsonState, err := json.Marshal(deviceStates)
-	if err != nil {
-		s.logger.Info("error serializing device states to json",
-			zap.String("agent_user_id", agentUserID),
-			zap.Error(err),
-		)
+// DeleteAgentUser is used to remove the specified user, and all of their devices, from the Google HomeGraph.
+// After this call the user will need to link their account again before any devices are visible to Google.
+func (s *Service) DeleteAgentUser(ctx context.Context, agentUserID string) error {
+	hgRequestID := s.newID()
+	if s.dryRun {
+		s.logDryRun(ctx, "deleteAgentUser", agentUserID, map[string]string{
+			"agentUserId": agentUserID,
+			"requestId":   hgRequestID,
+		})
+		return nil
 	}
 
-	call := s.deviceService.ReportStateAndNotification(&homegraph.ReportStateAndNotificationRequest{
-		AgentUserId: agentUserID,
-		RequestId:   uuid.New().String(),
-		Payload: &homegraph.StateAndNotificationPayload{
-			Devices: &homegraph.ReportStateAndNotificationDevice{
-				States: jsonState,
-			},
-		},
-	})
+	if err := s.checkHomeGraphCircuit("deleteAgentUser", hgRequestID); err != nil {
+		return err
+	}
+
+	call := s.agentUserService.Delete("agentUsers/" + agentUserID)
+	call.RequestId(hgRequestID)
+	s.setHomeGraphHeaders(call.Header())
 	call.Context(ctx)
 	resp, err := call.Do()
 	if err != nil {
-		s.logger.Info("error reporting state",
+		s.recordHomeGraphCall(ctx, "deleteAgentUser", agentUserID, 0, err)
+		s.logger.Info("error deleting agent user",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
 			zap.String("agent_user_id", agentUserID),
 			zap.Error(err),
 		)
-		return err
+		return &HomeGraphError{
+			Method:    "deleteAgentUser",
+			RequestID: hgRequestID,
+			Err:       classifyHomeGraphError(err),
+		}
 	}
+	s.recordHomeGraphCall(ctx, "deleteAgentUser", agentUserID, resp.ServerResponse.HTTPStatusCode, nil)
 	if resp.ServerResponse.HTTPStatusCode != http.StatusOK {
-		s.logger.Info("failed report state",
+		s.logger.Info("failed delete agent user",
+			requestIDField(ctx),
+			zap.String("homegraph_request_id", hgRequestID),
 			zap.String("agent_user_id", agentUserID),
 			zap.Int("status_code", resp.ServerResponse.HTTPStatusCode),
 		)
-		return ErrSyncFailed
+		return &HomeGraphError{
+			Method:     "deleteAgentUser",
+			RequestID:  hgRequestID,
+			StatusCode: resp.ServerResponse.HTTPStatusCode,
+			Err:        ErrDeleteAgentUserFailed,
+		}
 	}
 	return nil
 }
+
+// unlink handles all of the steps required when a user unlinks their account.
+// The provider is always informed; any cached tokens are revoked if the validator supports it;
+// the user is optionally deleted from the HomeGraph; and finally the unlink listener is notified.
+// Failures are logged but do not prevent the subsequent steps from running.
+func (s *Service) unlink(ctx context.Context, agentUserID string) {
+	if err := s.provider.Disconnect(ctx, agentUserID); err != nil {
+		s.logger.Info("disconnect error",
+			requestIDField(ctx),
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+	}
+
+	if revoker, ok := s.atValidator.(TokenRevoker); ok {
+		if err := revoker.RevokeTokens(ctx, agentUserID); err != nil {
+			s.logger.Info("error revoking tokens",
+				requestIDField(ctx),
+				zap.String("agent_user_id", agentUserID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if s.deleteAgentUserOnUnlink {
+		// Errors are logged by DeleteAgentUser
+		s.DeleteAgentUser(ctx, agentUserID)
+	}
+
+	if s.unlinkListener != nil {
+		s.unlinkListener.Unlinked(ctx, agentUserID)
+	}
+}
+
+// UpdateAvailableInputs replaces the set of inputs the supplied device exposes through the InputSelector trait.
+// If the set of inputs differs from what the device previously exposed a SYNC operation is requested
+// so Google picks up the new inputs; if nothing changed this is a no-op.
+// The caller is responsible for ensuring the updated device is returned by subsequent calls to Provider.Sync.
+func (s *Service) UpdateAvailableInputs(ctx context.Context, agentUserID string, device *Device, availableInputs []DeviceInput) error {
+	if equalDeviceInputs(device.AvailableInputs(), availableInputs) {
+		return nil
+	}
+
+	device.SetAvailableInputs(availableInputs)
+	return s.RequestSync(ctx, agentUserID)
+}
+
+// equalDeviceInputs returns whether the inputs have the same keys and names, in the same order.
+// A nil set of inputs (or synonyms) is considered equal to an empty one.
+func equalDeviceInputs(a []DeviceInput, b []DeviceInput) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx].Key != b[idx].Key || len(a[idx].Names) != len(b[idx].Names) {
+			return false
+		}
+		for nameIdx, name := range a[idx].Names {
+			other := b[idx].Names[nameIdx]
+			if name.LanguageCode != other.LanguageCode || len(name.Synonyms) != len(other.Synonyms) {
+				return false
+			}
+			for synonymIdx, synonym := range name.Synonyms {
+				if synonym != other.Synonyms[synonymIdx] {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
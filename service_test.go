@@ -0,0 +1,149 @@
+package action
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/api/homegraph/v1"
+	"google.golang.org/api/option"
+)
+
+type testHomeGraph struct {
+	paths   []string
+	bodies  []string
+	headers []http.Header
+
+	// failOn causes any request whose body contains the value to fail.
+	failOn string
+	// responses contains the body to return for requests to each path, if not empty.
+	responses map[string]string
+}
+
+func (thg *testHomeGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	thg.paths = append(thg.paths, r.URL.Path)
+	body, _ := ioutil.ReadAll(r.Body)
+	thg.bodies = append(thg.bodies, string(body))
+	thg.headers = append(thg.headers, r.Header)
+
+	if len(thg.failOn) > 0 && strings.Contains(string(body), thg.failOn) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp, found := thg.responses[r.URL.Path]; found {
+		w.Write([]byte(resp))
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func newTestHomeGraphService(t *testing.T, thg *testHomeGraph) *homegraph.Service {
+	server := httptest.NewServer(thg)
+	t.Cleanup(server.Close)
+
+	hgService, err := homegraph.NewService(context.Background(),
+		option.WithEndpoint(server.URL),
+		option.WithHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hgService
+}
+
+func TestServiceUpdateAvailableInputs(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	thg := &testHomeGraph{}
+
+	svc := NewService(logger, &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	inputs := []DeviceInput{
+		{
+			Key: "input-1",
+			Names: []DeviceInputName{
+				{
+					LanguageCode: "en-US",
+					Synonyms:     []string{"First Input"},
+				},
+			},
+		},
+	}
+	d := NewSimpleAVReceiver("test-id", inputs, 100, true, false)
+
+	err := svc.UpdateAvailableInputs(context.Background(), "agent-id", d, inputs)
+	assert.Nil(t, err)
+	assert.Empty(t, thg.paths)
+
+	updatedInputs := append(inputs, DeviceInput{
+		Key: "input-2",
+		Names: []DeviceInputName{
+			{
+				LanguageCode: "en-US",
+				Synonyms:     []string{"Second Input"},
+			},
+		},
+	})
+	err = svc.UpdateAvailableInputs(context.Background(), "agent-id", d, updatedInputs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v1/devices:requestSync"}, thg.paths)
+	assert.Equal(t, updatedInputs, d.AvailableInputs())
+}
+
+func TestServiceUpdateAvailableInputsEmpty(t *testing.T) {
+	thg := &testHomeGraph{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg))
+
+	d := NewSimpleAVReceiver("test-id", nil, 100, true, false)
+	assert.Nil(t, svc.UpdateAvailableInputs(context.Background(), "agent-id", d, []DeviceInput{}))
+	assert.Empty(t, thg.paths)
+
+	inputs := []DeviceInput{{Key: "input-1", Names: []DeviceInputName{{LanguageCode: "en-US"}}}}
+	d = NewSimpleAVReceiver("test-id", inputs, 100, true, false)
+	assert.Nil(t, svc.UpdateAvailableInputs(context.Background(), "agent-id", d, []DeviceInput{{Key: "input-1", Names: []DeviceInputName{{LanguageCode: "en-US", Synonyms: []string{}}}}}))
+	assert.Empty(t, thg.paths)
+
+	assert.Nil(t, svc.UpdateAvailableInputs(context.Background(), "agent-id", d, []DeviceInput{{Key: "input-1", Names: []DeviceInputName{{LanguageCode: "en-US", Synonyms: []string{"First Input"}}}}}))
+	assert.Equal(t, []string{"/v1/devices:requestSync"}, thg.paths)
+}
+
+func TestExecuteRequestDeviceCommands(t *testing.T) {
+	req := &ExecuteRequest{
+		Commands: []CommandArg{
+			{
+				TargetDevices: []DeviceArg{
+					{ID: "1", CustomData: map[string]interface{}{"port": 1}},
+					{ID: "2", CustomData: map[string]interface{}{"port": 2}},
+				},
+				Commands: []Command{
+					{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: true}},
+					{Name: "action.devices.commands.BrightnessAbsolute", BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 50}},
+				},
+			},
+			{
+				TargetDevices: []DeviceArg{{ID: "3"}},
+				Commands: []Command{
+					{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: false}},
+				},
+			},
+		},
+	}
+
+	deviceCommands := req.DeviceCommands()
+	assert.Len(t, deviceCommands, 5)
+	assert.Equal(t, "1", deviceCommands[0].Device.ID)
+	assert.Equal(t, 1, deviceCommands[0].Device.CustomData["port"])
+	assert.Equal(t, "action.devices.commands.OnOff", deviceCommands[0].Command.Name)
+	assert.Equal(t, "1", deviceCommands[1].Device.ID)
+	assert.Equal(t, 50, deviceCommands[1].Command.BrightnessAbsolute.Brightness)
+	assert.Equal(t, "2", deviceCommands[2].Device.ID)
+	assert.Equal(t, 2, deviceCommands[3].Device.CustomData["port"])
+	assert.Equal(t, "3", deviceCommands[4].Device.ID)
+	assert.False(t, deviceCommands[4].Command.OnOff.On)
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// WithShadowMode causes EXECUTE commands to be parsed, validated and logged without being passed to the provider.
+// Google is answered optimistically: every device which passed validation is reported as updated, with the state
+// implied by any absolute commands (i.e. OnOff or BrightnessAbsolute). This is intended for staging a new deployment
+// against mirrored live traffic. SYNC and QUERY are still answered by the provider.
+func WithShadowMode() ServiceOption {
+	return func(s *Service) {
+		s.shadowMode = true
+	}
+}
+
+// shadowExecute logs the commands in the request and builds the optimistic response to them.
+func (s *Service) shadowExecute(ctx context.Context, req *ExecuteRequest) *ExecuteResponse {
+	resp := &ExecuteResponse{
+		UpdatedState: NewDeviceState(true),
+	}
+
+	seen := map[string]bool{}
+	for _, deviceCommand := range req.DeviceCommands() {
+		s.logger.Info("shadow mode execute",
+			requestIDField(ctx),
+			zap.String("agent_user_id", req.AgentID),
+			zap.String("device_id", deviceCommand.Device.ID),
+			zap.String("command", deviceCommand.Command.Name),
+		)
+
+		recordCommandState(resp.UpdatedState, deviceCommand.Command)
+		if !seen[deviceCommand.Device.ID] {
+			seen[deviceCommand.Device.ID] = true
+			resp.UpdatedDevices = append(resp.UpdatedDevices, deviceCommand.Device.ID)
+		}
+	}
+	return resp
+}
+
+// recordCommandState records the state the device will be in once the command is applied, if it can be determined
+// from the command alone. Relative commands (i.e. BrightnessRelative) are ignored.
+func recordCommandState(ds DeviceState, c Command) {
+	switch {
+	case c.OnOff != nil:
+		ds.RecordOnOff(c.OnOff.On)
+	case c.BrightnessAbsolute != nil:
+		ds.RecordBrightness(c.BrightnessAbsolute.Brightness)
+	case c.ColorAbsolute != nil:
+		color := c.ColorAbsolute.Color
+		if color.Temperature != 0 {
+			ds.RecordColorTemperature(color.Temperature)
+		} else if color.HSV.Hue != 0 || color.HSV.Saturation != 0 || color.HSV.Value != 0 {
+			ds.RecordColorHSV(color.HSV.Hue, color.HSV.Saturation, color.HSV.Value)
+		} else {
+			ds.RecordColorRGB(color.RGB)
+		}
+	case c.Mute != nil:
+		ds.State["isMuted"] = c.Mute.Mute
+	case c.SetVolume != nil:
+		ds.State["currentVolume"] = c.SetVolume.Level
+	case c.SetInput != nil:
+		ds.RecordInput(c.SetInput.NewInput)
+	}
+}
@@ -0,0 +1,46 @@
+package action
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGoogleFulfillmentHandlerExecuteShadowMode(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	provider := &testProvider{}
+	svc := NewService(zap.New(core), &testAuthenticator{validToken: "asdf", userID: "agent-id"}, provider, nil,
+		WithShadowMode(),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBufferString(`{
+		"requestId": "1",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {
+				"commands": [{
+					"devices": [{"id": "light-1"}, {"id": "light-2"}],
+					"execution": [
+						{"command": "action.devices.commands.OnOff", "params": {"on": true}},
+						{"command": "action.devices.commands.BrightnessAbsolute", "params": {"brightness": 60}}
+					]
+				}]
+			}
+		}]
+	}`))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, provider.executeReq)
+	assert.Equal(t, `{"requestId":"1","payload":{"commands":[{"ids":["light-1","light-2"],"status":"SUCCESS","states":{"brightness":60,"on":true,"online":true}}]}}
+`, rr.Body.String())
+	assert.Len(t, logs.FilterMessage("shadow mode execute").All(), 4)
+}
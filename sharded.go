@@ -0,0 +1,207 @@
+package action
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ShardFunc returns the name of the shard which serves the specified device of the user.
+type ShardFunc func(agentUserID string, device DeviceArg) string
+
+// ShardedProvider is a Provider whose devices are spread across several backend shards, each served by a child Provider.
+// The devices in QUERY and EXECUTE requests are partitioned by the shard function, the shards are called concurrently,
+// and their results are merged into a single response. A shard which fails only fails the devices it serves; devices
+// mapped to an unknown shard are reported as deviceNotFound. SYNC and DISCONNECT are sent to every shard.
+type ShardedProvider struct {
+	shards  map[string]Provider
+	shardFn ShardFunc
+}
+
+// NewShardedProvider creates a provider which routes each device to the shard named by shardFn.
+func NewShardedProvider(shards map[string]Provider, shardFn ShardFunc) *ShardedProvider {
+	return &ShardedProvider{
+		shards:  shards,
+		shardFn: shardFn,
+	}
+}
+
+// shardNames returns the names of the shards in a stable order, so merged responses are deterministic.
+func (sp *ShardedProvider) shardNames() []string {
+	var names []string
+	for name := range sp.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sync returns the devices of every shard. If any shard fails the request fails, as an incomplete device list
+// would cause Google to remove the devices of that shard.
+func (sp *ShardedProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	names := sp.shardNames()
+	resps := make([]*SyncResponse, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for idx, name := range names {
+		wg.Add(1)
+		go func(idx int, provider Provider) {
+			defer wg.Done()
+			resps[idx], errs[idx] = provider.Sync(ctx, agentUserID)
+		}(idx, sp.shards[name])
+	}
+	wg.Wait()
+
+	resp := NewSyncResponse()
+	for idx := range names {
+		if errs[idx] != nil {
+			return nil, errs[idx]
+		}
+		if len(resps[idx].ErrorCode) > 0 {
+			return resps[idx], nil
+		}
+		resp.Add(resps[idx].Devices...)
+	}
+	return resp, nil
+}
+
+// Disconnect informs every shard that the user has unlinked their account.
+// Every shard is called even if one fails; the first error is returned.
+func (sp *ShardedProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	names := sp.shardNames()
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for idx, name := range names {
+		wg.Add(1)
+		go func(idx int, provider Provider) {
+			defer wg.Done()
+			errs[idx] = provider.Disconnect(ctx, agentUserID)
+		}(idx, sp.shards[name])
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query partitions the requested devices by shard and merges the states returned by each shard.
+func (sp *ShardedProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp := NewQueryResponse()
+
+	partitions := map[string]*QueryRequest{}
+	for _, device := range req.Devices {
+		name := sp.shardFn(req.AgentID, device)
+		if _, found := sp.shards[name]; !found {
+			resp.Add(device.ID, DeviceState{Status: "ERROR", ErrorCode: ErrorCodeDeviceNotFound})
+			continue
+		}
+		if partitions[name] == nil {
+			partitions[name] = &QueryRequest{AgentID: req.AgentID}
+		}
+		partitions[name].Devices = append(partitions[name].Devices, device)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, shardReq := range partitions {
+		wg.Add(1)
+		go func(provider Provider, shardReq *QueryRequest) {
+			defer wg.Done()
+			shardResp, err := provider.Query(ctx, shardReq)
+
+			mu.Lock()
+			defer mu.Unlock()
+			errCode := ""
+			if err != nil {
+				errCode = executeErrorCode(err)
+			} else if len(shardResp.ErrorCode) > 0 {
+				errCode = shardResp.ErrorCode
+			}
+			if len(errCode) > 0 {
+				for _, device := range shardReq.Devices {
+					resp.Add(device.ID, DeviceState{Status: "ERROR", ErrorCode: errCode})
+				}
+				return
+			}
+			for id, state := range shardResp.States {
+				resp.Add(id, state)
+			}
+		}(sp.shards[name], shardReq)
+	}
+	wg.Wait()
+
+	return resp, nil
+}
+
+// Execute partitions the target devices of each command by shard and merges the outcomes returned by each shard.
+// The updated states reported by the shards are combined into a single updated state.
+func (sp *ShardedProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	resp := &ExecuteResponse{
+		UpdatedState: NewDeviceState(false),
+	}
+
+	partitions := map[string]*ExecuteRequest{}
+	for _, commandArg := range req.Commands {
+		targets := map[string][]DeviceArg{}
+		for _, device := range commandArg.TargetDevices {
+			name := sp.shardFn(req.AgentID, device)
+			if _, found := sp.shards[name]; !found {
+				resp.AddFailedDevices(ErrorCodeDeviceNotFound, device.ID)
+				continue
+			}
+			targets[name] = append(targets[name], device)
+		}
+		for name, devices := range targets {
+			if partitions[name] == nil {
+				partitions[name] = &ExecuteRequest{AgentID: req.AgentID}
+			}
+			partitions[name].Commands = append(partitions[name].Commands, CommandArg{
+				TargetDevices: devices,
+				Commands:      commandArg.Commands,
+				FollowUpToken: commandArg.FollowUpToken,
+			})
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, shardReq := range partitions {
+		wg.Add(1)
+		go func(provider Provider, shardReq *ExecuteRequest) {
+			defer wg.Done()
+			shardResp, err := provider.Execute(ctx, shardReq)
+
+			mu.Lock()
+			defer mu.Unlock()
+			errCode := ""
+			if err != nil {
+				errCode = executeErrorCode(err)
+			} else if len(shardResp.ErrorCode) > 0 {
+				errCode = shardResp.ErrorCode
+			}
+			if len(errCode) > 0 {
+				for _, commandArg := range shardReq.Commands {
+					for _, device := range commandArg.TargetDevices {
+						resp.AddFailedDevices(errCode, device.ID)
+					}
+				}
+				return
+			}
+
+			mergeExecuteResponse(resp, shardResp)
+			resp.UpdatedState.Online = resp.UpdatedState.Online || shardResp.UpdatedState.Online
+			for key, value := range shardResp.UpdatedState.State {
+				resp.UpdatedState.State[key] = value
+			}
+		}(sp.shards[name], shardReq)
+	}
+	wg.Wait()
+
+	return resp, nil
+}
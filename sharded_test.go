@@ -0,0 +1,74 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func shardByPrefix(_ string, device DeviceArg) string {
+	return strings.SplitN(device.ID, "-", 2)[0]
+}
+
+func TestShardedProviderQuery(t *testing.T) {
+	east := &testProvider{queryResp: map[string]DeviceState{
+		"east-1": NewDeviceState(true).RecordOnOff(true),
+	}}
+	west := &testProvider{queryErr: NewTransientError(errors.New("shard down"))}
+	sp := NewShardedProvider(map[string]Provider{"east": east, "west": west}, shardByPrefix)
+
+	resp, err := sp.Query(context.Background(), &QueryRequest{
+		AgentID: "agent-id",
+		Devices: []DeviceArg{{ID: "east-1"}, {ID: "west-1"}, {ID: "north-1"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []DeviceArg{{ID: "east-1"}}, east.queryReq.Devices)
+	assert.Equal(t, []DeviceArg{{ID: "west-1"}}, west.queryReq.Devices)
+	assert.Equal(t, true, resp.States["east-1"].State["on"])
+	assert.Equal(t, ErrorCodeTransientError, resp.States["west-1"].ErrorCode)
+	assert.Equal(t, ErrorCodeDeviceNotFound, resp.States["north-1"].ErrorCode)
+}
+
+func TestShardedProviderExecute(t *testing.T) {
+	east := &testProvider{
+		executeRespDeviceState: NewDeviceState(true).RecordOnOff(true),
+		executeRespUpdated:     []string{"east-1"},
+	}
+	west := &testProvider{executeErr: errors.New("shard down")}
+	sp := NewShardedProvider(map[string]Provider{"east": east, "west": west}, shardByPrefix)
+
+	command := Command{Name: "action.devices.commands.OnOff", OnOff: &CommandOnOff{On: true}}
+	resp, err := sp.Execute(context.Background(), &ExecuteRequest{
+		AgentID: "agent-id",
+		Commands: []CommandArg{{
+			TargetDevices: []DeviceArg{{ID: "east-1"}, {ID: "west-1"}, {ID: "north-1"}},
+			Commands:      []Command{command},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []DeviceArg{{ID: "east-1"}}, east.executeReq.Commands[0].TargetDevices)
+	assert.Equal(t, []Command{command}, east.executeReq.Commands[0].Commands)
+	assert.Equal(t, []string{"east-1"}, resp.UpdatedDevices)
+	assert.Equal(t, true, resp.UpdatedState.State["on"])
+	assert.Equal(t, []string{"west-1"}, resp.FailedDevices[ErrorCodeUnknownError].Devices)
+	assert.Equal(t, []string{"north-1"}, resp.FailedDevices[ErrorCodeDeviceNotFound].Devices)
+}
+
+func TestShardedProviderSync(t *testing.T) {
+	east := &testProvider{syncResp: []*Device{NewLight("east-1")}}
+	west := &testProvider{syncResp: []*Device{NewLight("west-1")}}
+	sp := NewShardedProvider(map[string]Provider{"east": east, "west": west}, shardByPrefix)
+
+	resp, err := sp.Sync(context.Background(), "agent-id")
+	assert.Nil(t, err)
+	assert.Len(t, resp.Devices, 2)
+	assert.Equal(t, "east-1", resp.Devices[0].ID)
+	assert.Equal(t, "west-1", resp.Devices[1].ID)
+
+	west.syncErr = errors.New("shard down")
+	_, err = sp.Sync(context.Background(), "agent-id")
+	assert.NotNil(t, err)
+}
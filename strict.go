@@ -0,0 +1,261 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnknownField is returned if strict decoding is enabled and a request contains a field this library doesn't know.
+	ErrUnknownField = errors.New("unknown field")
+)
+
+// UnknownFieldsError lists the JSON path of each unknown field found by strict decoding (i.e. inputs[0].payload.commands[0].execution[0].params.brightnes).
+type UnknownFieldsError struct {
+	Paths []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnknownField, strings.Join(e.Paths, ", "))
+}
+
+// Is allows the error to match ErrUnknownField.
+func (e *UnknownFieldsError) Is(target error) bool {
+	return target == ErrUnknownField
+}
+
+// WithStrictDecoding rejects fulfillment requests containing fields this library doesn't know, answering protocolError
+// and logging the JSON path of each unknown field. This is intended for development and integration tests, so schema
+// drift (i.e. a new command param Google has started sending) is caught early; by default unknown fields are ignored.
+// Command params are only checked for commands this library parses; the params of a CommandGeneric are free-form.
+func WithStrictDecoding() ServiceOption {
+	return func(s *Service) {
+		s.strictDecoding = true
+	}
+}
+
+// CheckFulfillmentRequestFields returns an *UnknownFieldsError if the JSON fulfillment request contains any unknown fields.
+func CheckFulfillmentRequestFields(data []byte) error {
+	var raw interface{}
+	if err := unmarshalUseNumber(data, &raw); err != nil {
+		return err
+	}
+	return unknownFieldsErr(unknownFields(raw, reflect.TypeOf(FulfillmentRequest{}), ""))
+}
+
+// CheckDeviceFields returns an *UnknownFieldsError if the JSON device (as returned in SYNC) contains any unknown fields,
+// or any attributes which don't belong to one of its traits.
+func CheckDeviceFields(data []byte) error {
+	var raw interface{}
+	if err := unmarshalUseNumber(data, &raw); err != nil {
+		return err
+	}
+	paths := unknownFields(raw, reflect.TypeOf(deviceRaw{}), "")
+
+	device := &Device{}
+	if err := json.Unmarshal(data, device); err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for trait := range device.Traits {
+		for _, attribute := range traitCapabilities[trait].Attributes {
+			known[attribute] = true
+		}
+	}
+	for attribute := range device.Attributes {
+		if !known[attribute] {
+			paths = append(paths, "attributes."+attribute)
+		}
+	}
+	sort.Strings(paths)
+	return unknownFieldsErr(paths)
+}
+
+func unknownFieldsErr(paths []string) error {
+	if len(paths) < 1 {
+		return nil
+	}
+	return &UnknownFieldsError{Paths: paths}
+}
+
+var (
+	fulfillmentInputType = reflect.TypeOf(FulfillmentInput{})
+	commandType          = reflect.TypeOf(Command{})
+	rawMessageType       = reflect.TypeOf(json.RawMessage{})
+)
+
+// unknownFields returns the paths of the fields in the decoded JSON value which have no corresponding field in t.
+// Types with custom deserializers which this library defines are checked against what they deserialize.
+func unknownFields(raw interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case fulfillmentInputType:
+		return fulfillmentInputUnknownFields(raw, path)
+	case commandType:
+		return commandUnknownFields(raw, path)
+	case rawMessageType:
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var paths []string
+		for _, key := range sortedKeys(obj) {
+			field, found := jsonField(t, key)
+			if !found {
+				paths = append(paths, joinPath(path, key))
+				continue
+			}
+			paths = append(paths, unknownFields(obj[key], field.Type, joinPath(path, key))...)
+		}
+		return paths
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		var paths []string
+		for idx, elem := range arr {
+			paths = append(paths, unknownFields(elem, t.Elem(), fmt.Sprintf("%s[%d]", path, idx))...)
+		}
+		return paths
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var paths []string
+		for _, key := range sortedKeys(obj) {
+			paths = append(paths, unknownFields(obj[key], t.Elem(), joinPath(path, key))...)
+		}
+		return paths
+	}
+	return nil
+}
+
+// fulfillmentInputUnknownFields checks the payload of the input against the payload of its intent.
+// The payloads of intents this library doesn't parse aren't checked.
+func fulfillmentInputUnknownFields(raw interface{}, path string) []string {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var paths []string
+	for _, key := range sortedKeys(obj) {
+		switch key {
+		case "intent":
+		case "payload":
+			intent, _ := obj["intent"].(string)
+			switch intent {
+			case IntentQuery:
+				paths = append(paths, unknownFields(obj[key], reflect.TypeOf(QueryPayload{}), joinPath(path, key))...)
+			case IntentExecute:
+				paths = append(paths, unknownFields(obj[key], reflect.TypeOf(ExecutePayload{}), joinPath(path, key))...)
+			}
+		default:
+			paths = append(paths, joinPath(path, key))
+		}
+	}
+	return paths
+}
+
+// commandUnknownFields checks the params of the command against the details the command is parsed into.
+func commandUnknownFields(raw interface{}, path string) []string {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var paths []string
+	for _, key := range sortedKeys(obj) {
+		switch key {
+		case "command", "params":
+		case "challenge":
+			paths = append(paths, unknownFields(obj[key], reflect.TypeOf(CommandChallenge{}), joinPath(path, key))...)
+		default:
+			paths = append(paths, joinPath(path, key))
+		}
+	}
+
+	params, ok := obj["params"].(map[string]interface{})
+	if !ok {
+		return paths
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return paths
+	}
+	command := Command{}
+	if err := json.Unmarshal(data, &command); err != nil || command.Generic != nil {
+		return paths
+	}
+
+	details := commandDetailsType(command)
+	if details == nil {
+		return paths
+	}
+	delete(params, "followUpToken")
+	return append(paths, unknownFields(params, details, joinPath(path, "params"))...)
+}
+
+// commandDetailsType returns the type of the details set on the parsed command, if any.
+func commandDetailsType(command Command) reflect.Type {
+	v := reflect.ValueOf(command)
+	for idx := 0; idx < v.NumField(); idx++ {
+		field := v.Field(idx)
+		if field.Kind() != reflect.Ptr || field.IsNil() || field.Type() == reflect.TypeOf(command.Challenge) {
+			continue
+		}
+		return field.Type()
+	}
+	return nil
+}
+
+// jsonField returns the struct field the JSON key is deserialized into, matching case-insensitively as encoding/json does.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) < 1 {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path string, key string) string {
+	if len(path) < 1 {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	var keys []string
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCheckFulfillmentRequestFields(t *testing.T) {
+	assert.Nil(t, CheckFulfillmentRequestFields([]byte(`{
+		"requestId": "req-1",
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {"commands": [{
+				"devices": [{"id": "123", "customData": {"anything": 1}}],
+				"execution": [
+					{"command": "action.devices.commands.OnOff", "params": {"on": true, "followUpToken": "token"}},
+					{"command": "action.devices.commands.ColorAbsolute", "params": {"color": {"spectrumRGB": 255}}},
+					{"command": "action.devices.commands.SomethingNew", "params": {"whatever": 1}}
+				]
+			}]}
+		}]
+	}`)))
+
+	err := CheckFulfillmentRequestFields([]byte(`{
+		"requestId": "req-1",
+		"extra": true,
+		"inputs": [{
+			"intent": "action.devices.EXECUTE",
+			"payload": {"commands": [{
+				"devices": [{"id": "123", "name": "lamp"}],
+				"execution": [
+					{"command": "action.devices.commands.BrightnessAbsolute", "params": {"brightnes": 42}},
+					{"command": "action.devices.commands.ColorAbsolute", "params": {"color": {"hue": 1}}}
+				]
+			}]}
+		}]
+	}`))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+	var fieldsErr *UnknownFieldsError
+	assert.True(t, errors.As(err, &fieldsErr))
+	assert.Equal(t, []string{
+		"extra",
+		"inputs[0].payload.commands[0].devices[0].name",
+		"inputs[0].payload.commands[0].execution[0].params.brightnes",
+		"inputs[0].payload.commands[0].execution[1].params.color.hue",
+	}, fieldsErr.Paths)
+
+	err = CheckFulfillmentRequestFields([]byte(`{"requestId": "req-1", "inputs": [{"intent": "action.devices.QUERY", "payload": {"devices": [{"id": "123", "state": {}}]}}]}`))
+	assert.Equal(t, "unknown field: inputs[0].payload.devices[0].state", err.Error())
+}
+
+func TestCheckDeviceFields(t *testing.T) {
+	assert.Nil(t, CheckDeviceFields([]byte(`{"id": "1", "type": "action.devices.types.LIGHT", "traits": ["action.devices.traits.Brightness"], "willReportState": true, "attributes": {"commandOnlyBrightness": false}}`)))
+
+	err := CheckDeviceFields([]byte(`{"id": "1", "type": "action.devices.types.LIGHT", "roomhint": "kitchen", "colour": "red", "traits": ["action.devices.traits.OnOff"], "attributes": {"commandOnlyBrightness": false}}`))
+	var fieldsErr *UnknownFieldsError
+	assert.True(t, errors.As(err, &fieldsErr))
+	assert.Equal(t, []string{"attributes.commandOnlyBrightness", "colour"}, fieldsErr.Paths)
+}
+
+func TestGoogleFulfillmentHandlerStrictDecoding(t *testing.T) {
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	svc := NewService(zaptest.NewLogger(t), authenticator, &testProvider{}, nil, WithStrictDecoding())
+
+	req := httptest.NewRequest(http.MethodPost, GoogleFulfillmentPath, bytes.NewBuffer([]byte(`{"requestId": "req-1", "inputs": [{"intent": "action.devices.SYNC", "extra": 1}]}`)))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("authorization", "bearer asdf")
+	rr := httptest.NewRecorder()
+	svc.GoogleFulfillmentHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"requestId":"req-1","payload":{"errorCode":"protocolError","debugString":"unknown field: inputs[0].extra"}}
+`, rr.Body.String())
+}
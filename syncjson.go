@@ -0,0 +1,74 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidSyncJSON is returned by ParseSyncJSON if the data doesn't contain a set of devices in the SYNC format.
+	ErrInvalidSyncJSON = errors.New("invalid sync json")
+)
+
+// ParseSyncJSON reads the devices from a SYNC payload, such as one exported from another smart home framework or
+// copied from the Google documentation. This allows existing devices to be migrated to a provider using this library.
+// The data may contain a complete SYNC fulfillment response, just its payload (i.e. {"agentUserId": "...", "devices": [...]}),
+// or a JSON array of devices. ErrInvalidSyncJSON is returned if a device is missing its ID, type or name, and
+// ErrDuplicateDeviceID if more than one device has the same ID.
+func ParseSyncJSON(data []byte) ([]*Device, error) {
+	devicesData := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(devicesData, []byte("[")) {
+		var wrapper struct {
+			Payload *struct {
+				Devices json.RawMessage `json:"devices"`
+			} `json:"payload"`
+			Devices json.RawMessage `json:"devices"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSyncJSON, err)
+		}
+
+		devicesData = wrapper.Devices
+		if wrapper.Payload != nil {
+			devicesData = wrapper.Payload.Devices
+		}
+		if len(devicesData) < 1 {
+			return nil, fmt.Errorf("%w: no devices found", ErrInvalidSyncJSON)
+		}
+	}
+
+	var devices []*Device
+	if err := json.Unmarshal(devicesData, &devices); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSyncJSON, err)
+	}
+	for idx, device := range devices {
+		if device == nil || len(device.ID) < 1 {
+			return nil, fmt.Errorf("%w: device %d has no id", ErrInvalidSyncJSON, idx)
+		} else if len(device.Type) < 1 {
+			return nil, fmt.Errorf("%w: device %s has no type", ErrInvalidSyncJSON, device.ID)
+		} else if len(device.Name.Name) < 1 {
+			return nil, fmt.Errorf("%w: device %s has no name", ErrInvalidSyncJSON, device.ID)
+		}
+
+		// Match the devices created by the constructors, so traits can be added to the imported devices.
+		if device.Attributes == nil {
+			device.Attributes = map[string]interface{}{}
+		}
+	}
+	if err := CheckIDCollisions(devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// MarshalSyncJSON writes the devices as the payload of a SYNC fulfillment response for the specified user,
+// which can be read by ParseSyncJSON or imported into another smart home framework.
+// The output is indented to make it easier to review and edit by hand.
+func MarshalSyncJSON(agentUserID string, devices []*Device) ([]byte, error) {
+	resp := &SyncFulfillmentResponse{}
+	resp.Payload.UserID = agentUserID
+	resp.Payload.Devices = devices
+	return json.MarshalIndent(resp, "", "  ")
+}
@@ -0,0 +1,62 @@
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyncJSON(t *testing.T) {
+	devices, err := ParseSyncJSON(readGolden(t, "sync_response.json"))
+	assert.Nil(t, err)
+	assert.Len(t, devices, 3)
+	assert.Equal(t, "123", devices[0].ID)
+	assert.Equal(t, "Night light", devices[0].Name.Name)
+	assert.True(t, devices[0].Traits["action.devices.traits.OnOff"])
+
+	// The payload and a bare array of devices are accepted too.
+	payload, err := ParseSyncJSON([]byte(`{"agentUserId": "agent-id", "devices": [{"id": "light-1", "type": "action.devices.types.LIGHT", "name": {"name": "Lamp"}}]}`))
+	assert.Nil(t, err)
+	assert.Len(t, payload, 1)
+
+	array, err := ParseSyncJSON([]byte(` [{"id": "light-1", "type": "action.devices.types.LIGHT", "name": {"name": "Lamp"}}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, payload, array)
+}
+
+func TestParseSyncJSONInvalid(t *testing.T) {
+	for _, data := range []string{
+		`not json`,
+		`{"requestId": "1"}`,
+		`{"devices": {}}`,
+		`[{"type": "action.devices.types.LIGHT", "name": {"name": "Lamp"}}]`,
+		`[{"id": "light-1", "name": {"name": "Lamp"}}]`,
+		`[{"id": "light-1", "type": "action.devices.types.LIGHT"}]`,
+	} {
+		_, err := ParseSyncJSON([]byte(data))
+		assert.True(t, errors.Is(err, ErrInvalidSyncJSON), data)
+	}
+
+	_, err := ParseSyncJSON([]byte(`[
+		{"id": "light-1", "type": "action.devices.types.LIGHT", "name": {"name": "Lamp"}},
+		{"id": "light-1", "type": "action.devices.types.LIGHT", "name": {"name": "Other Lamp"}}
+	]`))
+	assert.True(t, errors.Is(err, ErrDuplicateDeviceID))
+}
+
+func TestMarshalSyncJSON(t *testing.T) {
+	light := NewLight("light-1")
+	light.Name.Name = "Lamp"
+	outlet := NewOutlet("outlet-1")
+	outlet.Name.Name = "Heater"
+	devices := []*Device{light, outlet}
+
+	data, err := MarshalSyncJSON("agent-id", devices)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"agentUserId": "agent-id"`)
+
+	parsed, err := ParseSyncJSON(data)
+	assert.Nil(t, err)
+	assert.Equal(t, devices, parsed)
+}
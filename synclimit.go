@@ -0,0 +1,130 @@
+package action
+
+import (
+	"encoding/json"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// SyncTruncation describes the devices which were removed from a SYNC response to fit within the configured limits.
+type SyncTruncation struct {
+	AgentUserID string
+	// TotalDevices is the number of devices the provider returned.
+	TotalDevices int
+	// DroppedDeviceIDs contains the IDs of the devices not included in the response, in sorted order.
+	DroppedDeviceIDs []string
+	// Reason is either "maxDevices" or "maxPayloadBytes", depending on which limit was exceeded.
+	Reason string
+}
+
+// SyncTruncatedFunc is invoked whenever a SYNC response is truncated.
+// This should be used to alert the operator, or the user, that not all of their devices are visible to Google.
+type SyncTruncatedFunc func(SyncTruncation)
+
+// WithMaxDevices limits the number of devices returned in response to SYNC.
+// If the provider returns more devices than this the devices are sorted by ID and only the first maxDevices are returned,
+// so the same devices are returned for each SYNC. See WithSyncTruncatedCallback to be notified when devices are dropped.
+func WithMaxDevices(maxDevices int) ServiceOption {
+	return func(s *Service) {
+		s.maxSyncDevices = maxDevices
+	}
+}
+
+// WithMaxSyncPayloadBytes limits the size of the serialized SYNC response.
+// If the response would exceed this size the devices are sorted by ID and the devices at the end are dropped until it fits.
+// See WithSyncTruncatedCallback to be notified when devices are dropped.
+func WithMaxSyncPayloadBytes(maxBytes int) ServiceOption {
+	return func(s *Service) {
+		s.maxSyncPayloadBytes = maxBytes
+	}
+}
+
+// WithSyncTruncatedCallback registers the function invoked whenever devices are dropped from a SYNC response
+// by WithMaxDevices or WithMaxSyncPayloadBytes.
+func WithSyncTruncatedCallback(onTruncate SyncTruncatedFunc) ServiceOption {
+	return func(s *Service) {
+		s.onSyncTruncated = onTruncate
+	}
+}
+
+// truncateSync applies any configured limits to the SYNC response, dropping devices as required.
+func (s *Service) truncateSync(syncResp *SyncFulfillmentResponse) {
+	if s.maxSyncDevices < 1 && s.maxSyncPayloadBytes < 1 {
+		return
+	}
+
+	devices := append([]*Device{}, syncResp.Payload.Devices...)
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+
+	kept := len(devices)
+	reason := ""
+	if s.maxSyncDevices > 0 && kept > s.maxSyncDevices {
+		kept = s.maxSyncDevices
+		reason = "maxDevices"
+	}
+
+	if s.maxSyncPayloadBytes > 0 {
+		fits := s.syncDevicesWithinSize(syncResp, devices[:kept])
+		if fits < kept {
+			kept = fits
+			reason = "maxPayloadBytes"
+		}
+	}
+
+	if len(reason) < 1 {
+		return
+	}
+
+	truncation := SyncTruncation{
+		AgentUserID:  syncResp.Payload.UserID,
+		TotalDevices: len(devices),
+		Reason:       reason,
+	}
+	for _, device := range devices[kept:] {
+		truncation.DroppedDeviceIDs = append(truncation.DroppedDeviceIDs, device.ID)
+	}
+
+	s.logger.Warn("sync response truncated",
+		zap.String("agent_user_id", truncation.AgentUserID),
+		zap.String("reason", reason),
+		zap.Int("total_devices", truncation.TotalDevices),
+		zap.Int("dropped_devices", len(truncation.DroppedDeviceIDs)),
+	)
+	if s.onSyncTruncated != nil {
+		s.onSyncTruncated(truncation)
+	}
+
+	syncResp.Payload.Devices = devices[:kept]
+}
+
+// syncDevicesWithinSize returns how many of the supplied devices can be included in the SYNC response while remaining under the size limit.
+func (s *Service) syncDevicesWithinSize(syncResp *SyncFulfillmentResponse, devices []*Device) int {
+	empty := *syncResp
+	empty.Payload.Devices = nil
+	emptyBytes, err := json.Marshal(empty)
+	if err != nil {
+		return len(devices)
+	}
+
+	// The devices are omitted from the empty response so account for the key and array being added.
+	size := len(emptyBytes) + len(`,"devices":[]`)
+	for idx, device := range devices {
+		deviceBytes, err := json.Marshal(device)
+		if err != nil {
+			return idx
+		}
+
+		size += len(deviceBytes)
+		if idx > 0 {
+			// Account for the separating comma.
+			size++
+		}
+		if size > s.maxSyncPayloadBytes {
+			return idx
+		}
+	}
+	return len(devices)
+}
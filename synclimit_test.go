@@ -0,0 +1,109 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func syncForTest(t *testing.T, svc *Service) *SyncFulfillmentResponse {
+	result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`))
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	syncResp := &SyncFulfillmentResponse{}
+	if err := json.Unmarshal(result.Body, syncResp); err != nil {
+		t.Fatal(err)
+	}
+	return syncResp
+}
+
+func TestServiceSyncMaxDevices(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("d"), NewOutlet("b"), NewOutlet("a"), NewOutlet("c")},
+	}
+
+	var truncations []SyncTruncation
+	svc := NewService(logger, authenticator, provider, nil, WithMaxDevices(2), WithSyncTruncatedCallback(func(truncation SyncTruncation) {
+		truncations = append(truncations, truncation)
+	}))
+
+	syncResp := syncForTest(t, svc)
+	assert.Len(t, syncResp.Payload.Devices, 2)
+	assert.Equal(t, "a", syncResp.Payload.Devices[0].ID)
+	assert.Equal(t, "b", syncResp.Payload.Devices[1].ID)
+	assert.Equal(t, []SyncTruncation{
+		{
+			AgentUserID:      "1836.15267389",
+			TotalDevices:     4,
+			DroppedDeviceIDs: []string{"c", "d"},
+			Reason:           "maxDevices",
+		},
+	}, truncations)
+
+	// The provider order is left untouched.
+	assert.Equal(t, "d", provider.syncResp[0].ID)
+}
+
+func TestServiceSyncMaxPayloadBytes(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{}
+	for i := 0; i < 10; i++ {
+		provider.syncResp = append(provider.syncResp, NewOutlet(fmt.Sprintf("device-%d", i)))
+	}
+
+	var truncation SyncTruncation
+	svc := NewService(logger, authenticator, provider, nil, WithMaxSyncPayloadBytes(1024), WithSyncTruncatedCallback(func(t SyncTruncation) {
+		truncation = t
+	}))
+
+	result := svc.Fulfill(context.Background(), "application/json", "bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [{"intent": "action.devices.SYNC"}]
+	}`))
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.LessOrEqual(t, len(strings.TrimSpace(string(result.Body))), 1024)
+
+	syncResp := &SyncFulfillmentResponse{}
+	assert.Nil(t, json.Unmarshal(result.Body, syncResp))
+	assert.NotEmpty(t, syncResp.Payload.Devices)
+	assert.Equal(t, "maxPayloadBytes", truncation.Reason)
+	assert.Equal(t, 10, len(syncResp.Payload.Devices)+len(truncation.DroppedDeviceIDs))
+}
+
+func TestServiceSyncWithinLimits(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("b"), NewOutlet("a")},
+	}
+
+	called := false
+	svc := NewService(logger, authenticator, provider, nil, WithMaxDevices(2), WithSyncTruncatedCallback(func(SyncTruncation) {
+		called = true
+	}))
+
+	syncResp := syncForTest(t, svc)
+	assert.Len(t, syncResp.Payload.Devices, 2)
+	assert.False(t, called)
+}
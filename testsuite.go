@@ -0,0 +1,162 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TestSuiteScenario is a check the Google Smart Home Test Suite runs against a device.
+// See https://developers.google.com/assistant/smarthome/tools/smart-home-test-suite
+type TestSuiteScenario struct {
+	// Intent exercised by the scenario; one of the Intent constants, or ReportState.
+	Intent string
+	Trait  string
+	// Name of the command for EXECUTE scenarios, otherwise empty.
+	Command string
+}
+
+func (tss TestSuiteScenario) String() string {
+	if len(tss.Command) > 0 {
+		return fmt.Sprintf("%s %s (%s)", tss.Intent, tss.Command, traitShortName(tss.Trait))
+	} else if len(tss.Trait) < 1 {
+		return tss.Intent
+	}
+	return fmt.Sprintf("%s %s", tss.Intent, traitShortName(tss.Trait))
+}
+
+// TestSuiteScenarioReportState identifies Test Suite scenarios which check the device state is reported to the HomeGraph.
+const TestSuiteScenarioReportState = "ReportState"
+
+// DeviceTestSuiteReport lists the Test Suite scenarios which apply to a device, along with the commands and state values
+// the provider must handle for the device to pass them.
+type DeviceTestSuiteReport struct {
+	DeviceID string
+	Type     string
+
+	Scenarios []TestSuiteScenario
+	// Commands the provider must execute, sorted by name.
+	Commands []string
+	// States the provider must return from QUERY, and report if the device will report state, sorted by name.
+	States []string
+	// UnknownTraits contains the traits this library has no capability information for; their scenarios aren't listed.
+	UnknownTraits []string
+}
+
+// TestSuiteReport describes the Test Suite scenarios which apply to the devices of a SYNC response,
+// computed from the capabilities of their traits (see TraitCapabilities), so certification work can be scoped.
+type TestSuiteReport struct {
+	Devices []DeviceTestSuiteReport
+}
+
+// NewTestSuiteReport computes the Test Suite scenarios which apply to the supplied devices.
+// Traits marked as command only have no QUERY or ReportState scenarios, and traits marked as query only have no
+// EXECUTE scenarios.
+func NewTestSuiteReport(devices []*Device) *TestSuiteReport {
+	report := &TestSuiteReport{}
+	for _, device := range devices {
+		deviceReport := DeviceTestSuiteReport{
+			DeviceID: device.ID,
+			Type:     device.Type,
+			Scenarios: []TestSuiteScenario{
+				{Intent: IntentSync},
+			},
+		}
+
+		for _, access := range device.TraitAccessReport() {
+			capability, found := traitCapabilities[access.Trait]
+			if !found {
+				deviceReport.UnknownTraits = append(deviceReport.UnknownTraits, access.Trait)
+				continue
+			}
+
+			if !access.QueryOnly {
+				for _, command := range capability.Commands {
+					deviceReport.Scenarios = append(deviceReport.Scenarios, TestSuiteScenario{
+						Intent:  IntentExecute,
+						Trait:   access.Trait,
+						Command: command,
+					})
+					deviceReport.Commands = append(deviceReport.Commands, command)
+				}
+			}
+			if !access.CommandOnly && len(capability.States) > 0 {
+				deviceReport.Scenarios = append(deviceReport.Scenarios, TestSuiteScenario{
+					Intent: IntentQuery,
+					Trait:  access.Trait,
+				})
+				if device.WillReportState {
+					deviceReport.Scenarios = append(deviceReport.Scenarios, TestSuiteScenario{
+						Intent: TestSuiteScenarioReportState,
+						Trait:  access.Trait,
+					})
+				}
+				deviceReport.States = append(deviceReport.States, capability.States...)
+			}
+		}
+		sort.Strings(deviceReport.Commands)
+		sort.Strings(deviceReport.States)
+
+		report.Devices = append(report.Devices, deviceReport)
+	}
+	return report
+}
+
+// Commands returns the sorted set of commands the provider must execute across all the devices.
+func (tsr *TestSuiteReport) Commands() []string {
+	var values [][]string
+	for _, device := range tsr.Devices {
+		values = append(values, device.Commands)
+	}
+	return sortedUnion(values)
+}
+
+// States returns the sorted set of state values the provider must report across all the devices.
+func (tsr *TestSuiteReport) States() []string {
+	var values [][]string
+	for _, device := range tsr.Devices {
+		values = append(values, device.States)
+	}
+	return sortedUnion(values)
+}
+
+// Write writes the report in a human-readable form.
+func (tsr *TestSuiteReport) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, device := range tsr.Devices {
+		fmt.Fprintf(&b, "Device %s (%s)\n", device.DeviceID, device.Type)
+		for _, scenario := range device.Scenarios {
+			fmt.Fprintf(&b, "  %s\n", scenario)
+		}
+		for _, trait := range device.UnknownTraits {
+			fmt.Fprintf(&b, "  unknown trait %s; scenarios not listed\n", trait)
+		}
+	}
+	fmt.Fprintf(&b, "Required commands:\n")
+	for _, command := range tsr.Commands() {
+		fmt.Fprintf(&b, "  %s\n", command)
+	}
+	fmt.Fprintf(&b, "Required states:\n")
+	for _, state := range tsr.States() {
+		fmt.Fprintf(&b, "  %s\n", state)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedUnion(values [][]string) []string {
+	seen := map[string]bool{}
+	var union []string
+	for _, vals := range values {
+		for _, val := range vals {
+			if !seen[val] {
+				seen[val] = true
+				union = append(union, val)
+			}
+		}
+	}
+	sort.Strings(union)
+	return union
+}
@@ -0,0 +1,48 @@
+package action
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTestSuiteReport(t *testing.T) {
+	light := NewLight("light-1").AddBrightnessTrait(true)
+	light.WillReportState = true
+	outlet := NewOutlet("outlet-1")
+	outlet.WillReportState = false
+	outlet.Traits["action.devices.traits.SomethingNew"] = true
+
+	report := NewTestSuiteReport([]*Device{light, outlet})
+	assert.Len(t, report.Devices, 2)
+
+	assert.Equal(t, []TestSuiteScenario{
+		{Intent: IntentSync},
+		{Intent: IntentExecute, Trait: TraitBrightness, Command: "action.devices.commands.BrightnessAbsolute"},
+		{Intent: IntentExecute, Trait: TraitBrightness, Command: "action.devices.commands.BrightnessRelative"},
+		{Intent: IntentExecute, Trait: TraitOnOff, Command: "action.devices.commands.OnOff"},
+		{Intent: IntentQuery, Trait: TraitOnOff},
+		{Intent: TestSuiteScenarioReportState, Trait: TraitOnOff},
+	}, report.Devices[0].Scenarios)
+	assert.Equal(t, []string{"on"}, report.Devices[0].States)
+
+	assert.Equal(t, []TestSuiteScenario{
+		{Intent: IntentSync},
+		{Intent: IntentExecute, Trait: TraitOnOff, Command: "action.devices.commands.OnOff"},
+		{Intent: IntentQuery, Trait: TraitOnOff},
+	}, report.Devices[1].Scenarios)
+	assert.Equal(t, []string{"action.devices.traits.SomethingNew"}, report.Devices[1].UnknownTraits)
+
+	assert.Equal(t, []string{
+		"action.devices.commands.BrightnessAbsolute",
+		"action.devices.commands.BrightnessRelative",
+		"action.devices.commands.OnOff",
+	}, report.Commands())
+	assert.Equal(t, []string{"on"}, report.States())
+
+	var b bytes.Buffer
+	assert.Nil(t, report.Write(&b))
+	assert.Contains(t, b.String(), "Device light-1 (action.devices.types.LIGHT)\n  action.devices.SYNC\n")
+	assert.Contains(t, b.String(), "  action.devices.EXECUTE action.devices.commands.OnOff (OnOff)\n")
+}
@@ -0,0 +1,96 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidThermostatMode is returned if a thermostat mode is not one of the modes supported by Google,
+	// or is not one of the modes declared by the device.
+	ErrInvalidThermostatMode = errors.New("invalid thermostat mode")
+)
+
+// ThermostatMode defines the modes a device with the TemperatureSetting trait can operate in.
+// See https://developers.google.com/assistant/smarthome/traits/temperaturesetting#device-attributes
+const (
+	ThermostatModeOff      = "off"
+	ThermostatModeHeat     = "heat"
+	ThermostatModeCool     = "cool"
+	ThermostatModeOn       = "on"
+	ThermostatModeHeatCool = "heatcool"
+	ThermostatModeAuto     = "auto"
+	ThermostatModeFanOnly  = "fan-only"
+	ThermostatModePurifier = "purifier"
+	ThermostatModeEco      = "eco"
+	ThermostatModeDry      = "dry"
+)
+
+var validThermostatModes = map[string]bool{
+	ThermostatModeOff:      true,
+	ThermostatModeHeat:     true,
+	ThermostatModeCool:     true,
+	ThermostatModeOn:       true,
+	ThermostatModeHeatCool: true,
+	ThermostatModeAuto:     true,
+	ThermostatModeFanOnly:  true,
+	ThermostatModePurifier: true,
+	ThermostatModeEco:      true,
+	ThermostatModeDry:      true,
+}
+
+// IsValidThermostatMode checks whether the supplied mode is one of the thermostat modes supported by Google.
+func IsValidThermostatMode(mode string) bool {
+	return validThermostatModes[mode]
+}
+
+// ValidateThermostatModes checks that each of the supplied modes is supported by Google, and that at least one is supplied.
+// Device.Validate uses this to check the modes supplied to NewThermostat or AddTemperatureSettingTrait.
+func ValidateThermostatModes(modes []string) error {
+	if len(modes) < 1 {
+		return fmt.Errorf("%w: at least one mode is required", ErrInvalidThermostatMode)
+	}
+	for _, mode := range modes {
+		if !IsValidThermostatMode(mode) {
+			return fmt.Errorf("%w: %q", ErrInvalidThermostatMode, mode)
+		}
+	}
+	return nil
+}
+
+// thermostatModes returns the modes declared by the device, whether it was built using AddTemperatureSettingTrait
+// or decoded from JSON.
+func thermostatModes(d *Device) []string {
+	switch modes := d.Attributes["availableThermostatModes"].(type) {
+	case []string:
+		return modes
+	case []interface{}:
+		var ret []string
+		for _, mode := range modes {
+			str, _ := mode.(string)
+			ret = append(ret, str)
+		}
+		return ret
+	}
+	return nil
+}
+
+// validateThermostatModeState checks that the mode recorded in the state is one of the modes declared by the device.
+// Devices which don't declare their modes are not checked.
+func validateThermostatModeState(d *Device, state DeviceState) error {
+	mode, found := state.State["thermostatMode"].(string)
+	if !found {
+		return nil
+	}
+	modes := thermostatModes(d)
+	if modes == nil {
+		return nil
+	}
+
+	for _, available := range modes {
+		if available == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not available on %s", ErrInvalidThermostatMode, mode, d.ID)
+}
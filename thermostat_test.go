@@ -0,0 +1,53 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateThermostatModes(t *testing.T) {
+	assert.True(t, IsValidThermostatMode(ThermostatModeHeatCool))
+	assert.False(t, IsValidThermostatMode("heat-cool"))
+
+	assert.Nil(t, ValidateThermostatModes([]string{ThermostatModeOff, ThermostatModeHeat, ThermostatModeFanOnly}))
+
+	err := ValidateThermostatModes([]string{ThermostatModeOff, "warm"})
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+	assert.Contains(t, err.Error(), "warm")
+
+	err = ValidateThermostatModes(nil)
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+}
+
+func TestDeviceStateValidateThermostatMode(t *testing.T) {
+	d := NewThermostat("1", []string{ThermostatModeOff, ThermostatModeHeat}, TemperatureUnitCelsius, [2]float64{})
+
+	assert.Nil(t, NewDeviceState(true).RecordThermostat(ThermostatState{Mode: ThermostatModeHeat, AmbientC: 20, SetpointC: 21}).ValidateForDevice(d))
+
+	err := NewDeviceState(true).RecordThermostat(ThermostatState{Mode: ThermostatModeCool, AmbientC: 20, SetpointC: 18}).ValidateForDevice(d)
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+
+	decoded := &Device{}
+	assert.Nil(t, roundtripJSON(d, decoded))
+	assert.Nil(t, NewDeviceState(true).RecordThermostat(ThermostatState{Mode: ThermostatModeHeat, AmbientC: 20, SetpointC: 21}).ValidateForDevice(decoded))
+	err = NewDeviceState(true).RecordThermostat(ThermostatState{Mode: ThermostatModeCool, AmbientC: 20, SetpointC: 18}).ValidateForDevice(decoded)
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+}
+
+func TestDeviceValidateThermostatModes(t *testing.T) {
+	assert.Nil(t, NewThermostat("1", []string{ThermostatModeOff, ThermostatModeHeat}, TemperatureUnitCelsius, [2]float64{}).Validate())
+
+	err := NewThermostat("1", []string{ThermostatModeOff, "warm"}, TemperatureUnitCelsius, [2]float64{}).Validate()
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+	assert.Contains(t, err.Error(), "warm")
+
+	err = NewDevice("2", DeviceTypeThermostat).AddTemperatureSettingTrait(nil, TemperatureUnitCelsius, [2]float64{}, false, false).Validate()
+	assert.True(t, errors.Is(err, ErrInvalidThermostatMode))
+
+	d := &Device{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"id":"3","traits":["action.devices.traits.TemperatureSetting"],"attributes":{"availableThermostatModes":["off","warm"]}}`), d))
+	assert.True(t, errors.Is(d.Validate(), ErrInvalidThermostatMode))
+}
@@ -1,13 +1,26 @@
 package action
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrStateNotSupported is returned if a device state contains a value for a trait the device does not have.
+	ErrStateNotSupported = errors.New("state not supported by device traits")
+)
 
 // DeviceState contains the state of a device.
 type DeviceState struct {
 	Online bool
 	Status string
+	// ErrorCode is set alongside an ERROR status to indicate why the state of the device couldn't be retrieved.
+	ErrorCode string
 
 	State map[string]interface{}
+
+	temperatureUnit string
 }
 
 // NewDeviceState creates a new device state to be added to as defined by the relevant traits on a device.
@@ -18,6 +31,28 @@ func NewDeviceState(online bool) DeviceState {
 	}
 }
 
+// NewDeviceState creates a new device state for this device.
+// If the device has a NativeTemperatureUnit the temperatures recorded in the state are converted from that unit to Celsius.
+func (d *Device) NewDeviceState(online bool) DeviceState {
+	return NewDeviceState(online).WithTemperatureUnit(d.NativeTemperatureUnit)
+}
+
+// WithTemperatureUnit declares the unit the temperatures supplied to the Record helpers are in.
+// The temperatures are converted to Celsius, which is what Google expects, when they are recorded.
+// If this is not set the temperatures are assumed to already be in Celsius.
+func (ds DeviceState) WithTemperatureUnit(unit string) DeviceState {
+	ds.temperatureUnit = unit
+	return ds
+}
+
+// celsius converts the supplied temperature from the unit of the state into Celsius.
+func (ds DeviceState) celsius(temperature float64) float64 {
+	if ds.temperatureUnit == TemperatureUnitFahrenheit {
+		return FahrenheitToCelsius(temperature)
+	}
+	return temperature
+}
+
 // RecordBrightness adds the current brightness to the device.
 // Should only be applied to devices with the Brightness trait
 // See https://developers.google.com/assistant/smarthome/traits/brightness
@@ -26,6 +61,40 @@ func (ds DeviceState) RecordBrightness(brightness int) DeviceState {
 	return ds
 }
 
+// RecordCapacityRemaining adds the remaining capacity of the device in the specified unit (i.e. the range of a vehicle in EnergyStorageUnitKilometers).
+// This can be called once for each unit the capacity is known in.
+// Should only be applied to devices with the EnergyStorage trait
+// See https://developers.google.com/assistant/smarthome/traits/energystorage
+func (ds DeviceState) RecordCapacityRemaining(rawValue int, unit string) DeviceState {
+	ds.recordCapacity("capacityRemaining", rawValue, unit)
+	return ds
+}
+
+// RecordCapacityUntilFull adds the capacity required to fully charge the device in the specified unit (i.e. EnergyStorageUnitSeconds).
+// This can be called once for each unit the capacity is known in.
+// Should only be applied to devices with the EnergyStorage trait
+// See https://developers.google.com/assistant/smarthome/traits/energystorage
+func (ds DeviceState) RecordCapacityUntilFull(rawValue int, unit string) DeviceState {
+	ds.recordCapacity("capacityUntilFull", rawValue, unit)
+	return ds
+}
+
+// recordCapacity sets the value of the specified unit in the capacity list stored under key, adding it if not present.
+func (ds DeviceState) recordCapacity(key string, rawValue int, unit string) {
+	capacity, _ := ds.State[key].([]map[string]interface{})
+	for _, entry := range capacity {
+		if entry["unit"] == unit {
+			entry["rawValue"] = rawValue
+			return
+		}
+	}
+
+	ds.State[key] = append(capacity, map[string]interface{}{
+		"rawValue": rawValue,
+		"unit":     unit,
+	})
+}
+
 // RecordColorTemperature adds the current color temperature (in Kelvin) to the device.
 // Should only be applied to devices with the ColorSetting trait
 // See https://developers.google.com/assistant/smarthome/traits/colorsetting
@@ -46,6 +115,13 @@ func (ds DeviceState) RecordColorRGB(spectrumRgb int) DeviceState {
 	return ds
 }
 
+// RecordColorRGBComponents adds the current color to the device using its individual red, green and blue components.
+// Should only be applied to devices with the ColorSetting trait
+// See https://developers.google.com/assistant/smarthome/traits/colorsetting
+func (ds DeviceState) RecordColorRGBComponents(r, g, b uint8) DeviceState {
+	return ds.RecordColorRGB(ColorFromRGB(r, g, b))
+}
+
 // RecordColorHSV adds the current color in HSV to the device.
 // Should only be applied to devices with the ColorSetting trait
 // See https://developers.google.com/assistant/smarthome/traits/colorsetting
@@ -60,6 +136,87 @@ func (ds DeviceState) RecordColorHSV(hue float64, saturation float64, value floa
 	return ds
 }
 
+// RecordApplication adds the key of the application currently in the foreground on the device.
+// Should only be applied to devices with the AppSelector trait
+// See https://developers.google.com/assistant/smarthome/traits/appselector
+func (ds DeviceState) RecordApplication(key string) DeviceState {
+	ds.State["currentApplication"] = key
+	return ds
+}
+
+// RecordBattery adds the remaining capacity of the battery as a percentage, along with whether it is charging and plugged in.
+// A descriptive capacity is derived from the percentage.
+// Should only be applied to devices with the EnergyStorage trait
+// See https://developers.google.com/assistant/smarthome/traits/energystorage
+func (ds DeviceState) RecordBattery(percent int, isCharging bool, isPluggedIn bool) DeviceState {
+	descriptive := "FULL"
+	switch {
+	case percent <= 10:
+		descriptive = "CRITICALLY_LOW"
+	case percent <= 30:
+		descriptive = "LOW"
+	case percent <= 70:
+		descriptive = "MEDIUM"
+	case percent < 100:
+		descriptive = "HIGH"
+	}
+
+	ds.State["descriptiveCapacityRemaining"] = descriptive
+	ds.recordCapacity("capacityRemaining", percent, EnergyStorageUnitPercentage)
+	ds.State["isCharging"] = isCharging
+	ds.State["isPluggedIn"] = isPluggedIn
+	return ds
+}
+
+// RecordCookingMode adds the mode the device is currently cooking in, or "NONE" if it is not cooking.
+// Should only be applied to devices with the Cook trait
+// See https://developers.google.com/assistant/smarthome/traits/cook
+func (ds DeviceState) RecordCookingMode(mode string) DeviceState {
+	ds.State["currentCookingMode"] = mode
+	return ds
+}
+
+// RecordDocked adds whether the device is currently docked.
+// Should only be applied to devices with the Dock trait
+// See https://developers.google.com/assistant/smarthome/traits/dock
+func (ds DeviceState) RecordDocked(isDocked bool) DeviceState {
+	ds.State["isDocked"] = isDocked
+	return ds
+}
+
+// RecordFanSpeed adds the name of the speed the fan is currently set to.
+// Should only be applied to devices with the FanSpeed trait
+// See https://developers.google.com/assistant/smarthome/traits/fanspeed
+func (ds DeviceState) RecordFanSpeed(speedName string) DeviceState {
+	ds.State["currentFanSpeedSetting"] = speedName
+	return ds
+}
+
+// RecordFanSpeedPercent adds the current speed of the fan as a percentage of its maximum speed.
+// Should only be applied to devices with the FanSpeed trait which support percentages
+// See https://developers.google.com/assistant/smarthome/traits/fanspeed
+func (ds DeviceState) RecordFanSpeedPercent(percent int) DeviceState {
+	ds.State["currentFanSpeedPercent"] = percent
+	return ds
+}
+
+// HumidityState contains the current state of a device with the HumiditySetting trait.
+type HumidityState struct {
+	// SetpointPercent is the relative humidity the device is trying to reach.
+	SetpointPercent int
+	// AmbientPercent is the current relative humidity measured by the device.
+	AmbientPercent int
+}
+
+// RecordHumidity adds the humidity setpoint and the currently measured humidity to the device.
+// Should only be applied to devices with the HumiditySetting trait
+// See https://developers.google.com/assistant/smarthome/traits/humiditysetting
+func (ds DeviceState) RecordHumidity(hs HumidityState) DeviceState {
+	ds.State["humiditySetpointPercent"] = hs.SetpointPercent
+	ds.State["humidityAmbientPercent"] = hs.AmbientPercent
+	return ds
+}
+
 // RecordInput adds the current input active to the device.
 // Should only be applied to devices with the InputSelector trait
 // See https://developers.google.com/assistant/smarthome/traits/inputselector
@@ -68,6 +225,64 @@ func (ds DeviceState) RecordInput(input string) DeviceState {
 	return ds
 }
 
+// RecordLockState adds whether the device is currently locked, and whether it is jammed and unable to change state.
+// Should only be applied to devices with the LockUnlock trait
+// See https://developers.google.com/assistant/smarthome/traits/lockunlock
+func (ds DeviceState) RecordLockState(isLocked bool, isJammed bool) DeviceState {
+	ds.State["isLocked"] = isLocked
+	ds.State["isJammed"] = isJammed
+	return ds
+}
+
+// MediaState defines the activity and playback states a device with the MediaState trait can report.
+const (
+	ActivityStateInactive = "INACTIVE"
+	ActivityStateStandby  = "STANDBY"
+	ActivityStateActive   = "ACTIVE"
+
+	PlaybackStatePaused         = "PAUSED"
+	PlaybackStatePlaying        = "PLAYING"
+	PlaybackStateFastForwarding = "FAST_FORWARDING"
+	PlaybackStateRewinding      = "REWINDING"
+	PlaybackStateBuffering      = "BUFFERING"
+	PlaybackStateStopped        = "STOPPED"
+)
+
+// RecordMediaState adds the current activity and playback states of the device.
+// Should only be applied to devices with the MediaState trait
+// See https://developers.google.com/assistant/smarthome/traits/mediastate
+func (ds DeviceState) RecordMediaState(activityState string, playbackState string) DeviceState {
+	ds.State["activityState"] = activityState
+	ds.State["playbackState"] = playbackState
+	return ds
+}
+
+// RecordMode adds the setting the named mode is currently in (i.e. "large" for the "load" mode).
+// This can be called once for each of the modes of the device.
+// Should only be applied to devices with the Modes trait
+// See https://developers.google.com/assistant/smarthome/traits/modes
+func (ds DeviceState) RecordMode(name string, setting string) DeviceState {
+	settings, ok := ds.State["currentModeSettings"].(map[string]interface{})
+	if !ok {
+		settings = map[string]interface{}{}
+		ds.State["currentModeSettings"] = settings
+	}
+	settings[name] = setting
+	return ds
+}
+
+// RecordOccupancy adds whether the area monitored by the device is currently occupied.
+// Should only be applied to devices with the OccupancySensing trait
+// See https://developers.google.com/assistant/smarthome/traits/occupancysensing
+func (ds DeviceState) RecordOccupancy(occupied bool) DeviceState {
+	if occupied {
+		ds.State["occupancy"] = "OCCUPIED"
+	} else {
+		ds.State["occupancy"] = "UNOCCUPIED"
+	}
+	return ds
+}
+
 // RecordOnOff adds the current on/off state to the device.
 // Should only be applied to devices with the OnOff trait
 // See https://developers.google.com/assistant/smarthome/traits/onoff
@@ -76,6 +291,165 @@ func (ds DeviceState) RecordOnOff(on bool) DeviceState {
 	return ds
 }
 
+// RecordOpenPercent adds how far open the device is, from 0 (closed) to 100 (fully open).
+// Should only be applied to devices with the OpenClose trait
+// See https://developers.google.com/assistant/smarthome/traits/openclose
+func (ds DeviceState) RecordOpenPercent(percent int) DeviceState {
+	ds.State["openPercent"] = percent
+	return ds
+}
+
+// RecordOpenPercentInDirection adds how far open the device is in the specified direction, from 0 (closed) to 100 (fully open).
+// This can be called once for each of the directions the device supports.
+// Should only be applied to devices with the OpenClose trait which have set their open directions
+// See https://developers.google.com/assistant/smarthome/traits/openclose
+func (ds DeviceState) RecordOpenPercentInDirection(direction string, percent int) DeviceState {
+	openState, _ := ds.State["openState"].([]map[string]interface{})
+	for _, entry := range openState {
+		if entry["openDirection"] == direction {
+			entry["openPercent"] = percent
+			return ds
+		}
+	}
+
+	ds.State["openState"] = append(openState, map[string]interface{}{
+		"openDirection": direction,
+		"openPercent":   percent,
+	})
+	return ds
+}
+
+// RecordRunCycle adds the name of the current and next cycles (in the specified language), along with the
+// number of seconds remaining in the current cycle and in total.
+// Should only be applied to devices with the RunCycle trait
+// See https://developers.google.com/assistant/smarthome/traits/runcycle
+func (ds DeviceState) RecordRunCycle(lang string, currentCycle string, nextCycle string, cycleRemainingSec int, totalRemainingSec int) DeviceState {
+	cycle := map[string]interface{}{
+		"currentCycle": currentCycle,
+		"lang":         lang,
+	}
+	if len(nextCycle) > 0 {
+		cycle["nextCycle"] = nextCycle
+	}
+	ds.State["currentRunCycle"] = []map[string]interface{}{cycle}
+	ds.State["currentCycleRemainingTime"] = cycleRemainingSec
+	ds.State["currentTotalRemainingTime"] = totalRemainingSec
+	return ds
+}
+
+// RecordSensorState adds the current descriptive state of the named sensor (i.e. "no smoke detected").
+// Should only be applied to devices with the SensorState trait
+// See https://developers.google.com/assistant/smarthome/traits/sensorstate
+func (ds DeviceState) RecordSensorState(name string, currentState string) DeviceState {
+	ds.sensorStateData(name)["currentSensorState"] = currentState
+	return ds
+}
+
+// RecordSensorRawValue adds the current numeric value of the named sensor.
+// Should only be applied to devices with the SensorState trait
+// See https://developers.google.com/assistant/smarthome/traits/sensorstate
+func (ds DeviceState) RecordSensorRawValue(name string, rawValue float64) DeviceState {
+	ds.sensorStateData(name)["rawValue"] = rawValue
+	return ds
+}
+
+// sensorStateData returns the entry of the named sensor in the current sensor state data, adding it if not present.
+func (ds DeviceState) sensorStateData(name string) map[string]interface{} {
+	data, _ := ds.State["currentSensorStateData"].([]map[string]interface{})
+	for _, entry := range data {
+		if entry["name"] == name {
+			return entry
+		}
+	}
+
+	entry := map[string]interface{}{
+		"name": name,
+	}
+	ds.State["currentSensorStateData"] = append(data, entry)
+	return entry
+}
+
+// RecordStartStop adds whether the device is currently running and whether it is paused.
+// If the device is running in specific zones, supply them as activeZones.
+// Should only be applied to devices with the StartStop trait
+// See https://developers.google.com/assistant/smarthome/traits/startstop
+func (ds DeviceState) RecordStartStop(isRunning bool, isPaused bool, activeZones ...string) DeviceState {
+	ds.State["isRunning"] = isRunning
+	ds.State["isPaused"] = isPaused
+	if len(activeZones) > 0 {
+		ds.State["activeZones"] = activeZones
+	}
+	return ds
+}
+
+// RecordTemperatureControl adds the target temperature of the device and the temperature it is currently at, both in Celsius.
+// If the state has a temperature unit declared using WithTemperatureUnit the temperatures are converted from that unit instead.
+// Should only be applied to devices with the TemperatureControl trait
+// See https://developers.google.com/assistant/smarthome/traits/temperaturecontrol
+func (ds DeviceState) RecordTemperatureControl(setpointC float64, ambientC float64) DeviceState {
+	ds.State["temperatureSetpointCelsius"] = ds.celsius(setpointC)
+	ds.State["temperatureAmbientCelsius"] = ds.celsius(ambientC)
+	return ds
+}
+
+// ThermostatState contains the current state of a device with the TemperatureSetting trait.
+// All temperatures are in Celsius, unless the state has a temperature unit declared using WithTemperatureUnit.
+type ThermostatState struct {
+	// Mode is the current mode of the thermostat; it must be one of the modes supplied to AddTemperatureSettingTrait.
+	Mode string
+	// AmbientC is the current temperature measured by the thermostat.
+	AmbientC float64
+	// SetpointC is the target temperature. It is ignored in "heatcool" mode.
+	SetpointC float64
+	// SetpointLowC and SetpointHighC are the target range when the thermostat is in "heatcool" mode.
+	SetpointLowC  float64
+	SetpointHighC float64
+	// HumidityAmbient is the current relative humidity percentage measured by the thermostat; leave as 0 if not supported.
+	HumidityAmbient float64
+}
+
+// RecordThermostat adds the current thermostat mode, temperatures and (optionally) humidity to the device.
+// Should only be applied to devices with the TemperatureSetting trait
+// See https://developers.google.com/assistant/smarthome/traits/temperaturesetting
+func (ds DeviceState) RecordThermostat(ts ThermostatState) DeviceState {
+	ds.State["thermostatMode"] = ts.Mode
+	ds.State["thermostatTemperatureAmbient"] = ds.celsius(ts.AmbientC)
+	if ts.Mode == ThermostatModeHeatCool {
+		ds.State["thermostatTemperatureSetpointLow"] = ds.celsius(ts.SetpointLowC)
+		ds.State["thermostatTemperatureSetpointHigh"] = ds.celsius(ts.SetpointHighC)
+	} else {
+		ds.State["thermostatTemperatureSetpoint"] = ds.celsius(ts.SetpointC)
+	}
+	if ts.HumidityAmbient != 0 {
+		ds.State["thermostatHumidityAmbient"] = ts.HumidityAmbient
+	}
+	return ds
+}
+
+// RecordTimer adds the number of seconds remaining on the timer of the device, and whether the timer is paused.
+// If no timer is running, remainingSec should be -1.
+// Should only be applied to devices with the Timer trait
+// See https://developers.google.com/assistant/smarthome/traits/timer
+func (ds DeviceState) RecordTimer(remainingSec int, paused bool) DeviceState {
+	ds.State["timerRemainingSec"] = remainingSec
+	ds.State["timerPaused"] = paused
+	return ds
+}
+
+// RecordToggle adds whether the named toggle (i.e. "sterilization") is currently on.
+// This can be called once for each of the toggles of the device.
+// Should only be applied to devices with the Toggles trait
+// See https://developers.google.com/assistant/smarthome/traits/toggles
+func (ds DeviceState) RecordToggle(name string, on bool) DeviceState {
+	settings, ok := ds.State["currentToggleSettings"].(map[string]interface{})
+	if !ok {
+		settings = map[string]interface{}{}
+		ds.State["currentToggleSettings"] = settings
+	}
+	settings[name] = on
+	return ds
+}
+
 // RecordVolume adds the current volume state to the device.
 // Should only be applied to devices with the Volume trait
 // See https://developers.google.com/assistant/smarthome/traits/volume
@@ -86,12 +460,16 @@ func (ds DeviceState) RecordVolume(volume int, isMuted bool) DeviceState {
 }
 
 // MarshalJSON is a custom JSON serializer for our DeviceState
+// The state is emitted as a map so the keys, including those of any nested maps, are in sorted order.
 func (ds DeviceState) MarshalJSON() ([]byte, error) {
 	payload := map[string]interface{}{}
 	payload["online"] = ds.Online
 	if len(ds.Status) > 0 {
 		payload["status"] = ds.Status
 	}
+	if len(ds.ErrorCode) > 0 {
+		payload["errorCode"] = ds.ErrorCode
+	}
 
 	for k, v := range ds.State {
 		payload[k] = v
@@ -100,21 +478,66 @@ func (ds DeviceState) MarshalJSON() ([]byte, error) {
 	return json.Marshal(payload)
 }
 
+// MarshalForReportState serializes the DeviceState in the form expected by the HomeGraph ReportState API.
+// This differs from MarshalJSON in that the execution status and error code are not included, as they are only valid in response to an intent.
+func (ds DeviceState) MarshalForReportState() ([]byte, error) {
+	payload := map[string]interface{}{}
+	payload["online"] = ds.Online
+
+	for k, v := range ds.State {
+		payload[k] = v
+	}
+
+	return json.Marshal(payload)
+}
+
+// ValidateForDevice checks that each of the recorded state values belongs to a trait the supplied device has.
+// ErrStateNotSupported is returned if a state value is recorded for a trait the device does not declare.
+// ErrInvalidThermostatMode is returned if the recorded thermostat mode is not one of the modes the device declares.
+func (ds DeviceState) ValidateForDevice(d *Device) error {
+	for k := range ds.State {
+		trait, known := stateTraits[k]
+		if !known {
+			continue
+		}
+		if !d.Traits[trait] {
+			return fmt.Errorf("%w: %s requires %s", ErrStateNotSupported, k, trait)
+		}
+	}
+	return validateThermostatModeState(d, ds)
+}
+
 // UnmarshalJSON is a custom JSON deserializer for our DeviceState
 func (ds *DeviceState) UnmarshalJSON(data []byte) error {
 	payload := map[string]interface{}{}
-	if err := json.Unmarshal(data, &payload); err != nil {
+	if err := unmarshalUseNumber(data, &payload); err != nil {
 		return err
 	}
 
 	if online, ok := payload["online"]; ok {
-		ds.Online = online.(bool)
+		onlineVal, ok := online.(bool)
+		if !ok {
+			return fmt.Errorf("online must be a bool, got %T", online)
+		}
+		ds.Online = onlineVal
 		delete(payload, "online")
 	}
 	if status, ok := payload["status"]; ok {
-		ds.Status = status.(string)
+		statusVal, ok := status.(string)
+		if !ok {
+			return fmt.Errorf("status must be a string, got %T", status)
+		}
+		ds.Status = statusVal
 		delete(payload, "status")
 	}
+	if errorCode, ok := payload["errorCode"]; ok {
+		errorCodeVal, ok := errorCode.(string)
+		if !ok {
+			return fmt.Errorf("errorCode must be a string, got %T", errorCode)
+		}
+		ds.ErrorCode = errorCodeVal
+		delete(payload, "errorCode")
+	}
 
 	ds.State = payload
 
@@ -2,6 +2,7 @@ package action
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,3 +26,144 @@ func TestDeviceStateJSONSerializeDeserialize(t *testing.T) {
 	assert.Nil(t, reserializedErr)
 	assert.Equal(t, serializedBytes, reserializedBytes)
 }
+
+func TestDeviceStateJSONSerializeDeterministic(t *testing.T) {
+	state := NewDeviceState(true).RecordVolume(10, false).RecordOnOff(true).RecordColorHSV(1, 0.5, 0.25)
+	state.Status = "SUCCESS"
+
+	serializedBytes, err := json.Marshal(state)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"color":{"spectrumHsv":{"hue":1,"saturation":0.5,"value":0.25}},"currentVolume":10,"isMuted":false,"on":true,"online":true,"status":"SUCCESS"}`, string(serializedBytes))
+}
+
+func TestDeviceStateMarshalForReportState(t *testing.T) {
+	state := NewDeviceState(true).RecordOnOff(true)
+	state.Status = "SUCCESS"
+
+	serializedBytes, err := state.MarshalForReportState()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"on":true,"online":true}`, string(serializedBytes))
+}
+
+func TestDeviceStateValidateForDevice(t *testing.T) {
+	light := NewLight("light-id").AddBrightnessTrait(false)
+
+	assert.Nil(t, NewDeviceState(true).RecordOnOff(true).RecordBrightness(10).ValidateForDevice(light))
+	assert.Nil(t, NewDeviceState(true).ValidateForDevice(light))
+
+	unknownState := NewDeviceState(true)
+	unknownState.State["someNewState"] = 1
+	assert.Nil(t, unknownState.ValidateForDevice(light))
+
+	err := NewDeviceState(true).RecordVolume(10, false).ValidateForDevice(light)
+	assert.True(t, errors.Is(err, ErrStateNotSupported))
+}
+
+func TestDeviceStateRecordColorRGBComponents(t *testing.T) {
+	state := NewDeviceState(true).RecordColorRGBComponents(0x00, 0x7b, 0xa7)
+	assert.Equal(t, map[string]interface{}{
+		"spectrumRgb": 31655,
+	}, state.State["color"])
+}
+
+func TestDeviceStateUnmarshalJSONInvalidTypes(t *testing.T) {
+	state := DeviceState{}
+	assert.NotNil(t, json.Unmarshal([]byte(`{"online":"yes"}`), &state))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"online":true,"status":1}`), &state))
+}
+
+func TestDeviceStateRecordSensorState(t *testing.T) {
+	ds := NewDeviceState(true).
+		RecordSensorState(SensorStateSmokeLevel, "no smoke detected").
+		RecordSensorRawValue(SensorStatePM25, 12.5).
+		RecordSensorState(SensorStatePM25, "healthy")
+
+	serializedBytes, err := json.Marshal(ds)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"currentSensorStateData":[{"currentSensorState":"no smoke detected","name":"SmokeLevel"},{"currentSensorState":"healthy","name":"PM2.5","rawValue":12.5}],"online":true}`, string(serializedBytes))
+}
+
+func TestDeviceStateRecordOccupancyOpenPercent(t *testing.T) {
+	ds := NewDeviceState(true).RecordOccupancy(true).RecordOpenPercent(100)
+	assert.Equal(t, "OCCUPIED", ds.State["occupancy"])
+	assert.Equal(t, 100, ds.State["openPercent"])
+
+	ds.RecordOccupancy(false)
+	assert.Equal(t, "UNOCCUPIED", ds.State["occupancy"])
+}
+
+func TestDeviceStateRecordThermostat(t *testing.T) {
+	ds := NewDeviceState(true).RecordThermostat(ThermostatState{
+		Mode:      "heat",
+		AmbientC:  19.5,
+		SetpointC: 21,
+	})
+	assert.Equal(t, "heat", ds.State["thermostatMode"])
+	assert.Equal(t, 21.0, ds.State["thermostatTemperatureSetpoint"])
+	assert.NotContains(t, ds.State, "thermostatTemperatureSetpointLow")
+	assert.NotContains(t, ds.State, "thermostatHumidityAmbient")
+
+	ds = NewDeviceState(true).RecordThermostat(ThermostatState{
+		Mode:            "heatcool",
+		AmbientC:        19.5,
+		SetpointLowC:    18,
+		SetpointHighC:   24,
+		HumidityAmbient: 40,
+	})
+	assert.NotContains(t, ds.State, "thermostatTemperatureSetpoint")
+	assert.Equal(t, 18.0, ds.State["thermostatTemperatureSetpointLow"])
+	assert.Equal(t, 24.0, ds.State["thermostatTemperatureSetpointHigh"])
+	assert.Equal(t, 40.0, ds.State["thermostatHumidityAmbient"])
+	assert.Nil(t, ds.ValidateForDevice(NewThermostat("thermostat-id", []string{"heatcool"}, TemperatureUnitCelsius, [2]float64{})))
+}
+
+func TestDeviceStateRecordOpenPercentInDirection(t *testing.T) {
+	ds := NewDeviceState(true).
+		RecordOpenPercentInDirection(OpenDirectionUp, 50).
+		RecordOpenPercentInDirection(OpenDirectionDown, 0).
+		RecordOpenPercentInDirection(OpenDirectionUp, 75)
+
+	serializedBytes, err := json.Marshal(ds)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"online":true,"openState":[{"openDirection":"UP","openPercent":75},{"openDirection":"DOWN","openPercent":0}]}`, string(serializedBytes))
+}
+
+func TestDeviceStateRecordBattery(t *testing.T) {
+	for percent, descriptive := range map[int]string{
+		5:   "CRITICALLY_LOW",
+		30:  "LOW",
+		50:  "MEDIUM",
+		99:  "HIGH",
+		100: "FULL",
+	} {
+		ds := NewDeviceState(true).RecordBattery(percent, true, true)
+		assert.Equal(t, descriptive, ds.State["descriptiveCapacityRemaining"])
+	}
+
+	serializedBytes, err := json.Marshal(NewDeviceState(true).RecordBattery(80, false, true))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"capacityRemaining":[{"rawValue":80,"unit":"PERCENTAGE"}],"descriptiveCapacityRemaining":"HIGH","isCharging":false,"isPluggedIn":true,"online":true}`, string(serializedBytes))
+}
+
+func TestDeviceStateRecordModesToggles(t *testing.T) {
+	ds := NewDeviceState(true).
+		RecordMode("load", "small").
+		RecordMode("temperature", "cold").
+		RecordMode("load", "large").
+		RecordToggle("sterilization", true).
+		RecordToggle("energysaving", false)
+
+	serializedBytes, err := json.Marshal(ds)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"currentModeSettings":{"load":"large","temperature":"cold"},"currentToggleSettings":{"energysaving":false,"sterilization":true},"online":true}`, string(serializedBytes))
+
+	// Decoded states can continue to be recorded into.
+	decoded := DeviceState{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"online":true,"currentModeSettings":{"load":"large"},"currentToggleSettings":{"sterilization":true}}`), &decoded))
+	decoded.RecordMode("temperature", "hot").RecordToggle("sterilization", false)
+	assert.Equal(t, map[string]interface{}{"load": "large", "temperature": "hot"}, decoded.State["currentModeSettings"])
+	assert.Equal(t, map[string]interface{}{"sterilization": false}, decoded.State["currentToggleSettings"])
+
+	washer := NewWasher("washer-id", []DeviceMode{NewMode("load", false)}, []DeviceToggle{NewToggle("sterilization")})
+	assert.Nil(t, ds.ValidateForDevice(washer))
+}
@@ -0,0 +1,91 @@
+package action
+
+// Trait defines a capability of a device; the set of traits assigned to a device dictates which commands it supports.
+// These are used as the keys of Device.Traits.
+// See https://developers.google.com/assistant/smarthome/traits for the full list of traits.
+const (
+	TraitAppSelector        = "action.devices.traits.AppSelector"
+	TraitArmDisarm          = "action.devices.traits.ArmDisarm"
+	TraitBrightness         = "action.devices.traits.Brightness"
+	TraitCameraStream       = "action.devices.traits.CameraStream"
+	TraitChannel            = "action.devices.traits.Channel"
+	TraitColorSetting       = "action.devices.traits.ColorSetting"
+	TraitCook               = "action.devices.traits.Cook"
+	TraitDispense           = "action.devices.traits.Dispense"
+	TraitDock               = "action.devices.traits.Dock"
+	TraitEnergyStorage      = "action.devices.traits.EnergyStorage"
+	TraitFanSpeed           = "action.devices.traits.FanSpeed"
+	TraitFill               = "action.devices.traits.Fill"
+	TraitHumiditySetting    = "action.devices.traits.HumiditySetting"
+	TraitInputSelector      = "action.devices.traits.InputSelector"
+	TraitLightEffects       = "action.devices.traits.LightEffects"
+	TraitLocator            = "action.devices.traits.Locator"
+	TraitLockUnlock         = "action.devices.traits.LockUnlock"
+	TraitMediaState         = "action.devices.traits.MediaState"
+	TraitModes              = "action.devices.traits.Modes"
+	TraitNetworkControl     = "action.devices.traits.NetworkControl"
+	TraitObjectDetection    = "action.devices.traits.ObjectDetection"
+	TraitOccupancySensing   = "action.devices.traits.OccupancySensing"
+	TraitOnOff              = "action.devices.traits.OnOff"
+	TraitOpenClose          = "action.devices.traits.OpenClose"
+	TraitReboot             = "action.devices.traits.Reboot"
+	TraitRotation           = "action.devices.traits.Rotation"
+	TraitRunCycle           = "action.devices.traits.RunCycle"
+	TraitScene              = "action.devices.traits.Scene"
+	TraitSensorState        = "action.devices.traits.SensorState"
+	TraitSoftwareUpdate     = "action.devices.traits.SoftwareUpdate"
+	TraitStartStop          = "action.devices.traits.StartStop"
+	TraitStatusReport       = "action.devices.traits.StatusReport"
+	TraitTemperatureControl = "action.devices.traits.TemperatureControl"
+	TraitTemperatureSetting = "action.devices.traits.TemperatureSetting"
+	TraitTimer              = "action.devices.traits.Timer"
+	TraitToggles            = "action.devices.traits.Toggles"
+	TraitTransportControl   = "action.devices.traits.TransportControl"
+	TraitVolume             = "action.devices.traits.Volume"
+)
+
+var validTraits = map[string]bool{
+	TraitAppSelector:        true,
+	TraitArmDisarm:          true,
+	TraitBrightness:         true,
+	TraitCameraStream:       true,
+	TraitChannel:            true,
+	TraitColorSetting:       true,
+	TraitCook:               true,
+	TraitDispense:           true,
+	TraitDock:               true,
+	TraitEnergyStorage:      true,
+	TraitFanSpeed:           true,
+	TraitFill:               true,
+	TraitHumiditySetting:    true,
+	TraitInputSelector:      true,
+	TraitLightEffects:       true,
+	TraitLocator:            true,
+	TraitLockUnlock:         true,
+	TraitMediaState:         true,
+	TraitModes:              true,
+	TraitNetworkControl:     true,
+	TraitObjectDetection:    true,
+	TraitOccupancySensing:   true,
+	TraitOnOff:              true,
+	TraitOpenClose:          true,
+	TraitReboot:             true,
+	TraitRotation:           true,
+	TraitRunCycle:           true,
+	TraitScene:              true,
+	TraitSensorState:        true,
+	TraitSoftwareUpdate:     true,
+	TraitStartStop:          true,
+	TraitStatusReport:       true,
+	TraitTemperatureControl: true,
+	TraitTemperatureSetting: true,
+	TraitTimer:              true,
+	TraitToggles:            true,
+	TraitTransportControl:   true,
+	TraitVolume:             true,
+}
+
+// IsValidTrait checks whether the supplied trait is one of the traits supported by Google.
+func IsValidTrait(trait string) bool {
+	return validTraits[trait]
+}
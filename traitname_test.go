@@ -0,0 +1,13 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidTrait(t *testing.T) {
+	assert.True(t, IsValidTrait(TraitOnOff))
+	assert.True(t, IsValidTrait("action.devices.traits.TemperatureSetting"))
+	assert.False(t, IsValidTrait("action.devices.traits.Toast"))
+}
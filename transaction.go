@@ -0,0 +1,98 @@
+package action
+
+import (
+	"context"
+	"errors"
+)
+
+// TransactionalExecutor executes each CommandArg of an EXECUTE request as an all-or-nothing group.
+// The commands are applied to each device in turn; if any of them fails, the commands already applied in the group
+// are undone (most recent first) using their inverse commands, and every device in the group is reported as failing.
+// This is useful when a single request targets a set of devices which only make sense together (i.e. an AV macro).
+// Execute can be called directly from the Execute method of a Provider.
+type TransactionalExecutor struct {
+	// Apply applies a single command to a single device.
+	// Returning an IntentError reports the group as failing with its error code; other errors report unknownError.
+	Apply func(ctx context.Context, device DeviceArg, command Command) error
+	// Inverse returns the command which undoes the supplied command. It is called before the command is applied,
+	// so the current state of the device can be used (see InverseCommand). Returning false indicates the command
+	// can't be undone, in which case it is left in place if the group is rolled back.
+	Inverse func(ctx context.Context, device DeviceArg, command Command) (Command, bool, error)
+	// OnRollbackFailure, if set, is called for each command which could not be undone during a rollback.
+	OnRollbackFailure func(ctx context.Context, device DeviceArg, command Command, err error)
+}
+
+// appliedCommand tracks how to undo a command which was applied as part of a group.
+type appliedCommand struct {
+	device  DeviceArg
+	inverse Command
+}
+
+// Execute applies each group of commands in the request, returning a single result for each group.
+func (te *TransactionalExecutor) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	resp := &ExecuteResponse{
+		UpdatedState: NewDeviceState(true),
+	}
+
+	for _, commandArg := range req.Commands {
+		var ids []string
+		for _, device := range commandArg.TargetDevices {
+			ids = append(ids, device.ID)
+		}
+
+		if err := te.executeGroup(ctx, commandArg); err != nil {
+			resp.AddFailedDevices(executeErrorCode(err), ids...)
+			continue
+		}
+		resp.UpdatedDevices = append(resp.UpdatedDevices, ids...)
+	}
+	return resp, nil
+}
+
+// executeGroup applies every command in the group, rolling back the applied commands if any of them fail.
+func (te *TransactionalExecutor) executeGroup(ctx context.Context, commandArg CommandArg) error {
+	var applied []appliedCommand
+	for _, deviceCommand := range commandArg.DeviceCommands() {
+		var inverse Command
+		undoable := false
+		if te.Inverse != nil {
+			var err error
+			inverse, undoable, err = te.Inverse(ctx, deviceCommand.Device, deviceCommand.Command)
+			if err != nil {
+				te.rollback(ctx, applied)
+				return err
+			}
+		}
+
+		if err := te.Apply(ctx, deviceCommand.Device, deviceCommand.Command); err != nil {
+			te.rollback(ctx, applied)
+			return err
+		}
+		if undoable {
+			applied = append(applied, appliedCommand{
+				device:  deviceCommand.Device,
+				inverse: inverse,
+			})
+		}
+	}
+	return nil
+}
+
+// rollback applies the inverse of each applied command, most recent first.
+func (te *TransactionalExecutor) rollback(ctx context.Context, applied []appliedCommand) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		err := te.Apply(ctx, applied[i].device, applied[i].inverse)
+		if err != nil && te.OnRollbackFailure != nil {
+			te.OnRollbackFailure(ctx, applied[i].device, applied[i].inverse, err)
+		}
+	}
+}
+
+// executeErrorCode returns the error code to report for a failed command.
+func executeErrorCode(err error) string {
+	var intentErr *IntentError
+	if errors.As(err, &intentErr) && len(intentErr.ErrorCode) > 0 {
+		return intentErr.ErrorCode
+	}
+	return ErrorCodeUnknownError
+}
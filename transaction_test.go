@@ -0,0 +1,91 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionalExecutor(t *testing.T) {
+	on := map[string]bool{"tv": false, "receiver": false, "lights": true, "fan": false, "projector": false}
+	var rollbackFailures []string
+
+	te := &TransactionalExecutor{
+		Apply: func(_ context.Context, device DeviceArg, command Command) error {
+			if device.ID == "projector" {
+				return NewIntentError("turnedOff", errors.New("projector unplugged"))
+			}
+			if (device.ID == "lights" || device.ID == "fan") && !command.OnOff.On {
+				return errors.New("lights unreachable")
+			}
+			on[device.ID] = command.OnOff.On
+			return nil
+		},
+		Inverse: func(_ context.Context, device DeviceArg, command Command) (Command, bool, error) {
+			return Command{
+				Name:  command.Name,
+				OnOff: &CommandOnOff{On: on[device.ID]},
+			}, true, nil
+		},
+		OnRollbackFailure: func(_ context.Context, device DeviceArg, _ Command, _ error) {
+			rollbackFailures = append(rollbackFailures, device.ID)
+		},
+	}
+
+	onCommand := Command{
+		Name:  "action.devices.commands.OnOff",
+		OnOff: &CommandOnOff{On: true},
+	}
+	resp, err := te.Execute(context.Background(), &ExecuteRequest{
+		Commands: []CommandArg{
+			{
+				TargetDevices: []DeviceArg{{ID: "tv"}, {ID: "receiver"}},
+				Commands:      []Command{onCommand},
+			},
+			{
+				TargetDevices: []DeviceArg{{ID: "receiver"}, {ID: "projector"}},
+				Commands:      []Command{{Name: onCommand.Name, OnOff: &CommandOnOff{On: false}}},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tv", "receiver"}, resp.UpdatedDevices)
+	assert.Equal(t, []string{"receiver", "projector"}, resp.FailedDevices["turnedOff"].Devices)
+	// The receiver was turned off by the second group, then restored when the projector failed.
+	assert.Equal(t, map[string]bool{"tv": true, "receiver": true, "lights": true, "fan": false, "projector": false}, on)
+	assert.Empty(t, rollbackFailures)
+
+	// Rollback failures are reported, and the group fails with unknownError for errors which aren't an IntentError.
+	resp, err = te.Execute(context.Background(), &ExecuteRequest{
+		Commands: []CommandArg{
+			{
+				TargetDevices: []DeviceArg{{ID: "tv"}, {ID: "projector"}},
+				Commands:      []Command{onCommand},
+			},
+			{
+				TargetDevices: []DeviceArg{{ID: "tv"}, {ID: "lights"}},
+				Commands:      []Command{{Name: onCommand.Name, OnOff: &CommandOnOff{On: false}}},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.UpdatedDevices)
+	assert.Equal(t, []string{"tv", "projector"}, resp.FailedDevices["turnedOff"].Devices)
+	assert.Equal(t, []string{"tv", "lights"}, resp.FailedDevices[ErrorCodeUnknownError].Devices)
+	assert.True(t, on["tv"])
+	assert.Empty(t, rollbackFailures)
+
+	resp, err = te.Execute(context.Background(), &ExecuteRequest{
+		Commands: []CommandArg{
+			{
+				TargetDevices: []DeviceArg{{ID: "fan"}, {ID: "projector"}},
+				Commands:      []Command{onCommand},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fan", "projector"}, resp.FailedDevices["turnedOff"].Devices)
+	assert.Equal(t, []string{"fan"}, rollbackFailures)
+}
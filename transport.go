@@ -0,0 +1,68 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// FulfillmentResult contains the response to a fulfillment request which should be returned to Google.
+type FulfillmentResult struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Fulfill processes a single fulfillment request independently of the HTTP server in use.
+// This is intended for frameworks which can't easily make use of GoogleFulfillmentHandler (i.e. fasthttp).
+// The contentType and authorization arguments are the values of the respective HTTP headers supplied by Google,
+// and body is the unmodified request body. The returned result should be written back to Google as-is.
+func (s *Service) Fulfill(ctx context.Context, contentType string, authorization string, body io.Reader) *FulfillmentResult {
+	rw := &resultWriter{
+		result: &FulfillmentResult{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, GoogleFulfillmentPath, body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte("Invalid request"))
+		return rw.result
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", authorization)
+
+	s.GoogleFulfillmentHandler(rw, req)
+
+	rw.result.Body = rw.body.Bytes()
+	return rw.result
+}
+
+// resultWriter captures what the fulfillment handler writes into a FulfillmentResult.
+type resultWriter struct {
+	result      *FulfillmentResult
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rw *resultWriter) Header() http.Header {
+	return rw.result.Header
+}
+
+func (rw *resultWriter) Write(data []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.body.Write(data)
+}
+
+func (rw *resultWriter) WriteHeader(statusCode int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.result.StatusCode = statusCode
+}
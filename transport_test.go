@@ -0,0 +1,42 @@
+package action
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceFulfill(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	authenticator := &testAuthenticator{
+		validToken: "asdf",
+		userID:     "1836.15267389",
+	}
+	provider := &testProvider{
+		syncResp: []*Device{NewOutlet("123")},
+	}
+	svc := NewService(logger, authenticator, provider, nil)
+
+	result := svc.Fulfill(context.Background(), "application/json", "Bearer asdf", strings.NewReader(`{
+		"requestId": "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+		"inputs": [
+		  {
+			"intent": "action.devices.SYNC"
+		  }
+		]
+	}`))
+
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	assert.Equal(t, `{"requestId":"ff36a3cc-ec34-11e6-b1a0-64510650abcf","payload":{"agentUserId":"1836.15267389","devices":[{"id":"123","type":"action.devices.types.OUTLET","traits":["action.devices.traits.OnOff"],"name":{},"willReportState":false,"deviceInfo":{}}]}}
+`, string(result.Body))
+
+	result = svc.Fulfill(context.Background(), "application/json", "Bearer invalid", strings.NewReader(`{}`))
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Equal(t, "Access Token Invalid", string(result.Body))
+}
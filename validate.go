@@ -0,0 +1,225 @@
+package action
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// commandTraits maps each of the commands this library parses to the trait a device must have to support it.
+var commandTraits = map[string]string{
+	"action.devices.commands.BrightnessAbsolute": TraitBrightness,
+	"action.devices.commands.BrightnessRelative": TraitBrightness,
+	"action.devices.commands.ColorAbsolute":      TraitColorSetting,
+	"action.devices.commands.OnOff":              TraitOnOff,
+	"action.devices.commands.mute":               TraitVolume,
+	"action.devices.commands.setVolume":          TraitVolume,
+	"action.devices.commands.volumeRelative":     TraitVolume,
+	"action.devices.commands.SetInput":           TraitInputSelector,
+	"action.devices.commands.NextInput":          TraitInputSelector,
+	"action.devices.commands.PreviousInput":      TraitInputSelector,
+	"action.devices.commands.selectChannel":      TraitChannel,
+	"action.devices.commands.relativeChannel":    TraitChannel,
+	"action.devices.commands.returnChannel":      TraitChannel,
+}
+
+// WithExecuteValidation enables validation of EXECUTE commands against the devices supplied in response to SYNC.
+// Commands which target a trait the device doesn't have are rejected with functionNotSupported,
+// and commands targeting unknown devices are rejected with deviceNotFound, without invoking the provider.
+// If clamp is true, values outside of the range declared by the device attributes are clamped to fit;
+// otherwise they are rejected with valueOutOfRange.
+// Commands for which the device requires acknowledgement (see Device.RequireChallenge) are answered with an ackNeeded challenge.
+// Commands which are not parsed by this library (i.e. CommandGeneric) are not validated.
+func WithExecuteValidation(clamp bool) ServiceOption {
+	return func(s *Service) {
+		s.registry = &deviceRegistry{
+			devices: map[string]map[string]*Device{},
+		}
+		s.clampExecuteValues = clamp
+	}
+}
+
+// deviceRegistry tracks the devices most recently supplied in response to SYNC for each user.
+type deviceRegistry struct {
+	mu      sync.Mutex
+	devices map[string]map[string]*Device
+}
+
+func (dr *deviceRegistry) record(agentUserID string, devices []*Device) {
+	byID := map[string]*Device{}
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.devices[agentUserID] = byID
+}
+
+func (dr *deviceRegistry) lookup(agentUserID string) (map[string]*Device, bool) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	devices, found := dr.devices[agentUserID]
+	return devices, found
+}
+
+// registeredDevices returns the devices known for the specified user.
+// If no SYNC has been observed for the user yet the provider is asked for the set of devices.
+func (s *Service) registeredDevices(ctx context.Context, agentUserID string) (map[string]*Device, error) {
+	if devices, found := s.registry.lookup(agentUserID); found {
+		return devices, nil
+	}
+
+	resp, err := s.provider.Sync(ctx, agentUserID)
+	if err != nil {
+		return nil, err
+	}
+	s.registry.record(agentUserID, s.devices.syncDevices(ctx, agentUserID, resp))
+
+	devices, _ := s.registry.lookup(agentUserID)
+	return devices, nil
+}
+
+// validateExecute checks each command against the devices it targets.
+// It returns the commands which passed validation, along with the IDs of the devices which failed indexed by error code.
+// Devices which require acknowledgement of the command before it is executed are returned under ChallengeAckNeeded.
+// If a device required values to be clamped it is split into its own CommandArg with the clamped commands.
+func (s *Service) validateExecute(ctx context.Context, agentUserID string, commandArgs []CommandArg) ([]CommandArg, map[string][]string) {
+	devices, err := s.registeredDevices(ctx, agentUserID)
+	if err != nil {
+		// Without a set of devices to validate against we let the provider decide.
+		s.logger.Info("unable to retrieve devices for validation",
+			zap.String("agent_user_id", agentUserID),
+			zap.Error(err),
+		)
+		return commandArgs, nil
+	}
+
+	failures := map[string][]string{}
+	var validated []CommandArg
+	for _, commandArg := range commandArgs {
+		unchanged := CommandArg{
+			Commands: commandArg.Commands,
+		}
+
+		for _, deviceArg := range commandArg.TargetDevices {
+			device, found := devices[deviceArg.ID]
+			if !found {
+				failures["deviceNotFound"] = append(failures["deviceNotFound"], deviceArg.ID)
+				continue
+			}
+
+			var commands []Command
+			var errCode string
+			for _, command := range commandArg.Commands {
+				var validatedCommand Command
+				validatedCommand, errCode = validateCommand(device, command, s.clampExecuteValues)
+				if len(errCode) > 0 {
+					break
+				}
+				// PINs can only be verified by the provider, but acknowledgement can be requested here.
+				if device.Challenges[command.Name] == ChallengeAckNeeded {
+					if errCode = command.CheckAck(); len(errCode) > 0 {
+						break
+					}
+				}
+				commands = append(commands, validatedCommand)
+			}
+
+			if len(errCode) > 0 {
+				failures[errCode] = append(failures[errCode], deviceArg.ID)
+			} else if reflect.DeepEqual(commands, commandArg.Commands) {
+				unchanged.TargetDevices = append(unchanged.TargetDevices, deviceArg)
+			} else {
+				validated = append(validated, CommandArg{
+					TargetDevices: []DeviceArg{deviceArg},
+					Commands:      commands,
+				})
+			}
+		}
+
+		if len(unchanged.TargetDevices) > 0 {
+			validated = append(validated, unchanged)
+		}
+	}
+
+	return validated, failures
+}
+
+// validateCommand checks that the device supports the command and that the supplied values are within range.
+// The returned command will have its values clamped if clamp is true; the supplied command is never modified.
+// If the command is not valid for this device the relevant error code is returned.
+func validateCommand(d *Device, c Command, clamp bool) (Command, string) {
+	trait, known := commandTraits[c.Name]
+	if !known {
+		return c, ""
+	}
+	if !d.Traits[trait] {
+		return c, "functionNotSupported"
+	}
+
+	switch {
+	case c.OnOff != nil:
+		if queryOnly, _ := d.Attributes["queryOnlyOnOff"].(bool); queryOnly {
+			return c, "functionNotSupported"
+		}
+	case c.BrightnessAbsolute != nil:
+		brightness := ClampBrightness(c.BrightnessAbsolute.Brightness)
+		if brightness != c.BrightnessAbsolute.Brightness {
+			if !clamp {
+				return c, "valueOutOfRange"
+			}
+			cmd := *c.BrightnessAbsolute
+			cmd.Brightness = brightness
+			c.BrightnessAbsolute = &cmd
+		}
+	case c.ColorAbsolute != nil:
+		if c.ColorAbsolute.Color.Temperature > 0 {
+			if opts := d.colorSettingOptions(); opts.TemperatureMinK == 0 && opts.TemperatureMaxK == 0 {
+				return c, "functionNotSupported"
+			}
+
+			temperature := d.ClampColorTemperature(c.ColorAbsolute.Color.Temperature)
+			if temperature != c.ColorAbsolute.Color.Temperature {
+				if !clamp {
+					return c, "valueOutOfRange"
+				}
+				cmd := *c.ColorAbsolute
+				cmd.Color.Temperature = temperature
+				c.ColorAbsolute = &cmd
+			}
+		} else if _, ok := d.Attributes["colorModel"]; !ok {
+			return c, "functionNotSupported"
+		}
+	case c.SetVolume != nil:
+		maxLevel, ok := NumberInt(d.Attributes["volumeMaxLevel"])
+		if !ok {
+			break
+		}
+
+		level := clampInt(c.SetVolume.Level, 0, maxLevel)
+		if level != c.SetVolume.Level {
+			if !clamp {
+				return c, "valueOutOfRange"
+			}
+			c.SetVolume = &CommandSetVolume{
+				Level: level,
+			}
+		}
+	case c.Mute != nil:
+		if canMute, _ := d.Attributes["volumeCanMuteAndUnmute"].(bool); !canMute {
+			return c, "functionNotSupported"
+		}
+	case c.SetInput != nil:
+		for _, input := range d.AvailableInputs() {
+			if input.Key == c.SetInput.NewInput {
+				return c, ""
+			}
+		}
+		return c, "valueOutOfRange"
+	}
+
+	return c, ""
+}
@@ -0,0 +1,264 @@
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestValidateCommand(t *testing.T) {
+	light := NewLight("light-id")
+	light.AddBrightnessTrait(false).AddColourTemperatureTrait(2000, 9000, false)
+	sensor := NewDevice("sensor-id", DeviceTypeSensor).AddOnOffTrait(false, true)
+	receiver := NewSimpleAVReceiver("receiver-id", []DeviceInput{{Key: "input-1"}}, 50, false, false)
+	colorTemperature := func(temperatureK int) Command {
+		c := Command{
+			Name:          "action.devices.commands.ColorAbsolute",
+			ColorAbsolute: &CommandColorAbsolute{},
+		}
+		c.ColorAbsolute.Color.Temperature = temperatureK
+		return c
+	}
+	decodedLight := &Device{}
+	if err := roundtripJSON(light, decodedLight); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name        string
+		device      *Device
+		command     Command
+		clamp       bool
+		wantCommand Command
+		wantErrCode string
+	}{
+		{
+			name:   "supported command",
+			device: light,
+			command: Command{
+				Name:  "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{On: true},
+			},
+			wantCommand: Command{
+				Name:  "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{On: true},
+			},
+		},
+		{
+			name:   "missing trait",
+			device: light,
+			command: Command{
+				Name:      "action.devices.commands.setVolume",
+				SetVolume: &CommandSetVolume{Level: 10},
+			},
+			wantErrCode: "functionNotSupported",
+		},
+		{
+			name:   "query only",
+			device: sensor,
+			command: Command{
+				Name:  "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{On: true},
+			},
+			wantErrCode: "functionNotSupported",
+		},
+		{
+			name:   "brightness out of range",
+			device: light,
+			command: Command{
+				Name:               "action.devices.commands.BrightnessAbsolute",
+				BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 120},
+			},
+			wantErrCode: "valueOutOfRange",
+		},
+		{
+			name:   "brightness clamped",
+			device: light,
+			command: Command{
+				Name:               "action.devices.commands.BrightnessAbsolute",
+				BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 120},
+			},
+			clamp: true,
+			wantCommand: Command{
+				Name:               "action.devices.commands.BrightnessAbsolute",
+				BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 100},
+			},
+		},
+		{
+			name:        "color temperature on decoded device",
+			device:      decodedLight,
+			command:     colorTemperature(4000),
+			wantCommand: colorTemperature(4000),
+		},
+		{
+			name:        "color temperature clamped on decoded device",
+			device:      decodedLight,
+			command:     colorTemperature(10000),
+			clamp:       true,
+			wantCommand: colorTemperature(9000),
+		},
+		{
+			name:        "color temperature not supported",
+			device:      receiver,
+			command:     colorTemperature(4000),
+			wantErrCode: "functionNotSupported",
+		},
+		{
+			name:   "volume clamped",
+			device: receiver,
+			command: Command{
+				Name:      "action.devices.commands.setVolume",
+				SetVolume: &CommandSetVolume{Level: 70},
+			},
+			clamp: true,
+			wantCommand: Command{
+				Name:      "action.devices.commands.setVolume",
+				SetVolume: &CommandSetVolume{Level: 50},
+			},
+		},
+		{
+			name:   "mute not supported",
+			device: receiver,
+			command: Command{
+				Name: "action.devices.commands.mute",
+				Mute: &CommandMute{Mute: true},
+			},
+			wantErrCode: "functionNotSupported",
+		},
+		{
+			name:   "unknown input",
+			device: receiver,
+			command: Command{
+				Name:     "action.devices.commands.SetInput",
+				SetInput: &CommandSetInput{NewInput: "input-2"},
+			},
+			wantErrCode: "valueOutOfRange",
+		},
+		{
+			name:   "generic commands are not validated",
+			device: light,
+			command: Command{
+				Name: "action.devices.commands.LockUnlock",
+				Generic: &CommandGeneric{
+					Command: "action.devices.commands.LockUnlock",
+				},
+			},
+			wantCommand: Command{
+				Name: "action.devices.commands.LockUnlock",
+				Generic: &CommandGeneric{
+					Command: "action.devices.commands.LockUnlock",
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.command
+			command, errCode := validateCommand(tt.device, tt.command, tt.clamp)
+			assert.Equal(t, tt.wantErrCode, errCode)
+			if len(errCode) < 1 {
+				assert.Equal(t, tt.wantCommand, command)
+			}
+			assert.Equal(t, original, tt.command)
+		})
+	}
+}
+
+func TestServiceValidateExecute(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	light := NewLight("light-id")
+	light.AddBrightnessTrait(false)
+	outlet := NewOutlet("outlet-id")
+
+	provider := &testProvider{
+		syncResp: []*Device{light, outlet},
+	}
+	svc := NewService(logger, &testAuthenticator{}, provider, nil, WithExecuteValidation(true))
+
+	commands, failures := svc.validateExecute(context.Background(), "agent-id", []CommandArg{
+		{
+			TargetDevices: []DeviceArg{{ID: "light-id"}, {ID: "outlet-id"}, {ID: "missing-id"}},
+			Commands: []Command{
+				{
+					Name:               "action.devices.commands.BrightnessAbsolute",
+					BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 50},
+				},
+			},
+		},
+		{
+			TargetDevices: []DeviceArg{{ID: "light-id"}},
+			Commands: []Command{
+				{
+					Name:               "action.devices.commands.BrightnessAbsolute",
+					BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: -10},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, []CommandArg{
+		{
+			TargetDevices: []DeviceArg{{ID: "light-id"}},
+			Commands: []Command{
+				{
+					Name:               "action.devices.commands.BrightnessAbsolute",
+					BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 50},
+				},
+			},
+		},
+		{
+			TargetDevices: []DeviceArg{{ID: "light-id"}},
+			Commands: []Command{
+				{
+					Name:               "action.devices.commands.BrightnessAbsolute",
+					BrightnessAbsolute: &CommandBrightnessAbsolute{Brightness: 0},
+				},
+			},
+		},
+	}, commands)
+	assert.Equal(t, map[string][]string{
+		"functionNotSupported": {"outlet-id"},
+		"deviceNotFound":       {"missing-id"},
+	}, failures)
+}
+
+func TestServiceValidateExecuteAckNeeded(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	provider := &testProvider{
+		syncResp: []*Device{NewGarageDoor("garage-id")},
+	}
+	svc := NewService(logger, &testAuthenticator{}, provider, nil, WithExecuteValidation(false))
+
+	openCommand := Command{
+		Name: "action.devices.commands.OpenClose",
+		Generic: &CommandGeneric{
+			Command: "action.devices.commands.OpenClose",
+			Params: map[string]interface{}{
+				"openPercent": 100.0,
+			},
+		},
+	}
+	commands, failures := svc.validateExecute(context.Background(), "agent-id", []CommandArg{
+		{
+			TargetDevices: []DeviceArg{{ID: "garage-id"}},
+			Commands:      []Command{openCommand},
+		},
+	})
+	assert.Empty(t, commands)
+	assert.Equal(t, map[string][]string{
+		ChallengeAckNeeded: {"garage-id"},
+	}, failures)
+
+	openCommand.Challenge = &CommandChallenge{Ack: true}
+	commands, failures = svc.validateExecute(context.Background(), "agent-id", []CommandArg{
+		{
+			TargetDevices: []DeviceArg{{ID: "garage-id"}},
+			Commands:      []Command{openCommand},
+		},
+	})
+	assert.Len(t, commands, 1)
+	assert.Empty(t, failures)
+}
@@ -0,0 +1,183 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrWebhookFailed is returned by WebhookProvider if the webhook responded with an unexpected status code,
+	// or a response which could not be decoded.
+	ErrWebhookFailed = errors.New("webhook failed")
+)
+
+// WebhookProvider is a Provider which forwards each intent to an HTTP service implementing the contract described
+// by OpenAPISpec, so the devices can be managed by a backend which isn't written in Go.
+// Each request is sent to the path of the intent (i.e. /query) under the base URL, with the user it is for in the
+// AgentUserIDHeader header and the request ID carried by the context, if any, as the requestId.
+// Errors the webhook reports for the entire request (i.e. a payload.errorCode) are returned as an IntentError,
+// so the same error code is reported to Google.
+type WebhookProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewWebhookProvider creates a provider which calls the webhook at the supplied base URL using the client.
+// If client is nil http.DefaultClient is used.
+func NewWebhookProvider(baseURL string, client *http.Client) *WebhookProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// call sends the input to the webhook at the path on behalf of the user, decoding the response into resp if it isn't nil.
+func (wp *WebhookProvider) call(ctx context.Context, path string, agentUserID string, input FulfillmentInput, resp interface{}) error {
+	body, err := json.Marshal(&FulfillmentRequest{
+		RequestID: RequestIDFromContext(ctx),
+		Inputs:    []FulfillmentInput{input},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wp.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentUserIDHeader, agentUserID)
+
+	httpResp, err := wp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+
+	var errResp ErrorFulfillmentResponse
+	if json.Unmarshal(respBody, &errResp) == nil && len(errResp.Payload.ErrorCode) > 0 {
+		intentErr := NewIntentError(errResp.Payload.ErrorCode, fmt.Errorf("%s: %s", path, errResp.Payload.ErrorCode))
+		intentErr.DebugString = errResp.Payload.DebugString
+		if httpResp.StatusCode != http.StatusOK {
+			intentErr.StatusCode = httpResp.StatusCode
+		}
+		return intentErr
+	} else if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned status %d", ErrWebhookFailed, path, httpResp.StatusCode)
+	}
+
+	if resp == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrWebhookFailed, path, err)
+	}
+	return nil
+}
+
+// Sync returns the devices supplied by the webhook.
+func (wp *WebhookProvider) Sync(ctx context.Context, agentUserID string) (*SyncResponse, error) {
+	resp := &SyncFulfillmentResponse{}
+	if err := wp.call(ctx, "/sync", agentUserID, FulfillmentInput{Intent: IntentSync}, resp); err != nil {
+		return nil, err
+	}
+	return &SyncResponse{
+		Devices: resp.Payload.Devices,
+	}, nil
+}
+
+// Disconnect informs the webhook the user has unlinked.
+func (wp *WebhookProvider) Disconnect(ctx context.Context, agentUserID string) error {
+	return wp.call(ctx, "/disconnect", agentUserID, FulfillmentInput{Intent: IntentDisconnect}, nil)
+}
+
+// Query returns the states supplied by the webhook.
+func (wp *WebhookProvider) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	payload := &QueryPayload{}
+	for _, device := range req.Devices {
+		payload.Devices = append(payload.Devices, DeviceHandle{
+			ID:         device.ID,
+			CustomData: device.CustomData,
+		})
+	}
+
+	resp := &QueryFulfillmentResponse{}
+	if err := wp.call(ctx, "/query", req.AgentID, FulfillmentInput{Intent: IntentQuery, Query: payload}, resp); err != nil {
+		return nil, err
+	}
+	return &QueryResponse{
+		States: resp.Payload.Devices,
+	}, nil
+}
+
+// Execute applies the commands using the webhook, and returns the results it supplied.
+// Devices the webhook reports as PENDING are recorded using their ID as the completion token,
+// so the webhook's backend completes them by calling Service.CompleteExecution with the device ID.
+func (wp *WebhookProvider) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	payload := &ExecutePayload{}
+	for _, commandArg := range req.Commands {
+		command := ExecuteCommandPayload{
+			Execution: commandArg.Commands,
+		}
+		for _, device := range commandArg.TargetDevices {
+			command.Devices = append(command.Devices, DeviceHandle{
+				ID:         device.ID,
+				CustomData: device.CustomData,
+			})
+		}
+		payload.Commands = append(payload.Commands, command)
+	}
+
+	resp := &ExecuteFulfillmentResponse{}
+	if err := wp.call(ctx, "/execute", req.AgentID, FulfillmentInput{Intent: IntentExecute, Execute: payload}, resp); err != nil {
+		return nil, err
+	}
+
+	result := &ExecuteResponse{
+		UpdatedState: NewDeviceState(true),
+	}
+	for _, command := range resp.Payload.Commands {
+		switch command.Status {
+		case "SUCCESS", "PENDING":
+			for k, v := range command.States {
+				if k != "online" {
+					result.UpdatedState.State[k] = v
+				}
+			}
+			if command.Status == "SUCCESS" {
+				result.UpdatedDevices = append(result.UpdatedDevices, command.IDs...)
+				continue
+			}
+			for _, id := range command.IDs {
+				result.AddPendingDevice(id, id)
+			}
+		case "OFFLINE":
+			result.OfflineDevices = append(result.OfflineDevices, command.IDs...)
+		default:
+			if command.ChallengeNeeded != nil {
+				result.AddChallengeNeeded(command.ChallengeNeeded.Type, command.IDs...)
+				continue
+			}
+			errCode := command.ErrorCode
+			if len(errCode) < 1 {
+				errCode = ErrorCodeUnknownError
+			}
+			result.AddFailedDevices(errCode, command.IDs...)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,146 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// specWebhook is a webhook which checks each request against the OpenAPI document before answering it
+// with the golden response of the intent.
+type specWebhook struct {
+	t    *testing.T
+	spec struct {
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Parameters map[string]map[string]interface{} `json:"parameters"`
+			Schemas    map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	agentUserIDs []string
+	requests     []map[string]interface{}
+}
+
+func newSpecWebhook(t *testing.T) *specWebhook {
+	sw := &specWebhook{t: t}
+	data, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &sw.spec); err != nil {
+		t.Fatal(err)
+	}
+	return sw
+}
+
+func (sw *specWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	op, found := sw.spec.Paths[r.URL.Path]["post"]
+	if !assert.True(sw.t, found && r.Method == http.MethodPost, "%s %s", r.Method, r.URL.Path) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	header := sw.spec.Components.Parameters["AgentUserID"]["name"].(string)
+	assert.NotEmpty(sw.t, r.Header.Get(header))
+	sw.agentUserIDs = append(sw.agentUserIDs, r.Header.Get(header))
+	assert.Equal(sw.t, "application/json", r.Header.Get("Content-Type"))
+
+	body, _ := ioutil.ReadAll(r.Body)
+	req := map[string]interface{}{}
+	assert.Nil(sw.t, json.Unmarshal(body, &req))
+	sw.requests = append(sw.requests, req)
+	for _, field := range sw.spec.Components.Schemas["FulfillmentRequest"]["required"].([]interface{}) {
+		assert.Contains(sw.t, req, field)
+	}
+	input := req["inputs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(sw.t, "Handles the "+input["intent"].(string)+" intent.", op["description"])
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/sync":
+		w.Write(readGolden(sw.t, "sync_response.json"))
+	case "/query":
+		w.Write(readGolden(sw.t, "query_response.json"))
+	case "/execute":
+		w.Write(readGolden(sw.t, "execute_response.json"))
+	default:
+		w.Write([]byte("{}"))
+	}
+}
+
+func TestWebhookProvider(t *testing.T) {
+	sw := newSpecWebhook(t)
+	server := httptest.NewServer(sw)
+	defer server.Close()
+
+	wp := NewWebhookProvider(server.URL+"/", nil)
+	ctx := ContextWithRequestID(context.Background(), "request-1")
+
+	syncResp, err := wp.Sync(ctx, "agent-id")
+	assert.Nil(t, err)
+	assert.Len(t, syncResp.Devices, 3)
+	assert.Equal(t, "123", syncResp.Devices[0].ID)
+
+	queryResp, err := wp.Query(ctx, &QueryRequest{
+		AgentID: "agent-id",
+		Devices: []DeviceArg{{ID: "123", CustomData: map[string]interface{}{"fooValue": "bar"}}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, true, queryResp.States["123"].State["on"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "123", "customData": map[string]interface{}{"fooValue": "bar"}},
+	}, sw.requests[1]["inputs"].([]interface{})[0].(map[string]interface{})["payload"].(map[string]interface{})["devices"])
+
+	executeResp, err := wp.Execute(ctx, &ExecuteRequest{
+		AgentID: "agent-id",
+		Commands: []CommandArg{{
+			TargetDevices: []DeviceArg{{ID: "123"}, {ID: "456"}, {ID: "lock-1"}},
+			Commands: []Command{{
+				Name:  "action.devices.commands.OnOff",
+				OnOff: &CommandOnOff{On: true},
+			}},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"123"}, executeResp.UpdatedDevices)
+	assert.Equal(t, true, executeResp.UpdatedState.State["on"])
+	assert.Equal(t, []string{"456"}, executeResp.FailedDevices["deviceTurnedOff"].Devices)
+	assert.Equal(t, map[string][]string{"challengeFailedPinNeeded": {"lock-1"}}, executeResp.ChallengeNeeded)
+
+	assert.Nil(t, wp.Disconnect(ctx, "agent-id"))
+
+	assert.Equal(t, []string{"agent-id", "agent-id", "agent-id", "agent-id"}, sw.agentUserIDs)
+	for _, req := range sw.requests {
+		assert.Equal(t, "request-1", req["requestId"])
+	}
+}
+
+func TestWebhookProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sync" {
+			w.Write([]byte(`{"payload":{"errorCode":"deviceOffline","debugString":"hub unreachable"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	wp := NewWebhookProvider(server.URL, nil)
+
+	_, err := wp.Sync(context.Background(), "agent-id")
+	intentErr, ok := asIntentError(err)
+	assert.True(t, ok)
+	assert.Equal(t, ErrorCodeDeviceOffline, intentErr.ErrorCode)
+	assert.Equal(t, "hub unreachable", intentErr.DebugString)
+	assert.Equal(t, http.StatusOK, intentErr.StatusCode)
+
+	_, err = wp.Query(context.Background(), &QueryRequest{AgentID: "agent-id"})
+	assert.True(t, errors.Is(err, ErrWebhookFailed))
+}
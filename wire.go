@@ -0,0 +1,173 @@
+package action
+
+import "encoding/json"
+
+// The types in this file define the wire format of the messages exchanged with Google during fulfillment.
+// They are exported so that tooling can construct or inspect fulfillment messages without duplicating them;
+// providers should not normally need to use them directly.
+
+// FulfillmentRequest matches the request format documented at https://developers.google.com/assistant/smarthome/develop/process-intents
+// It appears to be generated from a protobuf file but I was unable to locate the proper one.
+type FulfillmentRequest struct {
+	RequestID string             `json:"requestId"`
+	Inputs    []FulfillmentInput `json:"inputs"`
+}
+
+// FulfillmentInput matches the intent format documented at https://developers.google.com/assistant/smarthome/reference/intent/sync (first of the 4 intents)
+type FulfillmentInput struct {
+	Intent string `json:"intent"`
+
+	// based on the supplied intent one of the 2 below fields may be set
+	Query   *QueryPayload   `json:"-"`
+	Execute *ExecutePayload `json:"-"`
+
+	// Payload contains the unparsed payload, for intents which are not handled by this library.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// MarshalJSON is a custom JSON serializer for our FulfillmentInput
+func (i FulfillmentInput) MarshalJSON() ([]byte, error) {
+	var tmp struct {
+		Intent  string      `json:"intent"`
+		Payload interface{} `json:"payload,omitempty"`
+	}
+	tmp.Intent = i.Intent
+
+	if i.Query != nil {
+		tmp.Payload = i.Query
+	} else if i.Execute != nil {
+		tmp.Payload = i.Execute
+	} else if len(i.Payload) > 0 {
+		tmp.Payload = i.Payload
+	}
+
+	return json.Marshal(tmp)
+}
+
+// UnmarshalJSON is a custom JSON deserializer for our FulfillmentInput
+func (i *FulfillmentInput) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Intent  string          `json:"intent"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+
+	i.Intent = tmp.Intent
+	i.Payload = tmp.Payload
+	switch tmp.Intent {
+	case IntentQuery:
+		payload := &QueryPayload{}
+		err = json.Unmarshal(tmp.Payload, payload)
+		if err != nil {
+			return err
+		}
+		i.Query = payload
+	case IntentExecute:
+		payload := &ExecutePayload{}
+		err = json.Unmarshal(tmp.Payload, payload)
+		if err != nil {
+			return err
+		}
+		i.Execute = payload
+
+	}
+
+	return nil
+}
+
+// DeviceHandle identifies a single device in a QUERY or EXECUTE request.
+type DeviceHandle struct {
+	ID         string                 `json:"id"`
+	CustomData map[string]interface{} `json:"customData,omitempty"`
+}
+
+// QueryPayload contains the devices being queried.
+type QueryPayload struct {
+	Devices []DeviceHandle `json:"devices"`
+}
+
+// ExecutePayload contains the commands being executed.
+type ExecutePayload struct {
+	Commands []ExecuteCommandPayload `json:"commands"`
+}
+
+// ExecuteCommandPayload contains a set of commands to be executed against a set of devices.
+type ExecuteCommandPayload struct {
+	Devices   []DeviceHandle `json:"devices"`
+	Execution []Command      `json:"execution"`
+}
+
+// ExecuteCommandResult contains the result of executing commands against a set of devices.
+type ExecuteCommandResult struct {
+	IDs             []string                `json:"ids,omitempty"`
+	Status          string                  `json:"status,omitempty"`
+	ErrorCode       string                  `json:"errorCode,omitempty"`
+	ChallengeNeeded *ChallengeNeededPayload `json:"challengeNeeded,omitempty"`
+	States          map[string]interface{}  `json:"states,omitempty"`
+}
+
+// ChallengeNeededPayload describes the secondary verification required before a command can be executed.
+type ChallengeNeededPayload struct {
+	Type string `json:"type"`
+}
+
+// SyncFulfillmentResponse matches the response format documented at https://developers.google.com/assistant/smarthome/reference/intent/sync
+type SyncFulfillmentResponse struct {
+	RequestID string                 `json:"requestId,omitempty"`
+	Payload   SyncFulfillmentPayload `json:"payload"`
+}
+
+// SyncFulfillmentPayload contains the devices linked to the user.
+type SyncFulfillmentPayload struct {
+	UserID      string    `json:"agentUserId,omitempty"`
+	ErrorCode   string    `json:"errorCode,omitempty"`
+	DebugString string    `json:"debugString,omitempty"`
+	Devices     []*Device `json:"devices,omitempty"`
+}
+
+// QueryFulfillmentResponse matches the response format documented at https://developers.google.com/assistant/smarthome/reference/intent/query
+type QueryFulfillmentResponse struct {
+	RequestID string                  `json:"requestId,omitempty"`
+	Payload   QueryFulfillmentPayload `json:"payload"`
+}
+
+// QueryFulfillmentPayload contains the states of the queried devices, indexed by device ID.
+type QueryFulfillmentPayload struct {
+	ErrorCode   string                 `json:"errorCode,omitempty"`
+	DebugString string                 `json:"debugString,omitempty"`
+	Devices     map[string]DeviceState `json:"devices"`
+}
+
+// ExecuteFulfillmentResponse matches the response format documented at https://developers.google.com/assistant/smarthome/reference/intent/execute
+type ExecuteFulfillmentResponse struct {
+	RequestID string                    `json:"requestId,omitempty"`
+	Payload   ExecuteFulfillmentPayload `json:"payload"`
+}
+
+// ExecuteFulfillmentPayload contains the results of the executed commands.
+type ExecuteFulfillmentPayload struct {
+	ErrorCode   string                 `json:"errorCode,omitempty"`
+	DebugString string                 `json:"debugString,omitempty"`
+	Commands    []ExecuteCommandResult `json:"commands"`
+}
+
+// CustomFulfillmentResponse contains the response to an intent handled by a registered IntentHandler.
+type CustomFulfillmentResponse struct {
+	RequestID string      `json:"requestId,omitempty"`
+	Payload   interface{} `json:"payload"`
+}
+
+// ErrorFulfillmentResponse is returned in place of the intent-specific response if the entire intent failed.
+type ErrorFulfillmentResponse struct {
+	RequestID string                  `json:"requestId,omitempty"`
+	Payload   ErrorFulfillmentPayload `json:"payload"`
+}
+
+// ErrorFulfillmentPayload contains the reason the intent failed.
+type ErrorFulfillmentPayload struct {
+	ErrorCode   string `json:"errorCode"`
+	DebugString string `json:"debugString,omitempty"`
+}
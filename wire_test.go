@@ -0,0 +1,100 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFulfillmentRequestJSONRoundtrip(t *testing.T) {
+	for _, example := range []struct {
+		name string
+		want *FulfillmentRequest
+	}{
+		{
+			name: "sync",
+			want: &FulfillmentRequest{
+				RequestID: "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				Inputs: []FulfillmentInput{
+					{
+						Intent: IntentSync,
+					},
+				},
+			},
+		},
+		{
+			name: "query",
+			want: &FulfillmentRequest{
+				RequestID: "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				Inputs: []FulfillmentInput{
+					{
+						Intent: IntentQuery,
+						Query: &QueryPayload{
+							Devices: []DeviceHandle{
+								{
+									ID: "123",
+									CustomData: map[string]interface{}{
+										"fooValue": 74.0,
+									},
+								},
+							},
+						},
+						Payload: json.RawMessage(`{"devices":[{"id":"123","customData":{"fooValue":74}}]}`),
+					},
+				},
+			},
+		},
+		{
+			name: "execute",
+			want: &FulfillmentRequest{
+				RequestID: "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				Inputs: []FulfillmentInput{
+					{
+						Intent: IntentExecute,
+						Execute: &ExecutePayload{
+							Commands: []ExecuteCommandPayload{
+								{
+									Devices: []DeviceHandle{
+										{
+											ID: "123",
+										},
+									},
+									Execution: []Command{
+										{
+											Name:  "action.devices.commands.OnOff",
+											OnOff: &CommandOnOff{On: true},
+										},
+									},
+								},
+							},
+						},
+						Payload: json.RawMessage(`{"commands":[{"devices":[{"id":"123"}],"execution":[{"command":"action.devices.commands.OnOff","params":{"on":true}}]}]}`),
+					},
+				},
+			},
+		},
+		{
+			name: "custom",
+			want: &FulfillmentRequest{
+				RequestID: "ff36a3cc-ec34-11e6-b1a0-64510650abcf",
+				Inputs: []FulfillmentInput{
+					{
+						Intent:  "action.devices.IDENTIFY",
+						Payload: json.RawMessage(`{"target":{"deviceId":"local-device-id"}}`),
+					},
+				},
+			},
+		},
+	} {
+		t.Run(example.name, func(t *testing.T) {
+			got := &FulfillmentRequest{}
+			if err := roundtripJSON(example.want, got); err != nil {
+				t.Fatalf("error encoding and decoding JSON: %v", err)
+			}
+			if diff := cmp.Diff(example.want, got); diff != "" {
+				t.Errorf("unexpected diff in roundtrip result (-original, +roundtrip result):\n  %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrQueueFull is returned when submitting a HomeGraph operation to a full queue with the BackpressureError policy.
+	ErrQueueFull = errors.New("homegraph operation queue full")
+	// ErrWorkersNotConfigured is returned when submitting a HomeGraph operation if WithHomeGraphWorkers was not set.
+	ErrWorkersNotConfigured = errors.New("homegraph workers not configured")
+)
+
+// BackpressurePolicy defines what happens when a HomeGraph operation is submitted to a full queue.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock waits for space in the queue, or for the submitting context to be cancelled.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest discards the oldest queued operation to make space.
+	BackpressureDropOldest
+	// BackpressureError rejects the operation with ErrQueueFull.
+	BackpressureError
+)
+
+// HomeGraphOperation is a call to the HomeGraph (i.e. a ReportState or notification) run by a background worker.
+type HomeGraphOperation func(ctx context.Context) error
+
+// HomeGraphQueueStats describes the queue of background HomeGraph operations.
+type HomeGraphQueueStats struct {
+	// Depth is the number of operations waiting for a worker.
+	Depth    int
+	Capacity int
+
+	Submitted int
+	// Dropped is the number of queued operations discarded by BackpressureDropOldest.
+	Dropped int
+	// Rejected is the number of operations refused by BackpressureError, or abandoned while blocked by BackpressureBlock.
+	Rejected  int
+	Completed int
+	Failed    int
+}
+
+// WithHomeGraphWorkers runs ReportStateAsync, RequestSyncAsync and SubmitHomeGraphOperation on a bounded pool of
+// workers, rather than callers spawning their own goroutines. At most queueSize operations wait for a worker;
+// policy decides what happens when an operation is submitted once the queue is full.
+// The workers only run while RunHomeGraphWorkers is running.
+func WithHomeGraphWorkers(workers int, queueSize int, policy BackpressurePolicy) ServiceOption {
+	return func(s *Service) {
+		if workers < 1 {
+			workers = 1
+		}
+		if queueSize < 1 {
+			queueSize = 1
+		}
+		s.homeGraphQueue = &homeGraphQueue{
+			workers:  workers,
+			capacity: queueSize,
+			policy:   policy,
+			ready:    make(chan struct{}, 1),
+			freed:    make(chan struct{}, 1),
+		}
+	}
+}
+
+type homeGraphJob struct {
+	ctx  context.Context
+	name string
+	op   HomeGraphOperation
+}
+
+// homeGraphQueue holds the operations waiting for a worker.
+// ready is signalled when an operation is queued and freed when one is removed.
+type homeGraphQueue struct {
+	workers  int
+	capacity int
+	policy   BackpressurePolicy
+
+	mu    sync.Mutex
+	jobs  []homeGraphJob
+	stats HomeGraphQueueStats
+
+	ready chan struct{}
+	freed chan struct{}
+}
+
+// wakeOne wakes one waiter on the channel, if any, without blocking.
+func wakeOne(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// push queues the job, applying the backpressure policy if the queue is full.
+// It returns the job discarded to make space, if any.
+func (q *homeGraphQueue) push(ctx context.Context, job homeGraphJob) (*homeGraphJob, error) {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) < q.capacity {
+			q.jobs = append(q.jobs, job)
+			q.stats.Submitted++
+			hasSpace := len(q.jobs) < q.capacity
+			q.mu.Unlock()
+
+			wakeOne(q.ready)
+			if hasSpace {
+				// Pass the wakeup on to any other blocked submitters.
+				wakeOne(q.freed)
+			}
+			return nil, nil
+		}
+
+		switch q.policy {
+		case BackpressureDropOldest:
+			dropped := q.jobs[0]
+			q.jobs = append(q.jobs[1:], job)
+			q.stats.Submitted++
+			q.stats.Dropped++
+			q.mu.Unlock()
+
+			wakeOne(q.ready)
+			return &dropped, nil
+		case BackpressureError:
+			q.stats.Rejected++
+			q.mu.Unlock()
+			return nil, ErrQueueFull
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.freed:
+		case <-ctx.Done():
+			q.mu.Lock()
+			q.stats.Rejected++
+			q.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// pop removes the oldest job from the queue.
+func (q *homeGraphQueue) pop() (homeGraphJob, bool) {
+	q.mu.Lock()
+	if len(q.jobs) < 1 {
+		q.mu.Unlock()
+		return homeGraphJob{}, false
+	}
+	job := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	remaining := len(q.jobs)
+	q.mu.Unlock()
+
+	wakeOne(q.freed)
+	if remaining > 0 {
+		// Pass the wakeup on to any other idle workers.
+		wakeOne(q.ready)
+	}
+	return job, true
+}
+
+func (q *homeGraphQueue) record(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err != nil {
+		q.stats.Failed++
+	} else {
+		q.stats.Completed++
+	}
+}
+
+// SubmitHomeGraphOperation queues the operation to be run by a background worker, named for logging.
+// The operation receives a context carrying the values of ctx which isn't cancelled when ctx is, so operations
+// submitted while handling a fulfillment request outlive the request. Failures are logged.
+// ErrWorkersNotConfigured is returned if WithHomeGraphWorkers was not set; otherwise an error is only returned
+// if the operation could not be queued under the backpressure policy.
+func (s *Service) SubmitHomeGraphOperation(ctx context.Context, name string, op HomeGraphOperation) error {
+	if s.homeGraphQueue == nil {
+		return ErrWorkersNotConfigured
+	}
+
+	dropped, err := s.homeGraphQueue.push(ctx, homeGraphJob{
+		ctx:  detachContext(ctx),
+		name: name,
+		op:   op,
+	})
+	if err != nil {
+		s.logger.Info("unable to queue homegraph operation",
+			requestIDField(ctx),
+			zap.String("operation", name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if dropped != nil {
+		s.logger.Info("dropped queued homegraph operation",
+			requestIDField(dropped.ctx),
+			zap.String("operation", dropped.name),
+		)
+	}
+	return nil
+}
+
+// ReportStateAsync queues a ReportState of the device states to be run by a background worker.
+// See SubmitHomeGraphOperation.
+func (s *Service) ReportStateAsync(ctx context.Context, agentUserID string, deviceStates map[string]DeviceState) error {
+	return s.SubmitHomeGraphOperation(ctx, "reportState", func(ctx context.Context) error {
+		return s.ReportState(ctx, agentUserID, deviceStates)
+	})
+}
+
+// RequestSyncAsync queues a RequestSync for the user to be run by a background worker.
+// See SubmitHomeGraphOperation.
+func (s *Service) RequestSyncAsync(ctx context.Context, agentUserID string) error {
+	return s.SubmitHomeGraphOperation(ctx, "requestSync", func(ctx context.Context) error {
+		return s.RequestSync(ctx, agentUserID)
+	})
+}
+
+// HomeGraphQueueStats returns the current depth and counters of the queue of background HomeGraph operations.
+func (s *Service) HomeGraphQueueStats() HomeGraphQueueStats {
+	if s.homeGraphQueue == nil {
+		return HomeGraphQueueStats{}
+	}
+
+	s.homeGraphQueue.mu.Lock()
+	defer s.homeGraphQueue.mu.Unlock()
+
+	stats := s.homeGraphQueue.stats
+	stats.Depth = len(s.homeGraphQueue.jobs)
+	stats.Capacity = s.homeGraphQueue.capacity
+	return stats
+}
+
+// RunHomeGraphWorkers runs the workers configured by WithHomeGraphWorkers.
+// It blocks until the context is cancelled, so it should be run on its own goroutine. Operations which are running
+// when the context is cancelled are allowed to complete; operations which are still queued remain queued.
+func (s *Service) RunHomeGraphWorkers(ctx context.Context) {
+	if s.homeGraphQueue == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < s.homeGraphQueue.workers; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runHomeGraphWorker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Service) runHomeGraphWorker(ctx context.Context) {
+	q := s.homeGraphQueue
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, found := q.pop()
+		if !found {
+			select {
+			case <-q.ready:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		err := job.op(job.ctx)
+		q.record(err)
+		if err != nil {
+			s.logger.Info("homegraph operation failed",
+				requestIDField(job.ctx),
+				zap.String("operation", job.name),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordingOperation returns an operation which records its name once run.
+func recordingOperation(mu *sync.Mutex, ran *[]string, name string, wg *sync.WaitGroup) HomeGraphOperation {
+	return func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*ran = append(*ran, name)
+		wg.Done()
+		return nil
+	}
+}
+
+func TestHomeGraphWorkersNotConfigured(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil)
+	assert.Equal(t, ErrWorkersNotConfigured, svc.RequestSyncAsync(context.Background(), "agent-id"))
+}
+
+func TestHomeGraphWorkersBackpressureError(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithHomeGraphWorkers(1, 1, BackpressureError))
+
+	op := func(context.Context) error { return nil }
+	assert.Nil(t, svc.SubmitHomeGraphOperation(context.Background(), "first", op))
+	assert.True(t, errors.Is(svc.SubmitHomeGraphOperation(context.Background(), "second", op), ErrQueueFull))
+
+	stats := svc.HomeGraphQueueStats()
+	assert.Equal(t, 1, stats.Depth)
+	assert.Equal(t, 1, stats.Capacity)
+	assert.Equal(t, 1, stats.Submitted)
+	assert.Equal(t, 1, stats.Rejected)
+}
+
+func TestHomeGraphWorkersBackpressureDropOldest(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithHomeGraphWorkers(1, 2, BackpressureDropOldest))
+
+	var mu sync.Mutex
+	var ran []string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Nil(t, svc.SubmitHomeGraphOperation(context.Background(), name, recordingOperation(&mu, &ran, name, &wg)))
+	}
+	assert.Equal(t, 1, svc.HomeGraphQueueStats().Dropped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.RunHomeGraphWorkers(ctx)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"b", "c"}, ran)
+}
+
+func TestHomeGraphWorkersBackpressureBlock(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, nil, WithHomeGraphWorkers(2, 1, BackpressureBlock))
+
+	var mu sync.Mutex
+	var ran []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	assert.Nil(t, svc.SubmitHomeGraphOperation(context.Background(), "a", recordingOperation(&mu, &ran, "a", &wg)))
+
+	// Without workers running the submission blocks until its context expires.
+	timeoutCtx, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	assert.Equal(t, context.DeadlineExceeded, svc.SubmitHomeGraphOperation(timeoutCtx, "b", recordingOperation(&mu, &ran, "b", &wg)))
+	assert.Equal(t, 1, svc.HomeGraphQueueStats().Rejected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.RunHomeGraphWorkers(ctx)
+
+	assert.Nil(t, svc.SubmitHomeGraphOperation(context.Background(), "c", recordingOperation(&mu, &ran, "c", &wg)))
+	assert.Nil(t, svc.SubmitHomeGraphOperation(context.Background(), "d", recordingOperation(&mu, &ran, "d", &wg)))
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"a", "c", "d"}, ran)
+}
+
+func TestServiceReportStateAsync(t *testing.T) {
+	thg := &testHomeGraph{}
+	svc := NewService(zaptest.NewLogger(t), &testAuthenticator{}, &testProvider{}, newTestHomeGraphService(t, thg), WithHomeGraphWorkers(1, 10, BackpressureBlock))
+
+	// The operation outlives the context it was submitted with.
+	submitCtx, cancelSubmit := context.WithCancel(context.Background())
+	assert.Nil(t, svc.ReportStateAsync(submitCtx, "agent-id", map[string]DeviceState{
+		"test-id": NewDeviceState(true).RecordOnOff(true),
+	}))
+	cancelSubmit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunHomeGraphWorkers(ctx)
+		close(done)
+	}()
+	for svc.HomeGraphQueueStats().Completed < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	assert.Equal(t, []string{"/v1/devices:reportStateAndNotification"}, thg.paths)
+}